@@ -0,0 +1,126 @@
+package kvs
+
+// maxAliasDepth bounds the number of aliases followed when resolving a key.
+const maxAliasDepth = 16
+
+// Alias makes alias a second name for target. Get and Set on alias are applied
+// to target, and aliases may point at other aliases. Like a symbolic link, an alias
+// may point at a key that does not exist; reading it then returns ErrNotFound.
+// Calling Alias again with the same alias repoints it.
+//
+// Deleting an alias removes the alias and leaves its target in place. Deleting the
+// target leaves the alias dangling until the target is set again.
+//
+// It returns ErrDuplicate if alias is an existing key, ErrAliasLoop if the
+// alias would point back at itself and ErrReservedKey if alias or target is a
+// system key.
+func (kvs *KeyValueStore) Alias(alias, target string) error {
+	if isSystemKey(alias) || isSystemKey(target) {
+		return ErrReservedKey
+	}
+
+	resolved, err := kvs.ResolveAlias(target)
+	if err != nil {
+		return err
+	}
+	if resolved == alias {
+		return ErrAliasLoop
+	}
+
+	sh := kvs.shards[kvs.shardIndex(alias)]
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if _, err := sh.backend.Get(alias); err == nil {
+		return ErrDuplicate
+	}
+
+	sh.setAlias(alias, target)
+
+	return nil
+}
+
+// ResolveAlias returns the key that key refers to after following all aliases.
+// Keys that are not aliases resolve to themselves.
+func (kvs *KeyValueStore) ResolveAlias(key string) (string, error) {
+	sh, key, err := kvs.rlockKey(key)
+	if err != nil {
+		return "", err
+	}
+	sh.mu.RUnlock()
+
+	return key, nil
+}
+
+// aliasPath returns the aliases followed to resolve key, starting with key
+// itself; it is empty if key is not an alias.
+func (kvs *KeyValueStore) aliasPath(key string) ([]string, error) {
+	var path []string
+	for depth := 0; ; depth++ {
+		sh := kvs.shards[kvs.shardIndex(key)]
+		sh.mu.RLock()
+		target, ok := sh.aliases[key]
+		sh.mu.RUnlock()
+		if !ok {
+			return path, nil
+		}
+
+		if depth == maxAliasDepth {
+			return nil, ErrAliasLoop
+		}
+		if isSystemKey(target) {
+			return nil, ErrReservedKey
+		}
+		path = append(path, key)
+		key = target
+	}
+}
+
+// lockKey follows the aliases of key and returns the write-locked shard of the
+// key it resolves to, together with that key. Aliases are never followed into
+// the system keyspace; such aliases return ErrReservedKey.
+func (kvs *KeyValueStore) lockKey(key string) (*shard, string, error) {
+	for depth := 0; ; depth++ {
+		sh := kvs.shards[kvs.shardIndex(key)]
+		kvs.lockShard(sh)
+
+		target, ok := sh.aliases[key]
+		if !ok {
+			sh.recordAccess(key)
+			return sh, key, nil
+		}
+		sh.mu.Unlock()
+
+		if depth == maxAliasDepth {
+			return nil, "", ErrAliasLoop
+		}
+		if isSystemKey(target) {
+			return nil, "", ErrReservedKey
+		}
+		key = target
+	}
+}
+
+// rlockKey is like lockKey but read-locks the shard.
+func (kvs *KeyValueStore) rlockKey(key string) (*shard, string, error) {
+	for depth := 0; ; depth++ {
+		sh := kvs.shards[kvs.shardIndex(key)]
+		kvs.rlockShard(sh)
+
+		target, ok := sh.aliases[key]
+		if !ok {
+			sh.recordAccess(key)
+			return sh, key, nil
+		}
+		sh.mu.RUnlock()
+
+		if depth == maxAliasDepth {
+			return nil, "", ErrAliasLoop
+		}
+		if isSystemKey(target) {
+			return nil, "", ErrReservedKey
+		}
+		key = target
+	}
+}
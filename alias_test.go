@@ -0,0 +1,151 @@
+package kvs
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestAlias(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	if err := store.Set("config/v1", IntValue(1)); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if err := store.Alias("config/latest", "config/v1"); err != nil {
+		t.Fatalf("Alias returned an error: %v", err)
+	}
+
+	if val, err := store.Get("config/latest"); err != nil || val != IntValue(1) {
+		t.Errorf("Expected the alias to resolve to 1, got %v, %v", val, err)
+	}
+
+	if err := store.Set("config/v2", IntValue(2)); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if err := store.Alias("config/latest", "config/v2"); err != nil {
+		t.Fatalf("Alias returned an error: %v", err)
+	}
+	if val, _ := store.Get("config/latest"); val != IntValue(2) {
+		t.Errorf("Expected the repointed alias to resolve to 2, got %v", val)
+	}
+
+	if err := store.Set("config/latest", IntValue(3)); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if val, _ := store.Get("config/v2"); val != IntValue(3) {
+		t.Errorf("Expected Set on the alias to update the target, got %v", val)
+	}
+
+	keys, _ := store.Keys()
+	if len(keys) != 2 {
+		t.Errorf("Expected aliases to be excluded from Keys, got %v", keys)
+	}
+
+	if err := store.Delete("config/latest"); err != nil {
+		t.Fatalf("Delete returned an error: %v", err)
+	}
+	if _, err := store.Get("config/latest"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound for a deleted alias, got %v", err)
+	}
+	if _, err := store.Get("config/v2"); err != nil {
+		t.Errorf("Expected the target to survive deleting its alias, got %v", err)
+	}
+}
+
+func TestAlias_Dangling(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+
+	if err := store.Alias("a", "b"); err != nil {
+		t.Fatalf("Alias returned an error: %v", err)
+	}
+	if _, err := store.Get("a"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound for a dangling alias, got %v", err)
+	}
+	if err := store.Set("b", IntValue(1)); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if val, _ := store.Get("a"); val != IntValue(1) {
+		t.Errorf("Expected the alias to resolve once the target exists, got %v", val)
+	}
+}
+
+func TestAlias_Errors(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+
+	if err := store.Set("a", IntValue(1)); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if err := store.Alias("a", "b"); err != ErrDuplicate {
+		t.Errorf("Expected ErrDuplicate when aliasing an existing key, got %v", err)
+	}
+
+	if err := store.Alias("x", "y"); err != nil {
+		t.Fatalf("Alias returned an error: %v", err)
+	}
+	if err := store.Alias("y", "x"); err != ErrAliasLoop {
+		t.Errorf("Expected ErrAliasLoop, got %v", err)
+	}
+	if err := store.Alias("z", "z"); err != ErrAliasLoop {
+		t.Errorf("Expected ErrAliasLoop for a self alias, got %v", err)
+	}
+
+	if key, err := store.ResolveAlias("x"); err != nil || key != "y" {
+		t.Errorf("Expected x to resolve to y, got %q, %v", key, err)
+	}
+}
+
+func TestAlias_SystemTarget(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	_ = store.Bucket("secret").Set("k", Bytes("v"))
+	target := store.Bucket("secret").Prefix() + "k"
+
+	if err := store.Alias("x", target); err != ErrReservedKey {
+		t.Errorf("Expected ErrReservedKey for a system target, got %v", err)
+	}
+	if err := store.Alias("y", SystemPrefix+"stats/entries"); err != ErrReservedKey {
+		t.Errorf("Expected ErrReservedKey for a system target, got %v", err)
+	}
+
+	// Aliases into the system keyspace that exist anyway are not followed.
+	sh := store.shards[store.shardIndex("x")]
+	sh.setAlias("x", target)
+	if err := store.Set("x", Bytes("overwritten")); err != ErrReservedKey {
+		t.Errorf("Expected ErrReservedKey from Set, got %v", err)
+	}
+	if _, err := store.Get("x"); err != ErrReservedKey {
+		t.Errorf("Expected ErrReservedKey from Get, got %v", err)
+	}
+	_ = store.View(func(tx ReadTx) error {
+		if _, err := tx.Get("x"); err != ErrReservedKey {
+			t.Errorf("Expected ErrReservedKey from a view, got %v", err)
+		}
+		return nil
+	})
+	if val, _ := store.Bucket("secret").Get("k"); string(val.(Bytes)) != "v" {
+		t.Errorf("Expected the bucket entry to be unchanged, got %v", val)
+	}
+}
+
+func TestAlias_Snapshot(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	_ = store.Set("v1", IntValue(1))
+	if err := store.Alias("latest", "v1"); err != nil {
+		t.Fatalf("Alias returned an error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := store.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("WriteSnapshot returned an error: %v", err)
+	}
+
+	restored, _ := NewKeyValueStore(2)
+	if err := restored.ReadSnapshot(&buf); err != nil {
+		t.Fatalf("ReadSnapshot returned an error: %v", err)
+	}
+	if val, err := restored.Get("latest"); err != nil || val != IntValue(1) {
+		t.Errorf("Expected the alias to be restored, got %v, %v", val, err)
+	}
+}
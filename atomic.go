@@ -0,0 +1,314 @@
+package kvs
+
+import (
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// CompareAndSwap sets key to new if its current value equals old according to eq,
+// and reports whether it did. The comparison and the write happen under the shard
+// lock, so no other write can interleave. If eq is nil, reflect.DeepEqual is used.
+// It returns ErrNotFound if the key does not exist.
+func (kvs *KeyValueStore) CompareAndSwap(key string, old, new Value, eq func(a, b Value) bool) (swapped bool, err error) {
+	if len(kvs.opts.observers) > 0 {
+		defer kvs.observe(OpSet, key, time.Now(), &err)
+	}
+
+	if isSystemKey(key) {
+		return false, ErrReservedKey
+	}
+	if new == nil {
+		return false, ErrNilValue
+	}
+	eq = orDeepEqual(eq)
+
+	stored, err := kvs.compress(new)
+	if err != nil {
+		return false, err
+	}
+
+	sh, key, err := kvs.lockKey(key)
+	if err != nil {
+		return false, err
+	}
+	defer sh.mu.Unlock()
+
+	cur, err := kvs.current(sh, key)
+	if err != nil {
+		return false, err
+	}
+	if !eq(cur, old) {
+		return false, nil
+	}
+
+	if err := kvs.apply(sh, key, stored); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// CompareAndDelete deletes key if its current value equals expected according to
+// eq, and reports whether it did. The comparison and the delete happen under the
+// shard lock, so a concurrently refreshed entry is never removed. If eq is nil,
+// reflect.DeepEqual is used. If the key is an alias, the key it points at is
+// compared and deleted.
+// It returns ErrNotFound if the key does not exist and ErrImmutable if it is write-once.
+func (kvs *KeyValueStore) CompareAndDelete(key string, expected Value, eq func(a, b Value) bool) (deleted bool, err error) {
+	if len(kvs.opts.observers) > 0 {
+		defer kvs.observe(OpDelete, key, time.Now(), &err)
+	}
+
+	if isSystemKey(key) {
+		return false, ErrReservedKey
+	}
+	eq = orDeepEqual(eq)
+
+	sh, key, err := kvs.lockKey(key)
+	if err != nil {
+		return false, err
+	}
+	defer sh.mu.Unlock()
+
+	cur, err := kvs.current(sh, key)
+	if err != nil {
+		return false, err
+	}
+	if !eq(cur, expected) {
+		return false, nil
+	}
+	if sh.isImmutable(key) {
+		return false, ErrImmutable
+	}
+
+	if err := kvs.remove(sh, key); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// GetOrSet returns the value of key if it exists. Otherwise it calls compute and
+// stores and returns its value. loaded reports whether the value already existed.
+// compute runs under the shard lock, so it is called at most once per missing
+// key even with concurrent callers, but it must not use the store.
+// If compute returns an error, nothing is stored and the error is returned.
+func (kvs *KeyValueStore) GetOrSet(key string, compute func() (Value, error)) (_ Value, loaded bool, err error) {
+	if len(kvs.opts.observers) > 0 {
+		defer kvs.observe(OpGet, key, time.Now(), &err)
+	}
+
+	if isSystemKey(key) {
+		return nil, false, ErrReservedKey
+	}
+
+	sh, key, err := kvs.lockKey(key)
+	if err != nil {
+		return nil, false, err
+	}
+	defer sh.mu.Unlock()
+
+	cur, err := kvs.current(sh, key)
+	sh.counters.countGet(err)
+	if err == nil {
+		return cur, true, nil
+	}
+	if err != ErrNotFound {
+		return nil, false, err
+	}
+
+	val, err := compute()
+	if err != nil {
+		return nil, false, err
+	}
+	if val == nil {
+		return nil, false, ErrNilValue
+	}
+
+	stored, err := kvs.compress(val)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := kvs.apply(sh, key, stored); err != nil {
+		return nil, false, err
+	}
+
+	return val, false, nil
+}
+
+// GetAndDelete removes key and returns the value it held. Of several goroutines
+// consuming the same key, exactly one receives the value; the others get
+// ErrNotFound. If the key is an alias, the key it points at is consumed.
+// It returns ErrImmutable if the key is write-once.
+func (kvs *KeyValueStore) GetAndDelete(key string) (_ Value, err error) {
+	if len(kvs.opts.observers) > 0 {
+		defer kvs.observe(OpDelete, key, time.Now(), &err)
+	}
+
+	if isSystemKey(key) {
+		return nil, ErrReservedKey
+	}
+
+	sh, key, err := kvs.lockKey(key)
+	if err != nil {
+		return nil, err
+	}
+	defer sh.mu.Unlock()
+
+	val, err := kvs.current(sh, key)
+	sh.counters.countGet(err)
+	if err != nil {
+		return nil, err
+	}
+	if sh.isImmutable(key) {
+		return nil, ErrImmutable
+	}
+
+	if err := kvs.remove(sh, key); err != nil {
+		return nil, err
+	}
+
+	return val, nil
+}
+
+// Update replaces the value of key with the result of fn, which receives the
+// current value and whether the key exists. fn runs under the shard lock, so the
+// read-modify-write cannot interleave with other writes to the shard, but it must
+// not use the store. If fn returns an error, the key is left unchanged and the
+// error is returned. If the key is an alias, the key it points at is updated.
+// It returns ErrImmutable if the key is write-once and ErrNilValue if fn returns
+// a nil value.
+func (kvs *KeyValueStore) Update(key string, fn func(old Value, exists bool) (Value, error)) (err error) {
+	if len(kvs.opts.observers) > 0 {
+		defer kvs.observe(OpSet, key, time.Now(), &err)
+	}
+
+	if isSystemKey(key) {
+		return ErrReservedKey
+	}
+
+	sh, key, err := kvs.lockKey(key)
+	if err != nil {
+		return err
+	}
+	defer sh.mu.Unlock()
+
+	if sh.isImmutable(key) {
+		return ErrImmutable
+	}
+
+	old, err := kvs.current(sh, key)
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+
+	val, err := fn(old, old != nil)
+	if err != nil {
+		return err
+	}
+	if val == nil {
+		return ErrNilValue
+	}
+
+	val, err = kvs.compress(val)
+	if err != nil {
+		return err
+	}
+
+	return kvs.apply(sh, key, val)
+}
+
+// Incr adds delta to the integer held by key and returns the new value; a negative
+// delta decrements it. A missing key is created as a Counter holding delta.
+// Counter values and Bytes values holding a decimal integer can be incremented;
+// Bytes values stay Bytes so counters remain readable by byte-oriented clients.
+// It returns ErrNotNumber for other values and ErrOverflow if the result does
+// not fit into an int64.
+func (kvs *KeyValueStore) Incr(key string, delta int64) (n int64, err error) {
+	err = kvs.Update(key, func(old Value, exists bool) (Value, error) {
+		if !exists {
+			n = delta
+			return Counter(delta), nil
+		}
+
+		var cur int64
+		switch v := old.(type) {
+		case Counter:
+			cur = int64(v)
+		case Bytes:
+			i, err := strconv.ParseInt(string(v), 10, 64)
+			if err != nil {
+				return nil, ErrNotNumber
+			}
+			cur = i
+		default:
+			return nil, ErrNotNumber
+		}
+
+		n = cur + delta
+		if (delta > 0 && n < cur) || (delta < 0 && n > cur) {
+			return nil, ErrOverflow
+		}
+		if _, ok := old.(Bytes); ok {
+			return Bytes(strconv.FormatInt(n, 10)), nil
+		}
+
+		return Counter(n), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// Append appends data to the Bytes value of key and returns its new length.
+// A missing key is created holding a copy of data. The stored value is never
+// modified in place, so values returned by earlier reads stay unchanged.
+// It returns ErrNotBytes if the value is not Bytes.
+func (kvs *KeyValueStore) Append(key string, data []byte) (n int, err error) {
+	err = kvs.Update(key, func(old Value, exists bool) (Value, error) {
+		var cur Bytes
+		if exists {
+			b, ok := old.(Bytes)
+			if !ok {
+				return nil, ErrNotBytes
+			}
+			cur = b
+		}
+
+		val := make(Bytes, len(cur)+len(data))
+		copy(val, cur)
+		copy(val[len(cur):], data)
+		n = len(val)
+
+		return val, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return n, nil
+}
+
+// orDeepEqual returns eq, or reflect.DeepEqual if eq is nil.
+func orDeepEqual(eq func(a, b Value) bool) func(a, b Value) bool {
+	if eq != nil {
+		return eq
+	}
+
+	return func(a, b Value) bool {
+		return reflect.DeepEqual(a, b)
+	}
+}
+
+// current returns the decompressed value of key. The shard must be locked.
+func (kvs *KeyValueStore) current(sh *shard, key string) (Value, error) {
+	val, err := sh.backend.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return kvs.decompress(val)
+}
@@ -0,0 +1,345 @@
+package kvs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+)
+
+func TestCompareAndSwap(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	_ = store.Set("person", &Person{Name: "Alice", Age: 30})
+
+	swapped, err := store.CompareAndSwap("person", &Person{Name: "Bob", Age: 30}, &Person{Name: "Carol", Age: 40}, nil)
+	if err != nil {
+		t.Fatalf("CompareAndSwap returned an error: %v", err)
+	}
+	if swapped {
+		t.Error("Expected no swap for a different value")
+	}
+
+	swapped, err = store.CompareAndSwap("person", &Person{Name: "Alice", Age: 30}, &Person{Name: "Alice", Age: 31}, nil)
+	if err != nil {
+		t.Fatalf("CompareAndSwap returned an error: %v", err)
+	}
+	if !swapped {
+		t.Error("Expected a swap for an equal value")
+	}
+	if val, _ := store.Get("person"); val.(*Person).Age != 31 {
+		t.Errorf("Expected age 31, got %v", val)
+	}
+
+	if _, err := store.CompareAndSwap("missing", IntValue(1), IntValue(2), nil); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestCompareAndSwap_Concurrent(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	_ = store.Set("counter", IntValue(0))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				for {
+					val, _ := store.Get("counter")
+					n := val.(IntValue)
+					if ok, _ := store.CompareAndSwap("counter", n, n+1, nil); ok {
+						break
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if val, _ := store.Get("counter"); val.(IntValue) != 800 {
+		t.Errorf("Expected 800, got %v", val)
+	}
+}
+
+func TestCompareAndDelete(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	_ = store.Set("lease", Bytes("owner-1"))
+
+	deleted, err := store.CompareAndDelete("lease", Bytes("owner-2"), nil)
+	if err != nil {
+		t.Fatalf("CompareAndDelete returned an error: %v", err)
+	}
+	if deleted {
+		t.Error("Expected no delete for a different value")
+	}
+
+	deleted, err = store.CompareAndDelete("lease", Bytes("owner-1"), nil)
+	if err != nil {
+		t.Fatalf("CompareAndDelete returned an error: %v", err)
+	}
+	if !deleted {
+		t.Error("Expected a delete for an equal value")
+	}
+	if _, err := store.Get("lease"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if _, err := store.CompareAndDelete("lease", Bytes("owner-1"), nil); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	_ = store.SetImmutable("frozen", IntValue(1))
+	if _, err := store.CompareAndDelete("frozen", IntValue(1), nil); err != ErrImmutable {
+		t.Errorf("Expected ErrImmutable, got %v", err)
+	}
+}
+
+func TestGetOrSet(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	var calls int
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			val, _, err := store.GetOrSet("page", func() (Value, error) {
+				mu.Lock()
+				calls++
+				mu.Unlock()
+				return Bytes("rendered"), nil
+			})
+			if err != nil {
+				t.Errorf("GetOrSet returned an error: %v", err)
+				return
+			}
+			if string(val.(Bytes)) != "rendered" {
+				t.Errorf("unexpected value %v", val)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("Expected compute to be called once, got %d", calls)
+	}
+
+	_, loaded, err := store.GetOrSet("page", nil)
+	if err != nil {
+		t.Fatalf("GetOrSet returned an error: %v", err)
+	}
+	if !loaded {
+		t.Error("Expected the existing value to be loaded")
+	}
+
+	failed := errors.New("backend down")
+	if _, _, err := store.GetOrSet("other", func() (Value, error) { return nil, failed }); err != failed {
+		t.Errorf("Expected the compute error, got %v", err)
+	}
+	if _, err := store.Get("other"); err != ErrNotFound {
+		t.Errorf("Expected nothing to be stored, got %v", err)
+	}
+}
+
+func TestGetAndDelete(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		_ = store.Set(fmt.Sprintf("job-%d", i), IntValue(i))
+	}
+
+	var claimed sync.Map
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				val, err := store.GetAndDelete(fmt.Sprintf("job-%d", i))
+				if err == ErrNotFound {
+					continue
+				}
+				if err != nil {
+					t.Errorf("GetAndDelete returned an error: %v", err)
+					return
+				}
+				if _, dup := claimed.LoadOrStore(val, w); dup {
+					t.Errorf("job %v was claimed twice", val)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	n := 0
+	claimed.Range(func(_, _ any) bool {
+		n++
+		return true
+	})
+	if n != 100 {
+		t.Errorf("Expected 100 claimed jobs, got %d", n)
+	}
+	if keys, _ := store.Keys(); len(keys) != 0 {
+		t.Errorf("Expected an empty store, got %d keys", len(keys))
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				err := store.Update("counter", func(old Value, exists bool) (Value, error) {
+					if !exists {
+						return IntValue(1), nil
+					}
+					return old.(IntValue) + 1, nil
+				})
+				if err != nil {
+					t.Errorf("Update returned an error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if val, _ := store.Get("counter"); val.(IntValue) != 800 {
+		t.Errorf("Expected 800, got %v", val)
+	}
+
+	failed := errors.New("invalid")
+	err = store.Update("counter", func(old Value, exists bool) (Value, error) {
+		return nil, failed
+	})
+	if err != failed {
+		t.Errorf("Expected the update error, got %v", err)
+	}
+	if val, _ := store.Get("counter"); val.(IntValue) != 800 {
+		t.Errorf("Expected the value to be unchanged, got %v", val)
+	}
+
+	_ = store.SetImmutable("frozen", IntValue(1))
+	err = store.Update("frozen", func(old Value, exists bool) (Value, error) {
+		return IntValue(2), nil
+	})
+	if err != ErrImmutable {
+		t.Errorf("Expected ErrImmutable, got %v", err)
+	}
+}
+
+func TestIncr(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if _, err := store.Incr("hits", 1); err != nil {
+					t.Errorf("Incr returned an error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	n, err := store.Incr("hits", -10)
+	if err != nil {
+		t.Fatalf("Incr returned an error: %v", err)
+	}
+	if n != 790 {
+		t.Errorf("Expected 790, got %d", n)
+	}
+	if val, _ := store.Get("hits"); val != Counter(790) {
+		t.Errorf("Expected Counter(790), got %#v", val)
+	}
+
+	_ = store.Set("text", Bytes("41"))
+	if n, err := store.Incr("text", 1); err != nil || n != 42 {
+		t.Errorf("Expected 42, got %d, %v", n, err)
+	}
+	if val, _ := store.Get("text"); string(val.(Bytes)) != "42" {
+		t.Errorf("Expected Bytes 42, got %#v", val)
+	}
+
+	_ = store.Set("name", Bytes("alice"))
+	if _, err := store.Incr("name", 1); err != ErrNotNumber {
+		t.Errorf("Expected ErrNotNumber, got %v", err)
+	}
+
+	_ = store.Set("max", Counter(math.MaxInt64))
+	if _, err := store.Incr("max", 1); err != ErrOverflow {
+		t.Errorf("Expected ErrOverflow, got %v", err)
+	}
+}
+
+func TestAppend(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	n, err := store.Append("log", []byte("a"))
+	if err != nil {
+		t.Fatalf("Append returned an error: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("Expected length 1, got %d", n)
+	}
+	first, _ := store.Get("log")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if _, err := store.Append("log", []byte("b")); err != nil {
+					t.Errorf("Append returned an error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	val, _ := store.Get("log")
+	if len(val.(Bytes)) != 401 {
+		t.Errorf("Expected length 401, got %d", len(val.(Bytes)))
+	}
+	if string(first.(Bytes)) != "a" {
+		t.Errorf("Expected an earlier read to stay unchanged, got %q", first)
+	}
+
+	_ = store.Set("n", Counter(1))
+	if _, err := store.Append("n", []byte("x")); err != ErrNotBytes {
+		t.Errorf("Expected ErrNotBytes, got %v", err)
+	}
+}
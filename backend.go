@@ -0,0 +1,96 @@
+package kvs
+
+// Backend is an interface that defines the storage engine behind a single shard.
+// Shards serialize writes to their backend, but Get, Keys and Len may run
+// concurrently with each other. Implementations that modify their state on
+// reads must synchronize internally.
+// Backends that hold external resources may implement io.Closer; they are
+// closed by KeyValueStore.Close.
+type Backend interface {
+	// Get retrieves the value associated with the given key.
+	// If the key is not found, it returns an ErrNotFound error.
+	Get(key string) (Value, error)
+
+	// Set adds or updates the given key-value pair.
+	Set(key string, val Value) error
+
+	// Delete removes the key-value pair associated with the given key.
+	// If the key is not found, it returns an ErrNotFound error.
+	Delete(key string) error
+
+	// Keys returns a slice of all the keys in the backend.
+	Keys() ([]string, error)
+
+	// Len returns the number of entries in the backend.
+	Len() int
+}
+
+// BackendFactory creates the backend for the shard with the given id.
+type BackendFactory func(shardID int) (Backend, error)
+
+// memoryBackend is a Backend that keeps all entries in a map.
+type memoryBackend struct {
+	store map[string]Value
+	size  int64
+}
+
+// NewMemoryBackend creates a new in-memory Backend. It is the default backend of a KeyValueStore.
+func NewMemoryBackend() Backend {
+	return &memoryBackend{
+		store: make(map[string]Value),
+	}
+}
+
+// Get retrieves the value associated with the given key.
+func (m *memoryBackend) Get(key string) (Value, error) {
+	val, ok := m.store[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return val, nil
+}
+
+// Set adds or updates the given key-value pair.
+func (m *memoryBackend) Set(key string, val Value) error {
+	if old, ok := m.store[key]; ok {
+		m.size -= estimateSize(key, old)
+	}
+	m.store[key] = val
+	m.size += estimateSize(key, val)
+
+	return nil
+}
+
+// Delete removes the key-value pair associated with the given key.
+func (m *memoryBackend) Delete(key string) error {
+	old, ok := m.store[key]
+	if !ok {
+		return ErrNotFound
+	}
+
+	delete(m.store, key)
+	m.size -= estimateSize(key, old)
+
+	return nil
+}
+
+// Keys returns a slice of all the keys in the backend.
+func (m *memoryBackend) Keys() ([]string, error) {
+	keys := make([]string, 0, len(m.store))
+	for k := range m.store {
+		keys = append(keys, k)
+	}
+
+	return keys, nil
+}
+
+// Len returns the number of entries in the backend.
+func (m *memoryBackend) Len() int {
+	return len(m.store)
+}
+
+// bytes returns the estimated number of bytes held by the backend.
+func (m *memoryBackend) bytes() int64 {
+	return m.size
+}
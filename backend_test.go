@@ -0,0 +1,40 @@
+package kvs
+
+import "testing"
+
+func TestWithBackend(t *testing.T) {
+	var created []int
+	store, err := NewKeyValueStore(3, WithBackend(func(shardID int) (Backend, error) {
+		created = append(created, shardID)
+		return NewMemoryBackend(), nil
+	}))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	if len(created) != 3 {
+		t.Fatalf("Expected 3 backends, got %d", len(created))
+	}
+
+	if err := store.Set("person", Person{Name: "Alice", Age: 30}); err != nil {
+		t.Errorf("Set returned an error: %v", err)
+	}
+	if _, err := store.Get("person"); err != nil {
+		t.Errorf("Get returned an error: %v", err)
+	}
+	if err := store.Delete("person"); err != nil {
+		t.Errorf("Delete returned an error: %v", err)
+	}
+	if err := store.Delete("person"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestWithBackend_Error(t *testing.T) {
+	_, err := NewKeyValueStore(3, WithBackend(func(shardID int) (Backend, error) {
+		return nil, ErrUnknown
+	}))
+	if err != ErrUnknown {
+		t.Errorf("Expected ErrUnknown, got %v", err)
+	}
+}
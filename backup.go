@@ -0,0 +1,141 @@
+package kvs
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// DefaultPartSize is the part size used for multipart backup uploads.
+const DefaultPartSize = 8 << 20
+
+// defaultPartRetries is the number of times a failed part upload is retried.
+const defaultPartRetries = 3
+
+// BackupSink is an interface that defines a multipart upload target for backups,
+// modelled after S3-compatible object stores.
+type BackupSink interface {
+	// CreateUpload starts a multipart upload of the named object and returns its upload id.
+	CreateUpload(ctx context.Context, name string) (string, error)
+
+	// UploadPart uploads one part of an upload and returns its ETag.
+	// Part numbers start at 1 and may be uploaded again to replace a part.
+	UploadPart(ctx context.Context, name, uploadID string, number int, data []byte) (string, error)
+
+	// CompleteUpload assembles the uploaded parts into the final object.
+	CompleteUpload(ctx context.Context, name, uploadID string, parts []CompletedPart) error
+
+	// AbortUpload discards an upload and all of its parts.
+	AbortUpload(ctx context.Context, name, uploadID string) error
+}
+
+// CompletedPart identifies a successfully uploaded part.
+type CompletedPart struct {
+	Number int
+	ETag   string
+}
+
+// UploadState tracks the progress of a multipart upload so it can be resumed.
+type UploadState struct {
+	// Name is the name of the uploaded object.
+	Name string
+	// UploadID is the id assigned by the sink. It is empty until the upload is created.
+	UploadID string
+	// Parts lists the parts uploaded so far.
+	Parts []CompletedPart
+	// Offset is the number of bytes of the source uploaded so far.
+	Offset int64
+	// PartSize is the size of every part but the last. Zero means DefaultPartSize.
+	PartSize int
+}
+
+// Upload streams r to sink as a multipart upload described by state.
+// If Upload fails, state records the parts that were uploaded; calling Upload again
+// with the same state and a reader positioned at state.Offset resumes the upload.
+// Each part is retried a few times before Upload gives up.
+func Upload(ctx context.Context, sink BackupSink, r io.Reader, state *UploadState) error {
+	if state.PartSize <= 0 {
+		state.PartSize = DefaultPartSize
+	}
+
+	if state.UploadID == "" {
+		id, err := sink.CreateUpload(ctx, state.Name)
+		if err != nil {
+			return fmt.Errorf("kvs: create upload: %w", err)
+		}
+		state.UploadID = id
+	}
+
+	buf := make([]byte, state.PartSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		// An empty source is uploaded as a single empty part, as object
+		// stores reject completing an upload without parts.
+		if err == io.EOF && len(state.Parts) > 0 {
+			break
+		}
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+
+		number := len(state.Parts) + 1
+		etag, err := uploadPart(ctx, sink, state, number, buf[:n])
+		if err != nil {
+			return err
+		}
+
+		state.Parts = append(state.Parts, CompletedPart{Number: number, ETag: etag})
+		state.Offset += int64(n)
+
+		if n < len(buf) {
+			break
+		}
+	}
+
+	if err := sink.CompleteUpload(ctx, state.Name, state.UploadID, state.Parts); err != nil {
+		return fmt.Errorf("kvs: complete upload: %w", err)
+	}
+
+	return nil
+}
+
+// uploadPart uploads a single part, retrying on failure.
+func uploadPart(ctx context.Context, sink BackupSink, state *UploadState, number int, data []byte) (string, error) {
+	var err error
+	for attempt := 0; attempt <= defaultPartRetries; attempt++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+
+		var etag string
+		etag, err = sink.UploadPart(ctx, state.Name, state.UploadID, number, data)
+		if err == nil {
+			return etag, nil
+		}
+	}
+
+	return "", fmt.Errorf("kvs: upload part %d: %w", number, err)
+}
+
+// Backup streams a snapshot of the store to sink under the given name.
+// A live snapshot cannot be reproduced byte for byte, so a failed backup is aborted
+// rather than left resumable; write the snapshot to a file and use Upload to get
+// resumable uploads.
+func (kvs *KeyValueStore) Backup(ctx context.Context, sink BackupSink, name string) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(kvs.WriteSnapshot(kvs.throttle(pw)))
+	}()
+
+	state := &UploadState{Name: name}
+	err := Upload(ctx, sink, pr, state)
+	pr.CloseWithError(err)
+	if err != nil && state.UploadID != "" {
+		// The upload is aborted even if it failed because ctx is done.
+		if abortErr := sink.AbortUpload(context.WithoutCancel(ctx), name, state.UploadID); abortErr != nil {
+			return fmt.Errorf("%w (abort upload: %v)", err, abortErr)
+		}
+	}
+
+	return err
+}
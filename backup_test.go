@@ -0,0 +1,291 @@
+package kvs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+)
+
+// memorySink is a BackupSink that assembles uploads in memory.
+type memorySink struct {
+	parts    map[int][]byte
+	objects  map[string][]byte
+	failPart int
+	aborted  bool
+	// cancel, if set, is called when a part fails.
+	cancel context.CancelFunc
+}
+
+func newMemorySink() *memorySink {
+	return &memorySink{
+		parts:   make(map[int][]byte),
+		objects: make(map[string][]byte),
+	}
+}
+
+func (m *memorySink) CreateUpload(ctx context.Context, name string) (string, error) {
+	return "upload-1", nil
+}
+
+func (m *memorySink) UploadPart(ctx context.Context, name, uploadID string, number int, data []byte) (string, error) {
+	if number == m.failPart {
+		if m.cancel != nil {
+			m.cancel()
+		}
+		return "", errors.New("part failed")
+	}
+	m.parts[number] = append([]byte(nil), data...)
+	return fmt.Sprintf("etag-%d", number), nil
+}
+
+func (m *memorySink) CompleteUpload(ctx context.Context, name, uploadID string, parts []CompletedPart) error {
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	var buf bytes.Buffer
+	for _, p := range parts {
+		buf.Write(m.parts[p.Number])
+	}
+	m.objects[name] = buf.Bytes()
+	return nil
+}
+
+func (m *memorySink) AbortUpload(ctx context.Context, name, uploadID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	m.aborted = true
+	return nil
+}
+
+func TestSnapshot(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		if err := store.Set(fmt.Sprintf("key-%d", i), Person{Name: "Alice", Age: i}); err != nil {
+			t.Fatalf("Set returned an error: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := store.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("WriteSnapshot returned an error: %v", err)
+	}
+
+	restored, err := NewKeyValueStore(2)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	if err := restored.ReadSnapshot(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("ReadSnapshot returned an error: %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		val, err := restored.Get(fmt.Sprintf("key-%d", i))
+		if err != nil {
+			t.Fatalf("Get returned an error: %v", err)
+		}
+		if p, ok := val.(Person); !ok || p.Age != i {
+			t.Errorf("unexpected value %v", val)
+		}
+	}
+
+	truncated := buf.Bytes()[:buf.Len()-1]
+	if err := restored.ReadSnapshot(bytes.NewReader(truncated)); err != ErrCorruptSnapshot {
+		t.Errorf("Expected ErrCorruptSnapshot, got %v", err)
+	}
+}
+
+func TestSnapshot_LargeRecords(t *testing.T) {
+	store, _ := NewKeyValueStore(2)
+	large := bytes.Repeat([]byte("x"), 3*recordChunk+1)
+	_ = store.Set("large", Bytes(large))
+
+	var buf bytes.Buffer
+	if err := store.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("WriteSnapshot returned an error: %v", err)
+	}
+	restored, _ := NewKeyValueStore(2)
+	if err := restored.ReadSnapshot(&buf); err != nil {
+		t.Fatalf("ReadSnapshot returned an error: %v", err)
+	}
+	if val, err := restored.Get("large"); err != nil || !bytes.Equal(val.(Bytes), large) {
+		t.Errorf("Expected the large value to be restored, got %v", err)
+	}
+
+	// A record claiming 4 GiB in a short stream is rejected without
+	// allocating it.
+	corrupt := append(append([]byte{}, snapshotMagic...), snapshotEntry, 0x80, 0x80, 0x80, 0x80, 0x10, 'k')
+	if err := restored.ReadSnapshot(bytes.NewReader(corrupt)); err != ErrCorruptSnapshot {
+		t.Errorf("Expected ErrCorruptSnapshot, got %v", err)
+	}
+}
+
+func TestBackup(t *testing.T) {
+	store, err := NewKeyValueStore(4, WithCompression(FlateCompressor{}, 0))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		if err := store.Set(fmt.Sprintf("key-%d", i), Person{Name: "Alice", Age: i}); err != nil {
+			t.Fatalf("Set returned an error: %v", err)
+		}
+	}
+
+	sink := newMemorySink()
+	if err := store.Backup(context.Background(), sink, "backup"); err != nil {
+		t.Fatalf("Backup returned an error: %v", err)
+	}
+
+	restored, err := NewKeyValueStore(2)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	if err := restored.ReadSnapshot(bytes.NewReader(sink.objects["backup"])); err != nil {
+		t.Fatalf("ReadSnapshot returned an error: %v", err)
+	}
+	keys, _ := restored.Keys()
+	if len(keys) != 50 {
+		t.Errorf("Expected 50 keys, got %d", len(keys))
+	}
+
+	sink = newMemorySink()
+	sink.failPart = 1
+	if err := store.Backup(context.Background(), sink, "backup"); err == nil {
+		t.Error("Expected Backup to fail")
+	}
+	if !sink.aborted {
+		t.Error("Expected failed backup to be aborted")
+	}
+}
+
+func TestUpload_Resume(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), 10)
+	sink := newMemorySink()
+	sink.failPart = 3
+
+	state := &UploadState{Name: "object", PartSize: 20}
+	if err := Upload(context.Background(), sink, bytes.NewReader(data), state); err == nil {
+		t.Fatal("Expected Upload to fail")
+	}
+	if len(state.Parts) != 2 || state.Offset != 40 {
+		t.Fatalf("unexpected state after failure: %+v", state)
+	}
+
+	sink.failPart = 0
+	if err := Upload(context.Background(), sink, bytes.NewReader(data[state.Offset:]), state); err != nil {
+		t.Fatalf("Upload returned an error: %v", err)
+	}
+	if !bytes.Equal(sink.objects["object"], data) {
+		t.Error("resumed upload does not match the source")
+	}
+}
+
+func TestUpload_Empty(t *testing.T) {
+	sink := newMemorySink()
+	state := &UploadState{Name: "object"}
+	if err := Upload(context.Background(), sink, bytes.NewReader(nil), state); err != nil {
+		t.Fatalf("Upload returned an error: %v", err)
+	}
+	if len(state.Parts) != 1 {
+		t.Errorf("Expected a single empty part, got %+v", state.Parts)
+	}
+	if obj, ok := sink.objects["object"]; !ok || len(obj) != 0 {
+		t.Errorf("Expected an empty object, got %q", obj)
+	}
+}
+
+func TestBackup_Canceled(t *testing.T) {
+	store, _ := NewKeyValueStore(2)
+	_ = store.Set("a", IntValue(1))
+	sink := newMemorySink()
+	sink.failPart = 1
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sink.cancel = cancel
+	if err := store.Backup(ctx, sink, "backup"); err == nil {
+		t.Fatal("Expected Backup to fail")
+	}
+	if !sink.aborted {
+		t.Error("Expected the upload to be aborted after ctx was canceled")
+	}
+}
+
+func TestImportSnapshot_DryRun(t *testing.T) {
+	source, _ := NewKeyValueStore(2)
+	for i := 0; i < 4; i++ {
+		if err := source.Set(fmt.Sprintf("key-%d", i), IntValue(i)); err != nil {
+			t.Fatalf("Set returned an error: %v", err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := source.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("WriteSnapshot returned an error: %v", err)
+	}
+
+	target, _ := NewKeyValueStore(2)
+	if err := target.Set("key-0", IntValue(100)); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+
+	report, err := target.ImportSnapshot(bytes.NewReader(buf.Bytes()), DryRun())
+	if err != nil {
+		t.Fatalf("ImportSnapshot returned an error: %v", err)
+	}
+	if !report.DryRun || report.Created != 3 || report.Overwritten != 1 || len(report.OverwrittenSample) != 1 || report.OverwrittenSample[0] != "key-0" {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if val, _ := target.Get("key-0"); val != IntValue(100) {
+		t.Errorf("dry run modified key-0: %v", val)
+	}
+	if keys, _ := target.Keys(); len(keys) != 1 {
+		t.Errorf("dry run created keys: %v", keys)
+	}
+
+	report, err = target.ImportSnapshot(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("ImportSnapshot returned an error: %v", err)
+	}
+	if report.DryRun || report.Created != 3 || report.Overwritten != 1 {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if val, _ := target.Get("key-0"); val != IntValue(0) {
+		t.Errorf("import did not overwrite key-0: %v", val)
+	}
+}
+
+func TestImportSnapshot_SystemKeys(t *testing.T) {
+	store, _ := NewKeyValueStore(2)
+	data, _ := store.opts.codec.Marshal(IntValue(1))
+	snapshot := func(kind byte, key string) *bytes.Reader {
+		var buf bytes.Buffer
+		bw := bufio.NewWriter(&buf)
+		_, _ = bw.Write(snapshotMagic)
+		_ = writeRecord(bw, kind, key, data)
+		_ = bw.WriteByte(snapshotEnd)
+		_ = bw.Flush()
+		return bytes.NewReader(buf.Bytes())
+	}
+
+	if _, err := store.ImportSnapshot(snapshot(snapshotEntry, SystemPrefix+"config/codec")); !errors.Is(err, ErrReservedKey) {
+		t.Errorf("Expected ErrReservedKey for a computed system key, got %v", err)
+	}
+
+	key := store.Bucket("b").Prefix() + "a"
+	if _, err := store.ImportSnapshot(snapshot(snapshotImmutableEntry, key)); err != nil {
+		t.Fatalf("ImportSnapshot returned an error: %v", err)
+	}
+	if !store.IsImmutable(key) {
+		t.Error("Expected the bucket entry to be imported as write-once")
+	}
+	report, err := store.ImportSnapshot(snapshot(snapshotEntry, "c"), DryRun())
+	if err != nil || report.Created != 1 {
+		t.Errorf("Expected a created entry, got %+v, %v", report, err)
+	}
+	if stats := store.Stats(); stats.Hits != 0 || stats.Misses != 0 {
+		t.Errorf("Expected imports not to count gets, got %+v", stats.OpCounts)
+	}
+}
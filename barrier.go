@@ -0,0 +1,129 @@
+package kvs
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"time"
+)
+
+// errBarrierReleased aborts the arrival at a barrier that was already released.
+var errBarrierReleased = errors.New("kvs: barrier is released")
+
+// Barrier waits until n participants, the caller included, have arrived at the
+// barrier stored under key name, or ctx is done. Arrivals are kept in the key
+// like the holders of a semaphore and hold a lease while they wait, so a
+// participant that died stops counting once its lease expires. When the n-th
+// participant arrives the barrier is released for good: all waiters return and
+// later calls return immediately. Delete the key to use the name again.
+//
+//	if err := store.Barrier(ctx, "jobs/reindex/start", 4); err != nil {
+//		// Handle the error
+//	}
+//
+// If ctx is done first, the arrival is withdrawn and ctx.Err() is returned. It
+// returns ErrLeaseExpired if the arrival was lost while waiting, for example
+// because the key was deleted, ErrNotSemaphore if name holds another value and
+// ErrInvalidArgument if n is not positive or the lease duration is too short to
+// be renewed.
+func (kvs *KeyValueStore) Barrier(ctx context.Context, name string, n int, opts ...LeaseOption) error {
+	o := leaseOptions{ttl: defaultLeaseTTL}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := checkLease(n, o.ttl); err != nil {
+		return err
+	}
+	id := rand.Text()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// Watch before arriving, so the release is not missed.
+	changes := kvs.Watch(ctx, name)
+
+	released, err := kvs.arrive(name, id, n, o.ttl)
+	if err != nil || released {
+		return err
+	}
+	lease := kvs.startLease(name, id, o.ttl)
+	defer lease.stopRenewing()
+
+	for {
+		if err := kvs.waitForChange(ctx, name, &changes, time.Time{}); err != nil {
+			_ = lease.Release()
+			return err
+		}
+
+		val, err := kvs.getLive(name)
+		if err == ErrNotFound {
+			return ErrLeaseExpired
+		}
+		if err != nil {
+			return err
+		}
+		st, err := decodeLeaseState(val, true)
+		if err != nil {
+			return err
+		}
+		if st.Released {
+			return nil
+		}
+		if _, ok := st.Holders[id]; !ok {
+			return ErrLeaseExpired
+		}
+	}
+}
+
+// arrive adds id to the arrivals at the barrier name and releases the barrier if
+// n live participants have arrived. It reports whether the barrier is released.
+func (kvs *KeyValueStore) arrive(name, id string, n int, ttl time.Duration) (bool, error) {
+	released := false
+	err := kvs.Update(name, func(old Value, exists bool) (Value, error) {
+		st, err := decodeLeaseState(old, exists)
+		if err != nil {
+			return nil, err
+		}
+		if st.Released {
+			return nil, errBarrierReleased
+		}
+
+		now := time.Now()
+		st.Holders.prune(now)
+		st.Holders[id] = now.Add(ttl).UnixNano()
+		if len(st.Holders) >= n {
+			st.Holders = nil
+			st.Released = true
+			released = true
+		}
+
+		return st.encode()
+	})
+	if err == errBarrierReleased {
+		return true, nil
+	}
+
+	return released, err
+}
+
+// WaitFor waits until key exists and returns its value, or until ctx is done.
+// It acts as a latch: waiters block until any writer sets the key.
+//
+//	if _, err := store.WaitFor(ctx, "migrations/done"); err != nil {
+//		// Handle the error
+//	}
+func (kvs *KeyValueStore) WaitFor(ctx context.Context, key string) (Value, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// Watch before reading, so a set between the read and the wait is not missed.
+	changes := kvs.Watch(ctx, key)
+
+	for {
+		val, err := kvs.getLive(key)
+		if err != ErrNotFound {
+			return val, err
+		}
+		if err := kvs.waitForChange(ctx, key, &changes, time.Time{}); err != nil {
+			return nil, err
+		}
+	}
+}
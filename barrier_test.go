@@ -0,0 +1,133 @@
+package kvs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBarrier(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var arrived sync.WaitGroup
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		arrived.Add(1)
+		go func() {
+			defer arrived.Done()
+			errs <- store.Barrier(ctx, "start", 3)
+		}()
+	}
+	arrived.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Barrier returned an error: %v", err)
+		}
+	}
+
+	// A released barrier lets later participants through.
+	if err := store.Barrier(ctx, "start", 3); err != nil {
+		t.Errorf("Barrier returned an error: %v", err)
+	}
+}
+
+func TestBarrier_Timeout(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := store.Barrier(ctx, "start", 2); err != context.DeadlineExceeded {
+		t.Fatalf("Expected DeadlineExceeded, got %v", err)
+	}
+
+	val, err := store.Get("start")
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+	if string(val.(Bytes)) != `{"holders":{}}` {
+		t.Errorf("Expected the arrival to be withdrawn, got %s", val)
+	}
+}
+
+func TestBarrier_InvalidArgument(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	defer store.Close()
+
+	for _, ttl := range []time.Duration{0, 2 * time.Nanosecond} {
+		if err := store.Barrier(context.Background(), "start", 2, WithLeaseTTL(ttl)); err != ErrInvalidArgument {
+			t.Errorf("Expected ErrInvalidArgument for a lease of %v, got %v", ttl, err)
+		}
+	}
+	if err := store.Barrier(context.Background(), "start", 0); err != ErrInvalidArgument {
+		t.Errorf("Expected ErrInvalidArgument, got %v", err)
+	}
+}
+
+func TestBarrier_DeadParticipant(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	defer store.Close()
+
+	// A participant that arrived and died; its lease has expired.
+	expiry := time.Now().Add(-time.Second).UnixNano()
+	_ = store.Set("start", Bytes(fmt.Sprintf(`{"holders":{"dead":%d}}`, expiry)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := store.Barrier(ctx, "start", 2); err != context.DeadlineExceeded {
+		t.Errorf("Expected the dead participant not to count, got %v", err)
+	}
+}
+
+func TestWaitFor(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = store.Set("ready", IntValue(1))
+	}()
+	val, err := store.WaitFor(ctx, "ready")
+	if err != nil {
+		t.Fatalf("WaitFor returned an error: %v", err)
+	}
+	if val.(IntValue) != 1 {
+		t.Errorf("Expected 1, got %v", val)
+	}
+
+	short, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := store.WaitFor(short, "never"); err != context.DeadlineExceeded {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestWaitFor_ReadReplicas(t *testing.T) {
+	store, _ := NewKeyValueStore(4, WithReadReplicas(time.Hour))
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		_ = store.Set("ready", IntValue(1))
+	}()
+	// The replicas are not refreshed before the deadline.
+	if _, err := store.WaitFor(ctx, "ready"); err != nil {
+		t.Fatalf("WaitFor returned an error: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package kvs
+
+import (
+	"sync"
+	"time"
+)
+
+// WithWriteBatching makes concurrent Sets to the same shard wait up to window and
+// apply together under a single acquisition of the shard lock. The first Set to
+// arrive leads the batch; Sets that arrive within the window join it. This trades a
+// little latency per write for throughput when many goroutines write to few shards.
+func WithWriteBatching(window time.Duration) Option {
+	return func(o *options) {
+		o.batchWindow = window
+	}
+}
+
+// batchedSet is a Set waiting in a write batch.
+type batchedSet struct {
+	key string
+	val Value
+	err error
+}
+
+// writeBatch is a group of Sets applied under one shard lock acquisition.
+type writeBatch struct {
+	sets []batchedSet
+	done chan struct{}
+}
+
+// batcher collects the Sets of a shard into write batches.
+type batcher struct {
+	mu      sync.Mutex
+	pending *writeBatch
+}
+
+// batchSet adds a Set to the open batch of the key's shard, or opens and leads
+// a new batch, and returns the result of the Set once the batch is applied.
+func (kvs *KeyValueStore) batchSet(key string, val Value) error {
+	sh := kvs.shards[kvs.shardIndex(key)]
+	b := &sh.batcher
+
+	b.mu.Lock()
+	wb := b.pending
+	leader := wb == nil
+	if leader {
+		wb = &writeBatch{done: make(chan struct{})}
+		b.pending = wb
+	}
+	i := len(wb.sets)
+	wb.sets = append(wb.sets, batchedSet{key: key, val: val})
+	b.mu.Unlock()
+
+	if !leader {
+		<-wb.done
+		return wb.sets[i].err
+	}
+
+	time.Sleep(kvs.opts.batchWindow)
+
+	b.mu.Lock()
+	b.pending = nil
+	b.mu.Unlock()
+
+	kvs.applyBatch(sh, wb.sets)
+	close(wb.done)
+
+	return wb.sets[0].err
+}
+
+// applyBatch applies the Sets of a batch under one lock acquisition. Sets of keys
+// that turned out to be aliases are applied afterwards through their target.
+func (kvs *KeyValueStore) applyBatch(sh *shard, sets []batchedSet) {
+	var aliased []int
+
+	kvs.lockShard(sh)
+	for i := range sets {
+		bs := &sets[i]
+		if _, ok := sh.aliases[bs.key]; ok {
+			aliased = append(aliased, i)
+			continue
+		}
+
+		sh.recordAccess(bs.key)
+		bs.err = kvs.apply(sh, bs.key, bs.val)
+	}
+	sh.mu.Unlock()
+
+	for _, i := range aliased {
+		sets[i].err = kvs.set(sets[i].key, sets[i].val)
+	}
+}
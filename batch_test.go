@@ -0,0 +1,88 @@
+package kvs
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithWriteBatching(t *testing.T) {
+	store, err := NewKeyValueStore(2, WithWriteBatching(time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	if err := store.SetImmutable("frozen", IntValue(0)); err != nil {
+		t.Fatalf("SetImmutable returned an error: %v", err)
+	}
+	_ = store.Set("target", IntValue(0))
+	if err := store.Alias("alias", "target"); err != nil {
+		t.Fatalf("Alias returned an error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 100)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = store.Set(fmt.Sprintf("key-%d", i), IntValue(i))
+		}(i)
+	}
+	var frozenErr, aliasErr error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		frozenErr = store.Set("frozen", IntValue(1))
+	}()
+	go func() {
+		defer wg.Done()
+		aliasErr = store.Set("alias", IntValue(1))
+	}()
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("Set(key-%d) returned an error: %v", i, err)
+		}
+		if val, _ := store.Get(fmt.Sprintf("key-%d", i)); val != IntValue(i) {
+			t.Errorf("Expected key-%d to hold %d, got %v", i, i, val)
+		}
+	}
+	if frozenErr != ErrImmutable {
+		t.Errorf("Expected ErrImmutable for a batched write-once key, got %v", frozenErr)
+	}
+	if aliasErr != nil {
+		t.Errorf("Set through an alias returned an error: %v", aliasErr)
+	}
+	if val, _ := store.Get("target"); val != IntValue(1) {
+		t.Errorf("Expected the batched alias write to update the target, got %v", val)
+	}
+}
+
+func benchmarkContendedSet(b *testing.B, opts ...Option) {
+	store, err := NewKeyValueStore(1, opts...)
+	if err != nil {
+		b.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	b.SetParallelism(64)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if err := store.Set(fmt.Sprintf("key-%d", i%128), IntValue(i)); err != nil {
+				b.Errorf("Set returned an error: %v", err)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkSetContended(b *testing.B) {
+	benchmarkContendedSet(b)
+}
+
+func BenchmarkSetContended_Batched(b *testing.B) {
+	benchmarkContendedSet(b, WithWriteBatching(20*time.Microsecond))
+}
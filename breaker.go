@@ -0,0 +1,327 @@
+package kvs
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState string
+
+const (
+	// BreakerClosed lets every call through and counts consecutive failures.
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen rejects every call until the cooldown has passed.
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen lets a limited number of probes through; the first probe
+	// to report decides whether the breaker closes or opens again.
+	BreakerHalfOpen BreakerState = "half-open"
+)
+
+const (
+	// defaultBreakerFailures is the failure threshold used without WithFailureThreshold.
+	defaultBreakerFailures = 5
+	// defaultBreakerCooldown is the cooldown used without WithCooldown.
+	defaultBreakerCooldown = 30 * time.Second
+)
+
+// errBreakerUnchanged aborts an update that would not change the breaker.
+var errBreakerUnchanged = errors.New("kvs: breaker is unchanged")
+
+// breakerOptions holds the settings of a circuit breaker.
+type breakerOptions struct {
+	failures int
+	cooldown time.Duration
+	probes   int
+}
+
+// BreakerOption configures a circuit breaker.
+type BreakerOption func(*breakerOptions)
+
+// WithFailureThreshold sets the number of consecutive failures that open the
+// breaker. The default is 5.
+func WithFailureThreshold(n int) BreakerOption {
+	return func(o *breakerOptions) {
+		o.failures = n
+	}
+}
+
+// WithCooldown sets how long the breaker stays open before it lets probes
+// through. It is also the lifetime of a probe: a probe that never reports stops
+// counting after the cooldown. The default is 30 seconds.
+func WithCooldown(d time.Duration) BreakerOption {
+	return func(o *breakerOptions) {
+		o.cooldown = d
+	}
+}
+
+// WithHalfOpenProbes sets how many calls a half-open breaker lets through at a
+// time. The default is 1.
+func WithHalfOpenProbes(n int) BreakerOption {
+	return func(o *breakerOptions) {
+		o.probes = n
+	}
+}
+
+// breakerState is the stored state of a circuit breaker. It is stored as JSON,
+// so any client can read it.
+type breakerState struct {
+	State    BreakerState `json:"state"`
+	Failures int          `json:"failures,omitempty"`
+	// Until is the end of the cooldown of an open breaker in Unix nanoseconds.
+	Until int64 `json:"until,omitempty"`
+	// Probes are the probes of a half-open breaker.
+	Probes leaseHolders `json:"probes,omitempty"`
+}
+
+// decodeBreakerState parses the state stored in val. A missing key is a closed
+// breaker.
+func decodeBreakerState(val Value, exists bool) (*breakerState, error) {
+	st := &breakerState{State: BreakerClosed}
+	if exists {
+		b, ok := val.(Bytes)
+		if !ok {
+			return nil, ErrNotBreaker
+		}
+		if err := json.Unmarshal(b, st); err != nil {
+			return nil, ErrNotBreaker
+		}
+		switch st.State {
+		case BreakerClosed, BreakerOpen, BreakerHalfOpen:
+		default:
+			return nil, ErrNotBreaker
+		}
+	}
+	if st.Probes == nil {
+		st.Probes = make(leaseHolders)
+	}
+
+	return st, nil
+}
+
+// encode returns the stored form of st.
+func (st *breakerState) encode() (Value, error) {
+	b, err := json.Marshal(st)
+	if err != nil {
+		return nil, err
+	}
+
+	return Bytes(b), nil
+}
+
+// open opens the breaker until cooldown has passed.
+func (st *breakerState) open(now time.Time, cooldown time.Duration) {
+	*st = breakerState{State: BreakerOpen, Until: now.Add(cooldown).UnixNano()}
+}
+
+// Breaker is a circuit breaker whose state is kept in a key of the store, so all
+// goroutines using the store share it. Every transition is a single atomic
+// update of the key.
+type Breaker struct {
+	kvs  *KeyValueStore
+	name string
+	opts breakerOptions
+}
+
+// Breaker returns the circuit breaker stored under the key name. A closed
+// breaker lets calls through until WithFailureThreshold consecutive calls have
+// failed; it then opens and rejects calls with ErrBreakerOpen for the
+// WithCooldown duration. After the cooldown it is half-open and lets
+// WithHalfOpenProbes probes through: a successful probe closes it, a failed one
+// opens it again.
+//
+//	b := store.Breaker("breakers/payments", kvs.WithCooldown(10*time.Second))
+//	err := b.Do(func() error {
+//		return charge(order)
+//	})
+//
+// The options are not stored: every user of the breaker should pass the same ones.
+// The failure threshold, cooldown and number of probes must be positive,
+// otherwise Allow, Do and State return ErrInvalidArgument.
+func (kvs *KeyValueStore) Breaker(name string, opts ...BreakerOption) *Breaker {
+	o := breakerOptions{
+		failures: defaultBreakerFailures,
+		cooldown: defaultBreakerCooldown,
+		probes:   1,
+	}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return &Breaker{kvs: kvs, name: name, opts: o}
+}
+
+// check returns ErrInvalidArgument if the options of the breaker are invalid.
+func (b *Breaker) check() error {
+	if b.opts.failures <= 0 || b.opts.cooldown <= 0 || b.opts.probes <= 0 {
+		return ErrInvalidArgument
+	}
+
+	return nil
+}
+
+// Allow asks the breaker to let a call through. If it does, the caller must make
+// the call and report its outcome with done. Otherwise Allow returns
+// ErrBreakerOpen.
+//
+// It returns ErrNotBreaker if the key holds a value that is not a circuit breaker.
+func (b *Breaker) Allow() (done func(success bool) error, err error) {
+	if err := b.check(); err != nil {
+		return nil, err
+	}
+	// A closed breaker is only read, so calls through it do not contend for the key.
+	st, err := b.load()
+	if err != nil {
+		return nil, err
+	}
+	var probe string
+	if st.State == BreakerClosed {
+		return func(success bool) error {
+			return b.report(probe, success)
+		}, nil
+	}
+
+	err = b.kvs.Update(b.name, func(old Value, exists bool) (Value, error) {
+		st, err := decodeBreakerState(old, exists)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		if st.State == BreakerOpen {
+			if now.UnixNano() < st.Until {
+				return nil, ErrBreakerOpen
+			}
+			*st = breakerState{State: BreakerHalfOpen, Probes: make(leaseHolders)}
+		}
+		if st.State == BreakerClosed {
+			// The breaker closed since it was read; nothing to record.
+			return nil, errBreakerUnchanged
+		}
+
+		st.Probes.prune(now)
+		if len(st.Probes) >= b.opts.probes {
+			return nil, ErrBreakerOpen
+		}
+		probe = rand.Text()
+		st.Probes[probe] = now.Add(b.opts.cooldown).UnixNano()
+
+		return st.encode()
+	})
+	if err == errBreakerUnchanged {
+		err = nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return func(success bool) error {
+		return b.report(probe, success)
+	}, nil
+}
+
+// report records the outcome of a call let through by Allow. probe is the id of
+// the call if it was a probe of a half-open breaker.
+func (b *Breaker) report(probe string, success bool) error {
+	// Successes of a closed breaker without failures change nothing; only read it.
+	if success && probe == "" {
+		st, err := b.load()
+		if err != nil || (st.State == BreakerClosed && st.Failures == 0) {
+			return err
+		}
+	}
+
+	err := b.kvs.Update(b.name, func(old Value, exists bool) (Value, error) {
+		st, err := decodeBreakerState(old, exists)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case st.State == BreakerClosed && probe == "":
+			if success {
+				if st.Failures == 0 {
+					return nil, errBreakerUnchanged
+				}
+				st.Failures = 0
+			} else if st.Failures++; st.Failures >= b.opts.failures {
+				st.open(time.Now(), b.opts.cooldown)
+			}
+		case st.State == BreakerHalfOpen && probe != "":
+			if _, ok := st.Probes[probe]; !ok {
+				// The probe expired and no longer counts.
+				return nil, errBreakerUnchanged
+			}
+			if success {
+				*st = breakerState{State: BreakerClosed}
+			} else {
+				st.open(time.Now(), b.opts.cooldown)
+			}
+		default:
+			// The breaker changed state since the call was let through.
+			return nil, errBreakerUnchanged
+		}
+
+		return st.encode()
+	})
+	if err == errBreakerUnchanged {
+		return nil
+	}
+
+	return err
+}
+
+// Do calls fn if the breaker lets it through and records whether it returned an
+// error. It returns ErrBreakerOpen without calling fn if the breaker is open, and
+// the error of fn otherwise.
+func (b *Breaker) Do(fn func() error) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	callErr := fn()
+	if err := done(callErr == nil); err != nil {
+		return err
+	}
+
+	return callErr
+}
+
+// State returns the current state of the breaker. An open breaker whose cooldown
+// has passed is reported as half-open.
+func (b *Breaker) State() (BreakerState, error) {
+	if err := b.check(); err != nil {
+		return "", err
+	}
+	st, err := b.load()
+	if err != nil {
+		return "", err
+	}
+	if st.State == BreakerOpen && time.Now().UnixNano() >= st.Until {
+		return BreakerHalfOpen, nil
+	}
+
+	return st.State, nil
+}
+
+// load reads the state of the breaker.
+func (b *Breaker) load() (*breakerState, error) {
+	val, err := b.kvs.getLive(b.name)
+	if err != nil && err != ErrNotFound {
+		return nil, err
+	}
+
+	return decodeBreakerState(val, err == nil)
+}
+
+// Reset closes the breaker and clears its failures.
+func (b *Breaker) Reset() error {
+	err := b.kvs.Delete(b.name)
+	if err == ErrNotFound {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,151 @@
+package kvs
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBreaker(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	b := store.Breaker("payments", WithFailureThreshold(2), WithCooldown(20*time.Millisecond))
+	fail := errors.New("down")
+
+	if err := b.Do(func() error { return nil }); err != nil {
+		t.Fatalf("Do returned an error: %v", err)
+	}
+	if _, err := store.Get("payments"); err != ErrNotFound {
+		t.Errorf("Expected successes of a closed breaker not to write, got %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := b.Do(func() error { return fail }); err != fail {
+			t.Fatalf("Expected the error of the call, got %v", err)
+		}
+	}
+	if state, _ := b.State(); state != BreakerOpen {
+		t.Fatalf("Expected the breaker to be open, got %s", state)
+	}
+	called := false
+	if err := b.Do(func() error { called = true; return nil }); err != ErrBreakerOpen || called {
+		t.Fatalf("Expected ErrBreakerOpen without a call, got %v", err)
+	}
+
+	// After the cooldown a single probe is let through; a failure opens it again.
+	time.Sleep(30 * time.Millisecond)
+	if state, _ := b.State(); state != BreakerHalfOpen {
+		t.Errorf("Expected the breaker to be half-open, got %s", state)
+	}
+	done, err := b.Allow()
+	if err != nil {
+		t.Fatalf("Allow returned an error: %v", err)
+	}
+	if _, err := b.Allow(); err != ErrBreakerOpen {
+		t.Errorf("Expected a second probe to be rejected, got %v", err)
+	}
+	if err := done(false); err != nil {
+		t.Fatalf("done returned an error: %v", err)
+	}
+	if state, _ := b.State(); state != BreakerOpen {
+		t.Fatalf("Expected the breaker to open again, got %s", state)
+	}
+
+	// A successful probe closes it.
+	time.Sleep(30 * time.Millisecond)
+	if err := b.Do(func() error { return nil }); err != nil {
+		t.Fatalf("Do returned an error: %v", err)
+	}
+	if state, _ := b.State(); state != BreakerClosed {
+		t.Errorf("Expected the breaker to be closed, got %s", state)
+	}
+}
+
+func TestBreaker_ExpiredProbe(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	b := store.Breaker("payments", WithFailureThreshold(1), WithCooldown(20*time.Millisecond))
+	_ = b.Do(func() error { return errors.New("down") })
+	time.Sleep(30 * time.Millisecond)
+
+	// A probe that never reports stops counting after the cooldown.
+	if _, err := b.Allow(); err != nil {
+		t.Fatalf("Allow returned an error: %v", err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, err := b.Allow(); err != nil {
+		t.Errorf("Expected the expired probe to free its slot, got %v", err)
+	}
+}
+
+func TestBreaker_Concurrent(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	b := store.Breaker("payments", WithFailureThreshold(50))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = b.Do(func() error { return errors.New("down") })
+		}()
+	}
+	wg.Wait()
+
+	if state, _ := b.State(); state != BreakerOpen {
+		t.Errorf("Expected every failure to be counted, got %s", state)
+	}
+	if err := b.Reset(); err != nil {
+		t.Fatalf("Reset returned an error: %v", err)
+	}
+	if state, _ := b.State(); state != BreakerClosed {
+		t.Errorf("Expected the breaker to be closed, got %s", state)
+	}
+}
+
+func TestBreaker_NotBreaker(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	_ = store.Set("payments", IntValue(1))
+
+	if _, err := store.Breaker("payments").Allow(); err != ErrNotBreaker {
+		t.Errorf("Expected ErrNotBreaker, got %v", err)
+	}
+}
+
+func TestBreaker_ReadReplicas(t *testing.T) {
+	store, _ := NewKeyValueStore(4, WithReadReplicas(time.Hour))
+	defer store.Close()
+	b := store.Breaker("payments", WithFailureThreshold(1), WithCooldown(time.Hour))
+
+	_ = b.Do(func() error { return errors.New("down") })
+	// The replicas are not refreshed before the breaker is checked.
+	if state, _ := b.State(); state != BreakerOpen {
+		t.Errorf("Expected the breaker to be open, got %s", state)
+	}
+	if _, err := b.Allow(); err != ErrBreakerOpen {
+		t.Errorf("Expected ErrBreakerOpen, got %v", err)
+	}
+}
+
+func TestBreaker_InvalidArgument(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+
+	for _, opt := range []BreakerOption{
+		WithFailureThreshold(0),
+		WithCooldown(-time.Second),
+		WithHalfOpenProbes(0),
+	} {
+		b := store.Breaker("payments", opt)
+		if _, err := b.Allow(); err != ErrInvalidArgument {
+			t.Errorf("Expected ErrInvalidArgument from Allow, got %v", err)
+		}
+		if err := b.Do(func() error { return nil }); err != ErrInvalidArgument {
+			t.Errorf("Expected ErrInvalidArgument from Do, got %v", err)
+		}
+		if _, err := b.State(); err != ErrInvalidArgument {
+			t.Errorf("Expected ErrInvalidArgument from State, got %v", err)
+		}
+	}
+}
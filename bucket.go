@@ -0,0 +1,353 @@
+package kvs
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// bucketPrefix is the prefix of the system keys holding the entries of buckets.
+const bucketPrefix = SystemPrefix + "buckets/"
+
+// Bucket is a named keyspace within a KeyValueStore. Buckets share the shards of
+// the store but not their keys: a key set in one bucket is invisible to other
+// buckets and to the root keyspace. Buckets can be nested, and the entries of a
+// bucket are separate from those of its nested buckets. Entries of buckets are
+// part of snapshots, replicas and clones of the store.
+//
+// The entries of a bucket are stored under the system key prefix
+// "__kvs/buckets/<path>//", where path is the names of the bucket and its parents
+// joined by slashes, e.g. "__kvs/buckets/tenants/acme//plan".
+type Bucket struct {
+	kvs  *KeyValueStore
+	path []string
+	// prefix is the prefix of the entries of the bucket and tree the prefix of the
+	// entries of the bucket and its nested buckets.
+	prefix string
+	tree   string
+}
+
+var _ Store = (*Bucket)(nil)
+
+// BucketStats describes the contents of a bucket.
+type BucketStats struct {
+	// Entries is the number of entries in the bucket.
+	Entries int
+	// Bytes is the estimated size of the keys and values of the bucket.
+	Bytes int64
+}
+
+// Bucket returns the bucket called name. Buckets need not be created; a bucket
+// exists as long as it or one of its nested buckets holds entries. Names must be
+// non-empty and must not contain a slash, otherwise every operation on the bucket
+// returns ErrInvalidBucket.
+//
+//	users := store.Bucket("users")
+//	_ = users.Set("alice", kvs.Bytes("admin"))
+func (kvs *KeyValueStore) Bucket(name string) *Bucket {
+	return newBucket(kvs, []string{name})
+}
+
+// BucketAt returns the bucket at path, the names of the bucket and its parents
+// joined by slashes as returned by Bucket.Path.
+//
+//	acme := store.BucketAt("tenants/acme")
+func (kvs *KeyValueStore) BucketAt(path string) *Bucket {
+	return newBucket(kvs, strings.Split(path, "/"))
+}
+
+// newBucket returns the bucket at path.
+func newBucket(kvs *KeyValueStore, path []string) *Bucket {
+	tree := bucketPrefix + strings.Join(path, "/") + "/"
+	return &Bucket{kvs: kvs, path: path, prefix: tree + "/", tree: tree}
+}
+
+// Buckets returns the sorted names of the top-level buckets that hold entries.
+func (kvs *KeyValueStore) Buckets() ([]string, error) {
+	return kvs.childBuckets(bucketPrefix)
+}
+
+// childBuckets returns the sorted names of the buckets directly below the tree
+// prefix that hold entries.
+func (kvs *KeyValueStore) childBuckets(prefix string) ([]string, error) {
+	seen := make(map[string]struct{})
+	for _, sh := range kvs.shards {
+		sh.mu.RLock()
+		keys, err := sh.backend.Keys()
+		sh.mu.RUnlock()
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			rest, ok := strings.CutPrefix(key, prefix)
+			if !ok {
+				continue
+			}
+			if name, _, ok := strings.Cut(rest, "/"); ok && name != "" {
+				seen[name] = struct{}{}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// Name returns the name of the bucket.
+func (b *Bucket) Name() string {
+	return b.path[len(b.path)-1]
+}
+
+// Path returns the names of the bucket's parents and the bucket joined by slashes.
+func (b *Bucket) Path() string {
+	return strings.Join(b.path, "/")
+}
+
+// Bucket returns the bucket called name nested in b.
+//
+//	acme := store.Bucket("tenants").Bucket("acme")
+func (b *Bucket) Bucket(name string) *Bucket {
+	return newBucket(b.kvs, append(slices.Clip(b.path), name))
+}
+
+// Buckets returns the sorted names of the buckets nested directly in b that hold
+// entries.
+func (b *Bucket) Buckets() ([]string, error) {
+	if err := b.check(); err != nil {
+		return nil, err
+	}
+
+	return b.kvs.childBuckets(b.tree)
+}
+
+// Prefix returns the prefix of the system keys holding the entries of the
+// bucket, for use with Watch and Scan on the store. It does not cover the entries
+// of nested buckets.
+func (b *Bucket) Prefix() string {
+	return b.prefix
+}
+
+// check returns ErrInvalidBucket if the name of the bucket or of one of its
+// parents is invalid.
+func (b *Bucket) check() error {
+	for _, name := range b.path {
+		if name == "" || strings.Contains(name, "/") {
+			return ErrInvalidBucket
+		}
+	}
+
+	return nil
+}
+
+// Get retrieves the value of key in the bucket.
+// If the key is not found, it returns an ErrNotFound error.
+func (b *Bucket) Get(key string) (_ Value, err error) {
+	if err := b.check(); err != nil {
+		return nil, err
+	}
+	if len(b.kvs.opts.observers) > 0 {
+		defer b.kvs.observe(OpGet, b.prefix+key, time.Now(), &err)
+	}
+
+	return b.kvs.get(b.prefix + key)
+}
+
+// Set adds or updates the given key-value pair in the bucket.
+// It returns ErrNilValue if val is nil.
+func (b *Bucket) Set(key string, val Value) (err error) {
+	if err := b.check(); err != nil {
+		return err
+	}
+	if len(b.kvs.opts.observers) > 0 {
+		defer b.kvs.observe(OpSet, b.prefix+key, time.Now(), &err)
+	}
+
+	if val == nil {
+		return ErrNilValue
+	}
+	if bq := b.kvs.quotas.get(b.Path()); bq != nil {
+		return b.setWithQuota(bq, key, val)
+	}
+
+	return b.kvs.put(b.prefix+key, val)
+}
+
+// Delete removes key from the bucket.
+// If the key is not found, it returns an ErrNotFound error.
+func (b *Bucket) Delete(key string) (err error) {
+	if err := b.check(); err != nil {
+		return err
+	}
+	if len(b.kvs.opts.observers) > 0 {
+		defer b.kvs.observe(OpDelete, b.prefix+key, time.Now(), &err)
+	}
+
+	if bq := b.kvs.quotas.get(b.Path()); bq != nil {
+		return b.deleteWithQuota(bq, key)
+	}
+
+	return b.kvs.delete(b.prefix + key)
+}
+
+// Keys returns the keys of the bucket.
+func (b *Bucket) Keys() ([]string, error) {
+	if err := b.check(); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0)
+	err := b.kvs.eachShard(func(sh *shard, shardKeys []string) error {
+		for _, key := range shardKeys {
+			keys = append(keys, strings.TrimPrefix(key, b.prefix))
+		}
+		return nil
+	}, b.prefix, false)
+
+	return keys, err
+}
+
+// Clear deletes every entry of the bucket. Nested buckets are kept.
+// Write-once entries, which come from imported snapshots, are kept as well, and
+// Clear then returns ErrImmutable after deleting the other entries.
+func (b *Bucket) Clear() error {
+	return b.clear(false)
+}
+
+// ForceClear deletes every entry of the bucket like Clear, including write-once
+// entries.
+func (b *Bucket) ForceClear() error {
+	return b.clear(true)
+}
+
+// clear deletes the entries of the bucket, including write-once ones if force
+// is set.
+func (b *Bucket) clear(force bool) error {
+	if err := b.check(); err != nil {
+		return err
+	}
+
+	defer b.kvs.quotas.invalidate(b.Path(), false)
+
+	return b.kvs.removeAll(b.prefix, force)
+}
+
+// Drop deletes every entry of the bucket and of all buckets nested in it.
+// Write-once entries are kept, and Drop then returns ErrImmutable after deleting
+// the other entries.
+func (b *Bucket) Drop() error {
+	return b.drop(false)
+}
+
+// ForceDrop deletes the bucket and the buckets nested in it like Drop, including
+// write-once entries.
+func (b *Bucket) ForceDrop() error {
+	return b.drop(true)
+}
+
+// drop deletes the entries of the bucket and its nested buckets, including
+// write-once ones if force is set.
+func (b *Bucket) drop(force bool) error {
+	if err := b.check(); err != nil {
+		return err
+	}
+
+	defer b.kvs.quotas.invalidate(b.Path(), true)
+
+	return b.kvs.removeAll(b.tree, force)
+}
+
+// removeAll deletes the stored keys starting with prefix. Write-once entries are
+// deleted only if force is set; otherwise they are kept and removeAll returns
+// ErrImmutable once the other keys are deleted.
+func (kvs *KeyValueStore) removeAll(prefix string, force bool) error {
+	kept := false
+	err := kvs.eachShard(func(sh *shard, shardKeys []string) error {
+		for _, key := range shardKeys {
+			immutable := sh.isImmutable(key)
+			if immutable && !force {
+				kept = true
+				continue
+			}
+			if err := kvs.remove(sh, key); err != nil {
+				return err
+			}
+			if immutable {
+				sh.setImmutable(key, false)
+			}
+		}
+		return nil
+	}, prefix, true)
+	if err == nil && kept {
+		return ErrImmutable
+	}
+
+	return err
+}
+
+// Stats returns the number of entries of the bucket and their estimated size.
+func (b *Bucket) Stats() (BucketStats, error) {
+	if err := b.check(); err != nil {
+		return BucketStats{}, err
+	}
+
+	return b.kvs.usage(b.prefix)
+}
+
+// usage returns the number of stored entries starting with prefix and their
+// estimated size, counting keys without the prefix.
+func (kvs *KeyValueStore) usage(prefix string) (BucketStats, error) {
+	var stats BucketStats
+	err := kvs.eachShard(func(sh *shard, shardKeys []string) error {
+		for _, key := range shardKeys {
+			val, err := sh.backend.Get(key)
+			if err != nil {
+				return err
+			}
+			stats.Entries++
+			stats.Bytes += estimateSize(strings.TrimPrefix(key, prefix), val)
+		}
+		return nil
+	}, prefix, false)
+
+	return stats, err
+}
+
+// eachShard calls fn with every shard and its stored keys starting with prefix.
+// The shard is write-locked if write is set and read-locked otherwise.
+func (kvs *KeyValueStore) eachShard(fn func(sh *shard, keys []string) error, prefix string, write bool) error {
+	for _, sh := range kvs.shards {
+		if write {
+			kvs.lockShard(sh)
+		} else {
+			sh.mu.RLock()
+		}
+
+		keys, err := sh.backend.Keys()
+		if err == nil {
+			var matched []string
+			for _, key := range keys {
+				if strings.HasPrefix(key, prefix) {
+					matched = append(matched, key)
+				}
+			}
+			err = fn(sh, matched)
+		}
+
+		if write {
+			sh.mu.Unlock()
+		} else {
+			sh.mu.RUnlock()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
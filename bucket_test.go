@@ -0,0 +1,233 @@
+package kvs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestBucket(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	users, orders := store.Bucket("users"), store.Bucket("orders")
+
+	if err := users.Set("1", Bytes("alice")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if err := orders.Set("1", Bytes("book")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	_ = orders.Set("2", Bytes("pen"))
+	_ = store.Set("1", Bytes("root"))
+
+	if val, err := users.Get("1"); err != nil || string(val.(Bytes)) != "alice" {
+		t.Errorf("Expected alice, got %v, %v", val, err)
+	}
+	if val, err := orders.Get("1"); err != nil || string(val.(Bytes)) != "book" {
+		t.Errorf("Expected book, got %v, %v", val, err)
+	}
+	if val, err := store.Get("1"); err != nil || string(val.(Bytes)) != "root" {
+		t.Errorf("Expected root, got %v, %v", val, err)
+	}
+
+	keys, err := orders.Keys()
+	if err != nil {
+		t.Fatalf("Keys returned an error: %v", err)
+	}
+	sort.Strings(keys)
+	if fmt.Sprint(keys) != "[1 2]" {
+		t.Errorf("Expected [1 2], got %v", keys)
+	}
+	if keys, _ := store.Keys(); fmt.Sprint(keys) != "[1]" {
+		t.Errorf("Expected the root keyspace to hold only its own keys, got %v", keys)
+	}
+	if names, err := store.Buckets(); err != nil || fmt.Sprint(names) != "[orders users]" {
+		t.Errorf("Expected [orders users], got %v, %v", names, err)
+	}
+
+	stats, err := orders.Stats()
+	if err != nil {
+		t.Fatalf("Stats returned an error: %v", err)
+	}
+	if stats.Entries != 2 || stats.Bytes != int64(len("1book2pen")) {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	if err := orders.Delete("2"); err != nil {
+		t.Fatalf("Delete returned an error: %v", err)
+	}
+	if err := orders.Delete("2"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if err := orders.Clear(); err != nil {
+		t.Fatalf("Clear returned an error: %v", err)
+	}
+	if keys, _ := orders.Keys(); len(keys) != 0 {
+		t.Errorf("Expected an empty bucket, got %v", keys)
+	}
+	if _, err := users.Get("1"); err != nil {
+		t.Errorf("Expected other buckets to be unaffected, got %v", err)
+	}
+}
+
+func TestBucket_InvalidName(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+
+	for _, name := range []string{"", "a/b"} {
+		if err := store.Bucket(name).Set("k", Bytes("v")); err != ErrInvalidBucket {
+			t.Errorf("Expected ErrInvalidBucket for %q, got %v", name, err)
+		}
+	}
+}
+
+func TestBucket_Reserved(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	_ = store.Bucket("users").Set("1", Bytes("alice"))
+
+	key := SystemPrefix + "buckets/users//1"
+	if val, err := store.Get(key); err != nil || string(val.(Bytes)) != "alice" {
+		t.Errorf("Expected bucket entries to be readable as system keys, got %v, %v", val, err)
+	}
+	if err := store.Set(key, Bytes("mallory")); err != ErrReservedKey {
+		t.Errorf("Expected ErrReservedKey, got %v", err)
+	}
+
+	it := store.Scan("")
+	for it.Next() {
+		t.Errorf("Expected an unprefixed scan to skip bucket entries, got %s", it.Key())
+	}
+	it.Close()
+}
+
+func TestBucket_Watch(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	all := store.Watch(ctx, "")
+	users := store.Watch(ctx, store.Bucket("users").Prefix())
+
+	_ = store.Bucket("users").Set("1", Bytes("alice"))
+	_ = store.Set("root", Bytes("x"))
+
+	select {
+	case ev := <-users:
+		if ev.Key != SystemPrefix+"buckets/users//1" {
+			t.Errorf("unexpected event %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the bucket event")
+	}
+	select {
+	case ev := <-all:
+		if ev.Key != "root" {
+			t.Errorf("Expected an unprefixed watch to skip bucket events, got %+v", ev)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the root event")
+	}
+}
+
+func TestBucket_Snapshot(t *testing.T) {
+	store, _ := NewKeyValueStore(4, WithCodec(BytesCodec{}))
+	_ = store.Bucket("users").Set("1", Bytes("alice"))
+
+	var buf bytes.Buffer
+	if err := store.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("WriteSnapshot returned an error: %v", err)
+	}
+
+	restored, _ := NewKeyValueStore(8, WithCodec(BytesCodec{}))
+	if err := restored.ReadSnapshot(&buf); err != nil {
+		t.Fatalf("ReadSnapshot returned an error: %v", err)
+	}
+	if val, err := restored.Bucket("users").Get("1"); err != nil || string(val.(Bytes)) != "alice" {
+		t.Errorf("Expected the bucket to be restored, got %v, %v", val, err)
+	}
+}
+
+func TestBucket_Nested(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	tenants := store.Bucket("tenants")
+	acme := tenants.Bucket("acme")
+
+	_ = tenants.Set("acme//plan", Bytes("parent"))
+	_ = acme.Set("plan", Bytes("gold"))
+	_ = acme.Bucket("users").Set("alice", Bytes("admin"))
+	_ = tenants.Bucket("globex").Set("plan", Bytes("free"))
+
+	if acme.Path() != "tenants/acme" || acme.Name() != "acme" {
+		t.Errorf("unexpected path %s and name %s", acme.Path(), acme.Name())
+	}
+	if val, err := acme.Get("plan"); err != nil || string(val.(Bytes)) != "gold" {
+		t.Errorf("Expected gold, got %v, %v", val, err)
+	}
+	if keys, _ := tenants.Keys(); fmt.Sprint(keys) != "[acme//plan]" {
+		t.Errorf("Expected the parent to hold only its own keys, got %v", keys)
+	}
+	if names, err := tenants.Buckets(); err != nil || fmt.Sprint(names) != "[acme globex]" {
+		t.Errorf("Expected [acme globex], got %v, %v", names, err)
+	}
+	if names, err := store.Buckets(); err != nil || fmt.Sprint(names) != "[tenants]" {
+		t.Errorf("Expected [tenants], got %v, %v", names, err)
+	}
+
+	// Clear keeps nested buckets, Drop removes the whole subtree.
+	if err := acme.Clear(); err != nil {
+		t.Fatalf("Clear returned an error: %v", err)
+	}
+	if names, _ := acme.Buckets(); fmt.Sprint(names) != "[users]" {
+		t.Errorf("Expected [users], got %v", names)
+	}
+	if err := acme.Drop(); err != nil {
+		t.Fatalf("Drop returned an error: %v", err)
+	}
+	if _, err := acme.Bucket("users").Get("alice"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if names, _ := tenants.Buckets(); fmt.Sprint(names) != "[globex]" {
+		t.Errorf("Expected [globex], got %v", names)
+	}
+	if _, err := tenants.Get("acme//plan"); err != nil {
+		t.Errorf("Expected the parent to be unaffected, got %v", err)
+	}
+
+	if err := store.Bucket("a/b").Bucket("c").Set("k", Bytes("v")); err != ErrInvalidBucket {
+		t.Errorf("Expected ErrInvalidBucket, got %v", err)
+	}
+}
+
+func TestBucket_ClearImmutable(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	b := store.Bucket("b")
+	_ = b.Set("a", Bytes("1"))
+	// Write-once bucket entries come from imported snapshots.
+	if err := store.putImmutable(b.Prefix()+"frozen", Bytes("2")); err != nil {
+		t.Fatalf("putImmutable returned an error: %v", err)
+	}
+
+	if err := b.Clear(); err != ErrImmutable {
+		t.Errorf("Expected ErrImmutable, got %v", err)
+	}
+	if keys, _ := b.Keys(); fmt.Sprint(keys) != "[frozen]" {
+		t.Errorf("Expected only the write-once entry to be kept, got %v", keys)
+	}
+	if err := b.Drop(); err != ErrImmutable {
+		t.Errorf("Expected ErrImmutable, got %v", err)
+	}
+
+	if err := b.ForceDrop(); err != nil {
+		t.Fatalf("ForceDrop returned an error: %v", err)
+	}
+	if keys, _ := b.Keys(); len(keys) != 0 {
+		t.Errorf("Expected an empty bucket, got %v", keys)
+	}
+	if store.IsImmutable(b.Prefix() + "frozen") {
+		t.Error("Expected the write-once mark to be removed")
+	}
+}
@@ -0,0 +1,110 @@
+package kvs
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Export writes the entries of the bucket and of the buckets nested in it to w in
+// the snapshot format. Keys are written relative to the bucket, so Import can
+// load them into a bucket of another name or another store. Like WriteSnapshot,
+// the export is consistent per shard.
+//
+//	var buf bytes.Buffer
+//	if err := store.Bucket("tenants").Bucket("acme").Export(&buf); err != nil {
+//		// Handle the error
+//	}
+//	_, err := other.Bucket("tenants").Bucket("acme").Import(&buf)
+func (b *Bucket) Export(w io.Writer) error {
+	if err := b.check(); err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write(snapshotMagic); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, sh := range b.kvs.shards {
+		buf.Reset()
+		if err := b.encodeShard(&buf, sh); err != nil {
+			return err
+		}
+
+		// The shard lock is released before writing so a slow writer does not block the shard.
+		if _, err := bw.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	if err := bw.WriteByte(snapshotEnd); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// encodeShard writes the records of the bucket's entries in sh to w.
+func (b *Bucket) encodeShard(w recordWriter, sh *shard) error {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	keys, err := sh.Keys()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		rel, ok := strings.CutPrefix(key, b.tree)
+		if !ok {
+			continue
+		}
+		val, err := sh.backend.Get(key)
+		if err != nil {
+			return err
+		}
+		data, err := b.kvs.encode(val)
+		if err != nil {
+			return err
+		}
+		if err := writeRecord(w, snapshotEntry, rel, data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Import loads the entries of an export written by Bucket.Export into the
+// bucket and its nested buckets and reports how many keys were created or
+// overwritten. DryRun and progress options apply as for ImportSnapshot. Like
+// other imports, it bypasses the quotas of the buckets.
+func (b *Bucket) Import(r io.Reader, opts ...ImportOption) (ImportReport, error) {
+	if err := b.check(); err != nil {
+		return ImportReport{}, err
+	}
+
+	cr := &countingReader{r: r}
+	return b.kvs.importSnapshot(func(fn recordFunc) error {
+		return readSnapshot(cr, func(kind byte, key string, data []byte) error {
+			if kind != snapshotEntry || !isBucketEntry(key) {
+				return fmt.Errorf("%w: unexpected record %q in a bucket export", ErrCorruptSnapshot, key)
+			}
+			return fn(kind, b.tree+key, data)
+		})
+	}, cr.count, sizeOf(r), opts...)
+}
+
+// isBucketEntry reports whether key, relative to a bucket, is an entry of the
+// bucket ("/key") or of a nested bucket ("name//key" or deeper).
+func isBucketEntry(key string) bool {
+	if strings.HasPrefix(key, "/") {
+		return true
+	}
+	path, _, ok := strings.Cut(key, "//")
+
+	return ok && path != "" && !strings.HasPrefix(path, "/")
+}
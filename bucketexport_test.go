@@ -0,0 +1,63 @@
+package kvs
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestBucket_Export(t *testing.T) {
+	store, err := NewKeyValueStore(4, WithCodec(BytesCodec{}))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	acme := store.Bucket("tenants").Bucket("acme")
+	_ = acme.Set("plan", Bytes("gold"))
+	_ = acme.Bucket("users").Set("alice", Bytes("admin"))
+	_ = store.Bucket("tenants").Set("other", Bytes("x"))
+	_ = store.Set("root", Bytes("x"))
+
+	var buf bytes.Buffer
+	if err := acme.Export(&buf); err != nil {
+		t.Fatalf("Export returned an error: %v", err)
+	}
+	data := buf.Bytes()
+
+	other, _ := NewKeyValueStore(8, WithCodec(BytesCodec{}))
+	moved := other.BucketAt("customers/acme")
+	report, err := moved.Import(bytes.NewReader(data), DryRun())
+	if err != nil {
+		t.Fatalf("Import returned an error: %v", err)
+	}
+	if report.Created != 2 {
+		t.Errorf("Expected 2 created keys, got %+v", report)
+	}
+	if keys, _ := moved.Keys(); len(keys) != 0 {
+		t.Errorf("Expected a dry run not to change the store, got %v", keys)
+	}
+
+	if _, err := moved.Import(bytes.NewReader(data)); err != nil {
+		t.Fatalf("Import returned an error: %v", err)
+	}
+	if val, err := moved.Get("plan"); err != nil || string(val.(Bytes)) != "gold" {
+		t.Errorf("Expected gold, got %v, %v", val, err)
+	}
+	if val, err := moved.Bucket("users").Get("alice"); err != nil || string(val.(Bytes)) != "admin" {
+		t.Errorf("Expected the nested bucket to be imported, got %v, %v", val, err)
+	}
+	if names, _ := other.Buckets(); len(names) != 1 || names[0] != "customers" {
+		t.Errorf("Expected only the imported bucket, got %v", names)
+	}
+}
+
+func TestBucket_ImportRejectsSnapshots(t *testing.T) {
+	store, _ := NewKeyValueStore(4, WithCodec(BytesCodec{}))
+	_ = store.Set("root", Bytes("x"))
+
+	var buf bytes.Buffer
+	_ = store.WriteSnapshot(&buf)
+
+	if _, err := store.Bucket("b").Import(&buf); !errors.Is(err, ErrCorruptSnapshot) {
+		t.Errorf("Expected ErrCorruptSnapshot, got %v", err)
+	}
+}
@@ -0,0 +1,180 @@
+package kvs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// contentPrefix is the prefix of the system keys holding the entries of the
+// content-addressable store.
+const contentPrefix = SystemPrefix + "cas/"
+
+// refsMeta is the metadata name holding the reference count of a content entry.
+const refsMeta = "refs"
+
+// ContentStore is the content-addressable part of a KeyValueStore: the key of
+// every value is the SHA-256 hash of its encoding, so storing the same value
+// twice keeps a single copy. Entries cannot be changed, since a different value
+// has a different key, and are reference counted: every Put of a value takes a
+// reference and every Delete drops one, and the entry is removed with the last.
+//
+// Entries are stored as write-once system keys "__kvs/cas/<hash>" with their
+// reference count in the metadata, so both are part of snapshots and clones.
+type ContentStore struct {
+	kvs *KeyValueStore
+}
+
+// Content returns the content-addressable store of the store.
+//
+//	blobs := store.Content()
+//	key, err := blobs.Put(kvs.Bytes(data))
+//	// ...
+//	err = blobs.Delete(key)
+func (kvs *KeyValueStore) Content() *ContentStore {
+	return &ContentStore{kvs: kvs}
+}
+
+// Put stores val and returns its key, the hex-encoded SHA-256 hash of the value
+// as encoded by the store's codec. If the value is stored already, only its
+// reference count is increased.
+//
+// Deduplication relies on equal values having equal encodings. That holds for
+// Bytes and other values whose encoding is deterministic, but not for values
+// holding maps under GobCodec, which encodes maps in random order; such values
+// may be stored more than once under different keys.
+func (c *ContentStore) Put(val Value) (key string, err error) {
+	if val == nil {
+		return "", ErrNilValue
+	}
+	data, err := c.kvs.opts.codec.Marshal(val)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	key = hex.EncodeToString(sum[:])
+	if len(c.kvs.opts.observers) > 0 {
+		defer c.kvs.observe(OpSet, contentPrefix+key, time.Now(), &err)
+	}
+
+	val, err = c.kvs.compress(val)
+	if err != nil {
+		return "", err
+	}
+
+	sh, stored, err := c.kvs.lockKey(contentPrefix + key)
+	if err != nil {
+		return "", err
+	}
+	defer sh.mu.Unlock()
+
+	refs := 0
+	switch _, err := sh.backend.Get(stored); err {
+	case nil:
+		if refs, err = storedRefs(sh, stored); err != nil {
+			return "", err
+		}
+	case ErrNotFound:
+		if err := c.kvs.apply(sh, stored, val); err != nil {
+			return "", err
+		}
+		sh.setImmutable(stored, true)
+	default:
+		return "", err
+	}
+	sh.setMeta(stored, map[string]string{refsMeta: strconv.Itoa(refs + 1)})
+
+	return key, nil
+}
+
+// Get retrieves the value stored under key.
+// If the key is not found, it returns an ErrNotFound error.
+func (c *ContentStore) Get(key string) (_ Value, err error) {
+	if len(c.kvs.opts.observers) > 0 {
+		defer c.kvs.observe(OpGet, contentPrefix+key, time.Now(), &err)
+	}
+
+	return c.kvs.get(contentPrefix + key)
+}
+
+// Delete drops a reference to the value stored under key and removes the value
+// when it was the last one.
+// If the key is not found, it returns an ErrNotFound error.
+func (c *ContentStore) Delete(key string) (err error) {
+	if len(c.kvs.opts.observers) > 0 {
+		defer c.kvs.observe(OpDelete, contentPrefix+key, time.Now(), &err)
+	}
+
+	sh, stored, err := c.kvs.lockKey(contentPrefix + key)
+	if err != nil {
+		return err
+	}
+	defer sh.mu.Unlock()
+
+	if _, err := sh.backend.Get(stored); err != nil {
+		return err
+	}
+	refs, err := storedRefs(sh, stored)
+	if err != nil {
+		return err
+	}
+	if refs > 1 {
+		sh.setMeta(stored, map[string]string{refsMeta: strconv.Itoa(refs - 1)})
+		return nil
+	}
+
+	if err := c.kvs.remove(sh, stored); err != nil {
+		return err
+	}
+	sh.setImmutable(stored, false)
+
+	return nil
+}
+
+// Refs returns the number of references to the value stored under key.
+// If the key is not found, it returns an ErrNotFound error.
+func (c *ContentStore) Refs(key string) (int, error) {
+	sh, stored, err := c.kvs.rlockKey(contentPrefix + key)
+	if err != nil {
+		return 0, err
+	}
+	defer sh.mu.RUnlock()
+
+	if _, err := sh.backend.Get(stored); err != nil {
+		return 0, err
+	}
+
+	return storedRefs(sh, stored)
+}
+
+// Keys returns the keys of the stored values.
+func (c *ContentStore) Keys() ([]string, error) {
+	keys := make([]string, 0)
+	err := c.kvs.eachShard(func(sh *shard, shardKeys []string) error {
+		for _, key := range shardKeys {
+			keys = append(keys, strings.TrimPrefix(key, contentPrefix))
+		}
+		return nil
+	}, contentPrefix, false)
+
+	return keys, err
+}
+
+// storedRefs returns the reference count of the content entry stored under key.
+// Entries without a count have one reference. The shard must be locked.
+func storedRefs(sh *shard, key string) (int, error) {
+	s, ok := sh.meta[key][refsMeta]
+	if !ok {
+		return 1, nil
+	}
+
+	refs, err := strconv.Atoi(s)
+	if err != nil || refs < 1 {
+		return 0, fmt.Errorf("kvs: invalid reference count %q of %s", s, key)
+	}
+
+	return refs, nil
+}
@@ -0,0 +1,116 @@
+package kvs
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestContentStore(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	blobs := store.Content()
+
+	key, err := blobs.Put(Bytes("blob"))
+	if err != nil {
+		t.Fatalf("Put returned an error: %v", err)
+	}
+	again, err := blobs.Put(Bytes("blob"))
+	if err != nil || again != key {
+		t.Fatalf("Expected the same key %s, got %s, %v", key, again, err)
+	}
+	other, _ := blobs.Put(Bytes("other"))
+	if other == key {
+		t.Errorf("Expected different values to have different keys")
+	}
+
+	val, err := blobs.Get(key)
+	if err != nil || string(val.(Bytes)) != "blob" {
+		t.Errorf("Expected blob, got %v, %v", val, err)
+	}
+	if refs, _ := blobs.Refs(key); refs != 2 {
+		t.Errorf("Expected 2 references, got %d", refs)
+	}
+	if keys, _ := blobs.Keys(); len(keys) != 2 {
+		t.Errorf("Expected 2 keys, got %v", keys)
+	}
+	if keys, _ := store.Keys(); len(keys) != 0 {
+		t.Errorf("Expected content to be invisible in the root keyspace, got %v", keys)
+	}
+	if err := store.Set(contentPrefix+key, Bytes("changed")); err != ErrReservedKey {
+		t.Errorf("Expected ErrReservedKey, got %v", err)
+	}
+
+	if err := blobs.Delete(key); err != nil {
+		t.Fatalf("Delete returned an error: %v", err)
+	}
+	if _, err := blobs.Get(key); err != nil {
+		t.Errorf("Expected the value to be kept while referenced, got %v", err)
+	}
+	if err := blobs.Delete(key); err != nil {
+		t.Fatalf("Delete returned an error: %v", err)
+	}
+	if _, err := blobs.Get(key); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound after the last reference, got %v", err)
+	}
+	if err := blobs.Delete(key); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestContentStore_Snapshot(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	key, _ := store.Content().Put(Bytes("blob"))
+	_, _ = store.Content().Put(Bytes("blob"))
+
+	var buf bytes.Buffer
+	if err := store.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("WriteSnapshot returned an error: %v", err)
+	}
+	snapshot := buf.Bytes()
+	restored, _ := NewKeyValueStore(4)
+	if err := restored.ReadSnapshot(bytes.NewReader(snapshot)); err != nil {
+		t.Fatalf("ReadSnapshot returned an error: %v", err)
+	}
+
+	if refs, err := restored.Content().Refs(key); err != nil || refs != 2 {
+		t.Errorf("Expected 2 references after the restore, got %d, %v", refs, err)
+	}
+	if !restored.IsImmutable(contentPrefix + key) {
+		t.Error("Expected the restored entry to be write-once")
+	}
+
+	// Importing into a store that holds the entry takes the reference count
+	// of the snapshot.
+	_ = store.Content().Delete(key)
+	if err := store.ReadSnapshot(bytes.NewReader(snapshot)); err != nil {
+		t.Fatalf("ReadSnapshot returned an error: %v", err)
+	}
+	if refs, err := store.Content().Refs(key); err != nil || refs != 2 {
+		t.Errorf("Expected 2 references after the import, got %d, %v", refs, err)
+	}
+}
+
+func TestContentStore_Immutable(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	blobs := store.Content()
+	key, _ := blobs.Put(Bytes("blob"))
+
+	if !store.IsImmutable(contentPrefix + key) {
+		t.Error("Expected the entry to be write-once")
+	}
+	if err := store.put(contentPrefix+key, Bytes("other")); err != ErrImmutable {
+		t.Errorf("Expected ErrImmutable for an internal overwrite, got %v", err)
+	}
+
+	if err := blobs.Delete(key); err != nil {
+		t.Fatalf("Delete returned an error: %v", err)
+	}
+	if store.IsImmutable(contentPrefix + key) {
+		t.Error("Expected the write-once mark to be removed with the entry")
+	}
+	if _, err := blobs.Put(Bytes("blob")); err != nil {
+		t.Errorf("Expected the value to be stored again, got %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package kvs
+
+import "maps"
+
+// Clone returns an independent copy of the store with the same number of shards
+// and the same options, so every key lives in the same shard as in the original.
+// Values are copied with their Clone method, and aliases, write-once marks,
+// metadata, versions and the revision history are copied as well; the recycle
+// bin, statistics and slow log start empty. Writes to either store never affect the other.
+//
+// The copy uses in-memory backends, because a backend factory of the original
+// may point at shared resources such as files; pass WithBackend to use another.
+// opts are applied on top of the original's options. All shards are read-locked
+// while they are copied, so the copy is consistent across shards.
+//
+//	sim, err := store.Clone()
+//	if err != nil {
+//		// Handle the error
+//	}
+//	defer sim.Close()
+func (kvs *KeyValueStore) Clone(opts ...Option) (*KeyValueStore, error) {
+	inherit := func(o *options) {
+		*o = kvs.opts
+		o.backend = nil
+		// The copy records its own slow operations, and appending observers
+		// must not write into the original's list.
+		o.observers = make([]Observer, 0, len(kvs.opts.observers))
+		for _, obs := range kvs.opts.observers {
+			if l, ok := obs.(*slowLog); ok {
+				obs = l.empty()
+				if l == kvs.opts.slowLog {
+					o.slowLog = obs.(*slowLog)
+				}
+			}
+			o.observers = append(o.observers, obs)
+		}
+	}
+	c, err := NewKeyValueStore(kvs.count, append([]Option{inherit}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sh := range kvs.shards {
+		sh.mu.RLock()
+	}
+	defer func() {
+		for _, sh := range kvs.shards {
+			sh.mu.RUnlock()
+		}
+	}()
+
+	for i, sh := range kvs.shards {
+		if err := copyShard(c.shards[i], sh); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+	c.rev.Store(kvs.rev.Load())
+	c.compacted.Store(kvs.compacted.Load())
+	c.compactedAt.Store(kvs.compactedAt.Load())
+
+	// The replicas of the copy were taken while it was empty.
+	if c.opts.replicaInterval > 0 {
+		for _, sh := range c.shards {
+			if err := sh.refreshReplica(); err != nil {
+				c.Close()
+				return nil, err
+			}
+		}
+	}
+
+	return c, nil
+}
+
+// copyShard copies the entries and indexes of src into the empty shard dst.
+// src must be locked.
+func copyShard(dst, src *shard) error {
+	dst.mu.Lock()
+	defer dst.mu.Unlock()
+
+	keys, err := src.backend.Keys()
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		val, err := src.backend.Get(key)
+		if err != nil {
+			return err
+		}
+		if err := dst.backend.Set(key, val.Clone()); err != nil {
+			return err
+		}
+	}
+
+	dst.immutable = maps.Clone(src.immutable)
+	dst.aliases = maps.Clone(src.aliases)
+	dst.revs = maps.Clone(src.revs)
+	dst.horizon = src.horizon
+	if src.meta != nil {
+		dst.meta = make(map[string]map[string]string, len(src.meta))
+		for key, meta := range src.meta {
+			dst.meta[key] = maps.Clone(meta)
+		}
+	}
+	if src.history != nil {
+		dst.history = make(map[string]*history, len(src.history))
+		for key, h := range src.history {
+			versions := make([]version, len(h.versions))
+			for i, v := range h.versions {
+				if v.val != nil {
+					v.val = v.val.Clone()
+				}
+				versions[i] = v
+			}
+			dst.history[key] = &history{versions: versions, truncated: h.truncated}
+		}
+	}
+	dst.dirty.Store(true)
+
+	return nil
+}
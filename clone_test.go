@@ -0,0 +1,80 @@
+package kvs
+
+import (
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	store, err := NewKeyValueStore(4, WithRevisionHistory(4))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	_ = store.Set("a", Bytes("one"))
+	_ = store.Set("a", Bytes("two"))
+	_ = store.SetImmutable("frozen", IntValue(1))
+	_ = store.Alias("link", "a")
+	_ = store.SetMeta("a", map[string]string{"owner": "ops"})
+
+	c, err := store.Clone()
+	if err != nil {
+		t.Fatalf("Clone returned an error: %v", err)
+	}
+	defer c.Close()
+
+	for _, key := range []string{"a", "frozen", "link"} {
+		if c.ShardOf(key) != store.ShardOf(key) {
+			t.Errorf("Expected %s to live in the same shard", key)
+		}
+	}
+	if val, err := c.Get("link"); err != nil || string(val.(Bytes)) != "two" {
+		t.Errorf("Expected the alias to be copied, got %v, %v", val, err)
+	}
+	if err := c.Set("frozen", IntValue(2)); err != ErrImmutable {
+		t.Errorf("Expected ErrImmutable, got %v", err)
+	}
+	if meta, err := c.Meta("a"); err != nil || meta["owner"] != "ops" {
+		t.Errorf("Expected the metadata to be copied, got %v, %v", meta, err)
+	}
+	if c.Revision() != store.Revision() {
+		t.Errorf("Expected revision %d, got %d", store.Revision(), c.Revision())
+	}
+	if val, err := c.GetAtRevision("a", 1); err != nil || string(val.(Bytes)) != "one" {
+		t.Errorf("Expected the history to be copied, got %v, %v", val, err)
+	}
+
+	// The copies are independent, down to the values.
+	val, _ := store.Get("a")
+	val.(Bytes)[0] = 'X'
+	_ = c.Set("b", IntValue(1))
+
+	if val, _ := c.Get("a"); string(val.(Bytes)) != "two" {
+		t.Errorf("Expected the copy to keep its own value, got %v", val)
+	}
+	if _, err := store.Get("b"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestClone_SlowLog(t *testing.T) {
+	store, _ := NewKeyValueStore(4, WithSlowLog(0, 2))
+	_ = store.Set("a", IntValue(1))
+
+	c, err := store.Clone()
+	if err != nil {
+		t.Fatalf("Clone returned an error: %v", err)
+	}
+	defer c.Close()
+
+	if entries := c.SlowLog(); len(entries) != 0 {
+		t.Errorf("Expected the copy to start with an empty slow log, got %v", entries)
+	}
+	for i := 0; i < 3; i++ {
+		_ = c.Set("b", IntValue(i))
+	}
+	if entries := c.SlowLog(); len(entries) != 2 {
+		t.Errorf("Expected the capacity of the original, got %d entries", len(entries))
+	}
+	if entries := store.SlowLog(); len(entries) != 1 || entries[0].Key != "a" {
+		t.Errorf("Expected the original's slow log to be unaffected, got %v", entries)
+	}
+}
@@ -0,0 +1,254 @@
+// Command kvs-cli inspects and edits a kvs store, either a snapshot file opened
+// in-process or a remote kvs-server.
+//
+// Usage:
+//
+//	kvs-cli -file store.kvs [command [args...]]
+//	kvs-cli -addr localhost:7070 [command [args...]]
+//
+// Without a command, kvs-cli starts an interactive shell. Run "help" for the list
+// of commands.
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/bay0/kvs"
+	"github.com/bay0/kvs/httpserver"
+	"github.com/bay0/kvs/kvsclient"
+	"github.com/bay0/kvs/memcache"
+)
+
+const usage = `commands:
+  get <key>            print the value of a key
+  set <key> <value>    set a key to a string value
+  del <key>            delete a key
+  keys [prefix]        list keys, optionally only those starting with prefix
+  scan [prefix]        list keys and values, optionally only those starting with prefix
+  stats                print store statistics
+  save                 write the store back to its file (file mode only)
+  help                 print this help
+  quit                 leave the shell
+`
+
+// errQuit ends the shell.
+var errQuit = errors.New("quit")
+
+// cli executes commands against a store.
+type cli struct {
+	store kvs.Store
+	out   io.Writer
+
+	// local is set when the store is a snapshot file opened in-process.
+	local *kvs.KeyValueStore
+	path  string
+}
+
+func main() {
+	file := flag.String("file", "", "snapshot file to open in-process")
+	addr := flag.String("addr", "", "address of a kvs-server to connect to")
+	shards := flag.Int("shards", 16, "number of shards when opening a file")
+	flag.Parse()
+
+	c, err := open(*file, *addr, *shards)
+	if err != nil {
+		log.Fatal(err)
+	}
+	c.out = os.Stdout
+
+	if flag.NArg() > 0 {
+		if err := c.exec(flag.Args()); err != nil && err != errQuit {
+			log.Fatal(err)
+		}
+		if c.local != nil {
+			if err := c.save(); err != nil {
+				log.Fatal(err)
+			}
+		}
+		return
+	}
+
+	c.repl(os.Stdin)
+}
+
+// open connects to the store selected by the flags.
+func open(file, addr string, shards int) (*cli, error) {
+	switch {
+	case file != "" && addr != "":
+		return nil, errors.New("-file and -addr are mutually exclusive")
+
+	case addr != "":
+		conn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return nil, err
+		}
+		return &cli{store: kvsclient.New(conn)}, nil
+
+	case file != "":
+		store, err := kvs.NewKeyValueStore(shards)
+		if err != nil {
+			return nil, err
+		}
+		if err := store.LoadSnapshot(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		return &cli{store: store, local: store, path: file}, nil
+
+	default:
+		return nil, errors.New("one of -file or -addr is required")
+	}
+}
+
+// repl runs the interactive shell until EOF or quit.
+func (c *cli) repl(in io.Reader) {
+	scanner := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(c.out, "kvs> ")
+		if !scanner.Scan() {
+			fmt.Fprintln(c.out)
+			break
+		}
+
+		args := strings.Fields(scanner.Text())
+		if len(args) == 0 {
+			continue
+		}
+
+		err := c.exec(args)
+		if err == errQuit {
+			break
+		}
+		if err != nil {
+			fmt.Fprintln(c.out, "error:", err)
+		}
+	}
+
+	if c.local != nil {
+		if err := c.save(); err != nil {
+			fmt.Fprintln(c.out, "error:", err)
+		}
+	}
+}
+
+// exec runs a single command.
+func (c *cli) exec(args []string) error {
+	switch cmd := args[0]; cmd {
+	case "get":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: get <key>")
+		}
+		val, err := c.store.Get(args[1])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.out, format(val))
+
+	case "set":
+		if len(args) < 3 {
+			return fmt.Errorf("usage: set <key> <value>")
+		}
+		return c.store.Set(args[1], kvs.Bytes(strings.Join(args[2:], " ")))
+
+	case "del":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: del <key>")
+		}
+		return c.store.Delete(args[1])
+
+	case "keys", "scan":
+		prefix := ""
+		if len(args) > 1 {
+			prefix = args[1]
+		}
+		keys, err := c.keys(prefix)
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			if cmd == "keys" {
+				fmt.Fprintln(c.out, k)
+				continue
+			}
+			val, err := c.store.Get(k)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(c.out, "%s = %s\n", k, format(val))
+		}
+
+	case "stats":
+		keys, err := c.store.Keys()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.out, "keys: %d\n", len(keys))
+		if c.local != nil {
+			fmt.Fprintf(c.out, "size: %s\n", c.local.Size())
+		}
+
+	case "save":
+		if c.local == nil {
+			return fmt.Errorf("save is only available in file mode")
+		}
+		return c.save()
+
+	case "help":
+		fmt.Fprint(c.out, usage)
+
+	case "quit", "exit":
+		return errQuit
+
+	default:
+		return fmt.Errorf("unknown command %q, run help for a list of commands", cmd)
+	}
+
+	return nil
+}
+
+// keys returns the sorted keys starting with prefix.
+func (c *cli) keys(prefix string) ([]string, error) {
+	keys, err := c.store.Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := keys[:0]
+	for _, k := range keys {
+		if strings.HasPrefix(k, prefix) {
+			filtered = append(filtered, k)
+		}
+	}
+	sort.Strings(filtered)
+
+	return filtered, nil
+}
+
+// save writes a file-backed store back to disk.
+func (c *cli) save() error {
+	return c.local.SaveSnapshot(c.path)
+}
+
+// format renders a value for display.
+func format(val kvs.Value) string {
+	switch v := val.(type) {
+	case kvs.Bytes:
+		return string(v)
+	case httpserver.JSONValue:
+		return string(v)
+	case memcache.Item:
+		return string(v.Data)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
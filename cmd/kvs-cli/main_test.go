@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCLI_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "store.kvs")
+
+	c, err := open(path, "", 4)
+	if err != nil {
+		t.Fatalf("open returned an error: %v", err)
+	}
+	var out bytes.Buffer
+	c.out = &out
+
+	c.repl(strings.NewReader("set greeting hello world\nset other 1\nget greeting\ndel other\nkeys\nbogus\nquit\n"))
+
+	got := out.String()
+	if !strings.Contains(got, "hello world\n") {
+		t.Errorf("get did not print the value: %q", got)
+	}
+	if !strings.Contains(got, "kvs> greeting\n") {
+		t.Errorf("keys did not list the remaining key: %q", got)
+	}
+	if !strings.Contains(got, `error: unknown command "bogus"`) {
+		t.Errorf("unknown command was not reported: %q", got)
+	}
+
+	// The shell saves the store on exit, so reopening the file sees the changes.
+	c, err = open(path, "", 4)
+	if err != nil {
+		t.Fatalf("open returned an error: %v", err)
+	}
+	out.Reset()
+	c.out = &out
+
+	if err := c.exec([]string{"scan", "gr"}); err != nil {
+		t.Fatalf("scan returned an error: %v", err)
+	}
+	if out.String() != "greeting = hello world\n" {
+		t.Errorf("unexpected scan output %q", out.String())
+	}
+}
+
+func TestCLI_Open(t *testing.T) {
+	if _, err := open("", "", 4); err == nil {
+		t.Error("Expected an error without -file or -addr")
+	}
+	if _, err := open("a", "b", 4); err == nil {
+		t.Error("Expected an error with both -file and -addr")
+	}
+}
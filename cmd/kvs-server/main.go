@@ -0,0 +1,121 @@
+// Command kvs-server runs a standalone kvs store served over gRPC and, optionally,
+// HTTP and the memcached text protocol.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+
+	"github.com/bay0/kvs"
+	"github.com/bay0/kvs/grpcserver"
+	"github.com/bay0/kvs/httpserver"
+	"github.com/bay0/kvs/kvsauth"
+	"github.com/bay0/kvs/kvspb"
+	"github.com/bay0/kvs/kvsprom"
+	"github.com/bay0/kvs/memcache"
+)
+
+// serverCodec lets gRPC clients read values that were written over HTTP.
+// Values written over gRPC are returned by the HTTP API as base64 strings.
+type serverCodec struct {
+	kvs.BytesCodec
+}
+
+// Marshal returns the payload of Bytes and JSON values.
+func (c serverCodec) Marshal(val kvs.Value) ([]byte, error) {
+	if v, ok := val.(httpserver.JSONValue); ok {
+		return v, nil
+	}
+
+	return c.BytesCodec.Marshal(val)
+}
+
+// loadTokens reads API tokens from a JSON file mapping secrets to tokens:
+//
+//	{"s3cret": {"scopes": ["read", "write"], "buckets": ["billing"]}}
+func loadTokens(path string) (kvsauth.Tokens, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tokens kvsauth.Tokens
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func main() {
+	grpcAddr := flag.String("grpc", ":7070", "gRPC listen address")
+	httpAddr := flag.String("http", "", "HTTP listen address, disabled if empty")
+	memcacheAddr := flag.String("memcache", "", "memcached protocol listen address, disabled if empty")
+	metricsAddr := flag.String("metrics", "", "Prometheus metrics listen address, disabled if empty")
+	shards := flag.Int("shards", 64, "number of shards")
+	tokensFile := flag.String("tokens", "", "JSON file with the API tokens the gRPC and HTTP APIs require, disabled if empty")
+	flag.Parse()
+
+	var tokens kvsauth.Tokens
+	if *tokensFile != "" {
+		if *memcacheAddr != "" {
+			log.Fatal("the memcached protocol does not support tokens; drop -memcache or -tokens")
+		}
+		var err error
+		if tokens, err = loadTokens(*tokensFile); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var opts []kvs.Option
+	var collector *kvsprom.Collector
+	if *metricsAddr != "" {
+		collector = kvsprom.NewCollector()
+		opts = append(opts, kvs.WithObserver(collector))
+	}
+
+	store, err := kvs.NewKeyValueStore(*shards, opts...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+
+	if collector != nil {
+		collector.Track(store)
+		prometheus.MustRegister(collector)
+		go func() {
+			log.Fatal(http.ListenAndServe(*metricsAddr, promhttp.Handler()))
+		}()
+	}
+
+	if *httpAddr != "" {
+		go func() {
+			log.Fatal(http.ListenAndServe(*httpAddr, httpserver.New(store, httpserver.WithTokens(tokens))))
+		}()
+	}
+
+	if *memcacheAddr != "" {
+		go func() {
+			log.Fatal(memcache.New(store).ListenAndServe(*memcacheAddr))
+		}()
+	}
+
+	lis, err := net.Listen("tcp", *grpcAddr)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	gs := grpc.NewServer()
+	kvspb.RegisterKVSServer(gs, grpcserver.New(store, grpcserver.WithCodec(serverCodec{}), grpcserver.WithTokens(tokens)))
+
+	log.Printf("kvs-server listening on %s", lis.Addr())
+	log.Fatal(gs.Serve(lis))
+}
@@ -0,0 +1,120 @@
+package kvs
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// Codec is an interface that defines how values are converted to and from bytes.
+type Codec interface {
+	// Marshal returns the encoded form of the value.
+	Marshal(val Value) ([]byte, error)
+
+	// Unmarshal decodes a value previously encoded with Marshal.
+	Unmarshal(data []byte) (Value, error)
+}
+
+// GobCodec is a Codec that uses encoding/gob.
+// Value types must be registered with RegisterType. If AllowedTypes is not empty,
+// only the listed type names are decoded, which protects against unexpected types
+// in untrusted input such as snapshots from elsewhere.
+type GobCodec struct {
+	AllowedTypes []string
+}
+
+// gobEnvelope records the registered type name next to the encoded value.
+type gobEnvelope struct {
+	Type string
+	Data []byte
+}
+
+// Marshal encodes the value using gob.
+func (GobCodec) Marshal(val Value) ([]byte, error) {
+	name, err := TypeName(val)
+	if err != nil {
+		return nil, err
+	}
+
+	var data bytes.Buffer
+	if err := gob.NewEncoder(&data).Encode(val); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(gobEnvelope{Type: name, Data: data.Bytes()}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes a gob encoded value.
+func (c GobCodec) Unmarshal(data []byte) (Value, error) {
+	var env gobEnvelope
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&env); err != nil {
+		return nil, err
+	}
+
+	t, err := lookupType(env.Type, c.AllowedTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	ptr := reflect.New(t)
+	if err := gob.NewDecoder(bytes.NewReader(env.Data)).DecodeValue(ptr); err != nil {
+		return nil, fmt.Errorf("kvs: decode %s: %w", env.Type, err)
+	}
+
+	return ptr.Elem().Interface().(Value), nil
+}
+
+// JSONCodec is a Codec that uses encoding/json.
+// Value types must be registered with RegisterType. If AllowedTypes is not empty,
+// only the listed type names are decoded.
+type JSONCodec struct {
+	AllowedTypes []string
+}
+
+// jsonEnvelope records the registered type name next to the encoded value.
+type jsonEnvelope struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Marshal encodes the value as JSON.
+func (JSONCodec) Marshal(val Value) ([]byte, error) {
+	name, err := TypeName(val)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(val)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(jsonEnvelope{Type: name, Value: data})
+}
+
+// Unmarshal decodes a JSON encoded value.
+func (c JSONCodec) Unmarshal(data []byte) (Value, error) {
+	var env jsonEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, err
+	}
+
+	t, err := lookupType(env.Type, c.AllowedTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	ptr := reflect.New(t)
+	if err := json.Unmarshal(env.Value, ptr.Interface()); err != nil {
+		return nil, fmt.Errorf("kvs: decode %s: %w", env.Type, err)
+	}
+
+	return ptr.Elem().Interface().(Value), nil
+}
@@ -0,0 +1,215 @@
+package kvs
+
+import (
+	"log/slog"
+	"time"
+)
+
+// CompactionPolicy configures automatic compaction of the revision history.
+type CompactionPolicy struct {
+	// Interval is the time between two compactions.
+	Interval time.Duration
+	// MaxAge discards versions that were replaced more than MaxAge ago. Zero disables it.
+	MaxAge time.Duration
+	// MaxRevisions keeps the history of the last MaxRevisions revisions. Zero disables it.
+	MaxRevisions int64
+	// MinAge keeps the versions replaced within MinAge, even if MaxRevisions would
+	// discard them. Zero disables it.
+	MinAge time.Duration
+	// MinRevisions keeps the history of the last MinRevisions revisions, even if
+	// MaxAge would discard them. Zero disables it.
+	MinRevisions int64
+}
+
+// WithAutoCompaction compacts the revision history every policy.Interval to the
+// newest revision allowed by policy. MaxAge and MaxRevisions bound how much history
+// is kept, and MinAge and MinRevisions how much is kept at least; the minimums win
+// when they conflict. It has no effect without WithRevisionHistory.
+func WithAutoCompaction(policy CompactionPolicy) Option {
+	return func(o *options) {
+		o.compaction = policy
+	}
+}
+
+// CompactedRevision returns the revision the history was last compacted to.
+func (kvs *KeyValueStore) CompactedRevision() int64 {
+	return kvs.compacted.Load()
+}
+
+// Compact discards the versions that are only needed to read revisions older than
+// rev. Afterwards GetAtRevision returns ErrCompacted for those revisions, while every
+// revision from rev on reads as before. Compacting to a revision at or before the
+// last compaction does nothing.
+// It returns ErrFutureRevision if rev is greater than Revision.
+func (kvs *KeyValueStore) Compact(rev int64) error {
+	if rev > kvs.Revision() {
+		return ErrFutureRevision
+	}
+
+	for {
+		old := kvs.compacted.Load()
+		if rev <= old {
+			return nil
+		}
+		if kvs.compacted.CompareAndSwap(old, rev) {
+			break
+		}
+	}
+
+	var at int64
+	for _, sh := range kvs.shards {
+		sh.mu.Lock()
+		at = max(at, sh.compact(rev))
+		sh.mu.Unlock()
+	}
+	for old := kvs.compactedAt.Load(); at > old; old = kvs.compactedAt.Load() {
+		if kvs.compactedAt.CompareAndSwap(old, at) {
+			break
+		}
+	}
+
+	return nil
+}
+
+// compact drops the versions older than the one visible at rev, and the histories
+// of keys that were deleted at rev. It returns the time of the change made at rev
+// if the shard holds it, and zero otherwise. The shard must be locked.
+func (s *shard) compact(rev int64) int64 {
+	var at int64
+	for key, h := range s.history {
+		i := len(h.versions) - 1
+		for i >= 0 && h.versions[i].rev > rev {
+			i--
+		}
+		if i < 0 {
+			continue
+		}
+		if h.versions[i].rev == rev {
+			at = h.versions[i].at
+		}
+		if h.versions[i].deleted {
+			i++
+		}
+
+		if i == len(h.versions) {
+			delete(s.history, key)
+			continue
+		}
+		if i > 0 {
+			h.versions = append(h.versions[:0:0], h.versions[i:]...)
+			h.truncated = true
+		}
+	}
+
+	return at
+}
+
+// revisionSample is the revision of the store at a point in time.
+type revisionSample struct {
+	at  time.Time
+	rev int64
+}
+
+// revisionAgo returns the revision of the newest sample taken at least d before now.
+// It reports false if there is no such sample.
+func revisionAgo(samples []revisionSample, now time.Time, d time.Duration) (int64, bool) {
+	for i := len(samples) - 1; i >= 0; i-- {
+		if now.Sub(samples[i].at) >= d {
+			return samples[i].rev, true
+		}
+	}
+
+	return 0, false
+}
+
+// OldestVisible returns the oldest revision GetAtRevision can read for every key,
+// and the time of the change made at it, which is the zero time if it is not
+// known. Older revisions have been compacted or, for some keys, dropped because
+// their history reached the depth set by WithRevisionHistory. Without revision
+// history only the current revision is visible.
+func (kvs *KeyValueStore) OldestVisible() (int64, time.Time) {
+	if kvs.opts.historyDepth <= 0 {
+		return kvs.Revision(), time.Time{}
+	}
+
+	rev, at := kvs.compacted.Load(), kvs.compactedAt.Load()
+	for _, sh := range kvs.shards {
+		sh.mu.RLock()
+		if sh.horizon.rev > rev {
+			rev, at = sh.horizon.rev, sh.horizon.at
+		}
+		sh.mu.RUnlock()
+	}
+	if at == 0 {
+		return rev, time.Time{}
+	}
+
+	return rev, time.Unix(0, at)
+}
+
+// startCompaction runs the automatic compaction policy until Close.
+func (kvs *KeyValueStore) startCompaction() {
+	kvs.bg.Add(1)
+	go kvs.runCompaction()
+}
+
+// runCompaction compacts the history every interval until the store is closed.
+func (kvs *KeyValueStore) runCompaction() {
+	defer kvs.bg.Done()
+
+	policy := kvs.opts.compaction
+	ticker := time.NewTicker(policy.Interval)
+	defer ticker.Stop()
+
+	// samples remembers the revision at every tick within the longer of MaxAge
+	// and MinAge, oldest first, so the revision that was current then can be found.
+	var samples []revisionSample
+	horizon := max(policy.MaxAge, policy.MinAge)
+
+	for {
+		select {
+		case <-kvs.stop:
+			return
+		case now := <-ticker.C:
+			rev := kvs.Revision()
+			var target int64
+
+			if horizon > 0 {
+				samples = append(samples, revisionSample{at: now, rev: rev})
+				i := 0
+				for i+1 < len(samples) && now.Sub(samples[i+1].at) >= horizon {
+					i++
+				}
+				samples = samples[i:]
+			}
+
+			if policy.MaxRevisions > 0 {
+				target = rev - policy.MaxRevisions + 1
+			}
+			if policy.MaxAge > 0 {
+				if old, ok := revisionAgo(samples, now, policy.MaxAge); ok {
+					target = max(target, old)
+				}
+			}
+			if policy.MinRevisions > 0 {
+				target = min(target, rev-policy.MinRevisions+1)
+			}
+			if policy.MinAge > 0 {
+				old, ok := revisionAgo(samples, now, policy.MinAge)
+				if !ok {
+					continue
+				}
+				target = min(target, old)
+			}
+
+			if target <= kvs.CompactedRevision() {
+				continue
+			}
+			if err := kvs.Compact(target); err != nil {
+				kvs.log(slog.LevelError, "kvs: compaction failed", slog.Any("error", err))
+				continue
+			}
+			kvs.log(slog.LevelDebug, "kvs: history compacted", slog.Int64("revision", target))
+		}
+	}
+}
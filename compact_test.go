@@ -0,0 +1,145 @@
+package kvs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCompact(t *testing.T) {
+	store, err := NewKeyValueStore(4, WithRevisionHistory(10))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	_ = store.Set("a", IntValue(1)) // 1
+	_ = store.Set("b", IntValue(1)) // 2
+	_ = store.Delete("b")           // 3
+	_ = store.Set("a", IntValue(2)) // 4
+	_ = store.Set("a", IntValue(3)) // 5
+
+	if err := store.Compact(4); err != nil {
+		t.Fatalf("Compact returned an error: %v", err)
+	}
+	if store.CompactedRevision() != 4 {
+		t.Errorf("Expected compacted revision 4, got %d", store.CompactedRevision())
+	}
+
+	if _, err := store.GetAtRevision("a", 3); err != ErrCompacted {
+		t.Errorf("Expected ErrCompacted, got %v", err)
+	}
+	if val, err := store.GetAtRevision("a", 4); err != nil || val.(IntValue) != 2 {
+		t.Errorf("Expected 2 at revision 4, got %v, %v", val, err)
+	}
+	if _, err := store.GetAtRevision("b", 4); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	sh := store.shards[store.shardIndex("b")]
+	if _, ok := sh.history["b"]; ok {
+		t.Error("Expected the history of the deleted key to be dropped")
+	}
+	if n := len(store.shards[store.shardIndex("a")].history["a"].versions); n != 2 {
+		t.Errorf("Expected 2 retained versions of a, got %d", n)
+	}
+
+	if err := store.Compact(6); err != ErrFutureRevision {
+		t.Errorf("Expected ErrFutureRevision, got %v", err)
+	}
+}
+
+func TestAutoCompaction(t *testing.T) {
+	store, _ := NewKeyValueStore(4,
+		WithRevisionHistory(10),
+		WithAutoCompaction(CompactionPolicy{Interval: time.Millisecond, MaxRevisions: 2}),
+	)
+	defer store.Close()
+
+	for i := 0; i < 5; i++ {
+		_ = store.Set("a", IntValue(i))
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for store.CompactedRevision() != 4 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected compaction to revision 4, got %d", store.CompactedRevision())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAutoCompaction_Minimums(t *testing.T) {
+	store, _ := NewKeyValueStore(4,
+		WithRevisionHistory(10),
+		WithAutoCompaction(CompactionPolicy{Interval: time.Millisecond, MaxRevisions: 1, MinRevisions: 3}),
+	)
+	defer store.Close()
+
+	for i := 0; i < 5; i++ {
+		_ = store.Set("a", IntValue(i))
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for store.CompactedRevision() != 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected compaction to revision 3, got %d", store.CompactedRevision())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	time.Sleep(10 * time.Millisecond)
+	if rev := store.CompactedRevision(); rev != 3 {
+		t.Errorf("Expected MinRevisions to keep revision 3, got %d", rev)
+	}
+
+	aged, _ := NewKeyValueStore(4,
+		WithRevisionHistory(10),
+		WithAutoCompaction(CompactionPolicy{Interval: time.Millisecond, MaxRevisions: 1, MinAge: time.Hour}),
+	)
+	defer aged.Close()
+
+	for i := 0; i < 5; i++ {
+		_ = aged.Set("a", IntValue(i))
+	}
+	time.Sleep(10 * time.Millisecond)
+	if rev := aged.CompactedRevision(); rev != 0 {
+		t.Errorf("Expected MinAge to keep the whole history, got compaction to %d", rev)
+	}
+}
+
+func TestOldestVisible(t *testing.T) {
+	store, _ := NewKeyValueStore(4, WithRevisionHistory(2))
+
+	if rev, at := store.OldestVisible(); rev != 0 || !at.IsZero() {
+		t.Errorf("Expected revision 0 for a new store, got %d at %v", rev, at)
+	}
+
+	for i := 0; i < 4; i++ {
+		_ = store.Set("a", IntValue(i))
+	}
+	_ = store.Set("b", IntValue(0))
+
+	// The depth of 2 leaves versions 3 and 4 of a.
+	rev, at := store.OldestVisible()
+	if rev != 3 || at.IsZero() {
+		t.Errorf("Expected revision 3 with a time, got %d at %v", rev, at)
+	}
+	if _, err := store.GetAtRevision("a", rev); err != nil {
+		t.Errorf("GetAtRevision returned an error: %v", err)
+	}
+	if _, err := store.GetAtRevision("a", rev-1); err != ErrCompacted {
+		t.Errorf("Expected ErrCompacted before the oldest visible revision, got %v", err)
+	}
+
+	if err := store.Compact(5); err != nil {
+		t.Fatalf("Compact returned an error: %v", err)
+	}
+	if rev, _ := store.OldestVisible(); rev != 5 {
+		t.Errorf("Expected revision 5 after compaction, got %d", rev)
+	}
+
+	plain, _ := NewKeyValueStore(1)
+	_ = plain.Set("a", IntValue(1))
+	if rev, _ := plain.OldestVisible(); rev != 1 {
+		t.Errorf("Expected the current revision without history, got %d", rev)
+	}
+}
@@ -0,0 +1,110 @@
+package kvs
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+)
+
+// Compressor is an interface that defines a compression algorithm used for stored values.
+// Implementations for snappy, zstd and friends only need to wrap the respective library.
+type Compressor interface {
+	// Compress returns the compressed form of data.
+	Compress(data []byte) ([]byte, error)
+
+	// Decompress reverses Compress.
+	Decompress(data []byte) ([]byte, error)
+}
+
+// FlateCompressor is a Compressor based on compress/flate.
+type FlateCompressor struct {
+	// Level is the flate compression level. Zero means flate.DefaultCompression.
+	Level int
+}
+
+// Compress compresses data using flate.
+func (c FlateCompressor) Compress(data []byte) ([]byte, error) {
+	level := c.Level
+	if level == 0 {
+		level = flate.DefaultCompression
+	}
+
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Decompress decompresses flate compressed data.
+func (c FlateCompressor) Decompress(data []byte) ([]byte, error) {
+	r := flate.NewReader(bytes.NewReader(data))
+	defer r.Close()
+
+	return io.ReadAll(r)
+}
+
+// compressedValue is the stored form of a value that has been compressed.
+type compressedValue struct {
+	data []byte
+}
+
+// Clone creates a copy of the compressed value.
+func (c *compressedValue) Clone() Value {
+	data := make([]byte, len(c.data))
+	copy(data, c.data)
+
+	return &compressedValue{data: data}
+}
+
+// compress returns the value to store for val, compressing it when its
+// encoded size reaches the configured threshold.
+func (kvs *KeyValueStore) compress(val Value) (Value, error) {
+	if kvs.opts.compressor == nil {
+		return val, nil
+	}
+
+	data, err := kvs.opts.codec.Marshal(val)
+	if err != nil {
+		return nil, fmt.Errorf("kvs: encode value: %w", err)
+	}
+	if len(data) < kvs.opts.compressThreshold {
+		return val, nil
+	}
+
+	data, err = kvs.opts.compressor.Compress(data)
+	if err != nil {
+		return nil, fmt.Errorf("kvs: compress value: %w", err)
+	}
+
+	return &compressedValue{data: data}, nil
+}
+
+// decompress returns the original value for a stored value.
+func (kvs *KeyValueStore) decompress(val Value) (Value, error) {
+	cv, ok := val.(*compressedValue)
+	if !ok {
+		return val, nil
+	}
+
+	data, err := kvs.opts.compressor.Decompress(cv.data)
+	if err != nil {
+		return nil, fmt.Errorf("kvs: decompress value: %w", err)
+	}
+
+	val, err = kvs.opts.codec.Unmarshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("kvs: decode value: %w", err)
+	}
+
+	return val, nil
+}
@@ -0,0 +1,51 @@
+package kvs
+
+import (
+	"strings"
+	"testing"
+)
+
+func init() {
+	RegisterType[Person]("person/v1")
+	RegisterType[IntValue]("int/v1")
+}
+
+func TestCompression(t *testing.T) {
+	store, err := NewKeyValueStore(4, WithCompression(FlateCompressor{}, 512))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	small := Person{Name: "Alice", Age: 30}
+	large := Person{Name: strings.Repeat("Bob", 1000), Age: 40}
+
+	if err := store.Set("small", small); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if err := store.Set("large", large); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+
+	raw, _ := store.shards[store.shardIndex("small")].backend.Get("small")
+	if _, ok := raw.(*compressedValue); ok {
+		t.Error("value below the threshold was compressed")
+	}
+	raw, _ = store.shards[store.shardIndex("large")].backend.Get("large")
+	cv, ok := raw.(*compressedValue)
+	if !ok {
+		t.Fatal("value above the threshold was not compressed")
+	}
+	if len(cv.data) >= len(large.Name) {
+		t.Errorf("compressed size %d is not smaller than the original %d", len(cv.data), len(large.Name))
+	}
+
+	for key, want := range map[string]Person{"small": small, "large": large} {
+		val, err := store.Get(key)
+		if err != nil {
+			t.Fatalf("Get returned an error: %v", err)
+		}
+		if p, ok := val.(Person); !ok || p != want {
+			t.Errorf("Get(%q) returned unexpected value", key)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package kvs
+
+import (
+	"reflect"
+	"sort"
+)
+
+// DiffResult lists the keys in which two stores differ. All lists are sorted.
+type DiffResult struct {
+	// Added holds the keys that exist only in the other store.
+	Added []string
+	// Removed holds the keys that exist only in the store.
+	Removed []string
+	// Changed holds the keys that exist in both stores with different values.
+	Changed []string
+}
+
+// Empty reports whether the stores hold the same entries.
+func (d DiffResult) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// ConflictFn resolves a key that holds different values in two stores being
+// merged. It returns the value to keep, or nil to delete the key. If it returns
+// an error, the merge stops and returns it.
+type ConflictFn func(key string, ours, theirs Value) (Value, error)
+
+// KeepOurs is a ConflictFn that keeps the value of the store being merged into.
+func KeepOurs(key string, ours, theirs Value) (Value, error) {
+	return ours, nil
+}
+
+// KeepTheirs is a ConflictFn that takes the value of the other store.
+func KeepTheirs(key string, ours, theirs Value) (Value, error) {
+	return theirs, nil
+}
+
+// Diff compares the store with other and reports the keys that differ. Values are
+// compared with reflect.DeepEqual. Both stores are compared as of a Snapshot
+// taken when Diff is called, so concurrent writes do not tear the result.
+func (kvs *KeyValueStore) Diff(other *KeyValueStore) (DiffResult, error) {
+	ours := kvs.Snapshot()
+	defer ours.Release()
+	theirs := other.Snapshot()
+	defer theirs.Release()
+
+	d, _, err := diffSnapshots(ours, theirs)
+
+	return d, err
+}
+
+// Merge reconciles the store with other, for example after two nodes were
+// partitioned and accepted writes independently. Keys that exist only in other
+// are copied, keys that exist only in the store are kept, and keys that differ
+// are set to the value returned by resolve. A nil resolve takes the values of
+// other, like KeepTheirs. other is not modified.
+//
+// The entries are written one by one, so concurrent readers may observe a
+// partially merged store. If a write fails, Merge stops and returns the error.
+func (kvs *KeyValueStore) Merge(other *KeyValueStore, resolve ConflictFn) error {
+	if resolve == nil {
+		resolve = KeepTheirs
+	}
+
+	ours := kvs.Snapshot()
+	defer ours.Release()
+	theirs := other.Snapshot()
+	defer theirs.Release()
+
+	d, vals, err := diffSnapshots(ours, theirs)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range d.Added {
+		if err := kvs.Set(key, vals[key].theirs); err != nil {
+			return err
+		}
+	}
+	for _, key := range d.Changed {
+		val, err := resolve(key, vals[key].ours, vals[key].theirs)
+		if err != nil {
+			return err
+		}
+		if val == nil {
+			err = kvs.Delete(key)
+		} else {
+			err = kvs.Set(key, val)
+		}
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// diffValues holds the values of a key in both stores.
+type diffValues struct {
+	ours, theirs Value
+}
+
+// diffSnapshots compares two snapshots. It also returns the values of the
+// added and changed keys.
+func diffSnapshots(ours, theirs *StoreSnapshot) (DiffResult, map[string]diffValues, error) {
+	var d DiffResult
+	vals := make(map[string]diffValues)
+
+	ourKeys, err := ours.Keys()
+	if err != nil {
+		return d, nil, err
+	}
+	theirKeys, err := theirs.Keys()
+	if err != nil {
+		return d, nil, err
+	}
+
+	seen := make(map[string]struct{}, len(ourKeys))
+	for _, key := range ourKeys {
+		seen[key] = struct{}{}
+
+		ov, err := ours.Get(key)
+		if err != nil {
+			return d, nil, err
+		}
+		tv, err := theirs.Get(key)
+		if err == ErrNotFound {
+			d.Removed = append(d.Removed, key)
+			continue
+		}
+		if err != nil {
+			return d, nil, err
+		}
+		if !reflect.DeepEqual(ov, tv) {
+			d.Changed = append(d.Changed, key)
+			vals[key] = diffValues{ours: ov, theirs: tv}
+		}
+	}
+	for _, key := range theirKeys {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		tv, err := theirs.Get(key)
+		if err != nil {
+			return d, nil, err
+		}
+		d.Added = append(d.Added, key)
+		vals[key] = diffValues{theirs: tv}
+	}
+
+	sort.Strings(d.Added)
+	sort.Strings(d.Removed)
+	sort.Strings(d.Changed)
+
+	return d, vals, nil
+}
@@ -0,0 +1,102 @@
+package kvs
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func newDiffStores(t *testing.T) (*KeyValueStore, *KeyValueStore) {
+	t.Helper()
+
+	a, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	b, err := NewKeyValueStore(8)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	_ = a.Set("same", IntValue(1))
+	_ = b.Set("same", IntValue(1))
+	_ = a.Set("changed", IntValue(1))
+	_ = b.Set("changed", IntValue(2))
+	_ = a.Set("ours", IntValue(1))
+	_ = b.Set("theirs", IntValue(1))
+
+	return a, b
+}
+
+func TestDiff(t *testing.T) {
+	a, b := newDiffStores(t)
+
+	d, err := a.Diff(b)
+	if err != nil {
+		t.Fatalf("Diff returned an error: %v", err)
+	}
+	if got := fmt.Sprint(d.Added, d.Removed, d.Changed); got != "[theirs] [ours] [changed]" {
+		t.Errorf("unexpected diff %v", got)
+	}
+	if d.Empty() {
+		t.Error("Expected a non-empty diff")
+	}
+
+	if d, err := a.Diff(a); err != nil || !d.Empty() {
+		t.Errorf("Expected a store to equal itself, got %+v, %v", d, err)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	a, b := newDiffStores(t)
+
+	err := a.Merge(b, func(key string, ours, theirs Value) (Value, error) {
+		return ours.(IntValue) + theirs.(IntValue), nil
+	})
+	if err != nil {
+		t.Fatalf("Merge returned an error: %v", err)
+	}
+
+	for key, want := range map[string]IntValue{"same": 1, "changed": 3, "ours": 1, "theirs": 1} {
+		if val, err := a.Get(key); err != nil || val.(IntValue) != want {
+			t.Errorf("Expected %s to be %d, got %v, %v", key, want, val, err)
+		}
+	}
+	if _, err := b.Get("ours"); err != ErrNotFound {
+		t.Errorf("Expected the other store to be unchanged, got %v", err)
+	}
+}
+
+func TestMerge_Resolve(t *testing.T) {
+	a, b := newDiffStores(t)
+	if err := a.Merge(b, nil); err != nil {
+		t.Fatalf("Merge returned an error: %v", err)
+	}
+	if val, _ := a.Get("changed"); val.(IntValue) != 2 {
+		t.Errorf("Expected a nil resolve to take their value, got %v", val)
+	}
+
+	a, b = newDiffStores(t)
+	if err := a.Merge(b, KeepOurs); err != nil {
+		t.Fatalf("Merge returned an error: %v", err)
+	}
+	if val, _ := a.Get("changed"); val.(IntValue) != 1 {
+		t.Errorf("Expected KeepOurs to keep our value, got %v", val)
+	}
+
+	a, b = newDiffStores(t)
+	drop := func(string, Value, Value) (Value, error) { return nil, nil }
+	if err := a.Merge(b, drop); err != nil {
+		t.Fatalf("Merge returned an error: %v", err)
+	}
+	if _, err := a.Get("changed"); err != ErrNotFound {
+		t.Errorf("Expected a nil resolution to delete the key, got %v", err)
+	}
+
+	a, b = newDiffStores(t)
+	boom := errors.New("boom")
+	fail := func(string, Value, Value) (Value, error) { return nil, boom }
+	if err := a.Merge(b, fail); err != boom {
+		t.Errorf("Expected the resolve error, got %v", err)
+	}
+}
@@ -0,0 +1,182 @@
+package kvs
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// defaultDigestSample is the number of sample keys of a digest without
+// WithDigestSample.
+const defaultDigestSample = 10
+
+// Digest summarizes the changes of keys starting with a prefix over a window.
+type Digest struct {
+	// Prefix is the watched prefix.
+	Prefix string
+	// Start and End delimit the window the digest covers.
+	Start, End time.Time
+	// Sets and Deletes are the numbers of changes in the window.
+	Sets, Deletes int
+	// Sample holds up to WithDigestSample distinct changed keys, in the order
+	// they were first changed in the window.
+	Sample []string
+}
+
+// Changes returns the total number of changes in the window.
+func (d Digest) Changes() int {
+	return d.Sets + d.Deletes
+}
+
+// digestOptions holds the settings of a digest feed.
+type digestOptions struct {
+	sample int
+	empty  bool
+}
+
+// DigestOption configures a digest feed.
+type DigestOption func(*digestOptions)
+
+// WithDigestSample sets the number of sample keys of a digest. The default is 10.
+func WithDigestSample(n int) DigestOption {
+	return func(o *digestOptions) {
+		o.sample = n
+	}
+}
+
+// WithEmptyDigests makes a digest feed deliver a digest for windows without
+// changes, too, so the consumer can tell a quiet prefix from a stalled feed.
+func WithEmptyDigests() DigestOption {
+	return func(o *digestOptions) {
+		o.empty = true
+	}
+}
+
+// digestWindow accumulates the changes of the current window.
+type digestWindow struct {
+	mu      sync.Mutex
+	d       Digest
+	sampled map[string]struct{}
+	limit   int
+}
+
+// add counts ev.
+func (w *digestWindow) add(ev Event) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	switch ev.Type {
+	case EventSet:
+		w.d.Sets++
+	case EventDelete:
+		w.d.Deletes++
+	default:
+		return
+	}
+	if _, ok := w.sampled[ev.Key]; !ok && len(w.d.Sample) < w.limit {
+		w.sampled[ev.Key] = struct{}{}
+		w.d.Sample = append(w.d.Sample, ev.Key)
+	}
+}
+
+// take returns the digest of the window ending at end and starts the next one.
+func (w *digestWindow) take(end time.Time) Digest {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	d := w.d
+	d.End = end
+	w.d = Digest{Prefix: d.Prefix, Start: end}
+	w.sampled = make(map[string]struct{})
+
+	return d
+}
+
+// Digests returns a channel that receives a Digest of the changes of keys
+// starting with prefix every interval, for consumers that want to know what is
+// changing without receiving every change. Windows without changes are skipped
+// unless WithEmptyDigests is given. The channel is closed when ctx is done.
+//
+//	for d := range store.Digests(ctx, "orders/", time.Minute) {
+//		log.Printf("%d changes under orders/, e.g. %v", d.Changes(), d.Sample)
+//	}
+//
+// Changes are counted as they are published, so no change is missed. If the
+// consumer has not taken the previous digest when a window ends, the window is
+// delivered together with the next one.
+func (kvs *KeyValueStore) Digests(ctx context.Context, prefix string, interval time.Duration, opts ...DigestOption) <-chan Digest {
+	o := digestOptions{sample: defaultDigestSample}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	w := &digestWindow{
+		d:       Digest{Prefix: prefix, Start: time.Now()},
+		sampled: make(map[string]struct{}),
+		limit:   o.sample,
+	}
+	s := newSubscription(func(key string) bool {
+		return matchesPrefix(key, prefix)
+	}, 1, false)
+	s.sink = w.add
+	cancel := kvs.events.subscribe(s)
+
+	ch := make(chan Digest, 1)
+	go func() {
+		defer close(ch)
+		defer cancel()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		var pending *Digest
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case end := <-ticker.C:
+				d := w.take(end)
+				if pending != nil {
+					d = pending.merge(d, o.sample)
+					pending = nil
+				}
+				if d.Changes() == 0 && !o.empty {
+					continue
+				}
+				select {
+				case ch <- d:
+				default:
+					// The previous digest is still unread; deliver this window
+					// together with the next one.
+					pending = &d
+				}
+			}
+		}
+	}()
+
+	return ch
+}
+
+// merge returns the digest covering d followed by next, with up to limit
+// sample keys.
+func (d Digest) merge(next Digest, limit int) Digest {
+	merged := Digest{
+		Prefix:  d.Prefix,
+		Start:   d.Start,
+		End:     next.End,
+		Sets:    d.Sets + next.Sets,
+		Deletes: d.Deletes + next.Deletes,
+		Sample:  d.Sample,
+	}
+	for _, key := range next.Sample {
+		if len(merged.Sample) >= limit {
+			break
+		}
+		if !slices.Contains(merged.Sample, key) {
+			merged.Sample = append(merged.Sample, key)
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,66 @@
+package kvs
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestDigests(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	digests := store.Digests(ctx, "orders/", 20*time.Millisecond, WithDigestSample(2))
+
+	for i := 0; i < 5; i++ {
+		_ = store.Set(fmt.Sprintf("orders/%d", i), IntValue(i))
+	}
+	_ = store.Set("orders/0", IntValue(10))
+	_ = store.Delete("orders/1")
+	_ = store.Set("users/1", IntValue(1))
+
+	var total Digest
+	for total.Changes() < 7 {
+		select {
+		case d := <-digests:
+			if d.Prefix != "orders/" || !d.End.After(d.Start) {
+				t.Errorf("unexpected digest %+v", d)
+			}
+			total = total.merge(d, 2)
+		case <-ctx.Done():
+			t.Fatalf("timed out with %d changes", total.Changes())
+		}
+	}
+	if total.Sets != 6 || total.Deletes != 1 {
+		t.Errorf("Expected 6 sets and 1 delete, got %+v", total)
+	}
+	if fmt.Sprint(total.Sample) != "[orders/0 orders/1]" {
+		t.Errorf("Expected the first two keys as sample, got %v", total.Sample)
+	}
+
+	cancel()
+	for range digests {
+	}
+}
+
+func TestDigests_Empty(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	digests := store.Digests(ctx, "orders/", 10*time.Millisecond, WithEmptyDigests())
+
+	select {
+	case d := <-digests:
+		if d.Changes() != 0 {
+			t.Errorf("Expected an empty digest, got %+v", d)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for an empty digest")
+	}
+}
@@ -10,6 +10,35 @@ const (
 	ErrNotFound
 	ErrDuplicate
 	ErrInvalidNumShards
+	ErrCorruptSnapshot
+	ErrUnknownType
+	ErrTypeNotAllowed
+	ErrImmutable
+	ErrAliasLoop
+	ErrReservedKey
+	ErrMetaTooLarge
+	ErrCompacted
+	ErrFutureRevision
+	ErrNilValue
+	ErrTxnClosed
+	ErrVersionMismatch
+	ErrReadOnly
+	ErrNotNumber
+	ErrOverflow
+	ErrNotBytes
+	ErrTxnTooLarge
+	ErrTxnAborted
+	ErrInvalidSavepoint
+	ErrTxnConflict
+	ErrNotSemaphore
+	ErrLeaseExpired
+	ErrInvalidBucket
+	ErrNotBreaker
+	ErrBreakerOpen
+	ErrQuotaExceeded
+	ErrInvalidTenant
+	ErrRateLimited
+	ErrInvalidArgument
 )
 
 var errMsg = map[ErrCode]string{
@@ -17,6 +46,35 @@ var errMsg = map[ErrCode]string{
 	ErrNotFound:         "item not found",
 	ErrDuplicate:        "item already exists",
 	ErrInvalidNumShards: "invalid number of shards",
+	ErrCorruptSnapshot:  "corrupt snapshot",
+	ErrUnknownType:      "unknown value type",
+	ErrTypeNotAllowed:   "value type not allowed",
+	ErrImmutable:        "item is immutable",
+	ErrAliasLoop:        "too many levels of aliases",
+	ErrReservedKey:      "key is reserved",
+	ErrMetaTooLarge:     "metadata too large",
+	ErrCompacted:        "revision has been compacted",
+	ErrFutureRevision:   "revision is in the future",
+	ErrNilValue:         "value is nil",
+	ErrTxnClosed:        "transaction is closed",
+	ErrVersionMismatch:  "version mismatch",
+	ErrReadOnly:         "store is read-only",
+	ErrNotNumber:        "value is not a number",
+	ErrOverflow:         "integer overflow",
+	ErrNotBytes:         "value is not a byte string",
+	ErrTxnTooLarge:      "transaction is too large",
+	ErrTxnAborted:       "transaction was aborted",
+	ErrInvalidSavepoint: "savepoint is invalid",
+	ErrTxnConflict:      "transaction conflict",
+	ErrNotSemaphore:     "value is not a semaphore",
+	ErrLeaseExpired:     "lease has expired",
+	ErrInvalidBucket:    "invalid bucket name",
+	ErrNotBreaker:       "value is not a circuit breaker",
+	ErrBreakerOpen:      "circuit breaker is open",
+	ErrQuotaExceeded:    "quota exceeded",
+	ErrInvalidTenant:    "invalid tenant id",
+	ErrRateLimited:      "rate limit exceeded",
+	ErrInvalidArgument:  "invalid argument",
 }
 
 // Error returns the string representation of an error code.
@@ -0,0 +1,302 @@
+package kvs
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType is the kind of change reported by an Event.
+type EventType int
+
+const (
+	// EventSet reports that a key was set.
+	EventSet EventType = iota + 1
+	// EventDelete reports that a key or alias was deleted.
+	EventDelete
+	// EventOverflow is the last event of a watch whose consumer fell behind.
+	// Events after it were dropped and the channel is closed.
+	EventOverflow
+	// EventCorrupt reports that the integrity scrubber found an inconsistency
+	// at a key. The key may or may not have been repaired.
+	EventCorrupt
+	// EventGap reports that events before it were dropped because the consumer
+	// of a watch with OverflowDropOldest fell behind. The watch continues.
+	EventGap
+	// EventSynced marks the end of the initial state delivered by Sync.
+	EventSynced
+)
+
+// String returns the name of the event type.
+func (t EventType) String() string {
+	switch t {
+	case EventSet:
+		return "set"
+	case EventDelete:
+		return "delete"
+	case EventOverflow:
+		return "overflow"
+	case EventCorrupt:
+		return "corrupt"
+	case EventGap:
+		return "gap"
+	case EventSynced:
+		return "synced"
+	default:
+		return "unknown"
+	}
+}
+
+// Event is a change of a key delivered to subscribers.
+type Event struct {
+	// Type is the kind of change.
+	Type EventType
+	// Key is the changed key. Writes through an alias report the key the alias
+	// resolves to.
+	Key string
+	// Rev is the store revision of the change. It is zero for changes that do
+	// not create a revision, such as removing an alias, and for signals like
+	// EventOverflow.
+	Rev int64
+	// Time is when the event was published. It is zero for signals.
+	Time time.Time
+}
+
+// defaultEventBuffer is the number of events buffered for a subscriber.
+const defaultEventBuffer = 256
+
+// WithEventBuffer sets the number of events buffered for each subscriber.
+// The default is 256.
+func WithEventBuffer(size int) Option {
+	return func(o *options) {
+		o.eventBuffer = size
+	}
+}
+
+// subscription is a subscriber of an eventBus.
+type subscription struct {
+	match func(key string) bool
+	// limit is the number of undelivered events after which the subscriber
+	// is considered to have fallen behind.
+	limit int
+	// signal makes an overflowing subscriber receive EventOverflow before its
+	// channel is closed.
+	signal bool
+	// policy decides what happens when the subscriber falls behind. Unless it
+	// is OverflowCancel, events are queued and forwarded to ch by pump, and
+	// timeout bounds how long OverflowBlock makes a publisher wait.
+	policy  OverflowPolicy
+	timeout time.Duration
+	// sink, if set, receives the events in place of ch, under mu.
+	sink func(Event)
+
+	mu     sync.Mutex
+	ch     chan Event
+	closed bool
+	done   chan struct{}
+
+	queue []Event
+	// wake signals the pump that events were queued or the subscription was
+	// closed, space signals blocked publishers that the pump took an event, and
+	// quit is closed when the subscription is canceled.
+	wake    chan struct{}
+	space   chan struct{}
+	quit    chan struct{}
+	stopped bool
+}
+
+// newSubscription creates a subscription for keys accepted by match.
+func newSubscription(match func(string) bool, limit int, signal bool) *subscription {
+	size := limit
+	if signal {
+		// One slot is kept free for the overflow event.
+		size++
+	}
+
+	return &subscription{
+		match:  match,
+		limit:  limit,
+		signal: signal,
+		ch:     make(chan Event, size),
+		done:   make(chan struct{}),
+	}
+}
+
+// send delivers ev without blocking. A subscriber that has fallen behind is closed.
+func (s *subscription) send(ev Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	if s.sink != nil {
+		s.sink(ev)
+		return
+	}
+	if s.queued() {
+		s.enqueue(ev)
+		return
+	}
+
+	if len(s.ch) >= s.limit {
+		if s.signal {
+			s.ch <- Event{Type: EventOverflow}
+		}
+		s.closeLocked()
+		return
+	}
+
+	s.ch <- ev
+}
+
+// close closes the subscriber's channel unless it is closed already.
+func (s *subscription) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.queued() {
+		if !s.stopped {
+			s.stopped = true
+			s.closed = true
+			close(s.quit)
+		}
+		return
+	}
+
+	if !s.closed {
+		s.closeLocked()
+	}
+}
+
+// closeLocked closes the subscriber's channel. A queued subscription stops
+// accepting events, and the pump closes the channel once the queue is drained.
+// s.mu must be held.
+func (s *subscription) closeLocked() {
+	s.closed = true
+	if s.queued() {
+		notify(s.wake)
+		return
+	}
+
+	close(s.ch)
+	close(s.done)
+}
+
+// eventBus fans out events to subscribers.
+type eventBus struct {
+	mu   sync.RWMutex
+	subs map[*subscription]struct{}
+	n    atomic.Int32
+}
+
+// publish delivers an event to every subscriber whose pattern matches the key.
+// rev is the revision of the change, or zero if the event is not a recorded change.
+func (b *eventBus) publish(typ EventType, key string, rev int64) {
+	if b.n.Load() == 0 {
+		return
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	ev := Event{Type: typ, Key: key, Rev: rev, Time: time.Now()}
+	for s := range b.subs {
+		if s.match(key) {
+			s.send(ev)
+		}
+	}
+}
+
+// Subscribe delivers an event for every set and delete of a key matching pattern,
+// and for every inconsistency the scrubber finds at such a key.
+// In the pattern, '*' matches any sequence of characters, '?' matches a single
+// character and '\\' escapes the next character; "*" matches every key.
+//
+// Events of a key arrive in the order the changes were applied. Delivery never
+// blocks writers: a subscriber that falls behind by more than the event buffer
+// has its channel closed and must resubscribe and resynchronize. The returned
+// function cancels the subscription and closes the channel.
+func (kvs *KeyValueStore) Subscribe(pattern string) (<-chan Event, func()) {
+	size := kvs.opts.eventBuffer
+	if size <= 0 {
+		size = defaultEventBuffer
+	}
+
+	s := newSubscription(func(key string) bool {
+		return matchPattern(pattern, key)
+	}, size, false)
+
+	return s.ch, kvs.events.subscribe(s)
+}
+
+// subscribe adds s to the bus and returns a function that removes and closes it.
+func (b *eventBus) subscribe(s *subscription) func() {
+	b.mu.Lock()
+	if b.subs == nil {
+		b.subs = make(map[*subscription]struct{})
+	}
+	b.subs[s] = struct{}{}
+	b.n.Add(1)
+	b.mu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, s)
+			b.n.Add(-1)
+			b.mu.Unlock()
+			s.close()
+		})
+	}
+}
+
+// matchPattern reports whether key matches a glob pattern with '*', '?' and '\\'.
+func matchPattern(pattern, key string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 0 && pattern[0] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := 0; i <= len(key); i++ {
+				if matchPattern(pattern, key[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(key) == 0 {
+				return false
+			}
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if len(key) == 0 || key[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern = pattern[1:]
+		key = key[1:]
+	}
+
+	return len(key) == 0
+}
+
+// apply stores val under key in sh, records the revision and publishes the change. The shard must be locked.
+func (kvs *KeyValueStore) apply(sh *shard, key string, val Value) error {
+	if err := sh.set(key, val); err != nil {
+		return err
+	}
+	rev := kvs.record(sh, key, val, false)
+	kvs.events.publish(EventSet, key, rev)
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package kvs
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func receive(t *testing.T, ch <-chan Event) Event {
+	t.Helper()
+
+	select {
+	case ev, ok := <-ch:
+		if !ok {
+			t.Fatal("Expected an event, the channel was closed")
+		}
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("Expected an event, got none")
+	}
+
+	return Event{}
+}
+
+func TestSubscribe(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	events, cancel := store.Subscribe("user:*")
+	defer cancel()
+
+	_ = store.Set("order:1", IntValue(1))
+	_ = store.Set("user:1", IntValue(1))
+	_ = store.Delete("user:1")
+	_ = store.Delete("user:2")
+
+	if ev := receive(t, events); ev.Type != EventSet || ev.Key != "user:1" {
+		t.Errorf("Expected a set of user:1, got %+v", ev)
+	} else if ev.Rev != 2 || ev.Time.IsZero() {
+		t.Errorf("Expected revision 2 and a time, got %+v", ev)
+	}
+	if ev := receive(t, events); ev.Type != EventDelete || ev.Key != "user:1" {
+		t.Errorf("Expected a delete of user:1, got %+v", ev)
+	} else if ev.Rev != 3 {
+		t.Errorf("Expected revision 3, got %+v", ev)
+	}
+
+	select {
+	case ev := <-events:
+		t.Errorf("Expected no more events, got %+v", ev)
+	default:
+	}
+
+	cancel()
+	if _, ok := <-events; ok {
+		t.Error("Expected cancel to close the channel")
+	}
+	_ = store.Set("user:3", IntValue(3))
+}
+
+func TestSubscribe_Alias(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	_ = store.Alias("latest", "v1")
+
+	events, cancel := store.Subscribe("*")
+	defer cancel()
+
+	_ = store.Set("latest", IntValue(1))
+	_ = store.Delete("latest")
+
+	if ev := receive(t, events); ev.Key != "v1" || ev.Type != EventSet {
+		t.Errorf("Expected the write through the alias to report v1, got %+v", ev)
+	}
+	if ev := receive(t, events); ev.Key != "latest" || ev.Type != EventDelete {
+		t.Errorf("Expected the alias deletion to be reported, got %+v", ev)
+	}
+}
+
+func TestSubscribe_SlowSubscriber(t *testing.T) {
+	store, _ := NewKeyValueStore(4, WithEventBuffer(2))
+
+	events, cancel := store.Subscribe("*")
+	defer cancel()
+
+	for i := 0; i < 5; i++ {
+		if err := store.Set(fmt.Sprintf("key-%d", i), IntValue(i)); err != nil {
+			t.Fatalf("Set returned an error: %v", err)
+		}
+	}
+
+	n := 0
+	for range events {
+		n++
+	}
+	if n != 2 {
+		t.Errorf("Expected the buffered events before the channel was closed, got %d", n)
+	}
+}
+
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern, key string
+		want         bool
+	}{
+		{"*", "", true},
+		{"*", "anything", true},
+		{"user:*", "user:1", true},
+		{"user:*", "users:1", false},
+		{"*:1", "user:1", true},
+		{"user:?", "user:1", true},
+		{"user:?", "user:12", false},
+		{"a*b*c", "aXXbYYc", true},
+		{"a*b*c", "aXXbYY", false},
+		{`user\*`, "user*", true},
+		{`user\*`, "user1", false},
+		{"exact", "exact", true},
+		{"exact", "exactly", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchPattern(tt.pattern, tt.key); got != tt.want {
+			t.Errorf("matchPattern(%q, %q) = %v, want %v", tt.pattern, tt.key, got, tt.want)
+		}
+	}
+}
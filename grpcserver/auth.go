@@ -0,0 +1,64 @@
+package grpcserver
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/bay0/kvs/kvsauth"
+)
+
+// WithTokens requires every call to carry one of tokens as "authorization"
+// metadata of the form "Bearer <secret>". Get, Keys, Watch, Sync and
+// ExportBucket need kvsauth.ScopeRead, and Set, Delete, BatchSet and
+// ImportBucket kvsauth.ScopeWrite. Tokens restricted to buckets can only perform
+// key operations in a bucket named with kvs-bucket metadata and export or import
+// their buckets; they cannot watch or sync.
+//
+// Calls without a known token fail with Unauthenticated and calls the token does
+// not grant with PermissionDenied. Tokens are sent in the clear, so serve over TLS.
+func WithTokens(tokens kvsauth.Tokens) Option {
+	return func(s *Server) {
+		s.tokens = tokens
+	}
+}
+
+// authorize checks that the token of a call grants scope on the bucket at path,
+// or outside buckets for an empty path.
+func (s *Server) authorize(ctx context.Context, scope kvsauth.Scope, bucket string) error {
+	t, err := s.token(ctx, scope)
+	if err != nil {
+		return err
+	}
+
+	return allowBucket(t, bucket)
+}
+
+// token returns the token of a call after checking that it grants scope. Without
+// tokens, it returns a token that is not restricted to buckets.
+func (s *Server) token(ctx context.Context, scope kvsauth.Scope) (kvsauth.Token, error) {
+	if s.tokens == nil {
+		return kvsauth.Token{}, nil
+	}
+
+	t, ok := s.tokens.Lookup(kvsauth.BearerToken(firstValue(ctx, "authorization")))
+	if !ok {
+		return t, status.Error(codes.Unauthenticated, kvsauth.ErrUnauthenticated.Error())
+	}
+	if !t.Allows(scope) {
+		return t, status.Error(codes.PermissionDenied, kvsauth.ErrForbidden.Error())
+	}
+
+	return t, nil
+}
+
+// allowBucket checks that t may access the bucket at path, or anything outside
+// buckets for an empty path.
+func allowBucket(t kvsauth.Token, bucket string) error {
+	if !t.AllowsBucket(bucket) {
+		return status.Error(codes.PermissionDenied, kvsauth.ErrForbidden.Error())
+	}
+
+	return nil
+}
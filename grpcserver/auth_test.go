@@ -0,0 +1,131 @@
+package grpcserver
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/bay0/kvs"
+	"github.com/bay0/kvs/kvsauth"
+	"github.com/bay0/kvs/kvspb"
+)
+
+// dial serves srv in memory and returns a client of it.
+func dial(t *testing.T, srv kvspb.KVSServer) kvspb.KVSClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	kvspb.RegisterKVSServer(gs, srv)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient returned an error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return kvspb.NewKVSClient(conn)
+}
+
+func TestServer_Tokens(t *testing.T) {
+	store, err := kvs.NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	client := dial(t, New(store, WithTokens(kvsauth.Tokens{
+		"admin":   {Scopes: []kvsauth.Scope{kvsauth.ScopeAdmin}},
+		"reader":  {Scopes: []kvsauth.Scope{kvsauth.ScopeRead}},
+		"billing": {Scopes: []kvsauth.Scope{kvsauth.ScopeRead, kvsauth.ScopeWrite}, Buckets: []string{"billing"}},
+	})))
+
+	// call performs op with the given token and bucket metadata and returns the
+	// status code of its result. Streams report their status on the first Recv.
+	call := func(token, bucket, op string) codes.Code {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		if token != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+		}
+		if bucket != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, BucketMetadataKey, bucket)
+		}
+
+		var err error
+		switch op {
+		case "get":
+			_, err = client.Get(ctx, &kvspb.GetRequest{Key: "a"})
+		case "set":
+			_, err = client.Set(ctx, &kvspb.SetRequest{Key: "a", Value: []byte("1")})
+		case "watch":
+			var stream grpc.ServerStreamingClient[kvspb.WatchResponse]
+			if stream, err = client.Watch(ctx, &kvspb.WatchRequest{}); err == nil {
+				_, err = stream.Recv()
+			}
+		case "sync":
+			var stream grpc.ServerStreamingClient[kvspb.ChangeEvent]
+			if stream, err = client.Sync(ctx, &kvspb.SyncRequest{}); err == nil {
+				_, err = stream.Recv()
+			}
+		case "import":
+			var stream grpc.ClientStreamingClient[kvspb.BucketChunk, kvspb.ImportBucketResponse]
+			if stream, err = client.ImportBucket(ctx); err == nil {
+				if bucket != "" {
+					err = stream.Send(&kvspb.BucketChunk{Bucket: bucket})
+				}
+				if err == nil {
+					_, err = stream.CloseAndRecv()
+				}
+			}
+		}
+
+		return status.Code(err)
+	}
+
+	tests := []struct {
+		token, bucket, op string
+		want              codes.Code
+	}{
+		{"", "", "get", codes.Unauthenticated},
+		{"wrong", "", "get", codes.Unauthenticated},
+		{"", "", "watch", codes.Unauthenticated},
+		{"wrong", "", "watch", codes.Unauthenticated},
+		{"", "", "sync", codes.Unauthenticated},
+		{"wrong", "", "sync", codes.Unauthenticated},
+		{"admin", "", "set", codes.OK},
+		{"reader", "", "get", codes.OK},
+		{"reader", "", "set", codes.PermissionDenied},
+		{"reader", "", "sync", codes.OK},
+		{"billing", "", "get", codes.PermissionDenied},
+		{"billing", "billing", "set", codes.OK},
+		{"billing", "search", "get", codes.PermissionDenied},
+		{"billing", "", "watch", codes.PermissionDenied},
+		{"billing", "billing", "watch", codes.PermissionDenied},
+		{"billing", "", "sync", codes.PermissionDenied},
+		{"billing", "billing", "sync", codes.PermissionDenied},
+		{"", "", "import", codes.Unauthenticated},
+		{"wrong", "", "import", codes.Unauthenticated},
+		{"reader", "", "import", codes.PermissionDenied},
+		{"reader", "billing", "import", codes.PermissionDenied},
+		{"admin", "", "import", codes.InvalidArgument},
+		{"billing", "search", "import", codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		if got := call(tt.token, tt.bucket, tt.op); got != tt.want {
+			t.Errorf("%s with token %q and bucket %q returned %s, want %s", tt.op, tt.token, tt.bucket, got, tt.want)
+		}
+	}
+}
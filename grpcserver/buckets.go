@@ -0,0 +1,121 @@
+package grpcserver
+
+import (
+	"bufio"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	// Registers the gzip compressor, so clients can compress bucket transfers.
+	_ "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/status"
+
+	"github.com/bay0/kvs"
+	"github.com/bay0/kvs/kvsauth"
+	"github.com/bay0/kvs/kvspb"
+)
+
+// chunkSize is the size of the data of a BucketChunk sent by ExportBucket.
+const chunkSize = 64 << 10
+
+// BucketMetadataKey is the metadata key naming the bucket, by its path, the key
+// operations of a call are performed in.
+const BucketMetadataKey = "kvs-bucket"
+
+// bucketStore is implemented by stores with buckets.
+type bucketStore interface {
+	BucketAt(path string) *kvs.Bucket
+}
+
+// chunkSender sends the data written to it as BucketChunks.
+type chunkSender struct {
+	stream kvspb.KVS_ExportBucketServer
+}
+
+// Write sends p as one chunk.
+func (w chunkSender) Write(p []byte) (int, error) {
+	if err := w.stream.Send(&kvspb.BucketChunk{Data: p}); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// ExportBucket streams the export of the requested bucket in chunks.
+func (s *Server) ExportBucket(req *kvspb.ExportBucketRequest, stream kvspb.KVS_ExportBucketServer) error {
+	if err := s.authorize(stream.Context(), kvsauth.ScopeRead, req.GetBucket()); err != nil {
+		return err
+	}
+	bs, ok := s.store.(bucketStore)
+	if !ok {
+		return status.Error(codes.Unimplemented, "store does not support buckets")
+	}
+
+	bw := bufio.NewWriterSize(chunkSender{stream}, chunkSize)
+	if err := bs.BucketAt(req.GetBucket()).Export(bw); err != nil {
+		return toStatus(err)
+	}
+
+	return bw.Flush()
+}
+
+// chunkReader reads the data of the BucketChunks of an import.
+type chunkReader struct {
+	stream kvspb.KVS_ImportBucketServer
+	data   []byte
+}
+
+// Read returns the data of the current chunk, receiving the next one when it
+// is used up.
+func (r *chunkReader) Read(p []byte) (int, error) {
+	for len(r.data) == 0 {
+		chunk, err := r.stream.Recv()
+		if err != nil {
+			return 0, err
+		}
+		r.data = chunk.GetData()
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+
+	return n, nil
+}
+
+// ImportBucket loads the export streamed in chunks into the bucket named in the
+// first chunk. The token is checked before the first chunk is received, and its
+// buckets once the first chunk names the bucket.
+func (s *Server) ImportBucket(stream kvspb.KVS_ImportBucketServer) error {
+	token, err := s.token(stream.Context(), kvsauth.ScopeWrite)
+	if err != nil {
+		return err
+	}
+	bs, ok := s.store.(bucketStore)
+	if !ok {
+		return status.Error(codes.Unimplemented, "store does not support buckets")
+	}
+
+	first, err := stream.Recv()
+	if err == io.EOF {
+		return status.Error(codes.InvalidArgument, "empty import")
+	}
+	if err != nil {
+		return err
+	}
+	if err := allowBucket(token, first.GetBucket()); err != nil {
+		return err
+	}
+
+	var opts []kvs.ImportOption
+	if first.GetDryRun() {
+		opts = append(opts, kvs.DryRun())
+	}
+	r := &chunkReader{stream: stream, data: first.GetData()}
+	report, err := bs.BucketAt(first.GetBucket()).Import(r, opts...)
+	if err != nil {
+		return toStatus(err)
+	}
+
+	return stream.SendAndClose(&kvspb.ImportBucketResponse{
+		Created:     int64(report.Created),
+		Overwritten: int64(report.Overwritten),
+	})
+}
@@ -0,0 +1,333 @@
+// Package grpcserver exposes a kvs.Store as the gRPC service defined in kvspb.
+//
+//	store, _ := kvs.NewKeyValueStore(16)
+//	gs := grpc.NewServer()
+//	kvspb.RegisterKVSServer(gs, grpcserver.New(store))
+//
+// Stores with tenants perform the key operations of calls with a kvs-tenant
+// metadata entry for that tenant, and stores with buckets those of calls with a
+// kvs-bucket entry in that bucket. With WithTokens, calls must carry an API token
+// granting them.
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/bay0/kvs"
+	"github.com/bay0/kvs/kvsauth"
+	"github.com/bay0/kvs/kvsevent"
+	"github.com/bay0/kvs/kvspb"
+)
+
+// keysPerMessage is the number of keys sent in one KeysResponse.
+const keysPerMessage = 1000
+
+// Option configures optional behaviour of a Server.
+type Option func(*Server)
+
+// WithCodec sets the codec used to convert between wire payloads and values.
+// The default is kvs.BytesCodec, which stores payloads as kvs.Bytes.
+func WithCodec(c kvs.Codec) Option {
+	return func(s *Server) {
+		s.codec = c
+	}
+}
+
+// Server implements kvspb.KVSServer on top of a kvs.Store.
+type Server struct {
+	kvspb.UnimplementedKVSServer
+
+	store  kvs.Store
+	codec  kvs.Codec
+	tokens kvsauth.Tokens
+}
+
+// New creates a new Server backed by store.
+func New(store kvs.Store, opts ...Option) *Server {
+	s := &Server{
+		store: store,
+		codec: kvs.BytesCodec{},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Get retrieves the value associated with a key.
+func (s *Server) Get(ctx context.Context, req *kvspb.GetRequest) (*kvspb.GetResponse, error) {
+	store, err := s.storeFor(ctx, kvsauth.ScopeRead)
+	if err != nil {
+		return nil, err
+	}
+	val, err := store.Get(req.GetKey())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	data, err := s.codec.Marshal(val)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &kvspb.GetResponse{Value: data}, nil
+}
+
+// Set adds or updates a key-value pair.
+func (s *Server) Set(ctx context.Context, req *kvspb.SetRequest) (*kvspb.SetResponse, error) {
+	store, err := s.storeFor(ctx, kvsauth.ScopeWrite)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.set(store, req); err != nil {
+		return nil, err
+	}
+
+	return &kvspb.SetResponse{}, nil
+}
+
+// Delete removes a key-value pair.
+func (s *Server) Delete(ctx context.Context, req *kvspb.DeleteRequest) (*kvspb.DeleteResponse, error) {
+	store, err := s.storeFor(ctx, kvsauth.ScopeWrite)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Delete(req.GetKey()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &kvspb.DeleteResponse{}, nil
+}
+
+// BatchSet sets every pair received on the stream.
+func (s *Server) BatchSet(stream kvspb.KVS_BatchSetServer) error {
+	store, err := s.storeFor(stream.Context(), kvsauth.ScopeWrite)
+	if err != nil {
+		return err
+	}
+
+	var count int64
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return stream.SendAndClose(&kvspb.BatchSetResponse{Count: count})
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := s.set(store, req); err != nil {
+			return err
+		}
+		count++
+	}
+}
+
+// systemKeyser is implemented by stores with a reserved system keyspace.
+type systemKeyser interface {
+	SystemKeys() []string
+}
+
+// Keys streams the keys of the store in sorted chunks.
+// System keys are included when a prefix is given.
+func (s *Server) Keys(req *kvspb.KeysRequest, stream kvspb.KVS_KeysServer) error {
+	store, err := s.storeFor(stream.Context(), kvsauth.ScopeRead)
+	if err != nil {
+		return err
+	}
+	keys, err := store.Keys()
+	if err != nil {
+		return toStatus(err)
+	}
+	if sk, ok := store.(systemKeyser); ok && req.GetPrefix() != "" {
+		keys = append(keys, sk.SystemKeys()...)
+	}
+
+	filtered := keys[:0]
+	for _, k := range keys {
+		if strings.HasPrefix(k, req.GetPrefix()) {
+			filtered = append(filtered, k)
+		}
+	}
+	sort.Strings(filtered)
+
+	for len(filtered) > 0 {
+		n := keysPerMessage
+		if n > len(filtered) {
+			n = len(filtered)
+		}
+		if err := stream.Send(&kvspb.KeysResponse{Keys: filtered[:n]}); err != nil {
+			return err
+		}
+		filtered = filtered[n:]
+	}
+
+	return nil
+}
+
+// watcher is implemented by stores that can stream keyspace events.
+type watcher interface {
+	Watch(ctx context.Context, prefix string, opts ...kvs.WatchOption) <-chan kvs.Event
+}
+
+// eventTypes maps store event types to their wire representation.
+var eventTypes = map[kvs.EventType]kvspb.EventType{
+	kvs.EventSet:      kvspb.EventType_EVENT_TYPE_SET,
+	kvs.EventDelete:   kvspb.EventType_EVENT_TYPE_DELETE,
+	kvs.EventOverflow: kvspb.EventType_EVENT_TYPE_OVERFLOW,
+	kvs.EventCorrupt:  kvspb.EventType_EVENT_TYPE_CORRUPT,
+	kvs.EventGap:      kvspb.EventType_EVENT_TYPE_GAP,
+}
+
+// Watch streams the changes of keys starting with the requested prefix until the
+// client cancels the call or falls behind, in which case an overflow event is sent
+// before the stream ends.
+func (s *Server) Watch(req *kvspb.WatchRequest, stream kvspb.KVS_WatchServer) error {
+	if err := s.authorize(stream.Context(), kvsauth.ScopeRead, ""); err != nil {
+		return err
+	}
+	w, ok := s.store.(watcher)
+	if !ok {
+		return status.Error(codes.Unimplemented, "store does not support watches")
+	}
+
+	var opts []kvs.WatchOption
+	if req.GetBuffer() > 0 {
+		opts = append(opts, kvs.WithWatchBuffer(int(req.GetBuffer())))
+	}
+
+	for ev := range w.Watch(stream.Context(), req.GetPrefix(), opts...) {
+		typ, ok := eventTypes[ev.Type]
+		if !ok {
+			continue
+		}
+		resp := &kvspb.WatchResponse{Type: typ, Key: ev.Key, Event: kvsevent.FromEvent(ev).Proto()}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncer is implemented by stores that can stream their state followed by changes.
+type syncer interface {
+	Sync(ctx context.Context, prefix string, opts ...kvs.WatchOption) <-chan kvs.SyncEvent
+}
+
+// Sync streams the entries of keys starting with the requested prefix, an
+// EVENT_TYPE_SYNCED event and then their changes, with the values of sets, until
+// the client cancels the call or falls behind.
+func (s *Server) Sync(req *kvspb.SyncRequest, stream kvspb.KVS_SyncServer) error {
+	if err := s.authorize(stream.Context(), kvsauth.ScopeRead, ""); err != nil {
+		return err
+	}
+	y, ok := s.store.(syncer)
+	if !ok {
+		return status.Error(codes.Unimplemented, "store does not support sync")
+	}
+
+	var opts []kvs.WatchOption
+	if req.GetBuffer() > 0 {
+		opts = append(opts, kvs.WithWatchBuffer(int(req.GetBuffer())))
+	}
+
+	for ev := range y.Sync(stream.Context(), req.GetPrefix(), opts...) {
+		e := kvsevent.FromEvent(ev.Event)
+		if ev.Value != nil {
+			data, err := s.codec.Marshal(ev.Value)
+			if err != nil {
+				return status.Error(codes.Internal, err.Error())
+			}
+			e.Value = data
+		}
+		if err := stream.Send(e.Proto()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// storeFor authorizes scope for a call and returns the store serving its key
+// operations: the tenant named in its TenantMetadataKey or the bucket named in
+// its BucketMetadataKey, or the server's store without either.
+func (s *Server) storeFor(ctx context.Context, scope kvsauth.Scope) (kvs.Store, error) {
+	tenant, bucket := firstValue(ctx, TenantMetadataKey), firstValue(ctx, BucketMetadataKey)
+	if err := s.authorize(ctx, scope, bucket); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case tenant != "" && bucket != "":
+		return nil, status.Error(codes.InvalidArgument, "tenant and bucket are exclusive")
+	case tenant != "":
+		if ts, ok := s.store.(tenantStore); ok {
+			return ts.Tenant(tenant), nil
+		}
+		return nil, status.Error(codes.Unimplemented, "store does not support tenants")
+	case bucket != "":
+		if bs, ok := s.store.(bucketStore); ok {
+			return bs.BucketAt(bucket), nil
+		}
+		return nil, status.Error(codes.Unimplemented, "store does not support buckets")
+	default:
+		return s.store, nil
+	}
+}
+
+// firstValue returns the first value of the incoming metadata key of a call, or
+// "" if there is none.
+func firstValue(ctx context.Context, key string) string {
+	if values := metadata.ValueFromIncomingContext(ctx, key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+// set decodes and stores a single pair in store.
+func (s *Server) set(store kvs.Store, req *kvspb.SetRequest) error {
+	val, err := s.codec.Unmarshal(req.GetValue())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if err := store.Set(req.GetKey(), val); err != nil {
+		return toStatus(err)
+	}
+
+	return nil
+}
+
+// toStatus converts a store error into a gRPC status error.
+func toStatus(err error) error {
+	var code kvs.ErrCode
+	if !errors.As(err, &code) {
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	switch code {
+	case kvs.ErrNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case kvs.ErrDuplicate:
+		return status.Error(codes.AlreadyExists, err.Error())
+	case kvs.ErrReservedKey:
+		return status.Error(codes.PermissionDenied, err.Error())
+	case kvs.ErrInvalidBucket, kvs.ErrInvalidTenant, kvs.ErrCorruptSnapshot:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case kvs.ErrQuotaExceeded, kvs.ErrRateLimited:
+		return status.Error(codes.ResourceExhausted, err.Error())
+	default:
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+}
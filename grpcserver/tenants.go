@@ -0,0 +1,12 @@
+package grpcserver
+
+import "github.com/bay0/kvs"
+
+// TenantMetadataKey is the metadata key naming the tenant the key operations of
+// a call are performed for.
+const TenantMetadataKey = "kvs-tenant"
+
+// tenantStore is implemented by stores with tenants.
+type tenantStore interface {
+	Tenant(id string) *kvs.Tenant
+}
@@ -0,0 +1,146 @@
+package kvs
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+)
+
+// HotKey is a frequently accessed key reported by HotKeys.
+type HotKey struct {
+	// Key is the accessed key.
+	Key string
+	// Count is the estimated number of accesses. It never underestimates the true count.
+	Count uint64
+	// Error is the maximum amount by which Count may overestimate the true count.
+	Error uint64
+}
+
+// WithHotKeyTracking enables HotKeys. Every shard tracks the approximate access
+// counts of up to capacity keys with the space-saving algorithm, so keys accessed
+// more often than 1/capacity of a shard's operations are always reported.
+func WithHotKeyTracking(capacity int) Option {
+	return func(o *options) {
+		o.hotKeyCapacity = capacity
+	}
+}
+
+// hotCounter is a monitored key of a topK sketch.
+type hotCounter struct {
+	HotKey
+	index int
+}
+
+// topK is a space-saving sketch of the most frequently accessed keys.
+// It is safe for concurrent use.
+type topK struct {
+	mu       sync.Mutex
+	capacity int
+	counters map[string]*hotCounter
+	heap     hotHeap
+}
+
+// newTopK creates a sketch that monitors up to capacity keys.
+func newTopK(capacity int) *topK {
+	return &topK{
+		capacity: capacity,
+		counters: make(map[string]*hotCounter, capacity),
+	}
+}
+
+// record counts an access to key. An unmonitored key replaces the key with the
+// lowest count once the sketch is full and inherits its count as error.
+func (t *topK) record(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if c, ok := t.counters[key]; ok {
+		c.Count++
+		heap.Fix(&t.heap, c.index)
+		return
+	}
+
+	if len(t.heap) < t.capacity {
+		c := &hotCounter{HotKey: HotKey{Key: key, Count: 1}}
+		t.counters[key] = c
+		heap.Push(&t.heap, c)
+		return
+	}
+
+	c := t.heap[0]
+	delete(t.counters, c.Key)
+	c.Key = key
+	c.Error = c.Count
+	c.Count++
+	t.counters[key] = c
+	heap.Fix(&t.heap, 0)
+}
+
+// keys returns the monitored keys.
+func (t *topK) keys() []HotKey {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	keys := make([]HotKey, 0, len(t.heap))
+	for _, c := range t.heap {
+		keys = append(keys, c.HotKey)
+	}
+
+	return keys
+}
+
+// hotHeap is a min-heap of counters ordered by count.
+type hotHeap []*hotCounter
+
+func (h hotHeap) Len() int           { return len(h) }
+func (h hotHeap) Less(i, j int) bool { return h[i].Count < h[j].Count }
+
+func (h hotHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *hotHeap) Push(x any) {
+	c := x.(*hotCounter)
+	c.index = len(*h)
+	*h = append(*h, c)
+}
+
+func (h *hotHeap) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// recordAccess counts an access to key if hot key tracking is enabled.
+func (s *shard) recordAccess(key string) {
+	if s.hot != nil {
+		s.hot.record(key)
+	}
+}
+
+// HotKeys returns up to n of the most frequently accessed keys, most accessed first.
+// Accesses through an alias are counted for the key it resolves to.
+// It returns an empty slice unless the store was created with WithHotKeyTracking.
+func (kvs *KeyValueStore) HotKeys(n int) []HotKey {
+	keys := make([]HotKey, 0)
+	for _, sh := range kvs.shards {
+		if sh.hot != nil {
+			keys = append(keys, sh.hot.keys()...)
+		}
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Count != keys[j].Count {
+			return keys[i].Count > keys[j].Count
+		}
+		return keys[i].Key < keys[j].Key
+	})
+	if n >= 0 && len(keys) > n {
+		keys = keys[:n]
+	}
+
+	return keys
+}
@@ -0,0 +1,85 @@
+package kvs
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestHotKeys(t *testing.T) {
+	store, err := NewKeyValueStore(4, WithHotKeyTracking(64))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	_ = store.Set("hot", IntValue(1))
+	for i := 0; i < 100; i++ {
+		_, _ = store.Get("hot")
+	}
+	_ = store.Set("warm", IntValue(1))
+	for i := 0; i < 10; i++ {
+		_, _ = store.Get("warm")
+	}
+	for i := 0; i < 1000; i++ {
+		_, _ = store.Get(fmt.Sprintf("cold-%d", i))
+	}
+
+	keys := store.HotKeys(2)
+	if len(keys) != 2 {
+		t.Fatalf("Expected 2 hot keys, got %v", keys)
+	}
+	if keys[0].Key != "hot" || keys[0].Count-keys[0].Error > 101 || keys[0].Count < 101 {
+		t.Errorf("Expected hot to be the hottest key with about 101 accesses, got %+v", keys[0])
+	}
+	if keys[1].Key != "warm" {
+		t.Errorf("Expected warm to be the second hottest key, got %+v", keys[1])
+	}
+}
+
+func TestHotKeys_Alias(t *testing.T) {
+	store, _ := NewKeyValueStore(2, WithHotKeyTracking(4))
+	_ = store.Set("v1", IntValue(1))
+	_ = store.Alias("latest", "v1")
+
+	for i := 0; i < 5; i++ {
+		_, _ = store.Get("latest")
+	}
+
+	keys := store.HotKeys(1)
+	if len(keys) != 1 || keys[0].Key != "v1" || keys[0].Count < 6 {
+		t.Errorf("Expected alias reads to count for v1, got %v", keys)
+	}
+}
+
+func TestHotKeys_Disabled(t *testing.T) {
+	store, _ := NewKeyValueStore(2)
+	_ = store.Set("a", IntValue(1))
+
+	if keys := store.HotKeys(10); len(keys) != 0 {
+		t.Errorf("Expected no hot keys without tracking, got %v", keys)
+	}
+}
+
+func TestTopK_Exact(t *testing.T) {
+	tk := newTopK(3)
+	for key, n := range map[string]int{"a": 5, "b": 3, "c": 1} {
+		for i := 0; i < n; i++ {
+			tk.record(key)
+		}
+	}
+
+	for _, hk := range tk.keys() {
+		if hk.Error != 0 {
+			t.Errorf("Expected exact counts below capacity, got %+v", hk)
+		}
+	}
+
+	tk.record("d")
+	for _, hk := range tk.keys() {
+		if hk.Key == "c" {
+			t.Error("Expected the least counted key to be replaced")
+		}
+		if hk.Key == "d" && (hk.Count != 2 || hk.Error != 1) {
+			t.Errorf("Expected d to inherit the replaced count, got %+v", hk)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package httpserver
+
+import (
+	"net/http"
+	"time"
+)
+
+// ReadyFunc reports whether the server is ready to receive traffic.
+type ReadyFunc func() error
+
+// WithReadyCheck sets the check behind /readyz. The default always reports ready.
+func WithReadyCheck(ready ReadyFunc) Option {
+	return func(s *Server) {
+		s.ready = ready
+	}
+}
+
+// shardSizer is implemented by stores that can report per-shard entry counts.
+type shardSizer interface {
+	ShardSizes() []int
+}
+
+// Stats is the body of the /stats endpoint.
+type Stats struct {
+	Entries    int     `json:"entries"`
+	ShardSizes []int   `json:"shard_sizes,omitempty"`
+	Hits       uint64  `json:"hits"`
+	Misses     uint64  `json:"misses"`
+	HitRatio   float64 `json:"hit_ratio"`
+	Uptime     float64 `json:"uptime_seconds"`
+}
+
+// handleHealth serves /healthz, which reports whether the process is alive.
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReady serves /readyz, which reports whether the server can take traffic.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if s.ready != nil {
+		if err := s.ready(); err != nil {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": err.Error()})
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ready"})
+}
+
+// handleStats serves /stats.
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	keys, err := s.store.Keys()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	stats := Stats{
+		Entries: len(keys),
+		Hits:    s.hits.Load(),
+		Misses:  s.misses.Load(),
+		Uptime:  time.Since(s.started).Seconds(),
+	}
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+	if ss, ok := s.store.(shardSizer); ok {
+		stats.ShardSizes = ss.ShardSizes()
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
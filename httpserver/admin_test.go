@@ -0,0 +1,52 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bay0/kvs"
+)
+
+func TestAdmin(t *testing.T) {
+	store, err := kvs.NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	var notReady error = errors.New("loading")
+	srv := httptest.NewServer(New(store, WithReadyCheck(func() error { return notReady })))
+	defer srv.Close()
+
+	if status, _ := do(t, http.MethodGet, srv.URL+"/healthz", ""); status != http.StatusOK {
+		t.Errorf("/healthz returned status %d", status)
+	}
+	if status, _ := do(t, http.MethodGet, srv.URL+"/readyz", ""); status != http.StatusServiceUnavailable {
+		t.Errorf("/readyz returned status %d before ready", status)
+	}
+	notReady = nil
+	if status, _ := do(t, http.MethodGet, srv.URL+"/readyz", ""); status != http.StatusOK {
+		t.Errorf("/readyz returned status %d after ready", status)
+	}
+
+	do(t, http.MethodPut, srv.URL+"/keys/a", `1`)
+	do(t, http.MethodGet, srv.URL+"/keys/a", "")
+	do(t, http.MethodGet, srv.URL+"/keys/a", "")
+	do(t, http.MethodGet, srv.URL+"/keys/b", "")
+	do(t, http.MethodGet, srv.URL+"/keys/c", "")
+
+	status, body := do(t, http.MethodGet, srv.URL+"/stats", "")
+	if status != http.StatusOK {
+		t.Fatalf("/stats returned status %d", status)
+	}
+
+	var stats Stats
+	if err := json.Unmarshal([]byte(body), &stats); err != nil {
+		t.Fatalf("invalid stats body %s: %v", body, err)
+	}
+	if stats.Entries != 1 || len(stats.ShardSizes) != 4 || stats.Hits != 2 || stats.Misses != 2 || stats.HitRatio != 0.5 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
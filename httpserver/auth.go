@@ -0,0 +1,68 @@
+package httpserver
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/bay0/kvs/kvsauth"
+)
+
+// WithTokens requires every request except /healthz and /readyz to carry one of
+// tokens as "Authorization: Bearer <secret>". Reading keys and exporting buckets
+// needs kvsauth.ScopeRead, writing keys and importing buckets kvsauth.ScopeWrite
+// and the other endpoints kvsauth.ScopeAdmin. Tokens restricted to buckets can
+// only perform key operations in a bucket named with the X-Kvs-Bucket header and
+// export or import their buckets.
+//
+// Requests without a known token are answered with 401 and requests the token
+// does not grant with 403. Tokens are sent in the clear, so serve over TLS.
+func WithTokens(tokens kvsauth.Tokens) Option {
+	return func(s *Server) {
+		s.tokens = tokens
+	}
+}
+
+// requiredAccess returns the scope and the bucket, empty outside buckets, a
+// request needs a token for, and false if it needs none.
+func requiredAccess(r *http.Request) (kvsauth.Scope, string, bool) {
+	read := r.Method == http.MethodGet || r.Method == http.MethodHead
+
+	switch {
+	case r.URL.Path == "/healthz" || r.URL.Path == "/readyz":
+		return "", "", false
+	case r.URL.Path == "/keys" || strings.HasPrefix(r.URL.Path, "/keys/"):
+		if read {
+			return kvsauth.ScopeRead, r.Header.Get(BucketHeader), true
+		}
+		return kvsauth.ScopeWrite, r.Header.Get(BucketHeader), true
+	case strings.HasPrefix(r.URL.Path, "/buckets/"):
+		path, op, _ := bucketRoute(r)
+		if op == "export" {
+			return kvsauth.ScopeRead, path, true
+		}
+		return kvsauth.ScopeWrite, path, true
+	default:
+		return kvsauth.ScopeAdmin, "", true
+	}
+}
+
+// authorize checks the token of r and writes an error response if it does not
+// grant the request.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) bool {
+	scope, bucket, ok := requiredAccess(r)
+	if !ok {
+		return true
+	}
+
+	switch err := s.tokens.Authorize(kvsauth.BearerToken(r.Header.Get("Authorization")), scope, bucket); err {
+	case nil:
+		return true
+	case kvsauth.ErrUnauthenticated:
+		w.Header().Set("WWW-Authenticate", `Bearer realm="kvs"`)
+		writeJSON(w, http.StatusUnauthorized, errorBody{Error: err.Error()})
+	default:
+		writeJSON(w, http.StatusForbidden, errorBody{Error: err.Error()})
+	}
+
+	return false
+}
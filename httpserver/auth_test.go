@@ -0,0 +1,70 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bay0/kvs"
+	"github.com/bay0/kvs/kvsauth"
+)
+
+func TestServer_Tokens(t *testing.T) {
+	store, err := kvs.NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	srv := httptest.NewServer(New(store, WithTokens(kvsauth.Tokens{
+		"admin":   {Scopes: []kvsauth.Scope{kvsauth.ScopeAdmin}},
+		"reader":  {Scopes: []kvsauth.Scope{kvsauth.ScopeRead}},
+		"billing": {Scopes: []kvsauth.Scope{kvsauth.ScopeRead, kvsauth.ScopeWrite}, Buckets: []string{"billing"}},
+	})))
+	defer srv.Close()
+
+	request := func(token, bucket, method, path, body string) int {
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if bucket != "" {
+			req.Header.Set(BucketHeader, bucket)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s returned an error: %v", method, path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	tests := []struct {
+		token, bucket, method, path, body string
+		want                              int
+	}{
+		{"", "", http.MethodGet, "/healthz", "", http.StatusOK},
+		{"", "", http.MethodGet, "/keys", "", http.StatusUnauthorized},
+		{"wrong", "", http.MethodGet, "/keys", "", http.StatusUnauthorized},
+		{"admin", "", http.MethodPut, "/keys/a", `1`, http.StatusNoContent},
+		{"reader", "", http.MethodGet, "/keys/a", "", http.StatusOK},
+		{"reader", "", http.MethodPut, "/keys/a", `2`, http.StatusForbidden},
+		{"reader", "", http.MethodGet, "/stats", "", http.StatusForbidden},
+		{"admin", "", http.MethodGet, "/stats", "", http.StatusOK},
+		{"billing", "", http.MethodGet, "/keys/a", "", http.StatusForbidden},
+		{"billing", "billing", http.MethodPut, "/keys/invoice", `"paid"`, http.StatusNoContent},
+		{"billing", "billing/archive", http.MethodPut, "/keys/invoice", `"old"`, http.StatusNoContent},
+		{"billing", "search", http.MethodGet, "/keys", "", http.StatusForbidden},
+		{"billing", "", http.MethodGet, "/buckets/billing/export", "", http.StatusOK},
+		{"billing", "", http.MethodGet, "/buckets/search/export", "", http.StatusForbidden},
+		{"reader", "", http.MethodPost, "/buckets/billing/import", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if got := request(tt.token, tt.bucket, tt.method, tt.path, tt.body); got != tt.want {
+			t.Errorf("%s %s with token %q and bucket %q returned status %d, want %d", tt.method, tt.path, tt.token, tt.bucket, got, tt.want)
+		}
+	}
+
+	if val, err := store.Bucket("billing").Get("invoice"); err != nil || string(val.(JSONValue)) != `"paid"` {
+		t.Errorf("Expected the key to be set in the bucket, got %v, %v", val, err)
+	}
+}
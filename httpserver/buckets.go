@@ -0,0 +1,126 @@
+package httpserver
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bay0/kvs"
+)
+
+// BucketHeader is the request header naming the bucket, by its path, the key
+// operations of a request are performed in.
+const BucketHeader = "X-Kvs-Bucket"
+
+// bucketStore is implemented by stores with buckets.
+type bucketStore interface {
+	BucketAt(path string) *kvs.Bucket
+}
+
+// handleBucket serves GET /buckets/{path}/export and POST /buckets/{path}/import,
+// where path is the names of a bucket and its parents joined by slashes.
+//
+// Exports stream the entries of the bucket and its nested buckets in the snapshot
+// format, gzip-compressed if the client accepts it. Imports read such a stream,
+// gzip-compressed if the request says so with Content-Encoding, and respond with
+// the kvs.ImportReport; ?dry_run=true reports without changing the store. The
+// size of imports is not limited by WithMaxBodySize.
+func (s *Server) handleBucket(w http.ResponseWriter, r *http.Request) {
+	bs, ok := s.store.(bucketStore)
+	if !ok {
+		writeJSON(w, http.StatusNotImplemented, errorBody{Error: "store does not support buckets"})
+		return
+	}
+
+	path, op, ok := bucketRoute(r)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, errorBody{Error: "not found"})
+		return
+	}
+	b := bs.BucketAt(path)
+
+	switch op {
+	case "export":
+		if r.Method != http.MethodGet {
+			methodNotAllowed(w, http.MethodGet)
+			return
+		}
+		s.exportBucket(w, r, b)
+	case "import":
+		if r.Method != http.MethodPost {
+			methodNotAllowed(w, http.MethodPost)
+			return
+		}
+		s.importBucket(w, r, b)
+	default:
+		writeJSON(w, http.StatusNotFound, errorBody{Error: "not found"})
+	}
+}
+
+// bucketRoute splits the path of a /buckets/{path}/{op} request.
+func bucketRoute(r *http.Request) (path, op string, ok bool) {
+	rest, err := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), "/buckets/"))
+	i := strings.LastIndex(rest, "/")
+	if err != nil || i <= 0 {
+		return "", "", false
+	}
+
+	return rest[:i], rest[i+1:], true
+}
+
+// exportBucket streams the export of b.
+func (s *Server) exportBucket(w http.ResponseWriter, r *http.Request, b *kvs.Bucket) {
+	// Catch invalid names before the response starts.
+	if _, err := b.Buckets(); err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	var out io.Writer = w
+	if strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		defer zw.Close()
+		out = zw
+	}
+
+	if err := b.Export(out); err != nil {
+		// The status was sent already; abort the response so the client
+		// does not mistake a truncated export for a complete one.
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// importBucket loads the export in the request body into b.
+func (s *Server) importBucket(w http.ResponseWriter, r *http.Request, b *kvs.Bucket) {
+	var body io.Reader = r.Body
+	switch r.Header.Get("Content-Encoding") {
+	case "":
+	case "gzip":
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorBody{Error: err.Error()})
+			return
+		}
+		defer zr.Close()
+		body = zr
+	default:
+		writeJSON(w, http.StatusUnsupportedMediaType, errorBody{Error: "unsupported content encoding"})
+		return
+	}
+
+	var opts []kvs.ImportOption
+	if r.URL.Query().Get("dry_run") == "true" {
+		opts = append(opts, kvs.DryRun())
+	}
+	report, err := b.Import(body, opts...)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
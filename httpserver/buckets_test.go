@@ -0,0 +1,78 @@
+package httpserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bay0/kvs"
+)
+
+func TestServer_Buckets(t *testing.T) {
+	src, err := kvs.NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	_ = src.BucketAt("tenants/acme").Set("plan", JSONValue(`"gold"`))
+	dst, _ := kvs.NewKeyValueStore(4)
+
+	srcSrv := httptest.NewServer(New(src))
+	defer srcSrv.Close()
+	dstSrv := httptest.NewServer(New(dst))
+	defer dstSrv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srcSrv.URL+"/buckets/tenants/acme/export", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	export, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("export returned status %d with encoding %q", resp.StatusCode, resp.Header.Get("Content-Encoding"))
+	}
+
+	// The compressed export is passed on as it is.
+	req, _ = http.NewRequest(http.MethodPost, dstSrv.URL+"/buckets/tenants/acme/import", bytes.NewReader(export))
+	req.Header.Set("Content-Encoding", "gzip")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("import failed: %v", err)
+	}
+	var report kvs.ImportReport
+	_ = json.NewDecoder(resp.Body).Decode(&report)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || report.Created != 1 {
+		t.Fatalf("import returned status %d with report %+v", resp.StatusCode, report)
+	}
+	if val, err := dst.BucketAt("tenants/acme").Get("plan"); err != nil || string(val.(JSONValue)) != `"gold"` {
+		t.Errorf("Expected the bucket to be moved, got %v, %v", val, err)
+	}
+
+	var zbuf bytes.Buffer
+	zw := gzip.NewWriter(&zbuf)
+	zw.Write([]byte("not an export"))
+	zw.Close()
+	req, _ = http.NewRequest(http.MethodPost, dstSrv.URL+"/buckets/tenants/acme/import", &zbuf)
+	req.Header.Set("Content-Encoding", "gzip")
+	resp, _ = http.DefaultClient.Do(req)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for a corrupt import, got %d", resp.StatusCode)
+	}
+
+	if status, _ := do(t, http.MethodGet, srcSrv.URL+"/buckets/tenants%2F%2Facme/export", ""); status != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid bucket, got %d", status)
+	}
+	if status, _ := do(t, http.MethodPost, srcSrv.URL+"/buckets/tenants/export", ""); status != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, got %d", status)
+	}
+	if status, _ := do(t, http.MethodGet, srcSrv.URL+"/buckets/tenants/unknown", ""); status != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", status)
+	}
+}
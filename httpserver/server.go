@@ -0,0 +1,305 @@
+// Package httpserver exposes a kvs.Store over a JSON HTTP API.
+//
+// The API consists of the following endpoints:
+//
+//	GET    /keys          list all keys, optionally filtered with ?prefix=
+//	GET    /keys/{key}    retrieve the value of a key
+//	PUT    /keys/{key}    add or update a key with the JSON request body
+//	DELETE /keys/{key}    remove a key
+//
+// For operations it also serves /healthz (liveness), /readyz (readiness) and
+// /stats (entry counts, per-shard sizes, hit ratio of key lookups and uptime).
+// Stores with buckets can move a bucket between servers:
+//
+//	GET    /buckets/{path}/export    stream the entries of a bucket
+//	POST   /buckets/{path}/import    load an export into a bucket
+//
+// Stores with tenants perform the key operations of requests with an
+// X-Kvs-Tenant header for that tenant, and report its usage:
+//
+//	GET    /tenants/{id}/stats       entries, bytes and operations of a tenant
+//
+// Key operations of requests with an X-Kvs-Bucket header are performed in that
+// bucket. With WithTokens, requests must carry an API token granting them.
+package httpserver
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/bay0/kvs"
+	"github.com/bay0/kvs/kvsauth"
+)
+
+// DefaultMaxBodySize is the default limit for request bodies in bytes.
+const DefaultMaxBodySize = 1 << 20
+
+// JSONValue is a kvs.Value holding raw JSON. It is the value type created for
+// request bodies unless a different decoder is configured.
+type JSONValue json.RawMessage
+
+// Clone creates a copy of the value.
+func (v JSONValue) Clone() kvs.Value {
+	c := make(JSONValue, len(v))
+	copy(c, v)
+
+	return c
+}
+
+// MarshalJSON returns the raw JSON of the value.
+func (v JSONValue) MarshalJSON() ([]byte, error) {
+	return json.RawMessage(v).MarshalJSON()
+}
+
+// UnmarshalJSON stores a copy of data as the value.
+func (v *JSONValue) UnmarshalJSON(data []byte) error {
+	*v = append((*v)[:0], data...)
+	return nil
+}
+
+func init() {
+	kvs.RegisterType[JSONValue]("httpserver/json")
+}
+
+// DecodeFunc converts a JSON request body into a value.
+type DecodeFunc func(data []byte) (kvs.Value, error)
+
+// Option configures optional behaviour of a Server.
+type Option func(*Server)
+
+// WithDecoder sets the function used to turn request bodies into values.
+func WithDecoder(decode DecodeFunc) Option {
+	return func(s *Server) {
+		s.decode = decode
+	}
+}
+
+// WithMaxBodySize limits the size of request bodies.
+func WithMaxBodySize(n int64) Option {
+	return func(s *Server) {
+		s.maxBodySize = n
+	}
+}
+
+// Server is an http.Handler serving a kvs.Store.
+type Server struct {
+	store       kvs.Store
+	decode      DecodeFunc
+	maxBodySize int64
+	ready       ReadyFunc
+	tokens      kvsauth.Tokens
+	mux         *http.ServeMux
+
+	started time.Time
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+}
+
+// New creates a new Server backed by store.
+func New(store kvs.Store, opts ...Option) *Server {
+	s := &Server{
+		store:       store,
+		decode:      decodeJSON,
+		maxBodySize: DefaultMaxBodySize,
+		mux:         http.NewServeMux(),
+		started:     time.Now(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	s.mux.HandleFunc("/keys", s.handleList)
+	s.mux.HandleFunc("/keys/", s.handleKey)
+	s.mux.HandleFunc("/healthz", s.handleHealth)
+	s.mux.HandleFunc("/readyz", s.handleReady)
+	s.mux.HandleFunc("/stats", s.handleStats)
+	s.mux.HandleFunc("/buckets/", s.handleBucket)
+	s.mux.HandleFunc("/tenants/", s.handleTenant)
+
+	return s
+}
+
+// decodeJSON validates the body and stores it as a JSONValue.
+func decodeJSON(data []byte) (kvs.Value, error) {
+	if !json.Valid(data) {
+		return nil, errors.New("request body is not valid JSON")
+	}
+
+	return JSONValue(data), nil
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.tokens != nil && !s.authorize(w, r) {
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// systemKeyser is implemented by stores with a reserved system keyspace.
+type systemKeyser interface {
+	SystemKeys() []string
+}
+
+// handleList serves GET /keys. System keys are listed when a prefix is given.
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	store, ok := s.storeFor(w, r)
+	if !ok {
+		return
+	}
+	keys, err := store.Keys()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	prefix := r.URL.Query().Get("prefix")
+	if sk, ok := store.(systemKeyser); ok && prefix != "" {
+		keys = append(keys, sk.SystemKeys()...)
+	}
+
+	filtered := make([]string, 0, len(keys))
+	for _, k := range keys {
+		if strings.HasPrefix(k, prefix) {
+			filtered = append(filtered, k)
+		}
+	}
+	sort.Strings(filtered)
+
+	writeJSON(w, http.StatusOK, map[string][]string{"keys": filtered})
+}
+
+// handleKey serves GET, PUT and DELETE on /keys/{key}.
+func (s *Server) handleKey(w http.ResponseWriter, r *http.Request) {
+	key, err := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), "/keys/"))
+	if err != nil || key == "" {
+		writeJSON(w, http.StatusBadRequest, errorBody{Error: "invalid key"})
+		return
+	}
+	store, ok := s.storeFor(w, r)
+	if !ok {
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		val, err := store.Get(key)
+		if err != nil {
+			if errors.Is(err, kvs.ErrNotFound) {
+				s.misses.Add(1)
+			}
+			writeError(w, err)
+			return
+		}
+		s.hits.Add(1)
+		writeJSON(w, http.StatusOK, val)
+
+	case http.MethodPut:
+		data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, s.maxBodySize))
+		if err != nil {
+			writeJSON(w, http.StatusRequestEntityTooLarge, errorBody{Error: err.Error()})
+			return
+		}
+		val, err := s.decode(data)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, errorBody{Error: err.Error()})
+			return
+		}
+		if err := store.Set(key, val); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodDelete:
+		if err := store.Delete(key); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		methodNotAllowed(w, http.MethodGet, http.MethodPut, http.MethodDelete)
+	}
+}
+
+// storeFor returns the store serving the key operations of r: the tenant named
+// in its TenantHeader or the bucket named in its BucketHeader, or the server's
+// store without either. It writes an error response and returns false if the
+// store does not support the named one.
+func (s *Server) storeFor(w http.ResponseWriter, r *http.Request) (kvs.Store, bool) {
+	tenant, bucket := r.Header.Get(TenantHeader), r.Header.Get(BucketHeader)
+	switch {
+	case tenant != "" && bucket != "":
+		writeJSON(w, http.StatusBadRequest, errorBody{Error: "tenant and bucket are exclusive"})
+	case tenant != "":
+		if ts, ok := s.store.(tenantStore); ok {
+			return ts.Tenant(tenant), true
+		}
+		writeJSON(w, http.StatusNotImplemented, errorBody{Error: "store does not support tenants"})
+	case bucket != "":
+		if bs, ok := s.store.(bucketStore); ok {
+			return bs.BucketAt(bucket), true
+		}
+		writeJSON(w, http.StatusNotImplemented, errorBody{Error: "store does not support buckets"})
+	default:
+		return s.store, true
+	}
+
+	return nil, false
+}
+
+// errorBody is the JSON body of error responses.
+type errorBody struct {
+	Error string `json:"error"`
+}
+
+// writeError writes a store error with a matching status code.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, kvs.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, kvs.ErrReservedKey):
+		status = http.StatusForbidden
+	case errors.Is(err, kvs.ErrInvalidBucket), errors.Is(err, kvs.ErrInvalidTenant), errors.Is(err, kvs.ErrCorruptSnapshot):
+		status = http.StatusBadRequest
+	case errors.Is(err, kvs.ErrQuotaExceeded):
+		status = http.StatusInsufficientStorage
+	case errors.Is(err, kvs.ErrRateLimited):
+		status = http.StatusTooManyRequests
+	}
+
+	writeJSON(w, status, errorBody{Error: err.Error()})
+}
+
+// methodNotAllowed writes a 405 response listing the allowed methods.
+func methodNotAllowed(w http.ResponseWriter, allowed ...string) {
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeJSON(w, http.StatusMethodNotAllowed, errorBody{Error: "method not allowed"})
+}
+
+// writeJSON writes v as a JSON response.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		status = http.StatusInternalServerError
+		data, _ = json.Marshal(errorBody{Error: err.Error()})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
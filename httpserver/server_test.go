@@ -0,0 +1,100 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bay0/kvs"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	store, err := kvs.NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	srv := httptest.NewServer(New(store))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func do(t *testing.T, method, url, body string) (int, string) {
+	t.Helper()
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("NewRequest returned an error: %v", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s returned an error: %v", method, url, err)
+	}
+	defer resp.Body.Close()
+
+	data, _ := io.ReadAll(resp.Body)
+
+	return resp.StatusCode, strings.TrimSpace(string(data))
+}
+
+func TestServer(t *testing.T) {
+	srv := newTestServer(t)
+
+	if status, _ := do(t, http.MethodPut, srv.URL+"/keys/person", `{"name":"Alice","age":30}`); status != http.StatusNoContent {
+		t.Errorf("PUT returned status %d", status)
+	}
+	if status, _ := do(t, http.MethodPut, srv.URL+"/keys/a%2Fb", `"slash"`); status != http.StatusNoContent {
+		t.Errorf("PUT returned status %d", status)
+	}
+
+	status, body := do(t, http.MethodGet, srv.URL+"/keys/person", "")
+	if status != http.StatusOK || body != `{"name":"Alice","age":30}` {
+		t.Errorf("GET returned %d %s", status, body)
+	}
+
+	status, body = do(t, http.MethodGet, srv.URL+"/keys", "")
+	var list struct {
+		Keys []string `json:"keys"`
+	}
+	if err := json.Unmarshal([]byte(body), &list); err != nil || status != http.StatusOK {
+		t.Fatalf("GET /keys returned %d %s", status, body)
+	}
+	if len(list.Keys) != 2 || list.Keys[0] != "a/b" || list.Keys[1] != "person" {
+		t.Errorf("unexpected keys %v", list.Keys)
+	}
+
+	_, body = do(t, http.MethodGet, srv.URL+"/keys?prefix=pe", "")
+	if body != `{"keys":["person"]}` {
+		t.Errorf("unexpected filtered keys %s", body)
+	}
+
+	if status, _ := do(t, http.MethodDelete, srv.URL+"/keys/person", ""); status != http.StatusNoContent {
+		t.Errorf("DELETE returned status %d", status)
+	}
+	if status, _ := do(t, http.MethodGet, srv.URL+"/keys/person", ""); status != http.StatusNotFound {
+		t.Errorf("GET of deleted key returned status %d", status)
+	}
+	if status, _ := do(t, http.MethodDelete, srv.URL+"/keys/person", ""); status != http.StatusNotFound {
+		t.Errorf("DELETE of deleted key returned status %d", status)
+	}
+}
+
+func TestServer_BadRequests(t *testing.T) {
+	srv := newTestServer(t)
+
+	if status, _ := do(t, http.MethodPut, srv.URL+"/keys/person", `{not json`); status != http.StatusBadRequest {
+		t.Errorf("PUT of invalid JSON returned status %d", status)
+	}
+	if status, _ := do(t, http.MethodPost, srv.URL+"/keys/person", `{}`); status != http.StatusMethodNotAllowed {
+		t.Errorf("POST returned status %d", status)
+	}
+	if status, _ := do(t, http.MethodGet, srv.URL+"/keys/", ""); status != http.StatusBadRequest {
+		t.Errorf("GET without key returned status %d", status)
+	}
+}
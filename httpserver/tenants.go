@@ -0,0 +1,69 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/bay0/kvs"
+)
+
+// TenantHeader is the request header naming the tenant the key operations of a
+// request are performed for.
+const TenantHeader = "X-Kvs-Tenant"
+
+// tenantStore is implemented by stores with tenants.
+type tenantStore interface {
+	Tenant(id string) *kvs.Tenant
+}
+
+// TenantStats is the body of the /tenants/{id}/stats endpoint.
+type TenantStats struct {
+	Entries          int     `json:"entries"`
+	Bytes            int64   `json:"bytes"`
+	Hits             uint64  `json:"hits"`
+	Misses           uint64  `json:"misses"`
+	Sets             uint64  `json:"sets"`
+	Deletes          uint64  `json:"deletes"`
+	GetsPerSecond    float64 `json:"gets_per_sec"`
+	SetsPerSecond    float64 `json:"sets_per_sec"`
+	DeletesPerSecond float64 `json:"deletes_per_sec"`
+}
+
+// handleTenant serves GET /tenants/{id}/stats, the usage of a tenant.
+func (s *Server) handleTenant(w http.ResponseWriter, r *http.Request) {
+	ts, ok := s.store.(tenantStore)
+	if !ok {
+		writeJSON(w, http.StatusNotImplemented, errorBody{Error: "store does not support tenants"})
+		return
+	}
+
+	rest, err := url.PathUnescape(strings.TrimPrefix(r.URL.EscapedPath(), "/tenants/"))
+	id, found := strings.CutSuffix(rest, "/stats")
+	if err != nil || !found {
+		writeJSON(w, http.StatusNotFound, errorBody{Error: "not found"})
+		return
+	}
+	if r.Method != http.MethodGet {
+		methodNotAllowed(w, http.MethodGet)
+		return
+	}
+
+	stats, err := ts.Tenant(id).Stats()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, TenantStats{
+		Entries:          stats.Entries,
+		Bytes:            stats.Bytes,
+		Hits:             stats.Hits,
+		Misses:           stats.Misses,
+		Sets:             stats.Sets,
+		Deletes:          stats.Deletes,
+		GetsPerSecond:    stats.GetsPerSecond,
+		SetsPerSecond:    stats.SetsPerSecond,
+		DeletesPerSecond: stats.DeletesPerSecond,
+	})
+}
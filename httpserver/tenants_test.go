@@ -0,0 +1,61 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bay0/kvs"
+)
+
+func TestServer_Tenants(t *testing.T) {
+	store, err := kvs.NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	srv := httptest.NewServer(New(store))
+	defer srv.Close()
+
+	doTenant := func(method, path, body string) int {
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		req.Header.Set(TenantHeader, "billing")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s returned an error: %v", method, path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := doTenant(http.MethodPut, "/keys/invoice", `"paid"`); status != http.StatusNoContent {
+		t.Errorf("PUT returned status %d", status)
+	}
+	if status := doTenant(http.MethodGet, "/keys/invoice", ""); status != http.StatusOK {
+		t.Errorf("GET returned status %d", status)
+	}
+	if status, _ := do(t, http.MethodGet, srv.URL+"/keys/invoice", ""); status != http.StatusNotFound {
+		t.Errorf("Expected the key to be invisible without the tenant, got status %d", status)
+	}
+
+	status, body := do(t, http.MethodGet, srv.URL+"/tenants/billing/stats", "")
+	var stats TenantStats
+	if err := json.Unmarshal([]byte(body), &stats); err != nil || status != http.StatusOK {
+		t.Fatalf("stats returned %d %s", status, body)
+	}
+	if stats.Entries != 1 || stats.Sets != 1 || stats.Hits != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+
+	_ = store.Tenant("billing").SetQuota(kvs.TenantQuota{MaxEntries: 1, MaxOpsPerSecond: 3})
+	if status := doTenant(http.MethodPut, "/keys/receipt", `"sent"`); status != http.StatusInsufficientStorage {
+		t.Errorf("Expected status 507, got %d", status)
+	}
+	if status := doTenant(http.MethodGet, "/keys/invoice", ""); status != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429, got %d", status)
+	}
+	if status, _ := do(t, http.MethodGet, srv.URL+"/tenants/a%2Fb/stats", ""); status != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for an invalid tenant, got %d", status)
+	}
+}
@@ -0,0 +1,86 @@
+package kvs
+
+import "time"
+
+// SetImmutable adds a write-once key-value pair to the store.
+// Once set, the entry cannot be overwritten: Set and SetImmutable fail with
+// ErrImmutable, and Delete fails with ErrImmutable unless ForceDelete is used.
+// An existing mutable entry for the key is replaced. If the key is an alias,
+// the key it points at is set.
+func (kvs *KeyValueStore) SetImmutable(key string, val Value) (err error) {
+	if len(kvs.opts.observers) > 0 {
+		defer kvs.observe(OpSet, key, time.Now(), &err)
+	}
+
+	if isSystemKey(key) {
+		return ErrReservedKey
+	}
+	if val == nil {
+		return ErrNilValue
+	}
+
+	return kvs.putImmutable(key, val)
+}
+
+// putImmutable stores val under key as a write-once entry.
+func (kvs *KeyValueStore) putImmutable(key string, val Value) error {
+	val, err := kvs.compress(val)
+	if err != nil {
+		return err
+	}
+
+	sh, key, err := kvs.lockKey(key)
+	if err != nil {
+		return err
+	}
+	defer sh.mu.Unlock()
+
+	if err := kvs.apply(sh, key, val); err != nil {
+		return err
+	}
+	sh.setImmutable(key, true)
+
+	return nil
+}
+
+// IsImmutable reports whether key holds a write-once entry.
+func (kvs *KeyValueStore) IsImmutable(key string) bool {
+	sh := kvs.shards[kvs.shardIndex(key)]
+
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	return sh.isImmutable(key)
+}
+
+// ForceDelete removes the key-value pair associated with the given key, even if it is write-once.
+// If the key is not found in the store, it returns an ErrNotFound error.
+// If the key is an alias, only the alias is removed.
+func (kvs *KeyValueStore) ForceDelete(key string) (err error) {
+	if len(kvs.opts.observers) > 0 {
+		defer kvs.observe(OpDelete, key, time.Now(), &err)
+	}
+
+	if isSystemKey(key) {
+		return ErrReservedKey
+	}
+
+	sh := kvs.shards[kvs.shardIndex(key)]
+
+	kvs.lockShard(sh)
+	defer sh.mu.Unlock()
+	sh.recordAccess(key)
+
+	if _, ok := sh.aliases[key]; ok {
+		sh.removeAlias(key)
+		kvs.events.publish(EventDelete, key, 0)
+		return nil
+	}
+
+	if err := kvs.remove(sh, key); err != nil {
+		return err
+	}
+	sh.setImmutable(key, false)
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package kvs
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSetImmutable(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	if err := store.SetImmutable("audit-1", IntValue(1)); err != nil {
+		t.Fatalf("SetImmutable returned an error: %v", err)
+	}
+	if !store.IsImmutable("audit-1") {
+		t.Error("Expected audit-1 to be immutable")
+	}
+
+	if err := store.Set("audit-1", IntValue(2)); err != ErrImmutable {
+		t.Errorf("Expected ErrImmutable from Set, got %v", err)
+	}
+	if err := store.SetImmutable("audit-1", IntValue(2)); err != ErrImmutable {
+		t.Errorf("Expected ErrImmutable from SetImmutable, got %v", err)
+	}
+	if err := store.Delete("audit-1"); err != ErrImmutable {
+		t.Errorf("Expected ErrImmutable from Delete, got %v", err)
+	}
+	if val, _ := store.Get("audit-1"); val != IntValue(1) {
+		t.Errorf("Expected the original value, got %v", val)
+	}
+
+	if err := store.ForceDelete("audit-1"); err != nil {
+		t.Fatalf("ForceDelete returned an error: %v", err)
+	}
+	if store.IsImmutable("audit-1") {
+		t.Error("Expected the flag to be cleared by ForceDelete")
+	}
+	if err := store.Set("audit-1", IntValue(3)); err != nil {
+		t.Errorf("Set after ForceDelete returned an error: %v", err)
+	}
+	if err := store.ForceDelete("missing"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestSetImmutable_Snapshot(t *testing.T) {
+	store, _ := NewKeyValueStore(2)
+	if err := store.SetImmutable("audit-1", IntValue(1)); err != nil {
+		t.Fatalf("SetImmutable returned an error: %v", err)
+	}
+	if err := store.Set("plain", IntValue(2)); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := store.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("WriteSnapshot returned an error: %v", err)
+	}
+
+	restored, _ := NewKeyValueStore(2)
+	if err := restored.ReadSnapshot(bytes.NewReader(buf.Bytes())); err != nil {
+		t.Fatalf("ReadSnapshot returned an error: %v", err)
+	}
+	if !restored.IsImmutable("audit-1") || restored.IsImmutable("plain") {
+		t.Error("immutability was not preserved by the snapshot")
+	}
+
+	if _, err := restored.ImportSnapshot(bytes.NewReader(buf.Bytes()), DryRun()); !errors.Is(err, ErrImmutable) {
+		t.Errorf("Expected ErrImmutable when importing over a write-once key, got %v", err)
+	}
+}
+
+func TestSetImmutable_RecycleBin(t *testing.T) {
+	store, _ := NewKeyValueStore(2, WithRecycleBin(10, time.Minute))
+	if err := store.SetImmutable("audit-1", IntValue(1)); err != nil {
+		t.Fatalf("SetImmutable returned an error: %v", err)
+	}
+	if err := store.ForceDelete("audit-1"); err != nil {
+		t.Fatalf("ForceDelete returned an error: %v", err)
+	}
+	if err := store.Restore("audit-1"); err != nil {
+		t.Fatalf("Restore returned an error: %v", err)
+	}
+	if !store.IsImmutable("audit-1") {
+		t.Error("Expected the restored entry to be immutable")
+	}
+}
@@ -0,0 +1,103 @@
+package kvs
+
+// Isolation is the isolation level of a transaction.
+type Isolation int
+
+const (
+	// ReadCommitted transactions read the latest committed state and take no
+	// locks before Commit. A key read by the transaction may be changed by
+	// others before it commits. It is the default.
+	ReadCommitted Isolation = iota
+	// Serializable transactions remember the version of every key they read,
+	// and the aliases they followed, and validate at commit, under the locks of
+	// the written and read shards, that none of them has changed; otherwise Commit fails with ErrTxnConflict
+	// and nothing is written. Reads still take no locks, so conflicting
+	// transactions are detected rather than prevented and must be retried.
+	Serializable
+)
+
+// txnOptions holds the settings of a transaction.
+type txnOptions struct {
+	isolation Isolation
+}
+
+// TxnOption configures a transaction.
+type TxnOption func(*txnOptions)
+
+// WithIsolation sets the isolation level of the transaction.
+func WithIsolation(level Isolation) TxnOption {
+	return func(o *txnOptions) {
+		o.isolation = level
+	}
+}
+
+// txnRead is a key read by a serializable transaction: the aliases followed to
+// resolve it, the key it resolved to and its version at the first read, which
+// is zero if it did not exist.
+type txnRead struct {
+	aliases []string
+	target  string
+	version uint64
+}
+
+// readTracked reads key from the store and remembers its version. A key read
+// again keeps the version of its first read, so a change in between is detected.
+func (tx *Txn) readTracked(key string) (Value, error) {
+	_, tracked := tx.reads[key]
+	var aliases []string
+	if !tracked {
+		var err error
+		if aliases, err = tx.kvs.aliasPath(key); err != nil {
+			return nil, err
+		}
+	}
+
+	val, target, version, err := tx.kvs.getVersioned(key)
+	if err != nil && err != ErrNotFound {
+		return nil, err
+	}
+	if !tracked {
+		tx.reads[key] = txnRead{aliases: aliases, target: target, version: version}
+	}
+
+	return val, err
+}
+
+// readTargets returns the keys the reads of the transaction resolved to and the
+// aliases they followed.
+func (tx *Txn) readTargets() []string {
+	targets := make([]string, 0, len(tx.reads))
+	for _, r := range tx.reads {
+		targets = append(targets, r.target)
+		targets = append(targets, r.aliases...)
+	}
+
+	return targets
+}
+
+// validate returns ErrTxnConflict if a key read by the transaction has changed
+// or now resolves to another key, because an alias was created, repointed or
+// removed. The shards of all read targets and aliases must be locked.
+func (tx *Txn) validate() error {
+	for _, r := range tx.reads {
+		for i, alias := range r.aliases {
+			next := r.target
+			if i+1 < len(r.aliases) {
+				next = r.aliases[i+1]
+			}
+			if tx.kvs.shards[tx.kvs.shardIndex(alias)].aliases[alias] != next {
+				return ErrTxnConflict
+			}
+		}
+
+		sh := tx.kvs.shards[tx.kvs.shardIndex(r.target)]
+		if _, ok := sh.aliases[r.target]; ok {
+			return ErrTxnConflict
+		}
+		if uint64(sh.revs[r.target]) != r.version {
+			return ErrTxnConflict
+		}
+	}
+
+	return nil
+}
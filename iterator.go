@@ -0,0 +1,163 @@
+package kvs
+
+import (
+	"sort"
+	"strings"
+)
+
+// Iterator walks a sequence of entries. It must be closed when it is no longer
+// needed so the resources it holds are released.
+//
+//	it := store.Scan("user:")
+//	defer it.Close()
+//	for it.Next() {
+//		fmt.Println(it.Key(), it.Value())
+//	}
+//	if err := it.Err(); err != nil {
+//		// Handle the error
+//	}
+type Iterator interface {
+	// Next advances to the next entry and reports whether there is one.
+	// It returns false when the entries are exhausted, an error occurred or
+	// the iterator was closed.
+	Next() bool
+
+	// Key returns the key of the current entry.
+	Key() string
+
+	// Value returns the value of the current entry.
+	Value() Value
+
+	// Err returns the error that stopped the iteration, if any.
+	Err() error
+
+	// Close releases the resources of the iterator. It is safe to call Close
+	// more than once.
+	Close() error
+}
+
+// scanIterator iterates over the entries of a store one shard at a time.
+type scanIterator struct {
+	kvs    *KeyValueStore
+	prefix string
+	shard  int
+	system bool
+	keys   []string
+	vals   []Value
+	key    string
+	val    Value
+	err    error
+	closed bool
+}
+
+// Scan returns an iterator over the entries whose key starts with prefix; an empty
+// prefix scans the whole store. Keys are returned in order within a shard, shard
+// by shard. Every shard is read-locked only while its matching entries are copied,
+// so the iteration sees each shard at one point in time but writes to other shards
+// may interleave. System keys are included when a prefix is given.
+func (kvs *KeyValueStore) Scan(prefix string) Iterator {
+	return &scanIterator{kvs: kvs, prefix: prefix, system: prefix == ""}
+}
+
+// Next advances to the next entry.
+func (it *scanIterator) Next() bool {
+	for !it.closed && it.err == nil {
+		if len(it.keys) > 0 {
+			it.key, it.val = it.keys[0], it.vals[0]
+			it.keys, it.vals = it.keys[1:], it.vals[1:]
+			return true
+		}
+
+		switch {
+		case it.shard < len(it.kvs.shards):
+			it.err = it.loadShard(it.kvs.shards[it.shard])
+			it.shard++
+		case !it.system:
+			it.err = it.loadSystem()
+			it.system = true
+		default:
+			return false
+		}
+	}
+
+	return false
+}
+
+// loadShard copies the matching entries of sh.
+func (it *scanIterator) loadShard(sh *shard) error {
+	sh.mu.RLock()
+	keys, err := sh.backend.Keys()
+	if err != nil {
+		sh.mu.RUnlock()
+		return err
+	}
+
+	var matched []string
+	for _, key := range keys {
+		if matchesPrefix(key, it.prefix) {
+			matched = append(matched, key)
+		}
+	}
+	sort.Strings(matched)
+
+	vals := make([]Value, 0, len(matched))
+	for _, key := range matched {
+		val, err := sh.backend.Get(key)
+		if err != nil {
+			sh.mu.RUnlock()
+			return err
+		}
+		vals = append(vals, val)
+	}
+	sh.mu.RUnlock()
+
+	for i, val := range vals {
+		if vals[i], err = it.kvs.decompress(val); err != nil {
+			return err
+		}
+	}
+	it.keys, it.vals = matched, vals
+
+	return nil
+}
+
+// loadSystem copies the matching system keys.
+func (it *scanIterator) loadSystem() error {
+	for _, key := range it.kvs.SystemKeys() {
+		if !strings.HasPrefix(key, it.prefix) {
+			continue
+		}
+		val, err := it.kvs.systemValue(key)
+		if err != nil {
+			return err
+		}
+		it.keys = append(it.keys, key)
+		it.vals = append(it.vals, val)
+	}
+
+	return nil
+}
+
+// Key returns the key of the current entry.
+func (it *scanIterator) Key() string {
+	return it.key
+}
+
+// Value returns the value of the current entry.
+func (it *scanIterator) Value() Value {
+	return it.val
+}
+
+// Err returns the error that stopped the iteration.
+func (it *scanIterator) Err() error {
+	return it.err
+}
+
+// Close releases the entries buffered by the iterator.
+func (it *scanIterator) Close() error {
+	it.closed = true
+	it.keys, it.vals = nil, nil
+	it.key, it.val = "", nil
+
+	return nil
+}
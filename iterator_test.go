@@ -0,0 +1,75 @@
+package kvs
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestScan(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		_ = store.Set(fmt.Sprintf("user:%02d", i), IntValue(i))
+		_ = store.Set(fmt.Sprintf("order:%02d", i), IntValue(i))
+	}
+
+	it := store.Scan("user:")
+	defer it.Close()
+
+	seen := make(map[string]bool)
+	for it.Next() {
+		var i int
+		if _, err := fmt.Sscanf(it.Key(), "user:%d", &i); err != nil {
+			t.Fatalf("unexpected key %q", it.Key())
+		}
+		if it.Value().(IntValue) != IntValue(i) {
+			t.Errorf("Expected %d for %s, got %v", i, it.Key(), it.Value())
+		}
+		seen[it.Key()] = true
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Scan returned an error: %v", err)
+	}
+	if len(seen) != 20 {
+		t.Errorf("Expected 20 keys, got %d", len(seen))
+	}
+}
+
+func TestScan_Close(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	for i := 0; i < 10; i++ {
+		_ = store.Set(fmt.Sprintf("key-%d", i), IntValue(i))
+	}
+
+	it := store.Scan("")
+	if !it.Next() {
+		t.Fatal("Expected an entry")
+	}
+	if err := it.Close(); err != nil {
+		t.Fatalf("Close returned an error: %v", err)
+	}
+	if it.Next() {
+		t.Error("Expected Next to return false after Close")
+	}
+	if err := it.Close(); err != nil {
+		t.Errorf("Close returned an error on a closed iterator: %v", err)
+	}
+}
+
+func TestScan_System(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+
+	it := store.Scan(SystemPrefix + "config/")
+	defer it.Close()
+
+	n := 0
+	for it.Next() {
+		n++
+	}
+	if n != 3 {
+		t.Errorf("Expected 3 config keys, got %d", n)
+	}
+}
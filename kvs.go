@@ -1,6 +1,14 @@
 // Package kvs provides an in-memory key-value store implementation that supports sharding, batching, and transactions.
 package kvs
 
+import (
+	"io"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
 // Value is an interface that defines the methods that a value in the key-value store must implement.
 type Value interface {
 	// Clone creates a copy of the value.
@@ -15,6 +23,8 @@ type Store interface {
 
 	// Set adds or updates the given key-value pair in the store.
 	// If the key already exists, it overwrites the previous value.
+	// A nil value is rejected with an ErrNilValue error, so a value returned
+	// by Get is never nil.
 	Set(key string, val Value) error
 
 	// Delete removes the key-value pair associated with the given key from the store.
@@ -22,33 +32,113 @@ type Store interface {
 	Delete(key string) error
 
 	// Keys returns a slice of all the keys in the store.
-	Keys() []string
+	Keys() ([]string, error)
 }
 
-// KeyValueStore is a type that implements the Store interface using an in-memory map.
+var _ Store = (*KeyValueStore)(nil)
+
+// KeyValueStore is a type that implements the Store interface on top of sharded backends.
 type KeyValueStore struct {
 	shards []*shard
 	count  int
+	opts   options
+	bin    *recycleBin
+	events eventBus
+
+	// rev is the revision of the latest change and compacted the revision
+	// the history was last compacted to, which was made at compactedAt in
+	// Unix nanoseconds.
+	rev         atomic.Int64
+	compacted   atomic.Int64
+	compactedAt atomic.Int64
+
+	// scrub accumulates the findings of the integrity scrubber.
+	scrub scrubCounters
+	// txns tracks open transactions when long-running ones are detected.
+	txns txnTracker
+	// quotas holds the quotas of buckets.
+	quotas bucketQuotas
+	// tenants holds the usage and quotas of tenants.
+	tenants tenantAccounts
+
+	// stop is closed by Close to end the store's background goroutines,
+	// which are tracked by bg.
+	stop chan struct{}
+	bg   sync.WaitGroup
 }
 
 // NewKeyValueStore creates a new KeyValueStore instance with a specified number of shards.
-func NewKeyValueStore(numShards int) (*KeyValueStore, error) {
+func NewKeyValueStore(numShards int, opts ...Option) (*KeyValueStore, error) {
 	if numShards <= 0 {
 		return nil, ErrInvalidNumShards
 	}
 
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.codec == nil {
+		o.codec = GobCodec{}
+	}
+
+	if o.backend == nil {
+		o.backend = func(int) (Backend, error) {
+			return NewMemoryBackend(), nil
+		}
+	}
+
 	shards := make([]*shard, numShards)
 	for i := 0; i < numShards; i++ {
+		backend, err := o.backend(i)
+		if err != nil {
+			return nil, err
+		}
 		shards[i] = &shard{
-			id:    i,
-			store: make(map[string]Value),
+			id:      i,
+			mu:      newLock(o.lockStrategy),
+			backend: backend,
+		}
+		if o.hotKeyCapacity > 0 {
+			shards[i].hot = newTopK(o.hotKeyCapacity)
 		}
 	}
 
-	return &KeyValueStore{
+	kvs := &KeyValueStore{
 		shards: shards,
 		count:  numShards,
-	}, nil
+		opts:   o,
+		stop:   make(chan struct{}),
+	}
+	if o.recycleCapacity > 0 {
+		kvs.bin = newRecycleBin(o.recycleCapacity, o.recycleRetention)
+		if o.logger != nil {
+			kvs.bin.onEvict = func(key, reason string) {
+				kvs.log(o.logConfig.EvictionLevel, "kvs: recycle bin evicted entry",
+					slog.String("key", key),
+					slog.String("reason", reason),
+				)
+			}
+		}
+	}
+	if o.replicaInterval > 0 {
+		if err := kvs.startReplicas(); err != nil {
+			return nil, err
+		}
+	}
+	if o.historyDepth > 0 && o.compaction.Interval > 0 {
+		kvs.startCompaction()
+	}
+	if o.scrubInterval > 0 {
+		kvs.startScrubber()
+	}
+	if o.txnLimits.MaxAge > 0 {
+		kvs.startTxnWatchdog()
+	}
+	if o.statsInterval > 0 {
+		kvs.startStatsPublisher()
+	}
+
+	return kvs, nil
 }
 
 // shardIndex returns the index of the shard that should contain a given key.
@@ -61,57 +151,209 @@ func (kvs *KeyValueStore) shardIndex(key string) int {
 	return int(h) % kvs.count
 }
 
+// ShardOf returns the id of the shard that holds key.
+func (kvs *KeyValueStore) ShardOf(key string) int {
+	return kvs.shardIndex(key)
+}
+
 // Set adds or updates the given key-value pair in the store.
 // If the key already exists, it overwrites the previous value.
-func (kvs *KeyValueStore) Set(key string, val Value) error {
-	index := kvs.shardIndex(key)
-	sh := kvs.shards[index]
+// If the key is an alias, the key it points at is updated.
+// It returns ErrNilValue if val is nil.
+func (kvs *KeyValueStore) Set(key string, val Value) (err error) {
+	if len(kvs.opts.observers) > 0 {
+		defer kvs.observe(OpSet, key, time.Now(), &err)
+	}
 
-	sh.mu.Lock()
+	if isSystemKey(key) {
+		return ErrReservedKey
+	}
+	if val == nil {
+		return ErrNilValue
+	}
+
+	return kvs.put(key, val)
+}
+
+// put compresses and stores val under key without checking for reserved keys.
+func (kvs *KeyValueStore) put(key string, val Value) error {
+	val, err := kvs.compress(val)
+	if err != nil {
+		return err
+	}
+
+	return kvs.putCompressed(key, val)
+}
+
+// putCompressed stores an already compressed value under key, batching the
+// write if write batching is enabled.
+func (kvs *KeyValueStore) putCompressed(key string, val Value) error {
+	if kvs.opts.batchWindow > 0 {
+		return kvs.batchSet(key, val)
+	}
+
+	return kvs.set(key, val)
+}
+
+// set stores an already compressed value under key after resolving aliases.
+func (kvs *KeyValueStore) set(key string, val Value) error {
+	sh, key, err := kvs.lockKey(key)
+	if err != nil {
+		return err
+	}
 	defer sh.mu.Unlock()
 
-	sh.store[key] = val
-	return nil
+	return kvs.apply(sh, key, val)
 }
 
 // Get retrieves the value associated with the given key from the store.
 // If the key is not found in the store, it returns an error.
-func (kvs *KeyValueStore) Get(key string) (Value, error) {
-	index := kvs.shardIndex(key)
-	sh := kvs.shards[index]
+// If the key is an alias, the value of the key it points at is returned.
+func (kvs *KeyValueStore) Get(key string) (_ Value, err error) {
+	if len(kvs.opts.observers) > 0 {
+		defer kvs.observe(OpGet, key, time.Now(), &err)
+	}
+
+	if isSystemKey(key) {
+		return kvs.systemValue(key)
+	}
 
-	sh.mu.RLock()
-	defer sh.mu.RUnlock()
+	return kvs.get(key)
+}
+
+// get returns the value of key without checking for reserved keys.
+func (kvs *KeyValueStore) get(key string) (Value, error) {
+	if kvs.opts.replicaInterval > 0 {
+		val, err := kvs.replicaGet(key)
+		if err != nil {
+			return nil, err
+		}
+		return kvs.decompress(val)
+	}
+
+	sh, key, err := kvs.rlockKey(key)
+	if err != nil {
+		return nil, err
+	}
+	val, err := sh.backend.Get(key)
+	sh.mu.RUnlock()
+	sh.counters.countGet(err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return kvs.decompress(val)
+}
+
+// contains reports whether key holds a value, bypassing read replicas and the
+// operation counters.
+func (kvs *KeyValueStore) contains(key string) (bool, error) {
+	sh, key, err := kvs.rlockKey(key)
+	if err != nil {
+		return false, err
+	}
+	_, err = sh.backend.Get(key)
+	sh.mu.RUnlock()
+
+	if err == ErrNotFound {
+		return false, nil
+	}
 
-	val, ok := sh.store[key]
+	return err == nil, err
+}
 
-	if !ok {
-		return nil, ErrNotFound
+// getLive returns the value of key from its shard, bypassing read replicas and
+// the operation counters, for internal reads that must see the latest write.
+func (kvs *KeyValueStore) getLive(key string) (Value, error) {
+	sh, key, err := kvs.rlockKey(key)
+	if err != nil {
+		return nil, err
 	}
+	val, err := sh.backend.Get(key)
+	sh.mu.RUnlock()
 
-	return val, nil
+	if err != nil {
+		return nil, err
+	}
+
+	return kvs.decompress(val)
 }
 
 // Delete removes the key-value pair associated with the given key from the store.
 // If the key is not found in the store, it returns an error.
-func (kvs *KeyValueStore) Delete(key string) error {
+// If the key is an alias, only the alias is removed.
+func (kvs *KeyValueStore) Delete(key string) (err error) {
+	if len(kvs.opts.observers) > 0 {
+		defer kvs.observe(OpDelete, key, time.Now(), &err)
+	}
+
+	if isSystemKey(key) {
+		return ErrReservedKey
+	}
+
+	return kvs.delete(key)
+}
+
+// delete removes key without checking for reserved keys.
+func (kvs *KeyValueStore) delete(key string) error {
 	index := kvs.shardIndex(key)
 	sh := kvs.shards[index]
 
-	sh.mu.Lock()
+	kvs.lockShard(sh)
 	defer sh.mu.Unlock()
+	sh.recordAccess(key)
 
-	if _, ok := sh.store[key]; !ok {
-		return ErrNotFound
+	if _, ok := sh.aliases[key]; ok {
+		sh.removeAlias(key)
+		kvs.events.publish(EventDelete, key, 0)
+		return nil
 	}
 
-	delete(sh.store, key)
+	if sh.isImmutable(key) {
+		return ErrImmutable
+	}
+
+	return kvs.remove(sh, key)
+}
+
+// remove deletes key and its metadata from the shard, moving the entry to the
+// recycle bin if one is configured. The shard must be locked.
+func (kvs *KeyValueStore) remove(sh *shard, key string) error {
+	sh.preserve(key)
+	if kvs.bin == nil {
+		if err := sh.backend.Delete(key); err != nil {
+			return err
+		}
+		sh.setMeta(key, nil)
+		sh.counters.deletes.Add(1)
+		rev := kvs.record(sh, key, nil, true)
+		kvs.events.publish(EventDelete, key, rev)
+		return nil
+	}
+
+	val, err := sh.backend.Get(key)
+	if err != nil {
+		return err
+	}
+	if err := sh.backend.Delete(key); err != nil {
+		return err
+	}
+	kvs.bin.add(key, val, sh.isImmutable(key), sh.meta[key])
+	sh.setMeta(key, nil)
+	sh.counters.deletes.Add(1)
+	rev := kvs.record(sh, key, nil, true)
+	kvs.events.publish(EventDelete, key, rev)
 
 	return nil
 }
 
 // Keys returns a slice of all the keys in the store.
 func (kvs *KeyValueStore) Keys() ([]string, error) {
+	if kvs.opts.replicaInterval > 0 {
+		return kvs.replicaKeys(), nil
+	}
+
 	keys := make([]string, 0)
 
 	for _, sh := range kvs.shards {
@@ -121,7 +363,7 @@ func (kvs *KeyValueStore) Keys() ([]string, error) {
 		if err != nil {
 			return nil, err
 		}
-		keys = append(keys, shKeys...)
+		keys = append(keys, withoutSystemKeys(shKeys)...)
 	}
 
 	return keys, nil
@@ -133,10 +375,44 @@ func (kvs *KeyValueStore) Size() string {
 
 	for _, sh := range kvs.shards {
 		sh.mu.RLock()
-		size := uint64(len(sh.store))
+		size := uint64(sh.backend.Len())
 		totalSize += size
 		sh.mu.RUnlock()
 	}
 
 	return formatSize(totalSize)
 }
+
+// ShardSizes returns the number of entries in each shard, indexed by shard id.
+func (kvs *KeyValueStore) ShardSizes() []int {
+	sizes := make([]int, len(kvs.shards))
+
+	for i, sh := range kvs.shards {
+		sh.mu.RLock()
+		sizes[i] = sh.backend.Len()
+		sh.mu.RUnlock()
+	}
+
+	return sizes
+}
+
+// Close releases the resources held by the store's backends.
+// The store must not be used after it has been closed.
+func (kvs *KeyValueStore) Close() error {
+	close(kvs.stop)
+	kvs.bg.Wait()
+
+	var firstErr error
+
+	for _, sh := range kvs.shards {
+		sh.mu.Lock()
+		if c, ok := sh.backend.(io.Closer); ok {
+			if err := c.Close(); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+		sh.mu.Unlock()
+	}
+
+	return firstErr
+}
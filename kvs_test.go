@@ -48,6 +48,26 @@ func TestSet(t *testing.T) {
 	}
 }
 
+func TestSet_Nil(t *testing.T) {
+	store, err := NewKeyValueStore(10)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	if err := store.Set("person", nil); err != ErrNilValue {
+		t.Errorf("Expected ErrNilValue, got %v", err)
+	}
+	if err := store.SetImmutable("person", nil); err != ErrNilValue {
+		t.Errorf("Expected ErrNilValue from SetImmutable, got %v", err)
+	}
+	if err := store.SetWithMeta("person", nil, nil); err != ErrNilValue {
+		t.Errorf("Expected ErrNilValue from SetWithMeta, got %v", err)
+	}
+	if _, err := store.Get("person"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
 func TestGet(t *testing.T) {
 	store, err := NewKeyValueStore(10)
 	if err != nil {
@@ -0,0 +1,118 @@
+// Package kvsauth defines the API tokens the kvs network servers accept. A token
+// grants scopes, and can be restricted to some buckets:
+//
+//	tokens := kvsauth.Tokens{
+//		os.Getenv("KVS_ADMIN_TOKEN"):   {Scopes: []kvsauth.Scope{kvsauth.ScopeAdmin}},
+//		os.Getenv("KVS_BILLING_TOKEN"): {Scopes: []kvsauth.Scope{kvsauth.ScopeRead, kvsauth.ScopeWrite}, Buckets: []string{"billing"}},
+//	}
+//	handler := httpserver.New(store, httpserver.WithTokens(tokens))
+package kvsauth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"slices"
+	"strings"
+)
+
+// Scope is a class of operations a token grants.
+type Scope string
+
+const (
+	// ScopeRead grants reading keys, watching them and exporting buckets.
+	ScopeRead Scope = "read"
+	// ScopeWrite grants setting and deleting keys and importing buckets.
+	ScopeWrite Scope = "write"
+	// ScopeAdmin grants every scope, and the operations endpoints such as the
+	// statistics of the store and of tenants.
+	ScopeAdmin Scope = "admin"
+)
+
+var (
+	// ErrUnauthenticated is returned for requests without a known token.
+	ErrUnauthenticated = errors.New("kvsauth: missing or unknown token")
+	// ErrForbidden is returned for requests the token does not grant.
+	ErrForbidden = errors.New("kvsauth: operation not permitted by token")
+)
+
+// Token describes what the holder of an API token may do.
+type Token struct {
+	// Scopes are the scopes the token grants.
+	Scopes []Scope `json:"scopes"`
+	// Buckets restricts the token to the buckets at the given paths and the
+	// buckets nested in them. Such tokens cannot access the root keyspace,
+	// tenants or anything else outside their buckets. An empty list means no
+	// restriction.
+	Buckets []string `json:"buckets,omitempty"`
+}
+
+// Allows reports whether t grants scope.
+func (t Token) Allows(scope Scope) bool {
+	return slices.Contains(t.Scopes, scope) || slices.Contains(t.Scopes, ScopeAdmin)
+}
+
+// AllowsBucket reports whether t may access the bucket at path, the names of the
+// bucket and its parents joined by slashes. The empty path stands for anything
+// outside buckets.
+func (t Token) AllowsBucket(path string) bool {
+	if len(t.Buckets) == 0 {
+		return true
+	}
+	if path == "" {
+		return false
+	}
+
+	for _, b := range t.Buckets {
+		if path == b || strings.HasPrefix(path, b+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Tokens maps the secrets of API tokens to what they grant.
+type Tokens map[string]Token
+
+// Lookup returns the token with the given secret. Secrets are compared in
+// constant time.
+func (ts Tokens) Lookup(secret string) (Token, bool) {
+	var (
+		found Token
+		ok    bool
+	)
+	for s, t := range ts {
+		if subtle.ConstantTimeCompare([]byte(s), []byte(secret)) == 1 {
+			found, ok = t, true
+		}
+	}
+
+	return found, ok && secret != ""
+}
+
+// Authorize checks that the token with the given secret grants scope on the
+// bucket at path, or outside buckets for an empty path. It returns
+// ErrUnauthenticated if there is no such token and ErrForbidden if it does not
+// grant the operation.
+func (ts Tokens) Authorize(secret string, scope Scope, bucket string) error {
+	t, ok := ts.Lookup(secret)
+	if !ok {
+		return ErrUnauthenticated
+	}
+	if !t.Allows(scope) || !t.AllowsBucket(bucket) {
+		return ErrForbidden
+	}
+
+	return nil
+}
+
+// BearerToken returns the secret of an Authorization header value of the form
+// "Bearer <secret>", or "" if it has another form.
+func BearerToken(header string) string {
+	scheme, secret, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(secret)
+}
@@ -0,0 +1,49 @@
+package kvsauth
+
+import "testing"
+
+func TestTokens_Authorize(t *testing.T) {
+	tokens := Tokens{
+		"admin":   {Scopes: []Scope{ScopeAdmin}},
+		"reader":  {Scopes: []Scope{ScopeRead}},
+		"billing": {Scopes: []Scope{ScopeRead, ScopeWrite}, Buckets: []string{"tenants/billing"}},
+	}
+
+	tests := []struct {
+		secret string
+		scope  Scope
+		bucket string
+		want   error
+	}{
+		{"admin", ScopeWrite, "", nil},
+		{"admin", ScopeAdmin, "any", nil},
+		{"reader", ScopeRead, "", nil},
+		{"reader", ScopeRead, "any", nil},
+		{"reader", ScopeWrite, "", ErrForbidden},
+		{"billing", ScopeWrite, "tenants/billing", nil},
+		{"billing", ScopeWrite, "tenants/billing/invoices", nil},
+		{"billing", ScopeRead, "tenants/billingx", ErrForbidden},
+		{"billing", ScopeRead, "", ErrForbidden},
+		{"billing", ScopeAdmin, "tenants/billing", ErrForbidden},
+		{"unknown", ScopeRead, "", ErrUnauthenticated},
+		{"", ScopeRead, "", ErrUnauthenticated},
+	}
+	for _, tt := range tests {
+		if err := tokens.Authorize(tt.secret, tt.scope, tt.bucket); err != tt.want {
+			t.Errorf("Authorize(%q, %s, %q) = %v, want %v", tt.secret, tt.scope, tt.bucket, err, tt.want)
+		}
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	for header, want := range map[string]string{
+		"Bearer s3cret": "s3cret",
+		"bearer s3cret": "s3cret",
+		"Basic s3cret":  "",
+		"s3cret":        "",
+	} {
+		if got := BearerToken(header); got != want {
+			t.Errorf("BearerToken(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package kvsclient
+
+import (
+	"context"
+	"io"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding/gzip"
+
+	"github.com/bay0/kvs"
+	"github.com/bay0/kvs/kvspb"
+)
+
+// chunkSize is the size of the data of a BucketChunk sent by ImportBucket.
+const chunkSize = 64 << 10
+
+// ExportBucket writes the export of the bucket at path on the server to w, as
+// kvs.Bucket.Export does. path is the bucket's name and the names of its parents
+// joined by slashes. The transfer is gzip-compressed.
+//
+//	var buf bytes.Buffer
+//	if err := src.ExportBucket(ctx, "tenants/acme", &buf); err != nil {
+//		// Handle the error
+//	}
+//	report, err := dst.ImportBucket(ctx, "tenants/acme", &buf, false)
+func (c *Client) ExportBucket(ctx context.Context, path string, w io.Writer) error {
+	stream, err := c.rpc.ExportBucket(c.outgoing(ctx), &kvspb.ExportBucketRequest{Bucket: path}, grpc.UseCompressor(gzip.Name))
+	if err != nil {
+		return fromStatus(err)
+	}
+
+	for {
+		chunk, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fromStatus(err)
+		}
+		if _, err := w.Write(chunk.GetData()); err != nil {
+			return err
+		}
+	}
+}
+
+// ImportBucket loads an export read from r into the bucket at path on the server
+// and reports how many keys were created or overwritten. With dryRun set the
+// server only reports what it would change. The transfer is gzip-compressed.
+func (c *Client) ImportBucket(ctx context.Context, path string, r io.Reader, dryRun bool) (kvs.ImportReport, error) {
+	stream, err := c.rpc.ImportBucket(c.outgoing(ctx), grpc.UseCompressor(gzip.Name))
+	if err != nil {
+		return kvs.ImportReport{}, fromStatus(err)
+	}
+
+	chunk := &kvspb.BucketChunk{Bucket: path, DryRun: dryRun}
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 || chunk.GetBucket() != "" {
+			chunk.Data = buf[:n]
+			if err := stream.Send(chunk); err != nil {
+				if err == io.EOF {
+					// The server ended the call; its status is returned below.
+					break
+				}
+				return kvs.ImportReport{}, fromStatus(err)
+			}
+			chunk = &kvspb.BucketChunk{}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return kvs.ImportReport{}, err
+		}
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return kvs.ImportReport{}, fromStatus(err)
+	}
+
+	return kvs.ImportReport{
+		Created:     int(resp.GetCreated()),
+		Overwritten: int(resp.GetOverwritten()),
+		DryRun:      dryRun,
+	}, nil
+}
@@ -0,0 +1,352 @@
+// Package kvsclient is a client for the kvs gRPC service.
+//
+//	conn, err := grpc.NewClient("localhost:7070", grpc.WithTransportCredentials(insecure.NewCredentials()))
+//	if err != nil {
+//		// Handle the error
+//	}
+//	client := kvsclient.New(conn)
+//	err = client.Set("greeting", kvs.Bytes("hello"))
+package kvsclient
+
+import (
+	"context"
+	"io"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/bay0/kvs"
+	"github.com/bay0/kvs/kvsevent"
+	"github.com/bay0/kvs/kvspb"
+)
+
+// Metadata keys of a call, as read by grpcserver.
+const (
+	tenantMetadataKey = "kvs-tenant"
+	bucketMetadataKey = "kvs-bucket"
+)
+
+// Option configures optional behaviour of a Client.
+type Option func(*Client)
+
+// WithCodec sets the codec used to convert between values and wire payloads.
+// It must match the codec of the server. The default is kvs.BytesCodec.
+func WithCodec(c kvs.Codec) Option {
+	return func(cl *Client) {
+		cl.codec = c
+	}
+}
+
+// WithTimeout sets a deadline for each call made through the kvs.Store methods.
+// Zero means no deadline.
+func WithTimeout(d time.Duration) Option {
+	return func(cl *Client) {
+		cl.timeout = d
+	}
+}
+
+// WithTenant performs the key operations of the client for the tenant id of a
+// server whose store has tenants: Get, Set, Delete, Keys, KeysWithPrefix and
+// BatchSet.
+func WithTenant(id string) Option {
+	return func(cl *Client) {
+		cl.tenant = id
+	}
+}
+
+// WithBucket performs the key operations of the client in the bucket at path:
+// Get, Set, Delete, Keys, KeysWithPrefix and BatchSet.
+func WithBucket(path string) Option {
+	return func(cl *Client) {
+		cl.bucket = path
+	}
+}
+
+// WithToken sends the API token secret with every call, for servers that
+// require tokens. Use it with transport security, as the token is sent in the
+// clear.
+func WithToken(secret string) Option {
+	return func(cl *Client) {
+		cl.token = secret
+	}
+}
+
+// Client is a kvs.Store backed by a remote kvs gRPC server.
+type Client struct {
+	rpc     kvspb.KVSClient
+	codec   kvs.Codec
+	timeout time.Duration
+	tenant  string
+	bucket  string
+	token   string
+}
+
+var _ kvs.Store = (*Client)(nil)
+
+// New creates a new Client using conn.
+func New(conn grpc.ClientConnInterface, opts ...Option) *Client {
+	c := &Client{
+		rpc:   kvspb.NewKVSClient(conn),
+		codec: kvs.BytesCodec{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// context returns the context for a kvs.Store call.
+func (c *Client) context() (context.Context, context.CancelFunc) {
+	ctx := c.outgoing(context.Background())
+	if c.timeout > 0 {
+		return context.WithTimeout(ctx, c.timeout)
+	}
+
+	return context.WithCancel(ctx)
+}
+
+// outgoing returns ctx carrying the tenant, bucket and token of the client, if
+// it has them.
+func (c *Client) outgoing(ctx context.Context) context.Context {
+	var kv []string
+	if c.tenant != "" {
+		kv = append(kv, tenantMetadataKey, c.tenant)
+	}
+	if c.bucket != "" {
+		kv = append(kv, bucketMetadataKey, c.bucket)
+	}
+	if c.token != "" {
+		kv = append(kv, "authorization", "Bearer "+c.token)
+	}
+	if len(kv) == 0 {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+// Get retrieves the value associated with the given key from the server.
+// If the key is not found, it returns a kvs.ErrNotFound error.
+func (c *Client) Get(key string) (kvs.Value, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	resp, err := c.rpc.Get(ctx, &kvspb.GetRequest{Key: key})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	return c.codec.Unmarshal(resp.GetValue())
+}
+
+// Set adds or updates the given key-value pair on the server.
+// It returns kvs.ErrNilValue if val is nil.
+func (c *Client) Set(key string, val kvs.Value) error {
+	if val == nil {
+		return kvs.ErrNilValue
+	}
+
+	data, err := c.codec.Marshal(val)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := c.context()
+	defer cancel()
+
+	_, err = c.rpc.Set(ctx, &kvspb.SetRequest{Key: key, Value: data})
+
+	return fromStatus(err)
+}
+
+// Delete removes the key-value pair associated with the given key from the server.
+// If the key is not found, it returns a kvs.ErrNotFound error.
+func (c *Client) Delete(key string) error {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	_, err := c.rpc.Delete(ctx, &kvspb.DeleteRequest{Key: key})
+
+	return fromStatus(err)
+}
+
+// Keys returns a slice of all the keys on the server.
+func (c *Client) Keys() ([]string, error) {
+	ctx, cancel := c.context()
+	defer cancel()
+
+	return c.KeysWithPrefix(ctx, "")
+}
+
+// KeysWithPrefix returns the sorted keys on the server that start with prefix.
+func (c *Client) KeysWithPrefix(ctx context.Context, prefix string) ([]string, error) {
+	stream, err := c.rpc.Keys(c.outgoing(ctx), &kvspb.KeysRequest{Prefix: prefix})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	keys := make([]string, 0)
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			return keys, nil
+		}
+		if err != nil {
+			return nil, fromStatus(err)
+		}
+		keys = append(keys, resp.GetKeys()...)
+	}
+}
+
+// BatchSet streams all pairs to the server in a single call and returns the
+// number of pairs that were set. Pairs are sent in key order.
+func (c *Client) BatchSet(ctx context.Context, pairs map[string]kvs.Value) (int64, error) {
+	keys := make([]string, 0, len(pairs))
+	for k := range pairs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	stream, err := c.rpc.BatchSet(c.outgoing(ctx))
+	if err != nil {
+		return 0, fromStatus(err)
+	}
+
+	for _, k := range keys {
+		data, err := c.codec.Marshal(pairs[k])
+		if err != nil {
+			stream.CloseSend()
+			return 0, err
+		}
+		if err := stream.Send(&kvspb.SetRequest{Key: k, Value: data}); err != nil {
+			// The server ended the stream; its error is reported by CloseAndRecv.
+			break
+		}
+	}
+
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return 0, fromStatus(err)
+	}
+
+	return resp.GetCount(), nil
+}
+
+// eventTypes maps wire event types to store event types.
+var eventTypes = map[kvspb.EventType]kvs.EventType{
+	kvspb.EventType_EVENT_TYPE_SET:      kvs.EventSet,
+	kvspb.EventType_EVENT_TYPE_DELETE:   kvs.EventDelete,
+	kvspb.EventType_EVENT_TYPE_OVERFLOW: kvs.EventOverflow,
+	kvspb.EventType_EVENT_TYPE_CORRUPT:  kvs.EventCorrupt,
+	kvspb.EventType_EVENT_TYPE_GAP:      kvs.EventGap,
+}
+
+// Watch streams the changes of keys starting with prefix on the server. buffer is
+// the number of events the server buffers before the watch counts as fallen behind;
+// zero uses the server default. The channel is closed when ctx is done, the stream
+// ends, or after an kvs.EventOverflow event.
+func (c *Client) Watch(ctx context.Context, prefix string, buffer int) (<-chan kvs.Event, error) {
+	stream, err := c.rpc.Watch(c.outgoing(ctx), &kvspb.WatchRequest{Prefix: prefix, Buffer: int32(buffer)})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	ch := make(chan kvs.Event)
+	go func() {
+		defer close(ch)
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			ev := kvs.Event{Type: eventTypes[resp.GetType()], Key: resp.GetKey()}
+			// Servers that send the versioned event schema also report the
+			// revision and time of the change.
+			if msg := resp.GetEvent(); msg != nil {
+				if e, err := kvsevent.FromProto(msg); err == nil {
+					ev, _ = e.Event()
+				}
+			}
+			select {
+			case ch <- ev:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// Sync streams the entries of keys starting with prefix on the server, then a
+// kvs.EventSynced event and then their changes, with the same semantics as
+// KeyValueStore.Sync. buffer is as for Watch. The channel is closed when ctx is
+// done, the stream ends, a value cannot be decoded, or after an
+// kvs.EventOverflow event.
+func (c *Client) Sync(ctx context.Context, prefix string, buffer int) (<-chan kvs.SyncEvent, error) {
+	stream, err := c.rpc.Sync(c.outgoing(ctx), &kvspb.SyncRequest{Prefix: prefix, Buffer: int32(buffer)})
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	ch := make(chan kvs.SyncEvent)
+	go func() {
+		defer close(ch)
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				return
+			}
+			e, err := kvsevent.FromProto(msg)
+			if err != nil {
+				return
+			}
+			ev, _ := e.Event()
+
+			se := kvs.SyncEvent{Event: ev}
+			if ev.Type == kvs.EventSet {
+				if se.Value, err = c.codec.Unmarshal(e.Value); err != nil {
+					return
+				}
+			}
+			select {
+			case ch <- se:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// fromStatus converts a gRPC status error into a store error where possible.
+func fromStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch status.Code(err) {
+	case codes.NotFound:
+		return kvs.ErrNotFound
+	case codes.AlreadyExists:
+		return kvs.ErrDuplicate
+	case codes.PermissionDenied:
+		if status.Convert(err).Message() == kvs.ErrReservedKey.Error() {
+			return kvs.ErrReservedKey
+		}
+		return err
+	case codes.ResourceExhausted:
+		if status.Convert(err).Message() == kvs.ErrRateLimited.Error() {
+			return kvs.ErrRateLimited
+		}
+		return kvs.ErrQuotaExceeded
+	default:
+		return err
+	}
+}
@@ -0,0 +1,386 @@
+package kvsclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/bay0/kvs"
+	"github.com/bay0/kvs/grpcserver"
+	"github.com/bay0/kvs/kvsauth"
+	"github.com/bay0/kvs/kvspb"
+)
+
+func newTestClient(t *testing.T, opts ...Option) (*Client, *kvs.KeyValueStore) {
+	t.Helper()
+
+	store, err := kvs.NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	return New(dial(t, grpcserver.New(store)), opts...), store
+}
+
+// dial serves srv in memory and returns a connection to it.
+func dial(t *testing.T, srv kvspb.KVSServer) *grpc.ClientConn {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	kvspb.RegisterKVSServer(gs, srv)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient returned an error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func TestClient(t *testing.T) {
+	client, store := newTestClient(t)
+
+	if err := client.Set("greeting", kvs.Bytes("hello")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+
+	val, err := store.Get("greeting")
+	if err != nil {
+		t.Fatalf("Get on the store returned an error: %v", err)
+	}
+	if string(val.(kvs.Bytes)) != "hello" {
+		t.Errorf("unexpected stored value %v", val)
+	}
+
+	val, err = client.Get("greeting")
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+	if string(val.(kvs.Bytes)) != "hello" {
+		t.Errorf("unexpected value %v", val)
+	}
+
+	if err := client.Delete("greeting"); err != nil {
+		t.Fatalf("Delete returned an error: %v", err)
+	}
+	if _, err := client.Get("greeting"); err != kvs.ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if err := client.Delete("greeting"); err != kvs.ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestClient_Batch(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	pairs := make(map[string]kvs.Value)
+	for i := 0; i < 2500; i++ {
+		pairs[fmt.Sprintf("key-%04d", i)] = kvs.Bytes{byte(i)}
+	}
+
+	n, err := client.BatchSet(context.Background(), pairs)
+	if err != nil {
+		t.Fatalf("BatchSet returned an error: %v", err)
+	}
+	if n != int64(len(pairs)) {
+		t.Errorf("Expected %d pairs to be set, got %d", len(pairs), n)
+	}
+
+	keys, err := client.Keys()
+	if err != nil {
+		t.Fatalf("Keys returned an error: %v", err)
+	}
+	if len(keys) != len(pairs) {
+		t.Errorf("Expected %d keys, got %d", len(pairs), len(keys))
+	}
+
+	keys, err = client.KeysWithPrefix(context.Background(), "key-1")
+	if err != nil {
+		t.Fatalf("KeysWithPrefix returned an error: %v", err)
+	}
+	if len(keys) != 1000 || keys[0] != "key-1000" || keys[999] != "key-1999" {
+		t.Errorf("unexpected keys for prefix: %d keys", len(keys))
+	}
+}
+
+func TestClient_SystemKeys(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	val, err := client.Get("__kvs/stats/shards")
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+	if string(val.(kvs.Bytes)) != "4" {
+		t.Errorf("Expected 4 shards, got %s", val)
+	}
+
+	keys, err := client.KeysWithPrefix(context.Background(), kvs.SystemPrefix+"config/")
+	if err != nil {
+		t.Fatalf("KeysWithPrefix returned an error: %v", err)
+	}
+	if len(keys) != 3 {
+		t.Errorf("Expected 3 config keys, got %v", keys)
+	}
+
+	if err := client.Set("__kvs/stats/shards", kvs.Bytes("1")); err != kvs.ErrReservedKey {
+		t.Errorf("Expected ErrReservedKey, got %v", err)
+	}
+}
+
+func TestClient_Watch(t *testing.T) {
+	client, store := newTestClient(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := client.Watch(ctx, "config/", 0)
+	if err != nil {
+		t.Fatalf("Watch returned an error: %v", err)
+	}
+
+	// The server subscribes asynchronously, so write until the first event arrives.
+	timeout := time.After(5 * time.Second)
+	for seen := false; !seen; {
+		_ = store.Set("other", kvs.Bytes("x"))
+		_ = store.Set("config/a", kvs.Bytes("x"))
+		select {
+		case ev := <-events:
+			if ev.Type != kvs.EventSet || ev.Key != "config/a" {
+				t.Fatalf("Expected a set of config/a, got %+v", ev)
+			}
+			seen = true
+		case <-time.After(10 * time.Millisecond):
+		case <-timeout:
+			t.Fatal("timed out waiting for the first event")
+		}
+	}
+
+	if err := store.Delete("config/a"); err != nil {
+		t.Fatalf("Delete returned an error: %v", err)
+	}
+	for ev := range events {
+		if ev.Type == kvs.EventDelete {
+			if ev.Key != "config/a" {
+				t.Errorf("Expected a delete of config/a, got %+v", ev)
+			}
+			if ev.Rev != store.Revision() || ev.Time.IsZero() {
+				t.Errorf("Expected the revision and time of the delete, got %+v", ev)
+			}
+			return
+		}
+	}
+	t.Error("Expected a delete event before the stream ended")
+}
+
+func TestClient_Sync(t *testing.T) {
+	client, store := newTestClient(t)
+
+	if err := store.Set("config/a", kvs.Bytes("1")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if err := store.Set("other", kvs.Bytes("x")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := client.Sync(ctx, "config/", 0)
+	if err != nil {
+		t.Fatalf("Sync returned an error: %v", err)
+	}
+
+	ev := <-events
+	if ev.Type != kvs.EventSet || ev.Key != "config/a" || string(ev.Value.(kvs.Bytes)) != "1" {
+		t.Fatalf("Expected the listed entry of config/a, got %+v", ev)
+	}
+	if ev := <-events; ev.Type != kvs.EventSynced {
+		t.Fatalf("Expected a synced event, got %+v", ev)
+	}
+
+	if err := store.Set("config/b", kvs.Bytes("2")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	ev = <-events
+	if ev.Type != kvs.EventSet || ev.Key != "config/b" || string(ev.Value.(kvs.Bytes)) != "2" {
+		t.Errorf("Expected a set of config/b with its value, got %+v", ev)
+	}
+}
+
+func TestClient_Mirror(t *testing.T) {
+	client, store := newTestClient(t)
+
+	if err := store.Set("config/a", kvs.Bytes("1")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if err := store.Set("other", kvs.Bytes("x")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+
+	m, err := client.Mirror("config/")
+	if err != nil {
+		t.Fatalf("Mirror returned an error: %v", err)
+	}
+	defer m.Close()
+
+	select {
+	case <-m.Synced():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the mirror to sync")
+	}
+
+	val, err := m.Get("config/a")
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+	if string(val.(kvs.Bytes)) != "1" {
+		t.Errorf("unexpected value %v", val)
+	}
+	if val, err := m.Get("other"); err != nil || string(val.(kvs.Bytes)) != "x" {
+		t.Errorf("Expected keys outside the prefix to be read from the server, got %v, %v", val, err)
+	}
+
+	if err := m.Set("config/b", kvs.Bytes("2")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if _, err := store.Get("config/b"); err != nil {
+		t.Errorf("Expected the write to reach the server, got %v", err)
+	}
+	if err := store.Delete("config/a"); err != nil {
+		t.Fatalf("Delete returned an error: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		keys, err := m.Keys()
+		if err != nil {
+			t.Fatalf("Keys returned an error: %v", err)
+		}
+		if len(keys) == 1 && keys[0] == "config/b" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the mirror to hold only config/b, got %v", keys)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := m.Get("config/a"); err != kvs.ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestClient_Buckets(t *testing.T) {
+	src, srcStore := newTestClient(t)
+	dst, dstStore := newTestClient(t)
+	ctx := context.Background()
+
+	// Enough data to take several chunks.
+	big := kvs.Bytes(strings.Repeat("x", 100<<10))
+	_ = srcStore.BucketAt("tenants/acme").Set("blob", big)
+	_ = srcStore.BucketAt("tenants/acme/users").Set("alice", kvs.Bytes("admin"))
+
+	var buf bytes.Buffer
+	if err := src.ExportBucket(ctx, "tenants/acme", &buf); err != nil {
+		t.Fatalf("ExportBucket returned an error: %v", err)
+	}
+	report, err := dst.ImportBucket(ctx, "tenants/acme", &buf, false)
+	if err != nil {
+		t.Fatalf("ImportBucket returned an error: %v", err)
+	}
+	if report.Created != 2 {
+		t.Errorf("Expected 2 created keys, got %+v", report)
+	}
+	if val, err := dstStore.BucketAt("tenants/acme").Get("blob"); err != nil || !bytes.Equal(val.(kvs.Bytes), big) {
+		t.Errorf("Expected the blob to be moved, got an error: %v", err)
+	}
+	if _, err := dstStore.BucketAt("tenants/acme/users").Get("alice"); err != nil {
+		t.Errorf("Expected the nested bucket to be moved, got %v", err)
+	}
+
+	if _, err := dst.ImportBucket(ctx, "tenants/acme", strings.NewReader("garbage"), false); err == nil {
+		t.Error("Expected a corrupt import to fail")
+	}
+}
+
+func TestClient_Tenant(t *testing.T) {
+	client, store := newTestClient(t, WithTenant("billing"))
+
+	if err := client.Set("invoice", kvs.Bytes("paid")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if val, err := store.Tenant("billing").Get("invoice"); err != nil || string(val.(kvs.Bytes)) != "paid" {
+		t.Errorf("Expected the key to be set for the tenant, got %v, %v", val, err)
+	}
+	if _, err := store.Get("invoice"); err != kvs.ErrNotFound {
+		t.Errorf("Expected the root keyspace to be unchanged, got %v", err)
+	}
+	if keys, err := client.Keys(); err != nil || len(keys) != 1 || keys[0] != "invoice" {
+		t.Errorf("Expected [invoice], got %v, %v", keys, err)
+	}
+
+	_ = store.Tenant("billing").SetQuota(kvs.TenantQuota{MaxEntries: 1})
+	if err := client.Set("receipt", kvs.Bytes("sent")); err != kvs.ErrQuotaExceeded {
+		t.Errorf("Expected ErrQuotaExceeded, got %v", err)
+	}
+	_ = store.Tenant("billing").SetQuota(kvs.TenantQuota{MaxOpsPerSecond: 1})
+	_, _ = client.Get("invoice")
+	if _, err := client.Get("invoice"); err != kvs.ErrRateLimited {
+		t.Errorf("Expected ErrRateLimited, got %v", err)
+	}
+}
+
+func TestClient_Tokens(t *testing.T) {
+	store, _ := kvs.NewKeyValueStore(4)
+	conn := dial(t, grpcserver.New(store, grpcserver.WithTokens(kvsauth.Tokens{
+		"reader":  {Scopes: []kvsauth.Scope{kvsauth.ScopeRead}},
+		"billing": {Scopes: []kvsauth.Scope{kvsauth.ScopeRead, kvsauth.ScopeWrite}, Buckets: []string{"billing"}},
+	})))
+	_ = store.Set("a", kvs.Bytes("1"))
+
+	if _, err := New(conn).Get("a"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated without a token, got %v", err)
+	}
+
+	reader := New(conn, WithToken("reader"))
+	if _, err := reader.Get("a"); err != nil {
+		t.Errorf("Get returned an error: %v", err)
+	}
+	if err := reader.Set("a", kvs.Bytes("2")); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied for a write, got %v", err)
+	}
+
+	billing := New(conn, WithToken("billing"), WithBucket("billing"))
+	if err := billing.Set("invoice", kvs.Bytes("paid")); err != nil {
+		t.Errorf("Set returned an error: %v", err)
+	}
+	if _, err := store.Bucket("billing").Get("invoice"); err != nil {
+		t.Errorf("Expected the key to be set in the bucket, got %v", err)
+	}
+	if _, err := New(conn, WithToken("billing")).Get("a"); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied outside the bucket, got %v", err)
+	}
+	if err := billing.ExportBucket(context.Background(), "search", io.Discard); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied for another bucket, got %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package kvsclient
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bay0/kvs"
+)
+
+// mirrorRetry is how long a Mirror waits before syncing again after the stream ended.
+const mirrorRetry = 500 * time.Millisecond
+
+// mirrorShards is the number of shards of the local store of a Mirror.
+const mirrorShards = 16
+
+// Mirror is a kvs.Store that serves the keys starting with a prefix from an
+// in-process copy kept up to date with Sync, so hot read paths do not leave the
+// process. Writes go to the server and reach the copy through the change stream,
+// so a Get right after a Set may still return the old value.
+//
+// Until the copy is in sync, and whenever the stream is interrupted, reads are
+// forwarded to the server. Keys outside the prefix are always read from the server.
+type Mirror struct {
+	client *Client
+	prefix string
+	local  *kvs.KeyValueStore
+
+	// ready is set while the copy is in sync with the server.
+	ready  atomic.Bool
+	synced chan struct{}
+	once   sync.Once
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+var _ kvs.Store = (*Mirror)(nil)
+
+// Mirror starts mirroring the keys starting with prefix into a local read-only
+// store. The stream is restarted whenever it ends, including after the mirror
+// fell behind, until Close is called.
+//
+//	m, err := client.Mirror("config/")
+//	if err != nil {
+//		// Handle the error
+//	}
+//	defer m.Close()
+//	<-m.Synced()
+//	val, err := m.Get("config/timeout")
+func (c *Client) Mirror(prefix string) (*Mirror, error) {
+	local, err := kvs.NewKeyValueStore(mirrorShards)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m := &Mirror{
+		client: c,
+		prefix: prefix,
+		local:  local,
+		synced: make(chan struct{}),
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	go m.run(ctx)
+
+	return m, nil
+}
+
+// Synced returns a channel that is closed once the copy has been in sync with
+// the server for the first time.
+func (m *Mirror) Synced() <-chan struct{} {
+	return m.synced
+}
+
+// Get retrieves the value of key from the local copy if it is in sync and the
+// key starts with the prefix, and from the server otherwise.
+// If the key is not found, it returns a kvs.ErrNotFound error.
+func (m *Mirror) Get(key string) (kvs.Value, error) {
+	if !m.fromLocal(key) {
+		return m.client.Get(key)
+	}
+
+	return m.local.Get(key)
+}
+
+// Set adds or updates the given key-value pair on the server.
+func (m *Mirror) Set(key string, val kvs.Value) error {
+	return m.client.Set(key, val)
+}
+
+// Delete removes the key-value pair associated with the given key from the server.
+// If the key is not found, it returns a kvs.ErrNotFound error.
+func (m *Mirror) Delete(key string) error {
+	return m.client.Delete(key)
+}
+
+// Keys returns the mirrored keys, from the local copy if it is in sync and from
+// the server otherwise.
+func (m *Mirror) Keys() ([]string, error) {
+	if !m.ready.Load() {
+		ctx, cancel := m.client.context()
+		defer cancel()
+
+		return m.client.KeysWithPrefix(ctx, m.prefix)
+	}
+
+	return m.local.Keys()
+}
+
+// Close stops mirroring and releases the local copy.
+func (m *Mirror) Close() error {
+	m.cancel()
+	<-m.done
+
+	return m.local.Close()
+}
+
+// fromLocal reports whether key is read from the local copy.
+func (m *Mirror) fromLocal(key string) bool {
+	return m.ready.Load() && strings.HasPrefix(key, m.prefix)
+}
+
+// run syncs the local copy until ctx is done.
+func (m *Mirror) run(ctx context.Context) {
+	defer close(m.done)
+
+	for {
+		m.sync(ctx)
+		m.ready.Store(false)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(mirrorRetry):
+		}
+	}
+}
+
+// sync applies one Sync stream to the local copy until it ends. Keys that are
+// no longer listed when the stream starts are removed from the copy.
+func (m *Mirror) sync(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events, err := m.client.Sync(ctx, m.prefix, 0)
+	if err != nil {
+		return
+	}
+
+	listed := make(map[string]struct{})
+	for ev := range events {
+		switch ev.Type {
+		case kvs.EventSet:
+			_ = m.local.Set(ev.Key, ev.Value)
+			if listed != nil {
+				listed[ev.Key] = struct{}{}
+			}
+		case kvs.EventDelete:
+			_ = m.local.Delete(ev.Key)
+		case kvs.EventSynced:
+			m.prune(listed)
+			listed = nil
+			m.ready.Store(true)
+			m.once.Do(func() { close(m.synced) })
+		case kvs.EventOverflow, kvs.EventGap:
+			return
+		}
+	}
+}
+
+// prune removes the keys of the local copy that are not in listed.
+func (m *Mirror) prune(listed map[string]struct{}) {
+	keys, err := m.local.Keys()
+	if err != nil {
+		return
+	}
+
+	for _, key := range keys {
+		if _, ok := listed[key]; !ok {
+			_ = m.local.Delete(key)
+		}
+	}
+}
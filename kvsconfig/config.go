@@ -0,0 +1,230 @@
+// Package kvsconfig binds a bucket of a kvs store to a Go struct and keeps it up
+// to date, so services can use the store as their live configuration source.
+//
+//	cfg, err := kvsconfig.Bind[Settings](store, "settings",
+//		kvsconfig.WithValidator(func(s *Settings) error {
+//			if s.Timeout <= 0 {
+//				return errors.New("timeout must be positive")
+//			}
+//			return nil
+//		}))
+//	if err != nil {
+//		// Handle the error
+//	}
+//	defer cfg.Close()
+//	timeout := cfg.Load().Timeout
+package kvsconfig
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bay0/kvs"
+)
+
+// DecodeFunc decodes a document into the value pointed to by v.
+// json.Unmarshal and yaml.Unmarshal are DecodeFuncs.
+type DecodeFunc func(data []byte, v any) error
+
+// options holds the settings of a Config.
+type options[T any] struct {
+	decode   DecodeFunc
+	validate func(*T) error
+	onChange func(*T)
+	onError  func(error)
+}
+
+// Option configures a Config.
+type Option[T any] func(*options[T])
+
+// WithDecoder sets the function that decodes the documents of the bucket. The
+// default is json.Unmarshal; pass yaml.Unmarshal to keep the configuration in YAML.
+func WithDecoder[T any](decode DecodeFunc) Option[T] {
+	return func(o *options[T]) {
+		o.decode = decode
+	}
+}
+
+// WithValidator sets a function that checks every decoded configuration before it
+// is swapped in. A configuration it rejects is discarded and the previous one is
+// kept.
+func WithValidator[T any](validate func(*T) error) Option[T] {
+	return func(o *options[T]) {
+		o.validate = validate
+	}
+}
+
+// WithOnChange sets a function that is called with every new configuration after
+// it has been swapped in, starting with the one loaded by Bind.
+func WithOnChange[T any](fn func(*T)) Option[T] {
+	return func(o *options[T]) {
+		o.onChange = fn
+	}
+}
+
+// WithOnError sets a function that is called when an update of the bucket cannot
+// be decoded or is rejected by the validator.
+func WithOnError[T any](fn func(error)) Option[T] {
+	return func(o *options[T]) {
+		o.onError = fn
+	}
+}
+
+// Config holds the current configuration decoded from a bucket.
+type Config[T any] struct {
+	bucket *kvs.Bucket
+	opts   options[T]
+
+	current atomic.Pointer[T]
+	// raw is the content of the bucket the current configuration was decoded from.
+	raw []byte
+
+	mu  sync.Mutex
+	err error
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Bind decodes the bucket called bucket into a T and watches it for changes
+// until Close is called.
+//
+// Every entry of the bucket holds a kvs.Bytes document. The documents are decoded
+// into the same T in the order of their keys, so later keys override the fields
+// set by earlier ones, e.g. "00-defaults" and "10-production". An empty bucket
+// yields the zero T. Bind returns an error if the bucket cannot be decoded or the
+// configuration is rejected by the validator.
+func Bind[T any](store *kvs.KeyValueStore, bucket string, opts ...Option[T]) (*Config[T], error) {
+	c := &Config[T]{
+		bucket: store.Bucket(bucket),
+		opts:   options[T]{decode: json.Unmarshal},
+		done:   make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(&c.opts)
+	}
+
+	// Watch before the first load, so no update is missed in between.
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	events := store.Watch(ctx, c.bucket.Prefix())
+
+	if err := c.reload(); err != nil {
+		cancel()
+		return nil, err
+	}
+
+	go c.run(ctx, store, events)
+
+	return c, nil
+}
+
+// Load returns the current configuration. It is never nil, and it must not be
+// modified: it is shared by all callers until the next update.
+func (c *Config[T]) Load() *T {
+	return c.current.Load()
+}
+
+// Err returns the error of the last update of the bucket, or nil if it was
+// applied.
+func (c *Config[T]) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err
+}
+
+// Close stops watching the bucket. The last configuration remains available.
+func (c *Config[T]) Close() {
+	c.cancel()
+	<-c.done
+}
+
+// run reloads the configuration on every change of the bucket until ctx is done.
+func (c *Config[T]) run(ctx context.Context, store *kvs.KeyValueStore, events <-chan kvs.Event) {
+	defer close(c.done)
+
+	for {
+		for range events {
+			c.update()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		// The watch fell behind; watch again and catch up.
+		events = store.Watch(ctx, c.bucket.Prefix())
+		c.update()
+	}
+}
+
+// update reloads the configuration and reports a failure.
+func (c *Config[T]) update() {
+	err := c.reload()
+
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+
+	if err != nil && c.opts.onError != nil {
+		c.opts.onError(err)
+	}
+}
+
+// reload decodes the bucket and swaps in the result if the bucket changed.
+func (c *Config[T]) reload() error {
+	keys, err := c.bucket.Keys()
+	if err != nil {
+		return err
+	}
+	sort.Strings(keys)
+
+	var raw bytes.Buffer
+	var names []string
+	var docs [][]byte
+	for _, key := range keys {
+		val, err := c.bucket.Get(key)
+		if err == kvs.ErrNotFound {
+			// Deleted since listing; the event of the delete triggers another reload.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		doc, ok := val.(kvs.Bytes)
+		if !ok {
+			return fmt.Errorf("kvsconfig: %s holds %T, not kvs.Bytes", key, val)
+		}
+		fmt.Fprintf(&raw, "%d:%s%d:%s", len(key), key, len(doc), doc)
+		names = append(names, key)
+		docs = append(docs, doc)
+	}
+	if c.current.Load() != nil && bytes.Equal(raw.Bytes(), c.raw) {
+		return nil
+	}
+
+	cfg := new(T)
+	for i, doc := range docs {
+		if err := c.opts.decode(doc, cfg); err != nil {
+			return fmt.Errorf("kvsconfig: decoding %s: %w", names[i], err)
+		}
+	}
+	if c.opts.validate != nil {
+		if err := c.opts.validate(cfg); err != nil {
+			return fmt.Errorf("kvsconfig: invalid configuration: %w", err)
+		}
+	}
+
+	c.raw = raw.Bytes()
+	c.current.Store(cfg)
+	if c.opts.onChange != nil {
+		c.opts.onChange(cfg)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package kvsconfig
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/bay0/kvs"
+)
+
+type settings struct {
+	Timeout int      `json:"timeout"`
+	Hosts   []string `json:"hosts"`
+}
+
+func validate(s *settings) error {
+	if s.Timeout <= 0 {
+		return errors.New("timeout must be positive")
+	}
+	return nil
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the configuration")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBind(t *testing.T) {
+	store, err := kvs.NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	defer store.Close()
+	bucket := store.Bucket("settings")
+	_ = bucket.Set("00-defaults", kvs.Bytes(`{"timeout": 5, "hosts": ["a"]}`))
+	_ = bucket.Set("10-production", kvs.Bytes(`{"timeout": 30}`))
+
+	changes := make(chan *settings, 8)
+	cfg, err := Bind(store, "settings",
+		WithValidator(validate),
+		WithOnChange(func(s *settings) { changes <- s }))
+	if err != nil {
+		t.Fatalf("Bind returned an error: %v", err)
+	}
+	defer cfg.Close()
+
+	if s := cfg.Load(); s.Timeout != 30 || len(s.Hosts) != 1 {
+		t.Errorf("Expected the documents to be layered, got %+v", s)
+	}
+	<-changes
+
+	_ = bucket.Set("10-production", kvs.Bytes(`{"timeout": 60}`))
+	waitFor(t, func() bool { return cfg.Load().Timeout == 60 })
+	<-changes
+
+	// An invalid update is reported and the last good configuration is kept.
+	_ = bucket.Set("10-production", kvs.Bytes(`{"timeout": -1}`))
+	waitFor(t, func() bool { return cfg.Err() != nil })
+	if cfg.Load().Timeout != 60 {
+		t.Errorf("Expected the previous configuration to be kept, got %+v", cfg.Load())
+	}
+
+	_ = bucket.Delete("10-production")
+	waitFor(t, func() bool { return cfg.Load().Timeout == 5 })
+	if err := cfg.Err(); err != nil {
+		t.Errorf("Expected the error to be cleared, got %v", err)
+	}
+}
+
+func TestBind_Invalid(t *testing.T) {
+	store, _ := kvs.NewKeyValueStore(4)
+	defer store.Close()
+
+	if _, err := Bind(store, "settings", WithValidator(validate)); err == nil {
+		t.Error("Expected an empty bucket to be rejected by the validator")
+	}
+
+	_ = store.Bucket("broken").Set("doc", kvs.Bytes(`{`))
+	if _, err := Bind[settings](store, "broken"); err == nil {
+		t.Error("Expected a malformed document to be rejected")
+	}
+}
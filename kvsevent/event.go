@@ -0,0 +1,176 @@
+// Package kvsevent defines the versioned schema of the change events a kvs store
+// delivers to external consumers, with JSON and protobuf encodings. Network watch
+// APIs and other event producers share it, so consumers decode one documented
+// format instead of ad-hoc structs.
+//
+//	for ev := range store.Watch(ctx, "config/") {
+//		data, _ := json.Marshal(kvsevent.FromEvent(ev))
+//		publish(data)
+//	}
+package kvsevent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/bay0/kvs"
+	"github.com/bay0/kvs/kvspb"
+)
+
+// SchemaVersion is the version of the event schema produced by this package.
+// Fields are only ever added; the version changes when the meaning of an
+// existing field changes.
+const SchemaVersion = 1
+
+// ErrUnsupportedSchema is returned when decoding an event of a schema version
+// this package does not know.
+var ErrUnsupportedSchema = errors.New("kvsevent: unsupported schema version")
+
+// ChangeEvent is a change in the versioned event schema.
+type ChangeEvent struct {
+	// Schema is the version of the schema the event follows.
+	Schema int `json:"schema"`
+	// Op is the kind of change, as returned by kvs.EventType.String:
+	// "set", "delete", "overflow", "corrupt", "gap" or "synced".
+	Op string `json:"op"`
+	// Key is the changed key. It is empty for signals like "overflow".
+	Key string `json:"key,omitempty"`
+	// Bucket is the bucket the key belongs to, or empty for the root keyspace.
+	Bucket string `json:"bucket,omitempty"`
+	// Seq is the store revision of the change, or zero if the event is not a
+	// recorded change.
+	Seq int64 `json:"seq,omitempty"`
+	// Timestamp is when the change was published.
+	Timestamp time.Time `json:"timestamp,omitzero"`
+	// Value is the encoded value after the change, if the producer includes it.
+	// It is base64-encoded in JSON.
+	Value []byte `json:"value,omitempty"`
+}
+
+// ops maps the operation names of the schema to event types.
+var ops = map[string]kvs.EventType{
+	kvs.EventSet.String():      kvs.EventSet,
+	kvs.EventDelete.String():   kvs.EventDelete,
+	kvs.EventOverflow.String(): kvs.EventOverflow,
+	kvs.EventCorrupt.String():  kvs.EventCorrupt,
+	kvs.EventGap.String():      kvs.EventGap,
+	kvs.EventSynced.String():   kvs.EventSynced,
+}
+
+// protoOps maps event types to their protobuf representation.
+var protoOps = map[kvs.EventType]kvspb.EventType{
+	kvs.EventSet:      kvspb.EventType_EVENT_TYPE_SET,
+	kvs.EventDelete:   kvspb.EventType_EVENT_TYPE_DELETE,
+	kvs.EventOverflow: kvspb.EventType_EVENT_TYPE_OVERFLOW,
+	kvs.EventCorrupt:  kvspb.EventType_EVENT_TYPE_CORRUPT,
+	kvs.EventGap:      kvspb.EventType_EVENT_TYPE_GAP,
+	kvs.EventSynced:   kvspb.EventType_EVENT_TYPE_SYNCED,
+}
+
+// FromEvent converts a store event to the schema. The value is not included;
+// producers that deliver values set Value themselves.
+func FromEvent(ev kvs.Event) ChangeEvent {
+	return ChangeEvent{
+		Schema:    SchemaVersion,
+		Op:        ev.Type.String(),
+		Key:       ev.Key,
+		Seq:       ev.Rev,
+		Timestamp: ev.Time,
+	}
+}
+
+// Event converts e back to a store event. It returns an error if the operation
+// is unknown.
+func (e ChangeEvent) Event() (kvs.Event, error) {
+	typ, ok := ops[e.Op]
+	if !ok {
+		return kvs.Event{}, fmt.Errorf("kvsevent: unknown op %q", e.Op)
+	}
+
+	return kvs.Event{Type: typ, Key: e.Key, Rev: e.Seq, Time: e.Timestamp}, nil
+}
+
+// Validate checks that e follows a known schema version and operation.
+func (e ChangeEvent) Validate() error {
+	if e.Schema < 1 || e.Schema > SchemaVersion {
+		return fmt.Errorf("%w: %d", ErrUnsupportedSchema, e.Schema)
+	}
+	_, err := e.Event()
+
+	return err
+}
+
+// Proto returns the protobuf representation of e.
+func (e ChangeEvent) Proto() *kvspb.ChangeEvent {
+	msg := &kvspb.ChangeEvent{
+		Schema: uint32(e.Schema),
+		Op:     protoOps[ops[e.Op]],
+		Key:    e.Key,
+		Bucket: e.Bucket,
+		Seq:    e.Seq,
+		Value:  e.Value,
+	}
+	if !e.Timestamp.IsZero() {
+		msg.Timestamp = timestamppb.New(e.Timestamp)
+	}
+
+	return msg
+}
+
+// FromProto converts a protobuf event to the schema and validates it.
+func FromProto(msg *kvspb.ChangeEvent) (ChangeEvent, error) {
+	e := ChangeEvent{
+		Schema: int(msg.GetSchema()),
+		Key:    msg.GetKey(),
+		Bucket: msg.GetBucket(),
+		Seq:    msg.GetSeq(),
+		Value:  msg.GetValue(),
+	}
+	for typ, op := range protoOps {
+		if op == msg.GetOp() {
+			e.Op = typ.String()
+		}
+	}
+	if msg.GetTimestamp() != nil {
+		e.Timestamp = msg.GetTimestamp().AsTime()
+	}
+
+	if err := e.Validate(); err != nil {
+		return ChangeEvent{}, err
+	}
+
+	return e, nil
+}
+
+// MarshalProto encodes e in the protobuf wire format.
+func (e ChangeEvent) MarshalProto() ([]byte, error) {
+	return proto.Marshal(e.Proto())
+}
+
+// UnmarshalProto decodes and validates an event in the protobuf wire format.
+func UnmarshalProto(data []byte) (ChangeEvent, error) {
+	var msg kvspb.ChangeEvent
+	if err := proto.Unmarshal(data, &msg); err != nil {
+		return ChangeEvent{}, err
+	}
+
+	return FromProto(&msg)
+}
+
+// UnmarshalJSON decodes and validates an event in the JSON encoding.
+func UnmarshalJSON(data []byte) (ChangeEvent, error) {
+	var e ChangeEvent
+	if err := json.Unmarshal(data, &e); err != nil {
+		return ChangeEvent{}, err
+	}
+	if err := e.Validate(); err != nil {
+		return ChangeEvent{}, err
+	}
+
+	return e, nil
+}
@@ -0,0 +1,74 @@
+package kvsevent
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bay0/kvs"
+)
+
+func TestChangeEvent(t *testing.T) {
+	at := time.Date(2024, 5, 2, 14, 32, 0, 0, time.UTC)
+	e := FromEvent(kvs.Event{Type: kvs.EventSet, Key: "config/a", Rev: 7, Time: at})
+	e.Value = []byte("on")
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatalf("Marshal returned an error: %v", err)
+	}
+	want := `{"schema":1,"op":"set","key":"config/a","seq":7,"timestamp":"2024-05-02T14:32:00Z","value":"b24="}`
+	if string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+
+	fromJSON, err := UnmarshalJSON(data)
+	if err != nil {
+		t.Fatalf("UnmarshalJSON returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(fromJSON, e) {
+		t.Errorf("Expected %+v, got %+v", e, fromJSON)
+	}
+
+	wire, err := e.MarshalProto()
+	if err != nil {
+		t.Fatalf("MarshalProto returned an error: %v", err)
+	}
+	fromProto, err := UnmarshalProto(wire)
+	if err != nil {
+		t.Fatalf("UnmarshalProto returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(fromProto, e) {
+		t.Errorf("Expected %+v, got %+v", e, fromProto)
+	}
+
+	ev, err := fromProto.Event()
+	if err != nil {
+		t.Fatalf("Event returned an error: %v", err)
+	}
+	if ev != (kvs.Event{Type: kvs.EventSet, Key: "config/a", Rev: 7, Time: at}) {
+		t.Errorf("Unexpected event: %+v", ev)
+	}
+}
+
+func TestChangeEvent_Signal(t *testing.T) {
+	data, err := json.Marshal(FromEvent(kvs.Event{Type: kvs.EventOverflow}))
+	if err != nil {
+		t.Fatalf("Marshal returned an error: %v", err)
+	}
+	if string(data) != `{"schema":1,"op":"overflow"}` {
+		t.Errorf("Expected only the schema and op, got %s", data)
+	}
+}
+
+func TestUnmarshal_Invalid(t *testing.T) {
+	if _, err := UnmarshalJSON([]byte(`{"schema":2,"op":"set"}`)); !errors.Is(err, ErrUnsupportedSchema) {
+		t.Errorf("Expected ErrUnsupportedSchema, got %v", err)
+	}
+	if _, err := UnmarshalJSON([]byte(`{"schema":1,"op":"expire"}`)); err == nil || !strings.Contains(err.Error(), "expire") {
+		t.Errorf("Expected an unknown op error, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+// Package kvsexpvar publishes the metrics of a kvs store with the standard expvar
+// package, so they are served on /debug/vars without extra dependencies.
+//
+//	m := kvsexpvar.Publish("kvs")
+//	store, err := kvs.NewKeyValueStore(16, kvs.WithObserver(m))
+//	m.Track(store)
+package kvsexpvar
+
+import (
+	"errors"
+	"expvar"
+	"sync"
+	"time"
+
+	"github.com/bay0/kvs"
+)
+
+// ShardSource is implemented by stores whose shards can be inspected.
+// *kvs.KeyValueStore implements it.
+type ShardSource interface {
+	ShardSizes() []int
+	ShardBytes() []int64
+}
+
+// Metrics is a kvs.Observer that counts store operations in an expvar.Map.
+type Metrics struct {
+	vars   *expvar.Map
+	ops    expvar.Map
+	errs   expvar.Map
+	misses expvar.Int
+
+	mu    sync.RWMutex
+	store ShardSource
+}
+
+var _ kvs.Observer = (*Metrics)(nil)
+
+// Publish creates a Metrics and publishes it as an expvar.Map named prefix, holding
+// the operation counts per operation ("ops"), failed operations per operation
+// ("errors"), misses ("misses") and, once a store is tracked, the entries
+// ("shard_entries") and estimated bytes ("shard_bytes") of every shard.
+// Like expvar.Publish, it panics if prefix is already in use.
+func Publish(prefix string) *Metrics {
+	m := &Metrics{}
+	m.ops.Init()
+	m.errs.Init()
+
+	m.vars = expvar.NewMap(prefix)
+	m.vars.Set("ops", &m.ops)
+	m.vars.Set("errors", &m.errs)
+	m.vars.Set("misses", &m.misses)
+	m.vars.Set("shard_entries", expvar.Func(func() any {
+		if store := m.tracked(); store != nil {
+			return store.ShardSizes()
+		}
+		return []int{}
+	}))
+	m.vars.Set("shard_bytes", expvar.Func(func() any {
+		if store := m.tracked(); store != nil {
+			return store.ShardBytes()
+		}
+		return []int64{}
+	}))
+
+	return m
+}
+
+// Track sets the store whose shard sizes are published.
+func (m *Metrics) Track(store ShardSource) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.store = store
+}
+
+// tracked returns the tracked store, or nil.
+func (m *Metrics) tracked() ShardSource {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.store
+}
+
+// ObserveOp counts an operation.
+func (m *Metrics) ObserveOp(op kvs.Op, _ string, _ time.Duration, err error) {
+	m.ops.Add(string(op), 1)
+
+	switch {
+	case err == nil:
+	case errors.Is(err, kvs.ErrNotFound):
+		if op == kvs.OpGet {
+			m.misses.Add(1)
+		}
+	default:
+		m.errs.Add(string(op), 1)
+	}
+}
@@ -0,0 +1,55 @@
+package kvsexpvar
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+
+	"github.com/bay0/kvs"
+)
+
+func TestPublish(t *testing.T) {
+	m := Publish("kvs_test")
+	store, err := kvs.NewKeyValueStore(2, kvs.WithObserver(m))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	m.Track(store)
+
+	_ = store.Set("a", kvs.Bytes("1"))
+	_, _ = store.Get("a")
+	_, _ = store.Get("missing")
+	_ = store.Set("__kvs/x", kvs.Bytes("1"))
+
+	v := expvar.Get("kvs_test")
+	if v == nil {
+		t.Fatal("Expected kvs_test to be published")
+	}
+
+	var got struct {
+		Ops          map[string]int64 `json:"ops"`
+		Errors       map[string]int64 `json:"errors"`
+		Misses       int64            `json:"misses"`
+		ShardEntries []int            `json:"shard_entries"`
+		ShardBytes   []int64          `json:"shard_bytes"`
+	}
+	if err := json.Unmarshal([]byte(v.String()), &got); err != nil {
+		t.Fatalf("Unmarshal returned an error: %v", err)
+	}
+
+	if got.Ops["get"] != 2 || got.Ops["set"] != 2 {
+		t.Errorf("unexpected operation counts %v", got.Ops)
+	}
+	if got.Errors["set"] != 1 {
+		t.Errorf("Expected one failed set, got %v", got.Errors)
+	}
+	if got.Misses != 1 {
+		t.Errorf("Expected 1 miss, got %d", got.Misses)
+	}
+	if len(got.ShardEntries) != 2 || got.ShardEntries[0]+got.ShardEntries[1] != 1 {
+		t.Errorf("unexpected shard entries %v", got.ShardEntries)
+	}
+	if len(got.ShardBytes) != 2 {
+		t.Errorf("unexpected shard bytes %v", got.ShardBytes)
+	}
+}
@@ -0,0 +1,134 @@
+// Package kvsotel records OpenTelemetry spans for kvs store operations.
+//
+// A Tracer is registered with the store as an observer:
+//
+//	t := kvsotel.NewTracer(kvsotel.WithKeyAttribute(nil))
+//	store, err := kvs.NewKeyValueStore(16, kvs.WithObserver(t))
+//
+// The store API does not carry a context, so store spans are recorded as root
+// spans. RPCs served by grpcserver and httpserver are traced with the standard
+// otelgrpc and otelhttp instrumentation.
+package kvsotel
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/bay0/kvs"
+)
+
+// instrumentationName is the name of the tracer used by this package.
+const instrumentationName = "github.com/bay0/kvs/kvsotel"
+
+// ShardLocator is implemented by stores that can tell which shard holds a key.
+// *kvs.KeyValueStore implements it.
+type ShardLocator interface {
+	ShardOf(key string) int
+}
+
+// Option configures a Tracer.
+type Option func(*Tracer)
+
+// WithTracerProvider sets the provider used to create the tracer.
+// The default is the global provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(t *Tracer) {
+		t.provider = tp
+	}
+}
+
+// WithKeyAttribute records the key of every operation as the kvs.key attribute.
+// Keys are passed through redact first, so sensitive parts can be masked or hashed;
+// a nil redact records keys unchanged. Keys are not recorded by default.
+func WithKeyAttribute(redact func(key string) string) Option {
+	return func(t *Tracer) {
+		t.keys = true
+		t.redact = redact
+	}
+}
+
+// WithShardAttribute records the shard of every operation as the kvs.shard attribute.
+func WithShardAttribute(store ShardLocator) Option {
+	return func(t *Tracer) {
+		t.shards = store
+	}
+}
+
+// WithSampleRate records spans for only the given fraction of operations, between 0 and 1.
+// Operations that are not sampled cost no more than a random number. The default is 1.
+func WithSampleRate(rate float64) Option {
+	return func(t *Tracer) {
+		t.rate = rate
+	}
+}
+
+// Tracer is a kvs.Observer that records a span for every store operation.
+type Tracer struct {
+	provider trace.TracerProvider
+	tracer   trace.Tracer
+	keys     bool
+	redact   func(string) string
+	shards   ShardLocator
+	rate     float64
+}
+
+var _ kvs.Observer = (*Tracer)(nil)
+
+// NewTracer creates a Tracer with the given options.
+func NewTracer(opts ...Option) *Tracer {
+	t := &Tracer{
+		rate: 1,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if t.provider == nil {
+		t.provider = otel.GetTracerProvider()
+	}
+	t.tracer = t.provider.Tracer(instrumentationName)
+
+	return t
+}
+
+// ObserveOp records a span covering the operation.
+func (t *Tracer) ObserveOp(op kvs.Op, key string, d time.Duration, err error) {
+	if t.rate < 1 && rand.Float64() >= t.rate {
+		return
+	}
+
+	end := time.Now()
+	attrs := []attribute.KeyValue{attribute.String("kvs.op", string(op))}
+	if t.shards != nil {
+		attrs = append(attrs, attribute.Int("kvs.shard", t.shards.ShardOf(key)))
+	}
+	if t.keys {
+		if t.redact != nil {
+			key = t.redact(key)
+		}
+		attrs = append(attrs, attribute.String("kvs.key", key))
+	}
+
+	_, span := t.tracer.Start(context.Background(), "kvs."+string(op),
+		trace.WithTimestamp(end.Add(-d)),
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attrs...),
+	)
+
+	switch {
+	case err == nil:
+	case errors.Is(err, kvs.ErrNotFound):
+		span.SetAttributes(attribute.Bool("kvs.hit", false))
+	default:
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End(trace.WithTimestamp(end))
+}
@@ -0,0 +1,107 @@
+package kvsotel
+
+import (
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/bay0/kvs"
+)
+
+func newTestStore(t *testing.T, opts ...Option) (*kvs.KeyValueStore, *tracetest.SpanRecorder) {
+	t.Helper()
+
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+
+	tracer := NewTracer(append([]Option{WithTracerProvider(tp)}, opts...)...)
+	store, err := kvs.NewKeyValueStore(4, kvs.WithObserver(tracer))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	return store, rec
+}
+
+func attr(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+
+	return attribute.Value{}, false
+}
+
+func TestTracer(t *testing.T) {
+	store, rec := newTestStore(t)
+
+	_ = store.Set("a", kvs.Bytes("1"))
+	_, _ = store.Get("missing")
+	_ = store.Delete("missing")
+
+	spans := rec.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(spans))
+	}
+
+	names := []string{"kvs.set", "kvs.get", "kvs.delete"}
+	for i, name := range names {
+		if spans[i].Name() != name {
+			t.Errorf("Expected span %d to be %s, got %s", i, name, spans[i].Name())
+		}
+		if _, ok := attr(spans[i], "kvs.key"); ok {
+			t.Errorf("Expected keys not to be recorded by default")
+		}
+	}
+
+	if hit, ok := attr(spans[1], "kvs.hit"); !ok || hit.AsBool() {
+		t.Errorf("Expected the miss to be recorded as kvs.hit=false")
+	}
+	if spans[1].Status().Code == codes.Error {
+		t.Errorf("Expected a miss not to be recorded as an error")
+	}
+	if spans[0].EndTime().Before(spans[0].StartTime()) {
+		t.Errorf("Expected the span to end after it started")
+	}
+}
+
+func TestTracer_Attributes(t *testing.T) {
+	var store *kvs.KeyValueStore
+	locator := shardLocatorFunc(func(key string) int { return store.ShardOf(key) })
+
+	store, rec := newTestStore(t,
+		WithKeyAttribute(func(key string) string { return strings.Repeat("*", len(key)) }),
+		WithShardAttribute(locator),
+	)
+
+	_ = store.Set("secret", kvs.Bytes("1"))
+
+	span := rec.Ended()[0]
+	if key, _ := attr(span, "kvs.key"); key.AsString() != "******" {
+		t.Errorf("Expected the redacted key, got %q", key.AsString())
+	}
+	if shard, ok := attr(span, "kvs.shard"); !ok || int(shard.AsInt64()) != store.ShardOf("secret") {
+		t.Errorf("Expected the shard attribute to be %d, got %v", store.ShardOf("secret"), shard.AsInt64())
+	}
+}
+
+func TestTracer_SampleRate(t *testing.T) {
+	store, rec := newTestStore(t, WithSampleRate(0))
+
+	for i := 0; i < 10; i++ {
+		_ = store.Set("a", kvs.Bytes("1"))
+	}
+
+	if n := len(rec.Ended()); n != 0 {
+		t.Errorf("Expected no spans with a sample rate of 0, got %d", n)
+	}
+}
+
+type shardLocatorFunc func(key string) int
+
+func (f shardLocatorFunc) ShardOf(key string) int { return f(key) }
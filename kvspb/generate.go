@@ -0,0 +1,5 @@
+// Package kvspb contains the protobuf messages and gRPC bindings of the kvs service,
+// generated from proto/kvs/v1/kvs.proto.
+package kvspb
+
+//go:generate protoc -I ../proto --go_out=.. --go_opt=module=github.com/bay0/kvs --go-grpc_out=.. --go-grpc_opt=module=github.com/bay0/kvs kvs/v1/kvs.proto
@@ -0,0 +1,1070 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.12
+// 	protoc        (unknown)
+// source: kvs/v1/kvs.proto
+
+package kvspb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type EventType int32
+
+const (
+	EventType_EVENT_TYPE_UNSPECIFIED EventType = 0
+	EventType_EVENT_TYPE_SET         EventType = 1
+	EventType_EVENT_TYPE_DELETE      EventType = 2
+	EventType_EVENT_TYPE_OVERFLOW    EventType = 3
+	EventType_EVENT_TYPE_CORRUPT     EventType = 4
+	EventType_EVENT_TYPE_GAP         EventType = 5
+	EventType_EVENT_TYPE_SYNCED      EventType = 6
+)
+
+// Enum value maps for EventType.
+var (
+	EventType_name = map[int32]string{
+		0: "EVENT_TYPE_UNSPECIFIED",
+		1: "EVENT_TYPE_SET",
+		2: "EVENT_TYPE_DELETE",
+		3: "EVENT_TYPE_OVERFLOW",
+		4: "EVENT_TYPE_CORRUPT",
+		5: "EVENT_TYPE_GAP",
+		6: "EVENT_TYPE_SYNCED",
+	}
+	EventType_value = map[string]int32{
+		"EVENT_TYPE_UNSPECIFIED": 0,
+		"EVENT_TYPE_SET":         1,
+		"EVENT_TYPE_DELETE":      2,
+		"EVENT_TYPE_OVERFLOW":    3,
+		"EVENT_TYPE_CORRUPT":     4,
+		"EVENT_TYPE_GAP":         5,
+		"EVENT_TYPE_SYNCED":      6,
+	}
+)
+
+func (x EventType) Enum() *EventType {
+	p := new(EventType)
+	*p = x
+	return p
+}
+
+func (x EventType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (EventType) Descriptor() protoreflect.EnumDescriptor {
+	return file_kvs_v1_kvs_proto_enumTypes[0].Descriptor()
+}
+
+func (EventType) Type() protoreflect.EnumType {
+	return &file_kvs_v1_kvs_proto_enumTypes[0]
+}
+
+func (x EventType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use EventType.Descriptor instead.
+func (EventType) EnumDescriptor() ([]byte, []int) {
+	return file_kvs_v1_kvs_proto_rawDescGZIP(), []int{0}
+}
+
+type GetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRequest) Reset() {
+	*x = GetRequest{}
+	mi := &file_kvs_v1_kvs_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRequest) ProtoMessage() {}
+
+func (x *GetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kvs_v1_kvs_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
+func (*GetRequest) Descriptor() ([]byte, []int) {
+	return file_kvs_v1_kvs_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GetRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type GetResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Value         []byte                 `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetResponse) Reset() {
+	*x = GetResponse{}
+	mi := &file_kvs_v1_kvs_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetResponse) ProtoMessage() {}
+
+func (x *GetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kvs_v1_kvs_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetResponse.ProtoReflect.Descriptor instead.
+func (*GetResponse) Descriptor() ([]byte, []int) {
+	return file_kvs_v1_kvs_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetResponse) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+type SetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	Value         []byte                 `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetRequest) Reset() {
+	*x = SetRequest{}
+	mi := &file_kvs_v1_kvs_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetRequest) ProtoMessage() {}
+
+func (x *SetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kvs_v1_kvs_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetRequest.ProtoReflect.Descriptor instead.
+func (*SetRequest) Descriptor() ([]byte, []int) {
+	return file_kvs_v1_kvs_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *SetRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *SetRequest) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+type SetResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetResponse) Reset() {
+	*x = SetResponse{}
+	mi := &file_kvs_v1_kvs_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetResponse) ProtoMessage() {}
+
+func (x *SetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kvs_v1_kvs_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetResponse.ProtoReflect.Descriptor instead.
+func (*SetResponse) Descriptor() ([]byte, []int) {
+	return file_kvs_v1_kvs_proto_rawDescGZIP(), []int{3}
+}
+
+type DeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	mi := &file_kvs_v1_kvs_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kvs_v1_kvs_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_kvs_v1_kvs_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DeleteRequest) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type DeleteResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteResponse) Reset() {
+	*x = DeleteResponse{}
+	mi := &file_kvs_v1_kvs_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteResponse) ProtoMessage() {}
+
+func (x *DeleteResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kvs_v1_kvs_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteResponse.ProtoReflect.Descriptor instead.
+func (*DeleteResponse) Descriptor() ([]byte, []int) {
+	return file_kvs_v1_kvs_proto_rawDescGZIP(), []int{5}
+}
+
+type BatchSetResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// count is the number of pairs that were set.
+	Count         int64 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchSetResponse) Reset() {
+	*x = BatchSetResponse{}
+	mi := &file_kvs_v1_kvs_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchSetResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchSetResponse) ProtoMessage() {}
+
+func (x *BatchSetResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kvs_v1_kvs_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchSetResponse.ProtoReflect.Descriptor instead.
+func (*BatchSetResponse) Descriptor() ([]byte, []int) {
+	return file_kvs_v1_kvs_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BatchSetResponse) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type KeysRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// prefix restricts the result to keys starting with it.
+	Prefix        string `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeysRequest) Reset() {
+	*x = KeysRequest{}
+	mi := &file_kvs_v1_kvs_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeysRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeysRequest) ProtoMessage() {}
+
+func (x *KeysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kvs_v1_kvs_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeysRequest.ProtoReflect.Descriptor instead.
+func (*KeysRequest) Descriptor() ([]byte, []int) {
+	return file_kvs_v1_kvs_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *KeysRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+type KeysResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Keys          []string               `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *KeysResponse) Reset() {
+	*x = KeysResponse{}
+	mi := &file_kvs_v1_kvs_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeysResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeysResponse) ProtoMessage() {}
+
+func (x *KeysResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kvs_v1_kvs_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeysResponse.ProtoReflect.Descriptor instead.
+func (*KeysResponse) Descriptor() ([]byte, []int) {
+	return file_kvs_v1_kvs_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *KeysResponse) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type WatchRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// prefix restricts the events to keys starting with it.
+	Prefix string `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// buffer is the number of events the server buffers before the watch overflows.
+	// Zero selects the server default.
+	Buffer        int32 `protobuf:"varint,2,opt,name=buffer,proto3" json:"buffer,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchRequest) Reset() {
+	*x = WatchRequest{}
+	mi := &file_kvs_v1_kvs_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchRequest) ProtoMessage() {}
+
+func (x *WatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kvs_v1_kvs_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchRequest.ProtoReflect.Descriptor instead.
+func (*WatchRequest) Descriptor() ([]byte, []int) {
+	return file_kvs_v1_kvs_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *WatchRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *WatchRequest) GetBuffer() int32 {
+	if x != nil {
+		return x.Buffer
+	}
+	return 0
+}
+
+type SyncRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// prefix restricts the keys and events to keys starting with it.
+	Prefix string `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// buffer is the number of events the server buffers before the sync overflows.
+	// Zero selects the server default.
+	Buffer        int32 `protobuf:"varint,2,opt,name=buffer,proto3" json:"buffer,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SyncRequest) Reset() {
+	*x = SyncRequest{}
+	mi := &file_kvs_v1_kvs_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SyncRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SyncRequest) ProtoMessage() {}
+
+func (x *SyncRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kvs_v1_kvs_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SyncRequest.ProtoReflect.Descriptor instead.
+func (*SyncRequest) Descriptor() ([]byte, []int) {
+	return file_kvs_v1_kvs_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *SyncRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *SyncRequest) GetBuffer() int32 {
+	if x != nil {
+		return x.Buffer
+	}
+	return 0
+}
+
+type ExportBucketRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// bucket is the path of the bucket: its name and the names of its parents
+	// joined by slashes.
+	Bucket        string `protobuf:"bytes,1,opt,name=bucket,proto3" json:"bucket,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportBucketRequest) Reset() {
+	*x = ExportBucketRequest{}
+	mi := &file_kvs_v1_kvs_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportBucketRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportBucketRequest) ProtoMessage() {}
+
+func (x *ExportBucketRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kvs_v1_kvs_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportBucketRequest.ProtoReflect.Descriptor instead.
+func (*ExportBucketRequest) Descriptor() ([]byte, []int) {
+	return file_kvs_v1_kvs_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ExportBucketRequest) GetBucket() string {
+	if x != nil {
+		return x.Bucket
+	}
+	return ""
+}
+
+type BucketChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// bucket is the path of the bucket to import into; only read from the first
+	// chunk of an import.
+	Bucket string `protobuf:"bytes,1,opt,name=bucket,proto3" json:"bucket,omitempty"`
+	Data   []byte `protobuf:"bytes,2,opt,name=data,proto3" json:"data,omitempty"`
+	// dry_run reports what an import would change without changing the store;
+	// only read from the first chunk of an import.
+	DryRun        bool `protobuf:"varint,3,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BucketChunk) Reset() {
+	*x = BucketChunk{}
+	mi := &file_kvs_v1_kvs_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BucketChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BucketChunk) ProtoMessage() {}
+
+func (x *BucketChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_kvs_v1_kvs_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BucketChunk.ProtoReflect.Descriptor instead.
+func (*BucketChunk) Descriptor() ([]byte, []int) {
+	return file_kvs_v1_kvs_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *BucketChunk) GetBucket() string {
+	if x != nil {
+		return x.Bucket
+	}
+	return ""
+}
+
+func (x *BucketChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *BucketChunk) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+type ImportBucketResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// created is the number of keys that did not exist before.
+	Created int64 `protobuf:"varint,1,opt,name=created,proto3" json:"created,omitempty"`
+	// overwritten is the number of existing keys that were replaced.
+	Overwritten   int64 `protobuf:"varint,2,opt,name=overwritten,proto3" json:"overwritten,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportBucketResponse) Reset() {
+	*x = ImportBucketResponse{}
+	mi := &file_kvs_v1_kvs_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportBucketResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportBucketResponse) ProtoMessage() {}
+
+func (x *ImportBucketResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kvs_v1_kvs_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportBucketResponse.ProtoReflect.Descriptor instead.
+func (*ImportBucketResponse) Descriptor() ([]byte, []int) {
+	return file_kvs_v1_kvs_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ImportBucketResponse) GetCreated() int64 {
+	if x != nil {
+		return x.Created
+	}
+	return 0
+}
+
+func (x *ImportBucketResponse) GetOverwritten() int64 {
+	if x != nil {
+		return x.Overwritten
+	}
+	return 0
+}
+
+type WatchResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  EventType              `protobuf:"varint,1,opt,name=type,proto3,enum=kvs.v1.EventType" json:"type,omitempty"`
+	Key   string                 `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	// event is the change in the versioned event schema.
+	Event         *ChangeEvent `protobuf:"bytes,3,opt,name=event,proto3" json:"event,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchResponse) Reset() {
+	*x = WatchResponse{}
+	mi := &file_kvs_v1_kvs_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchResponse) ProtoMessage() {}
+
+func (x *WatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kvs_v1_kvs_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchResponse.ProtoReflect.Descriptor instead.
+func (*WatchResponse) Descriptor() ([]byte, []int) {
+	return file_kvs_v1_kvs_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *WatchResponse) GetType() EventType {
+	if x != nil {
+		return x.Type
+	}
+	return EventType_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *WatchResponse) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *WatchResponse) GetEvent() *ChangeEvent {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+// ChangeEvent is the versioned schema of a change delivered to external
+// consumers. Fields are only ever added, and schema is incremented when the
+// meaning of an existing field changes.
+type ChangeEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// schema is the version of the schema the event follows.
+	Schema uint32    `protobuf:"varint,1,opt,name=schema,proto3" json:"schema,omitempty"`
+	Op     EventType `protobuf:"varint,2,opt,name=op,proto3,enum=kvs.v1.EventType" json:"op,omitempty"`
+	Key    string    `protobuf:"bytes,3,opt,name=key,proto3" json:"key,omitempty"`
+	// bucket is the bucket the key belongs to, or empty for the root keyspace.
+	Bucket string `protobuf:"bytes,4,opt,name=bucket,proto3" json:"bucket,omitempty"`
+	// seq is the store revision of the change, or zero if the event is not a
+	// recorded change.
+	Seq       int64                  `protobuf:"varint,5,opt,name=seq,proto3" json:"seq,omitempty"`
+	Timestamp *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	// value is the encoded value after the change, if the producer includes it.
+	Value         []byte `protobuf:"bytes,7,opt,name=value,proto3,oneof" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChangeEvent) Reset() {
+	*x = ChangeEvent{}
+	mi := &file_kvs_v1_kvs_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChangeEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChangeEvent) ProtoMessage() {}
+
+func (x *ChangeEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_kvs_v1_kvs_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChangeEvent.ProtoReflect.Descriptor instead.
+func (*ChangeEvent) Descriptor() ([]byte, []int) {
+	return file_kvs_v1_kvs_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ChangeEvent) GetSchema() uint32 {
+	if x != nil {
+		return x.Schema
+	}
+	return 0
+}
+
+func (x *ChangeEvent) GetOp() EventType {
+	if x != nil {
+		return x.Op
+	}
+	return EventType_EVENT_TYPE_UNSPECIFIED
+}
+
+func (x *ChangeEvent) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *ChangeEvent) GetBucket() string {
+	if x != nil {
+		return x.Bucket
+	}
+	return ""
+}
+
+func (x *ChangeEvent) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *ChangeEvent) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *ChangeEvent) GetValue() []byte {
+	if x != nil {
+		return x.Value
+	}
+	return nil
+}
+
+var File_kvs_v1_kvs_proto protoreflect.FileDescriptor
+
+const file_kvs_v1_kvs_proto_rawDesc = "" +
+	"\n" +
+	"\x10kvs/v1/kvs.proto\x12\x06kvs.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x1e\n" +
+	"\n" +
+	"GetRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"#\n" +
+	"\vGetResponse\x12\x14\n" +
+	"\x05value\x18\x01 \x01(\fR\x05value\"4\n" +
+	"\n" +
+	"SetRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value\"\r\n" +
+	"\vSetResponse\"!\n" +
+	"\rDeleteRequest\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\"\x10\n" +
+	"\x0eDeleteResponse\"(\n" +
+	"\x10BatchSetResponse\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x03R\x05count\"%\n" +
+	"\vKeysRequest\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\"\"\n" +
+	"\fKeysResponse\x12\x12\n" +
+	"\x04keys\x18\x01 \x03(\tR\x04keys\">\n" +
+	"\fWatchRequest\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\x12\x16\n" +
+	"\x06buffer\x18\x02 \x01(\x05R\x06buffer\"=\n" +
+	"\vSyncRequest\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\x12\x16\n" +
+	"\x06buffer\x18\x02 \x01(\x05R\x06buffer\"-\n" +
+	"\x13ExportBucketRequest\x12\x16\n" +
+	"\x06bucket\x18\x01 \x01(\tR\x06bucket\"R\n" +
+	"\vBucketChunk\x12\x16\n" +
+	"\x06bucket\x18\x01 \x01(\tR\x06bucket\x12\x12\n" +
+	"\x04data\x18\x02 \x01(\fR\x04data\x12\x17\n" +
+	"\adry_run\x18\x03 \x01(\bR\x06dryRun\"R\n" +
+	"\x14ImportBucketResponse\x12\x18\n" +
+	"\acreated\x18\x01 \x01(\x03R\acreated\x12 \n" +
+	"\voverwritten\x18\x02 \x01(\x03R\voverwritten\"s\n" +
+	"\rWatchResponse\x12%\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x11.kvs.v1.EventTypeR\x04type\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\x12)\n" +
+	"\x05event\x18\x03 \x01(\v2\x13.kvs.v1.ChangeEventR\x05event\"\xe3\x01\n" +
+	"\vChangeEvent\x12\x16\n" +
+	"\x06schema\x18\x01 \x01(\rR\x06schema\x12!\n" +
+	"\x02op\x18\x02 \x01(\x0e2\x11.kvs.v1.EventTypeR\x02op\x12\x10\n" +
+	"\x03key\x18\x03 \x01(\tR\x03key\x12\x16\n" +
+	"\x06bucket\x18\x04 \x01(\tR\x06bucket\x12\x10\n" +
+	"\x03seq\x18\x05 \x01(\x03R\x03seq\x128\n" +
+	"\ttimestamp\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x12\x19\n" +
+	"\x05value\x18\a \x01(\fH\x00R\x05value\x88\x01\x01B\b\n" +
+	"\x06_value*\xae\x01\n" +
+	"\tEventType\x12\x1a\n" +
+	"\x16EVENT_TYPE_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eEVENT_TYPE_SET\x10\x01\x12\x15\n" +
+	"\x11EVENT_TYPE_DELETE\x10\x02\x12\x17\n" +
+	"\x13EVENT_TYPE_OVERFLOW\x10\x03\x12\x16\n" +
+	"\x12EVENT_TYPE_CORRUPT\x10\x04\x12\x12\n" +
+	"\x0eEVENT_TYPE_GAP\x10\x05\x12\x15\n" +
+	"\x11EVENT_TYPE_SYNCED\x10\x062\x84\x04\n" +
+	"\x03KVS\x12.\n" +
+	"\x03Get\x12\x12.kvs.v1.GetRequest\x1a\x13.kvs.v1.GetResponse\x12.\n" +
+	"\x03Set\x12\x12.kvs.v1.SetRequest\x1a\x13.kvs.v1.SetResponse\x127\n" +
+	"\x06Delete\x12\x15.kvs.v1.DeleteRequest\x1a\x16.kvs.v1.DeleteResponse\x12:\n" +
+	"\bBatchSet\x12\x12.kvs.v1.SetRequest\x1a\x18.kvs.v1.BatchSetResponse(\x01\x123\n" +
+	"\x04Keys\x12\x13.kvs.v1.KeysRequest\x1a\x14.kvs.v1.KeysResponse0\x01\x126\n" +
+	"\x05Watch\x12\x14.kvs.v1.WatchRequest\x1a\x15.kvs.v1.WatchResponse0\x01\x122\n" +
+	"\x04Sync\x12\x13.kvs.v1.SyncRequest\x1a\x13.kvs.v1.ChangeEvent0\x01\x12B\n" +
+	"\fExportBucket\x12\x1b.kvs.v1.ExportBucketRequest\x1a\x13.kvs.v1.BucketChunk0\x01\x12C\n" +
+	"\fImportBucket\x12\x13.kvs.v1.BucketChunk\x1a\x1c.kvs.v1.ImportBucketResponse(\x01B\x1bZ\x19github.com/bay0/kvs/kvspbb\x06proto3"
+
+var (
+	file_kvs_v1_kvs_proto_rawDescOnce sync.Once
+	file_kvs_v1_kvs_proto_rawDescData []byte
+)
+
+func file_kvs_v1_kvs_proto_rawDescGZIP() []byte {
+	file_kvs_v1_kvs_proto_rawDescOnce.Do(func() {
+		file_kvs_v1_kvs_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_kvs_v1_kvs_proto_rawDesc), len(file_kvs_v1_kvs_proto_rawDesc)))
+	})
+	return file_kvs_v1_kvs_proto_rawDescData
+}
+
+var file_kvs_v1_kvs_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_kvs_v1_kvs_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
+var file_kvs_v1_kvs_proto_goTypes = []any{
+	(EventType)(0),                // 0: kvs.v1.EventType
+	(*GetRequest)(nil),            // 1: kvs.v1.GetRequest
+	(*GetResponse)(nil),           // 2: kvs.v1.GetResponse
+	(*SetRequest)(nil),            // 3: kvs.v1.SetRequest
+	(*SetResponse)(nil),           // 4: kvs.v1.SetResponse
+	(*DeleteRequest)(nil),         // 5: kvs.v1.DeleteRequest
+	(*DeleteResponse)(nil),        // 6: kvs.v1.DeleteResponse
+	(*BatchSetResponse)(nil),      // 7: kvs.v1.BatchSetResponse
+	(*KeysRequest)(nil),           // 8: kvs.v1.KeysRequest
+	(*KeysResponse)(nil),          // 9: kvs.v1.KeysResponse
+	(*WatchRequest)(nil),          // 10: kvs.v1.WatchRequest
+	(*SyncRequest)(nil),           // 11: kvs.v1.SyncRequest
+	(*ExportBucketRequest)(nil),   // 12: kvs.v1.ExportBucketRequest
+	(*BucketChunk)(nil),           // 13: kvs.v1.BucketChunk
+	(*ImportBucketResponse)(nil),  // 14: kvs.v1.ImportBucketResponse
+	(*WatchResponse)(nil),         // 15: kvs.v1.WatchResponse
+	(*ChangeEvent)(nil),           // 16: kvs.v1.ChangeEvent
+	(*timestamppb.Timestamp)(nil), // 17: google.protobuf.Timestamp
+}
+var file_kvs_v1_kvs_proto_depIdxs = []int32{
+	0,  // 0: kvs.v1.WatchResponse.type:type_name -> kvs.v1.EventType
+	16, // 1: kvs.v1.WatchResponse.event:type_name -> kvs.v1.ChangeEvent
+	0,  // 2: kvs.v1.ChangeEvent.op:type_name -> kvs.v1.EventType
+	17, // 3: kvs.v1.ChangeEvent.timestamp:type_name -> google.protobuf.Timestamp
+	1,  // 4: kvs.v1.KVS.Get:input_type -> kvs.v1.GetRequest
+	3,  // 5: kvs.v1.KVS.Set:input_type -> kvs.v1.SetRequest
+	5,  // 6: kvs.v1.KVS.Delete:input_type -> kvs.v1.DeleteRequest
+	3,  // 7: kvs.v1.KVS.BatchSet:input_type -> kvs.v1.SetRequest
+	8,  // 8: kvs.v1.KVS.Keys:input_type -> kvs.v1.KeysRequest
+	10, // 9: kvs.v1.KVS.Watch:input_type -> kvs.v1.WatchRequest
+	11, // 10: kvs.v1.KVS.Sync:input_type -> kvs.v1.SyncRequest
+	12, // 11: kvs.v1.KVS.ExportBucket:input_type -> kvs.v1.ExportBucketRequest
+	13, // 12: kvs.v1.KVS.ImportBucket:input_type -> kvs.v1.BucketChunk
+	2,  // 13: kvs.v1.KVS.Get:output_type -> kvs.v1.GetResponse
+	4,  // 14: kvs.v1.KVS.Set:output_type -> kvs.v1.SetResponse
+	6,  // 15: kvs.v1.KVS.Delete:output_type -> kvs.v1.DeleteResponse
+	7,  // 16: kvs.v1.KVS.BatchSet:output_type -> kvs.v1.BatchSetResponse
+	9,  // 17: kvs.v1.KVS.Keys:output_type -> kvs.v1.KeysResponse
+	15, // 18: kvs.v1.KVS.Watch:output_type -> kvs.v1.WatchResponse
+	16, // 19: kvs.v1.KVS.Sync:output_type -> kvs.v1.ChangeEvent
+	13, // 20: kvs.v1.KVS.ExportBucket:output_type -> kvs.v1.BucketChunk
+	14, // 21: kvs.v1.KVS.ImportBucket:output_type -> kvs.v1.ImportBucketResponse
+	13, // [13:22] is the sub-list for method output_type
+	4,  // [4:13] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_kvs_v1_kvs_proto_init() }
+func file_kvs_v1_kvs_proto_init() {
+	if File_kvs_v1_kvs_proto != nil {
+		return
+	}
+	file_kvs_v1_kvs_proto_msgTypes[15].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_kvs_v1_kvs_proto_rawDesc), len(file_kvs_v1_kvs_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   16,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_kvs_v1_kvs_proto_goTypes,
+		DependencyIndexes: file_kvs_v1_kvs_proto_depIdxs,
+		EnumInfos:         file_kvs_v1_kvs_proto_enumTypes,
+		MessageInfos:      file_kvs_v1_kvs_proto_msgTypes,
+	}.Build()
+	File_kvs_v1_kvs_proto = out.File
+	file_kvs_v1_kvs_proto_goTypes = nil
+	file_kvs_v1_kvs_proto_depIdxs = nil
+}
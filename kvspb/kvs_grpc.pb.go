@@ -0,0 +1,462 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: kvs/v1/kvs.proto
+
+package kvspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	KVS_Get_FullMethodName          = "/kvs.v1.KVS/Get"
+	KVS_Set_FullMethodName          = "/kvs.v1.KVS/Set"
+	KVS_Delete_FullMethodName       = "/kvs.v1.KVS/Delete"
+	KVS_BatchSet_FullMethodName     = "/kvs.v1.KVS/BatchSet"
+	KVS_Keys_FullMethodName         = "/kvs.v1.KVS/Keys"
+	KVS_Watch_FullMethodName        = "/kvs.v1.KVS/Watch"
+	KVS_Sync_FullMethodName         = "/kvs.v1.KVS/Sync"
+	KVS_ExportBucket_FullMethodName = "/kvs.v1.KVS/ExportBucket"
+	KVS_ImportBucket_FullMethodName = "/kvs.v1.KVS/ImportBucket"
+)
+
+// KVSClient is the client API for KVS service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// KVS exposes a key-value store over gRPC.
+type KVSClient interface {
+	// Get retrieves the value associated with a key.
+	// It fails with NOT_FOUND if the key does not exist.
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
+	// Set adds or updates a key-value pair.
+	Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*SetResponse, error)
+	// Delete removes a key-value pair.
+	// It fails with NOT_FOUND if the key does not exist.
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	// BatchSet streams key-value pairs to the server, which sets them as they arrive.
+	BatchSet(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[SetRequest, BatchSetResponse], error)
+	// Keys streams the keys of the store in chunks.
+	Keys(ctx context.Context, in *KeysRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[KeysResponse], error)
+	// Watch streams the changes of keys starting with a prefix. If the client falls
+	// behind, the stream ends with an EVENT_TYPE_OVERFLOW event.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchResponse], error)
+	// Sync streams every key starting with a prefix with its value, then an
+	// EVENT_TYPE_SYNCED event, and then the changes of those keys with the values
+	// of sets. If the client falls behind, the stream ends with an
+	// EVENT_TYPE_OVERFLOW event.
+	Sync(ctx context.Context, in *SyncRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChangeEvent], error)
+	// ExportBucket streams the entries of a bucket and its nested buckets in the
+	// snapshot format, split into chunks.
+	ExportBucket(ctx context.Context, in *ExportBucketRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BucketChunk], error)
+	// ImportBucket loads an export streamed in chunks into a bucket. The bucket
+	// and dry_run are taken from the first chunk.
+	ImportBucket(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[BucketChunk, ImportBucketResponse], error)
+}
+
+type kVSClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewKVSClient(cc grpc.ClientConnInterface) KVSClient {
+	return &kVSClient{cc}
+}
+
+func (c *kVSClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetResponse)
+	err := c.cc.Invoke(ctx, KVS_Get_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVSClient) Set(ctx context.Context, in *SetRequest, opts ...grpc.CallOption) (*SetResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetResponse)
+	err := c.cc.Invoke(ctx, KVS_Set_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVSClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteResponse)
+	err := c.cc.Invoke(ctx, KVS_Delete_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVSClient) BatchSet(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[SetRequest, BatchSetResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &KVS_ServiceDesc.Streams[0], KVS_BatchSet_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SetRequest, BatchSetResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KVS_BatchSetClient = grpc.ClientStreamingClient[SetRequest, BatchSetResponse]
+
+func (c *kVSClient) Keys(ctx context.Context, in *KeysRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[KeysResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &KVS_ServiceDesc.Streams[1], KVS_Keys_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[KeysRequest, KeysResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KVS_KeysClient = grpc.ServerStreamingClient[KeysResponse]
+
+func (c *kVSClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &KVS_ServiceDesc.Streams[2], KVS_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, WatchResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KVS_WatchClient = grpc.ServerStreamingClient[WatchResponse]
+
+func (c *kVSClient) Sync(ctx context.Context, in *SyncRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChangeEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &KVS_ServiceDesc.Streams[3], KVS_Sync_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[SyncRequest, ChangeEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KVS_SyncClient = grpc.ServerStreamingClient[ChangeEvent]
+
+func (c *kVSClient) ExportBucket(ctx context.Context, in *ExportBucketRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[BucketChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &KVS_ServiceDesc.Streams[4], KVS_ExportBucket_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportBucketRequest, BucketChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KVS_ExportBucketClient = grpc.ServerStreamingClient[BucketChunk]
+
+func (c *kVSClient) ImportBucket(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[BucketChunk, ImportBucketResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &KVS_ServiceDesc.Streams[5], KVS_ImportBucket_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[BucketChunk, ImportBucketResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KVS_ImportBucketClient = grpc.ClientStreamingClient[BucketChunk, ImportBucketResponse]
+
+// KVSServer is the server API for KVS service.
+// All implementations must embed UnimplementedKVSServer
+// for forward compatibility.
+//
+// KVS exposes a key-value store over gRPC.
+type KVSServer interface {
+	// Get retrieves the value associated with a key.
+	// It fails with NOT_FOUND if the key does not exist.
+	Get(context.Context, *GetRequest) (*GetResponse, error)
+	// Set adds or updates a key-value pair.
+	Set(context.Context, *SetRequest) (*SetResponse, error)
+	// Delete removes a key-value pair.
+	// It fails with NOT_FOUND if the key does not exist.
+	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	// BatchSet streams key-value pairs to the server, which sets them as they arrive.
+	BatchSet(grpc.ClientStreamingServer[SetRequest, BatchSetResponse]) error
+	// Keys streams the keys of the store in chunks.
+	Keys(*KeysRequest, grpc.ServerStreamingServer[KeysResponse]) error
+	// Watch streams the changes of keys starting with a prefix. If the client falls
+	// behind, the stream ends with an EVENT_TYPE_OVERFLOW event.
+	Watch(*WatchRequest, grpc.ServerStreamingServer[WatchResponse]) error
+	// Sync streams every key starting with a prefix with its value, then an
+	// EVENT_TYPE_SYNCED event, and then the changes of those keys with the values
+	// of sets. If the client falls behind, the stream ends with an
+	// EVENT_TYPE_OVERFLOW event.
+	Sync(*SyncRequest, grpc.ServerStreamingServer[ChangeEvent]) error
+	// ExportBucket streams the entries of a bucket and its nested buckets in the
+	// snapshot format, split into chunks.
+	ExportBucket(*ExportBucketRequest, grpc.ServerStreamingServer[BucketChunk]) error
+	// ImportBucket loads an export streamed in chunks into a bucket. The bucket
+	// and dry_run are taken from the first chunk.
+	ImportBucket(grpc.ClientStreamingServer[BucketChunk, ImportBucketResponse]) error
+	mustEmbedUnimplementedKVSServer()
+}
+
+// UnimplementedKVSServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedKVSServer struct{}
+
+func (UnimplementedKVSServer) Get(context.Context, *GetRequest) (*GetResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedKVSServer) Set(context.Context, *SetRequest) (*SetResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Set not implemented")
+}
+func (UnimplementedKVSServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedKVSServer) BatchSet(grpc.ClientStreamingServer[SetRequest, BatchSetResponse]) error {
+	return status.Error(codes.Unimplemented, "method BatchSet not implemented")
+}
+func (UnimplementedKVSServer) Keys(*KeysRequest, grpc.ServerStreamingServer[KeysResponse]) error {
+	return status.Error(codes.Unimplemented, "method Keys not implemented")
+}
+func (UnimplementedKVSServer) Watch(*WatchRequest, grpc.ServerStreamingServer[WatchResponse]) error {
+	return status.Error(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedKVSServer) Sync(*SyncRequest, grpc.ServerStreamingServer[ChangeEvent]) error {
+	return status.Error(codes.Unimplemented, "method Sync not implemented")
+}
+func (UnimplementedKVSServer) ExportBucket(*ExportBucketRequest, grpc.ServerStreamingServer[BucketChunk]) error {
+	return status.Error(codes.Unimplemented, "method ExportBucket not implemented")
+}
+func (UnimplementedKVSServer) ImportBucket(grpc.ClientStreamingServer[BucketChunk, ImportBucketResponse]) error {
+	return status.Error(codes.Unimplemented, "method ImportBucket not implemented")
+}
+func (UnimplementedKVSServer) mustEmbedUnimplementedKVSServer() {}
+func (UnimplementedKVSServer) testEmbeddedByValue()             {}
+
+// UnsafeKVSServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to KVSServer will
+// result in compilation errors.
+type UnsafeKVSServer interface {
+	mustEmbedUnimplementedKVSServer()
+}
+
+func RegisterKVSServer(s grpc.ServiceRegistrar, srv KVSServer) {
+	// If the following call panics, it indicates UnimplementedKVSServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&KVS_ServiceDesc, srv)
+}
+
+func _KVS_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVSServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVS_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVSServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVS_Set_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVSServer).Set(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVS_Set_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVSServer).Set(ctx, req.(*SetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVS_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVSServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: KVS_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVSServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVS_BatchSet_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(KVSServer).BatchSet(&grpc.GenericServerStream[SetRequest, BatchSetResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KVS_BatchSetServer = grpc.ClientStreamingServer[SetRequest, BatchSetResponse]
+
+func _KVS_Keys_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(KeysRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KVSServer).Keys(m, &grpc.GenericServerStream[KeysRequest, KeysResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KVS_KeysServer = grpc.ServerStreamingServer[KeysResponse]
+
+func _KVS_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KVSServer).Watch(m, &grpc.GenericServerStream[WatchRequest, WatchResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KVS_WatchServer = grpc.ServerStreamingServer[WatchResponse]
+
+func _KVS_Sync_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SyncRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KVSServer).Sync(m, &grpc.GenericServerStream[SyncRequest, ChangeEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KVS_SyncServer = grpc.ServerStreamingServer[ChangeEvent]
+
+func _KVS_ExportBucket_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportBucketRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KVSServer).ExportBucket(m, &grpc.GenericServerStream[ExportBucketRequest, BucketChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KVS_ExportBucketServer = grpc.ServerStreamingServer[BucketChunk]
+
+func _KVS_ImportBucket_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(KVSServer).ImportBucket(&grpc.GenericServerStream[BucketChunk, ImportBucketResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type KVS_ImportBucketServer = grpc.ClientStreamingServer[BucketChunk, ImportBucketResponse]
+
+// KVS_ServiceDesc is the grpc.ServiceDesc for KVS service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var KVS_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "kvs.v1.KVS",
+	HandlerType: (*KVSServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Get",
+			Handler:    _KVS_Get_Handler,
+		},
+		{
+			MethodName: "Set",
+			Handler:    _KVS_Set_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _KVS_Delete_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "BatchSet",
+			Handler:       _KVS_BatchSet_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Keys",
+			Handler:       _KVS_Keys_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Watch",
+			Handler:       _KVS_Watch_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Sync",
+			Handler:       _KVS_Sync_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ExportBucket",
+			Handler:       _KVS_ExportBucket_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ImportBucket",
+			Handler:       _KVS_ImportBucket_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "kvs/v1/kvs.proto",
+}
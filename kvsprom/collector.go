@@ -0,0 +1,164 @@
+// Package kvsprom exposes the metrics of a kvs store to Prometheus.
+//
+// A Collector is registered with the store as an observer and with a
+// Prometheus registry as a collector:
+//
+//	c := kvsprom.NewCollector()
+//	store, err := kvs.NewKeyValueStore(16, kvs.WithObserver(c))
+//	c.Track(store)
+//	prometheus.MustRegister(c)
+package kvsprom
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/bay0/kvs"
+)
+
+// ShardSource is implemented by stores whose shards can be inspected by a Collector.
+// *kvs.KeyValueStore implements it.
+type ShardSource interface {
+	ShardSizes() []int
+	ShardBytes() []int64
+}
+
+// Option configures a Collector.
+type Option func(*Collector)
+
+// WithNamespace sets the namespace prefixed to every metric name. The default is "kvs".
+func WithNamespace(ns string) Option {
+	return func(c *Collector) {
+		c.namespace = ns
+	}
+}
+
+// WithBuckets sets the buckets of the operation latency histogram, in seconds.
+// The default is prometheus.DefBuckets.
+func WithBuckets(buckets []float64) Option {
+	return func(c *Collector) {
+		c.buckets = buckets
+	}
+}
+
+// Collector is a prometheus.Collector and kvs.Observer that counts store
+// operations, records their latency and reports the size of every shard.
+type Collector struct {
+	namespace string
+	buckets   []float64
+
+	ops     *prometheus.CounterVec
+	misses  prometheus.Counter
+	latency *prometheus.HistogramVec
+	entries *prometheus.Desc
+	bytes   *prometheus.Desc
+
+	mu    sync.RWMutex
+	store ShardSource
+}
+
+var (
+	_ prometheus.Collector = (*Collector)(nil)
+	_ kvs.Observer         = (*Collector)(nil)
+)
+
+// NewCollector creates a Collector. Pass it to kvs.WithObserver to count operations
+// and to Track to report shard sizes.
+func NewCollector(opts ...Option) *Collector {
+	c := &Collector{
+		namespace: "kvs",
+		buckets:   prometheus.DefBuckets,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.ops = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: c.namespace,
+		Name:      "operations_total",
+		Help:      "Number of store operations by operation and result.",
+	}, []string{"op", "result"})
+	c.misses = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: c.namespace,
+		Name:      "misses_total",
+		Help:      "Number of gets for keys that were not found.",
+	})
+	c.latency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: c.namespace,
+		Name:      "operation_duration_seconds",
+		Help:      "Latency of store operations, including the time spent waiting for the shard lock.",
+		Buckets:   c.buckets,
+	}, []string{"op"})
+	c.entries = prometheus.NewDesc(
+		prometheus.BuildFQName(c.namespace, "", "shard_entries"),
+		"Number of entries in a shard.",
+		[]string{"shard"}, nil,
+	)
+	c.bytes = prometheus.NewDesc(
+		prometheus.BuildFQName(c.namespace, "", "shard_bytes"),
+		"Estimated number of bytes held by a shard.",
+		[]string{"shard"}, nil,
+	)
+
+	return c
+}
+
+// Track sets the store whose shard sizes are reported on every collection.
+func (c *Collector) Track(store ShardSource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.store = store
+}
+
+// ObserveOp counts an operation and records its latency.
+func (c *Collector) ObserveOp(op kvs.Op, _ string, d time.Duration, err error) {
+	result := "ok"
+	switch {
+	case err == nil:
+	case errors.Is(err, kvs.ErrNotFound):
+		result = "not_found"
+		if op == kvs.OpGet {
+			c.misses.Inc()
+		}
+	default:
+		result = "error"
+	}
+
+	c.ops.WithLabelValues(string(op), result).Inc()
+	c.latency.WithLabelValues(string(op)).Observe(d.Seconds())
+}
+
+// Describe sends the descriptors of the collector's metrics to ch.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.ops.Describe(ch)
+	c.misses.Describe(ch)
+	c.latency.Describe(ch)
+	ch <- c.entries
+	ch <- c.bytes
+}
+
+// Collect sends the current values of the collector's metrics to ch.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.ops.Collect(ch)
+	c.misses.Collect(ch)
+	c.latency.Collect(ch)
+
+	c.mu.RLock()
+	store := c.store
+	c.mu.RUnlock()
+	if store == nil {
+		return
+	}
+
+	for i, n := range store.ShardSizes() {
+		ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(n), strconv.Itoa(i))
+	}
+	for i, n := range store.ShardBytes() {
+		ch <- prometheus.MustNewConstMetric(c.bytes, prometheus.GaugeValue, float64(n), strconv.Itoa(i))
+	}
+}
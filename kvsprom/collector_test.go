@@ -0,0 +1,56 @@
+package kvsprom
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/bay0/kvs"
+)
+
+func TestCollector(t *testing.T) {
+	c := NewCollector()
+	store, err := kvs.NewKeyValueStore(2, kvs.WithObserver(c))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	c.Track(store)
+
+	if err := store.Set("a", kvs.Bytes("hello")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if _, err := store.Get("a"); err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+	if _, err := store.Get("missing"); err != kvs.ErrNotFound {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(c); err != nil {
+		t.Fatalf("Register returned an error: %v", err)
+	}
+
+	expected := `
+# HELP kvs_misses_total Number of gets for keys that were not found.
+# TYPE kvs_misses_total counter
+kvs_misses_total 1
+# HELP kvs_operations_total Number of store operations by operation and result.
+# TYPE kvs_operations_total counter
+kvs_operations_total{op="get",result="not_found"} 1
+kvs_operations_total{op="get",result="ok"} 1
+kvs_operations_total{op="set",result="ok"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected), "kvs_misses_total", "kvs_operations_total"); err != nil {
+		t.Error(err)
+	}
+
+	if n := testutil.CollectAndCount(c, "kvs_shard_entries"); n != 2 {
+		t.Errorf("Expected 2 shard_entries series, got %d", n)
+	}
+	if n := testutil.CollectAndCount(c, "kvs_operation_duration_seconds"); n != 2 {
+		t.Errorf("Expected 2 latency series, got %d", n)
+	}
+}
@@ -0,0 +1,121 @@
+package kvs
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// LockStrategy selects how shards synchronize access to their entries.
+type LockStrategy int
+
+const (
+	// LockMutex guards every shard with a sync.RWMutex. It is the default.
+	LockMutex LockStrategy = iota
+	// LockSpin guards every shard with a reader-writer spin lock that spins briefly,
+	// then yields and finally backs off with growing sleeps. Waiting writers block new
+	// readers, so writers are not starved by a steady stream of reads. It suits short
+	// critical sections on machines with spare cores, where parking and waking
+	// goroutines costs more than the wait itself.
+	LockSpin
+)
+
+// WithLockStrategy sets the lock used by every shard.
+func WithLockStrategy(s LockStrategy) Option {
+	return func(o *options) {
+		o.lockStrategy = s
+	}
+}
+
+// rwLocker is the lock of a shard.
+type rwLocker interface {
+	Lock()
+	Unlock()
+	RLock()
+	RUnlock()
+	TryLock() bool
+	TryRLock() bool
+}
+
+// newLock returns a lock for the strategy.
+func newLock(s LockStrategy) rwLocker {
+	if s == LockSpin {
+		return &spinRWMutex{}
+	}
+
+	return &sync.RWMutex{}
+}
+
+const (
+	// spinIterations is the number of busy retries before a waiter starts yielding.
+	spinIterations = 32
+	// yieldIterations is the number of retries that yield the processor before a
+	// waiter starts sleeping.
+	yieldIterations = 64
+	// maxBackoff bounds the sleep between retries.
+	maxBackoff = time.Millisecond
+)
+
+// spinRWMutex is a reader-writer lock that waits by spinning and backing off instead
+// of parking goroutines.
+type spinRWMutex struct {
+	// state is the number of readers holding the lock, or -1 if a writer holds it.
+	state atomic.Int32
+	// writers is the number of writers waiting for the lock.
+	writers atomic.Int32
+}
+
+// Lock acquires the lock for writing.
+func (m *spinRWMutex) Lock() {
+	m.writers.Add(1)
+	for i := 0; !m.state.CompareAndSwap(0, -1); i++ {
+		backoff(i)
+	}
+	m.writers.Add(-1)
+}
+
+// Unlock releases the lock held for writing.
+func (m *spinRWMutex) Unlock() {
+	m.state.Store(0)
+}
+
+// TryLock acquires the lock for writing if it is free and reports whether it did.
+func (m *spinRWMutex) TryLock() bool {
+	return m.state.CompareAndSwap(0, -1)
+}
+
+// RLock acquires the lock for reading.
+func (m *spinRWMutex) RLock() {
+	for i := 0; !m.TryRLock(); i++ {
+		backoff(i)
+	}
+}
+
+// RUnlock releases the lock held for reading.
+func (m *spinRWMutex) RUnlock() {
+	m.state.Add(-1)
+}
+
+// TryRLock acquires the lock for reading if no writer holds or waits for it and
+// reports whether it did.
+func (m *spinRWMutex) TryRLock() bool {
+	if m.writers.Load() > 0 {
+		return false
+	}
+
+	s := m.state.Load()
+	return s >= 0 && m.state.CompareAndSwap(s, s+1)
+}
+
+// backoff waits before the i-th retry of a lock acquisition.
+func backoff(i int) {
+	switch {
+	case i < spinIterations:
+	case i < yieldIterations:
+		runtime.Gosched()
+	default:
+		d := time.Microsecond << min(i-yieldIterations, 10)
+		time.Sleep(min(d, maxBackoff))
+	}
+}
@@ -0,0 +1,125 @@
+package kvs
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSpinRWMutex(t *testing.T) {
+	var m spinRWMutex
+	counter := 0
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				m.Lock()
+				counter++
+				m.Unlock()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				m.RLock()
+				_ = counter
+				m.RUnlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if counter != 8000 {
+		t.Errorf("Expected 8000 increments, got %d", counter)
+	}
+}
+
+func TestSpinRWMutex_WriterBlocksNewReaders(t *testing.T) {
+	var m spinRWMutex
+	m.RLock()
+
+	locked := make(chan struct{})
+	go func() {
+		m.Lock()
+		close(locked)
+		m.Unlock()
+	}()
+
+	for m.writers.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if m.TryRLock() {
+		t.Error("Expected a waiting writer to block new readers")
+	}
+	if m.TryLock() {
+		t.Error("Expected TryLock to fail while a reader holds the lock")
+	}
+
+	m.RUnlock()
+	<-locked
+}
+
+func TestWithLockStrategy(t *testing.T) {
+	store, err := NewKeyValueStore(4, WithLockStrategy(LockSpin))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("key-%d", i)
+			for j := 0; j < 100; j++ {
+				if err := store.Set(key, IntValue(j)); err != nil {
+					t.Errorf("Set returned an error: %v", err)
+				}
+				if _, err := store.Get(key); err != nil {
+					t.Errorf("Get returned an error: %v", err)
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if keys, _ := store.Keys(); len(keys) != 16 {
+		t.Errorf("Expected 16 keys, got %d", len(keys))
+	}
+}
+
+// BenchmarkMixed runs a read-heavy workload with 10% writes on a few shards.
+// Run it with -cpu 1,4,16 to compare the lock strategies across core counts.
+func BenchmarkMixed(b *testing.B) {
+	strategies := map[string]LockStrategy{"Mutex": LockMutex, "Spin": LockSpin}
+
+	for _, name := range []string{"Mutex", "Spin"} {
+		b.Run(name, func(b *testing.B) {
+			store, err := NewKeyValueStore(4, WithLockStrategy(strategies[name]))
+			if err != nil {
+				b.Fatalf("NewKeyValueStore returned an error: %v", err)
+			}
+			for i := 0; i < 64; i++ {
+				_ = store.Set(fmt.Sprintf("key-%d", i), IntValue(i))
+			}
+
+			b.ResetTimer()
+			b.RunParallel(func(pb *testing.PB) {
+				i := 0
+				for pb.Next() {
+					key := fmt.Sprintf("key-%d", i%64)
+					if i%10 == 0 {
+						_ = store.Set(key, IntValue(i))
+					} else {
+						_, _ = store.Get(key)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
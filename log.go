@@ -0,0 +1,99 @@
+package kvs
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// LogConfig configures the events a store logs. The zero value logs recovery and
+// eviction events at slog.LevelInfo and does not log slow operations.
+type LogConfig struct {
+	// SlowThreshold is the duration above which operations and shard lock waits
+	// are logged. Zero disables slow operation logging.
+	SlowThreshold time.Duration
+	// SlowLevel is the level of slow operation and lock wait records.
+	SlowLevel slog.Level
+	// EvictionLevel is the level of records for entries dropped from the recycle bin.
+	EvictionLevel slog.Level
+	// RecoveryLevel is the level of records for imported snapshots.
+	RecoveryLevel slog.Level
+}
+
+// WithLogger makes the store log slow operations, long shard lock waits, recycle bin
+// evictions and snapshot imports to l. Failed scheduled snapshots are logged at
+// slog.LevelError. Without a logger the store is silent.
+func WithLogger(l *slog.Logger, cfg LogConfig) Option {
+	return func(o *options) {
+		o.logger = l
+		o.logConfig = cfg
+		if cfg.SlowThreshold > 0 {
+			o.observers = append(o.observers, slowOpLogger{logger: l, cfg: cfg})
+		}
+	}
+}
+
+// slowOpLogger is an Observer that logs operations slower than the configured threshold.
+type slowOpLogger struct {
+	logger *slog.Logger
+	cfg    LogConfig
+}
+
+// ObserveOp logs the operation if it was slow.
+func (s slowOpLogger) ObserveOp(op Op, key string, d time.Duration, err error) {
+	if d < s.cfg.SlowThreshold {
+		return
+	}
+
+	s.logger.Log(context.Background(), s.cfg.SlowLevel, "kvs: slow operation",
+		slog.String("op", string(op)),
+		slog.String("key", key),
+		slog.Duration("duration", d),
+		slog.Any("error", err),
+	)
+}
+
+// log writes a record to the store's logger, if it has one.
+func (kvs *KeyValueStore) log(level slog.Level, msg string, attrs ...slog.Attr) {
+	if kvs.opts.logger == nil {
+		return
+	}
+
+	kvs.opts.logger.LogAttrs(context.Background(), level, msg, attrs...)
+}
+
+// lockShard write-locks sh for a key operation, accounting for contention and
+// logging the wait if it exceeds the slow threshold.
+func (kvs *KeyValueStore) lockShard(sh *shard) {
+	if !sh.mu.TryLock() {
+		start := time.Now()
+		sh.mu.Lock()
+		kvs.contended(sh, start)
+	}
+	sh.touch()
+}
+
+// rlockShard read-locks sh for a key operation, accounting for contention and
+// logging the wait if it exceeds the slow threshold.
+func (kvs *KeyValueStore) rlockShard(sh *shard) {
+	if !sh.mu.TryRLock() {
+		start := time.Now()
+		sh.mu.RLock()
+		kvs.contended(sh, start)
+	}
+	sh.touch()
+}
+
+// contended records a lock wait on sh that started at start.
+func (kvs *KeyValueStore) contended(sh *shard, start time.Time) {
+	wait := time.Since(start)
+	sh.contention.count.Add(1)
+	sh.contention.wait.Add(int64(wait))
+
+	if t := kvs.opts.logConfig.SlowThreshold; t > 0 && wait >= t {
+		kvs.log(kvs.opts.logConfig.SlowLevel, "kvs: slow shard lock wait",
+			slog.Int("shard", sh.id),
+			slog.Duration("wait", wait),
+		)
+	}
+}
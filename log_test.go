@@ -0,0 +1,101 @@
+package kvs
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newLogStore(t *testing.T, cfg LogConfig, opts ...Option) (*KeyValueStore, *bytes.Buffer) {
+	t.Helper()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	store, err := NewKeyValueStore(2, append(opts, WithLogger(logger, cfg))...)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	return store, &buf
+}
+
+func TestWithLogger_SlowOperations(t *testing.T) {
+	store, buf := newLogStore(t, LogConfig{SlowThreshold: time.Nanosecond, SlowLevel: slog.LevelWarn})
+
+	if err := store.Set("a", IntValue(1)); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, "level=WARN") || !strings.Contains(out, `msg="kvs: slow operation" op=set key=a`) {
+		t.Errorf("Expected a slow operation record, got %q", out)
+	}
+}
+
+func TestWithLogger_Silent(t *testing.T) {
+	store, buf := newLogStore(t, LogConfig{})
+
+	_ = store.Set("a", IntValue(1))
+	_, _ = store.Get("a")
+	_ = store.Delete("a")
+
+	if buf.Len() != 0 {
+		t.Errorf("Expected no records without a slow threshold, got %q", buf.String())
+	}
+}
+
+func TestWithLogger_Evictions(t *testing.T) {
+	store, buf := newLogStore(t, LogConfig{EvictionLevel: slog.LevelDebug}, WithRecycleBin(1, time.Minute))
+
+	for _, key := range []string{"a", "b"} {
+		_ = store.Set(key, IntValue(1))
+		_ = store.Delete(key)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `level=DEBUG msg="kvs: recycle bin evicted entry" key=a reason=capacity`) {
+		t.Errorf("Expected an eviction record, got %q", out)
+	}
+}
+
+func TestWithLogger_Recovery(t *testing.T) {
+	src, _ := NewKeyValueStore(2)
+	_ = src.Set("a", IntValue(1))
+
+	var snap bytes.Buffer
+	if err := src.WriteSnapshot(&snap); err != nil {
+		t.Fatalf("WriteSnapshot returned an error: %v", err)
+	}
+
+	store, buf := newLogStore(t, LogConfig{})
+	if err := store.ReadSnapshot(&snap); err != nil {
+		t.Fatalf("ReadSnapshot returned an error: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `level=INFO msg="kvs: snapshot imported" created=1 overwritten=0 dry_run=false`) {
+		t.Errorf("Expected a recovery record, got %q", out)
+	}
+}
+
+func TestWithLogger_LockWait(t *testing.T) {
+	store, buf := newLogStore(t, LogConfig{SlowThreshold: time.Millisecond})
+	sh := store.shards[store.ShardOf("a")]
+
+	sh.mu.Lock()
+	done := make(chan struct{})
+	go func() {
+		_ = store.Set("a", IntValue(1))
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	sh.mu.Unlock()
+	<-done
+
+	if !strings.Contains(buf.String(), "kvs: slow shard lock wait") {
+		t.Errorf("Expected a lock wait record, got %q", buf.String())
+	}
+}
@@ -0,0 +1,429 @@
+// Package memcache serves a kvs.Store over the memcached text protocol, so
+// existing memcached clients can talk to kvs.
+//
+// The supported commands are get, gets, set, add, replace, delete, flush_all,
+// stats, version and quit. Entries written through the server are stored as Item
+// values; kvs.Bytes values written by other front ends are served with zero flags.
+package memcache
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bay0/kvs"
+)
+
+const (
+	// maxKeyLength is the longest key memcached accepts.
+	maxKeyLength = 250
+
+	// relativeExpiryLimit is the largest expiration time in seconds that is
+	// interpreted as relative; larger values are unix timestamps.
+	relativeExpiryLimit = 60 * 60 * 24 * 30
+
+	// DefaultMaxItemSize is the default limit for the size of a stored item.
+	DefaultMaxItemSize = 1 << 20
+)
+
+// Item is the value stored for entries written through the memcached protocol.
+type Item struct {
+	Flags   uint32
+	Data    []byte
+	Expires time.Time
+}
+
+// Clone creates a copy of the item.
+func (it Item) Clone() kvs.Value {
+	data := make([]byte, len(it.Data))
+	copy(data, it.Data)
+	it.Data = data
+
+	return it
+}
+
+func init() {
+	kvs.RegisterType[Item]("memcache/item/v1")
+}
+
+// expired reports whether the item has expired at now.
+func (it Item) expired(now time.Time) bool {
+	return !it.Expires.IsZero() && !now.Before(it.Expires)
+}
+
+// Server is a memcached protocol front end for a kvs.Store.
+type Server struct {
+	store       kvs.Store
+	maxItemSize int
+	started     time.Time
+
+	mu        sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+	wg        sync.WaitGroup
+
+	cmdGet    atomic.Uint64
+	cmdSet    atomic.Uint64
+	getHits   atomic.Uint64
+	getMisses atomic.Uint64
+}
+
+// Option configures optional behaviour of a Server.
+type Option func(*Server)
+
+// WithMaxItemSize limits the size of stored items in bytes.
+func WithMaxItemSize(n int) Option {
+	return func(s *Server) {
+		s.maxItemSize = n
+	}
+}
+
+// New creates a new Server backed by store.
+func New(store kvs.Store, opts ...Option) *Server {
+	s := &Server{
+		store:       store,
+		maxItemSize: DefaultMaxItemSize,
+		started:     time.Now(),
+		listeners:   make(map[net.Listener]struct{}),
+		conns:       make(map[net.Conn]struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// ErrServerClosed is returned by Serve after Close has been called.
+var ErrServerClosed = errors.New("memcache: server closed")
+
+// ListenAndServe listens on the TCP address addr and serves connections.
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(l)
+}
+
+// Serve accepts connections on l and serves them until Close is called.
+func (s *Server) Serve(l net.Listener) error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	s.listeners[l] = struct{}{}
+	s.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			s.mu.Lock()
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go s.serveConn(conn)
+	}
+}
+
+// Close stops all listeners, closes open connections and waits for their handlers to return.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	for l := range s.listeners {
+		l.Close()
+	}
+	for c := range s.conns {
+		c.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+
+	return nil
+}
+
+// serveConn handles the commands of a single connection.
+func (s *Server) serveConn(conn net.Conn) {
+	defer func() {
+		conn.Close()
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		s.wg.Done()
+	}()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+
+	for {
+		line, err := readLine(r)
+		if err != nil {
+			return
+		}
+
+		quit := s.handle(r, w, line)
+		if err := w.Flush(); err != nil || quit {
+			return
+		}
+	}
+}
+
+// readLine reads a single \r\n terminated command line.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// handle executes a single command and reports whether the connection should be closed.
+func (s *Server) handle(r *bufio.Reader, w *bufio.Writer, line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		w.WriteString("ERROR\r\n")
+		return false
+	}
+
+	switch fields[0] {
+	case "get", "gets":
+		s.get(w, fields[1:], fields[0] == "gets")
+	case "set", "add", "replace":
+		return s.storage(r, w, fields)
+	case "delete":
+		s.delete(w, fields[1:])
+	case "flush_all":
+		s.flushAll(w, fields[1:])
+	case "stats":
+		s.stats(w)
+	case "version":
+		w.WriteString("VERSION kvs\r\n")
+	case "quit":
+		return true
+	default:
+		w.WriteString("ERROR\r\n")
+	}
+
+	return false
+}
+
+// lookup returns the live item stored for key.
+func (s *Server) lookup(key string) (Item, bool) {
+	val, err := s.store.Get(key)
+	if err != nil {
+		return Item{}, false
+	}
+
+	switch v := val.(type) {
+	case Item:
+		if v.expired(time.Now()) {
+			s.store.Delete(key)
+			return Item{}, false
+		}
+		return v, true
+	case kvs.Bytes:
+		return Item{Data: v}, true
+	default:
+		return Item{}, false
+	}
+}
+
+// get serves get and gets. kvs has no CAS ids, so gets reports zero.
+func (s *Server) get(w *bufio.Writer, keys []string, cas bool) {
+	if len(keys) == 0 {
+		w.WriteString("ERROR\r\n")
+		return
+	}
+
+	for _, key := range keys {
+		s.cmdGet.Add(1)
+
+		it, ok := s.lookup(key)
+		if !ok {
+			s.getMisses.Add(1)
+			continue
+		}
+		s.getHits.Add(1)
+
+		if cas {
+			fmt.Fprintf(w, "VALUE %s %d %d 0\r\n", key, it.Flags, len(it.Data))
+		} else {
+			fmt.Fprintf(w, "VALUE %s %d %d\r\n", key, it.Flags, len(it.Data))
+		}
+		w.Write(it.Data)
+		w.WriteString("\r\n")
+	}
+
+	w.WriteString("END\r\n")
+}
+
+// storage serves set, add and replace and reports whether the connection should be closed.
+func (s *Server) storage(r *bufio.Reader, w *bufio.Writer, fields []string) bool {
+	if len(fields) != 5 && len(fields) != 6 {
+		w.WriteString("ERROR\r\n")
+		return false
+	}
+
+	key := fields[1]
+	flags, errFlags := strconv.ParseUint(fields[2], 10, 32)
+	exptime, errExp := strconv.ParseInt(fields[3], 10, 64)
+	size, errSize := strconv.Atoi(fields[4])
+	noreply := len(fields) == 6 && fields[5] == "noreply"
+	if errFlags != nil || errExp != nil || errSize != nil || size < 0 {
+		w.WriteString("CLIENT_ERROR bad command line format\r\n")
+		return false
+	}
+
+	if size > s.maxItemSize {
+		// Swallow the data block so the connection stays in sync.
+		if _, err := io.CopyN(io.Discard, r, int64(size)+2); err != nil {
+			return true
+		}
+		w.WriteString("SERVER_ERROR object too large for cache\r\n")
+		return false
+	}
+
+	data := make([]byte, size+2)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return true
+	}
+	if string(data[size:]) != "\r\n" {
+		w.WriteString("CLIENT_ERROR bad data chunk\r\n")
+		return false
+	}
+
+	if len(key) > maxKeyLength {
+		w.WriteString("CLIENT_ERROR key too long\r\n")
+		return false
+	}
+
+	s.cmdSet.Add(1)
+
+	_, exists := s.lookup(key)
+	if (fields[0] == "add" && exists) || (fields[0] == "replace" && !exists) {
+		reply(w, noreply, "NOT_STORED")
+		return false
+	}
+
+	it := Item{Flags: uint32(flags), Data: data[:size], Expires: expiry(exptime, time.Now())}
+	if err := s.store.Set(key, it); err != nil {
+		reply(w, noreply, "SERVER_ERROR "+err.Error())
+		return false
+	}
+
+	reply(w, noreply, "STORED")
+
+	return false
+}
+
+// expiry converts a memcached expiration time into an absolute time.
+func expiry(exptime int64, now time.Time) time.Time {
+	switch {
+	case exptime == 0:
+		return time.Time{}
+	case exptime < 0:
+		return now
+	case exptime <= relativeExpiryLimit:
+		return now.Add(time.Duration(exptime) * time.Second)
+	default:
+		return time.Unix(exptime, 0)
+	}
+}
+
+// delete serves delete.
+func (s *Server) delete(w *bufio.Writer, args []string) {
+	if len(args) == 0 {
+		w.WriteString("ERROR\r\n")
+		return
+	}
+	noreply := args[len(args)-1] == "noreply"
+
+	if _, ok := s.lookup(args[0]); !ok {
+		reply(w, noreply, "NOT_FOUND")
+		return
+	}
+
+	if err := s.store.Delete(args[0]); err != nil {
+		if errors.Is(err, kvs.ErrNotFound) {
+			reply(w, noreply, "NOT_FOUND")
+			return
+		}
+		reply(w, noreply, "SERVER_ERROR "+err.Error())
+		return
+	}
+
+	reply(w, noreply, "DELETED")
+}
+
+// flushAll serves flush_all by deleting every key. Delayed flushes are not supported.
+func (s *Server) flushAll(w *bufio.Writer, args []string) {
+	noreply := len(args) > 0 && args[len(args)-1] == "noreply"
+
+	keys, err := s.store.Keys()
+	if err != nil {
+		reply(w, noreply, "SERVER_ERROR "+err.Error())
+		return
+	}
+
+	for _, k := range keys {
+		if err := s.store.Delete(k); err != nil && !errors.Is(err, kvs.ErrNotFound) {
+			reply(w, noreply, "SERVER_ERROR "+err.Error())
+			return
+		}
+	}
+
+	reply(w, noreply, "OK")
+}
+
+// stats serves stats.
+func (s *Server) stats(w *bufio.Writer) {
+	items := 0
+	if keys, err := s.store.Keys(); err == nil {
+		items = len(keys)
+	}
+
+	now := time.Now()
+	fmt.Fprintf(w, "STAT pid %d\r\n", os.Getpid())
+	fmt.Fprintf(w, "STAT uptime %d\r\n", int64(now.Sub(s.started).Seconds()))
+	fmt.Fprintf(w, "STAT time %d\r\n", now.Unix())
+	fmt.Fprintf(w, "STAT version kvs\r\n")
+	fmt.Fprintf(w, "STAT curr_items %d\r\n", items)
+	fmt.Fprintf(w, "STAT cmd_get %d\r\n", s.cmdGet.Load())
+	fmt.Fprintf(w, "STAT cmd_set %d\r\n", s.cmdSet.Load())
+	fmt.Fprintf(w, "STAT get_hits %d\r\n", s.getHits.Load())
+	fmt.Fprintf(w, "STAT get_misses %d\r\n", s.getMisses.Load())
+	w.WriteString("END\r\n")
+}
+
+// reply writes a response line unless the client asked for noreply.
+func reply(w *bufio.Writer, noreply bool, msg string) {
+	if noreply {
+		return
+	}
+
+	w.WriteString(msg + "\r\n")
+}
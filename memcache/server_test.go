@@ -0,0 +1,141 @@
+package memcache
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bay0/kvs"
+)
+
+func newTestConn(t *testing.T) (*bufio.ReadWriter, kvs.Store) {
+	t.Helper()
+
+	store, err := kvs.NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen returned an error: %v", err)
+	}
+
+	srv := New(store)
+	go srv.Serve(l)
+	t.Cleanup(func() { srv.Close() })
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial returned an error: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	return bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn)), store
+}
+
+// roundTrip sends req and reads lines until one of the terminators is seen.
+func roundTrip(t *testing.T, rw *bufio.ReadWriter, req string) string {
+	t.Helper()
+
+	rw.WriteString(req)
+	if err := rw.Flush(); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	var lines []string
+	for {
+		line, err := rw.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read failed after %q: %v", lines, err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		lines = append(lines, line)
+
+		switch {
+		case line == "END", line == "STORED", line == "NOT_STORED", line == "DELETED",
+			line == "NOT_FOUND", line == "OK", line == "ERROR",
+			strings.HasPrefix(line, "VERSION"), strings.HasPrefix(line, "CLIENT_ERROR"),
+			strings.HasPrefix(line, "SERVER_ERROR"):
+			return strings.Join(lines, "\n")
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	rw, store := newTestConn(t)
+
+	if got := roundTrip(t, rw, "set greeting 42 0 5\r\nhello\r\n"); got != "STORED" {
+		t.Errorf("set returned %q", got)
+	}
+	if got := roundTrip(t, rw, "get greeting missing\r\n"); got != "VALUE greeting 42 5\nhello\nEND" {
+		t.Errorf("get returned %q", got)
+	}
+	if got := roundTrip(t, rw, "add greeting 0 0 2\r\nhi\r\n"); got != "NOT_STORED" {
+		t.Errorf("add of an existing key returned %q", got)
+	}
+	if got := roundTrip(t, rw, "replace missing 0 0 2\r\nhi\r\n"); got != "NOT_STORED" {
+		t.Errorf("replace of a missing key returned %q", got)
+	}
+
+	if err := store.Set("raw", kvs.Bytes("bytes")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if got := roundTrip(t, rw, "gets raw\r\n"); got != "VALUE raw 0 5 0\nbytes\nEND" {
+		t.Errorf("gets returned %q", got)
+	}
+
+	if got := roundTrip(t, rw, "delete greeting\r\n"); got != "DELETED" {
+		t.Errorf("delete returned %q", got)
+	}
+	if got := roundTrip(t, rw, "delete greeting\r\n"); got != "NOT_FOUND" {
+		t.Errorf("second delete returned %q", got)
+	}
+
+	if got := roundTrip(t, rw, "flush_all\r\n"); got != "OK" {
+		t.Errorf("flush_all returned %q", got)
+	}
+	if keys, _ := store.Keys(); len(keys) != 0 {
+		t.Errorf("Expected an empty store after flush_all, got %v", keys)
+	}
+
+	stats := roundTrip(t, rw, "stats\r\n")
+	if !strings.Contains(stats, "STAT get_hits 2") || !strings.Contains(stats, "STAT curr_items 0") {
+		t.Errorf("unexpected stats %q", stats)
+	}
+
+	if got := roundTrip(t, rw, "bogus\r\n"); got != "ERROR" {
+		t.Errorf("unknown command returned %q", got)
+	}
+}
+
+func TestServer_Expiry(t *testing.T) {
+	rw, _ := newTestConn(t)
+
+	if got := roundTrip(t, rw, "set gone 0 -1 1\r\nx\r\n"); got != "STORED" {
+		t.Errorf("set returned %q", got)
+	}
+	if got := roundTrip(t, rw, "get gone\r\n"); got != "END" {
+		t.Errorf("get of an expired key returned %q", got)
+	}
+}
+
+func TestServer_Noreply(t *testing.T) {
+	rw, _ := newTestConn(t)
+
+	rw.WriteString("set quiet 0 0 1 noreply\r\nq\r\n")
+	if got := roundTrip(t, rw, "get quiet\r\n"); got != "VALUE quiet 0 1\nq\nEND" {
+		t.Errorf("get returned %q", got)
+	}
+}
+
+func TestServer_BadDataChunk(t *testing.T) {
+	rw, _ := newTestConn(t)
+
+	if got := roundTrip(t, rw, "set key 0 0 2\r\nabc\r\n"); got != "CLIENT_ERROR bad data chunk" {
+		t.Errorf("set with a bad chunk returned %q", got)
+	}
+}
@@ -0,0 +1,170 @@
+package kvs
+
+import (
+	"encoding/binary"
+	"maps"
+	"slices"
+	"time"
+)
+
+// MaxMetaSize is the maximum total size in bytes of the names and values in an
+// entry's metadata.
+const MaxMetaSize = 1024
+
+// metaSize returns the total size of the names and values in meta.
+func metaSize(meta map[string]string) int {
+	n := 0
+	for k, v := range meta {
+		n += len(k) + len(v)
+	}
+
+	return n
+}
+
+// SetWithMeta adds or updates the given key-value pair and replaces the entry's
+// metadata with meta. Metadata is a small set of user-defined strings kept next to
+// the value, so it can be read with Meta without decoding the value. Set keeps the
+// metadata of the entry it overwrites, and Delete drops it.
+// It returns ErrMetaTooLarge if meta exceeds MaxMetaSize.
+func (kvs *KeyValueStore) SetWithMeta(key string, val Value, meta map[string]string) (err error) {
+	if len(kvs.opts.observers) > 0 {
+		defer kvs.observe(OpSet, key, time.Now(), &err)
+	}
+
+	if isSystemKey(key) {
+		return ErrReservedKey
+	}
+	if val == nil {
+		return ErrNilValue
+	}
+	if metaSize(meta) > MaxMetaSize {
+		return ErrMetaTooLarge
+	}
+
+	val, err = kvs.compress(val)
+	if err != nil {
+		return err
+	}
+
+	sh, key, err := kvs.lockKey(key)
+	if err != nil {
+		return err
+	}
+	defer sh.mu.Unlock()
+
+	if err := kvs.apply(sh, key, val); err != nil {
+		return err
+	}
+	sh.setMeta(key, maps.Clone(meta))
+
+	return nil
+}
+
+// SetMeta replaces the metadata of an existing entry without touching its value.
+// It returns ErrNotFound if the key does not exist and ErrMetaTooLarge if meta
+// exceeds MaxMetaSize.
+func (kvs *KeyValueStore) SetMeta(key string, meta map[string]string) error {
+	if isSystemKey(key) {
+		return ErrReservedKey
+	}
+	if metaSize(meta) > MaxMetaSize {
+		return ErrMetaTooLarge
+	}
+
+	sh, key, err := kvs.lockKey(key)
+	if err != nil {
+		return err
+	}
+	defer sh.mu.Unlock()
+
+	if sh.isImmutable(key) {
+		return ErrImmutable
+	}
+	if _, err := sh.backend.Get(key); err != nil {
+		return err
+	}
+	sh.setMeta(key, maps.Clone(meta))
+
+	return nil
+}
+
+// Meta returns a copy of the metadata of the entry associated with the given key.
+// Entries without metadata return an empty map. If the key is not found in the
+// store, it returns an ErrNotFound error.
+func (kvs *KeyValueStore) Meta(key string) (map[string]string, error) {
+	sh, key, err := kvs.rlockKey(key)
+	if err != nil {
+		return nil, err
+	}
+	defer sh.mu.RUnlock()
+
+	if _, err := sh.backend.Get(key); err != nil {
+		return nil, err
+	}
+
+	meta := maps.Clone(sh.meta[key])
+	if meta == nil {
+		meta = map[string]string{}
+	}
+
+	return meta, nil
+}
+
+// encodeMeta returns the binary form of meta used in snapshots: every name and
+// value is written as a uvarint length followed by its bytes, names in sorted order.
+func encodeMeta(meta map[string]string) []byte {
+	var data []byte
+	for _, k := range slices.Sorted(maps.Keys(meta)) {
+		data = binary.AppendUvarint(data, uint64(len(k)))
+		data = append(data, k...)
+		data = binary.AppendUvarint(data, uint64(len(meta[k])))
+		data = append(data, meta[k]...)
+	}
+
+	return data
+}
+
+// decodeMeta parses metadata written by encodeMeta.
+func decodeMeta(data []byte) (map[string]string, error) {
+	meta := make(map[string]string)
+
+	next := func() (string, error) {
+		n, size := binary.Uvarint(data)
+		if size <= 0 || n > uint64(len(data)-size) {
+			return "", ErrCorruptSnapshot
+		}
+		s := string(data[size : size+int(n)])
+		data = data[size+int(n):]
+		return s, nil
+	}
+
+	for len(data) > 0 {
+		k, err := next()
+		if err != nil {
+			return nil, err
+		}
+		v, err := next()
+		if err != nil {
+			return nil, err
+		}
+		meta[k] = v
+	}
+
+	return meta, nil
+}
+
+// restoreMeta sets the metadata of an existing entry, even if it is write-once.
+// It is used when importing snapshots.
+func (kvs *KeyValueStore) restoreMeta(key string, meta map[string]string) error {
+	sh := kvs.shards[kvs.shardIndex(key)]
+
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if _, err := sh.backend.Get(key); err != nil {
+		return err
+	}
+	sh.setMeta(key, meta)
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package kvs
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetWithMeta(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	meta := map[string]string{"owner": "alice", "content-type": "person"}
+	if err := store.SetWithMeta("p1", Person{Name: "Alice", Age: 30}, meta); err != nil {
+		t.Fatalf("SetWithMeta returned an error: %v", err)
+	}
+	meta["owner"] = "mallory"
+
+	got, err := store.Meta("p1")
+	if err != nil {
+		t.Fatalf("Meta returned an error: %v", err)
+	}
+	if got["owner"] != "alice" || got["content-type"] != "person" {
+		t.Errorf("unexpected metadata %v", got)
+	}
+	got["owner"] = "mallory"
+	if again, _ := store.Meta("p1"); again["owner"] != "alice" {
+		t.Error("Expected Meta to return a copy")
+	}
+
+	if err := store.Set("p1", Person{Name: "Alice", Age: 31}); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if got, _ := store.Meta("p1"); got["owner"] != "alice" {
+		t.Errorf("Expected Set to keep the metadata, got %v", got)
+	}
+
+	if err := store.SetMeta("p1", map[string]string{"owner": "bob"}); err != nil {
+		t.Fatalf("SetMeta returned an error: %v", err)
+	}
+	if got, _ := store.Meta("p1"); len(got) != 1 || got["owner"] != "bob" {
+		t.Errorf("Expected SetMeta to replace the metadata, got %v", got)
+	}
+
+	if err := store.Delete("p1"); err != nil {
+		t.Fatalf("Delete returned an error: %v", err)
+	}
+	if _, err := store.Meta("p1"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if err := store.Set("p1", IntValue(1)); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if got, _ := store.Meta("p1"); len(got) != 0 {
+		t.Errorf("Expected Delete to drop the metadata, got %v", got)
+	}
+}
+
+func TestSetWithMeta_Errors(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+
+	big := map[string]string{"k": strings.Repeat("x", MaxMetaSize)}
+	if err := store.SetWithMeta("a", IntValue(1), big); err != ErrMetaTooLarge {
+		t.Errorf("Expected ErrMetaTooLarge, got %v", err)
+	}
+	if err := store.SetMeta("missing", map[string]string{"a": "b"}); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestSetWithMeta_SnapshotAndRecycleBin(t *testing.T) {
+	store, _ := NewKeyValueStore(4, WithRecycleBin(10, time.Minute))
+	if err := store.SetWithMeta("a", IntValue(1), map[string]string{"tag": "x"}); err != nil {
+		t.Fatalf("SetWithMeta returned an error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := store.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("WriteSnapshot returned an error: %v", err)
+	}
+	restored, _ := NewKeyValueStore(2)
+	if err := restored.ReadSnapshot(&buf); err != nil {
+		t.Fatalf("ReadSnapshot returned an error: %v", err)
+	}
+	if got, _ := restored.Meta("a"); got["tag"] != "x" {
+		t.Errorf("Expected the snapshot to keep the metadata, got %v", got)
+	}
+
+	if err := store.Delete("a"); err != nil {
+		t.Fatalf("Delete returned an error: %v", err)
+	}
+	if err := store.Restore("a"); err != nil {
+		t.Fatalf("Restore returned an error: %v", err)
+	}
+	if got, _ := store.Meta("a"); got["tag"] != "x" {
+		t.Errorf("Expected Restore to bring back the metadata, got %v", got)
+	}
+}
@@ -0,0 +1,95 @@
+package kvs
+
+// BatchGet returns the values of keys, omitting keys that do not exist. Keys are
+// grouped by shard and every shard is read-locked once, so the values of a shard
+// are read at one point in time. Aliases are followed one by one after the shards
+// are read.
+func (kvs *KeyValueStore) BatchGet(keys []string) (map[string]Value, error) {
+	vals := make(map[string]Value, len(keys))
+	byShard := make([][]string, len(kvs.shards))
+	var aliased []string
+
+	for _, key := range keys {
+		if isSystemKey(key) || kvs.opts.replicaInterval > 0 {
+			aliased = append(aliased, key)
+			continue
+		}
+		i := kvs.shardIndex(key)
+		byShard[i] = append(byShard[i], key)
+	}
+
+	for i, shKeys := range byShard {
+		if len(shKeys) == 0 {
+			continue
+		}
+
+		sh := kvs.shards[i]
+		kvs.rlockShard(sh)
+		for _, key := range shKeys {
+			if _, ok := sh.aliases[key]; ok {
+				aliased = append(aliased, key)
+				continue
+			}
+
+			sh.recordAccess(key)
+			val, err := sh.backend.Get(key)
+			sh.counters.countGet(err)
+			if err == ErrNotFound {
+				continue
+			}
+			if err != nil {
+				sh.mu.RUnlock()
+				return nil, err
+			}
+			vals[key] = val
+		}
+		sh.mu.RUnlock()
+	}
+
+	for key, val := range vals {
+		val, err := kvs.decompress(val)
+		if err != nil {
+			return nil, err
+		}
+		vals[key] = val
+	}
+
+	for _, key := range aliased {
+		val, err := kvs.Get(key)
+		if err == ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		vals[key] = val
+	}
+
+	return vals, nil
+}
+
+// BatchSet sets all pairs atomically: either every pair is written or, if a key
+// is write-once, none is and ErrImmutable is returned. Only the shards of the keys
+// are locked, in shard order, so concurrent batches and transactions over
+// overlapping keys cannot deadlock. Keys that are aliases set the key they point at.
+func (kvs *KeyValueStore) BatchSet(pairs map[string]Value) error {
+	tx := &Txn{kvs: kvs, writes: make(map[string]txnWrite, len(pairs))}
+	tx.open.Store(true)
+
+	for key, val := range pairs {
+		if isSystemKey(key) {
+			return ErrReservedKey
+		}
+		if val == nil {
+			return ErrNilValue
+		}
+
+		val, err := kvs.compress(val)
+		if err != nil {
+			return err
+		}
+		tx.writes[key] = txnWrite{val: val}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,105 @@
+package kvs
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestBatchGet(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	keys := make([]string, 0, 101)
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key%d", i)
+		_ = store.Set(key, IntValue(i))
+		keys = append(keys, key)
+	}
+	if err := store.Alias("alias", "key7"); err != nil {
+		t.Fatalf("Alias returned an error: %v", err)
+	}
+	keys = append(keys, "missing", "alias", SystemPrefix+"stats/shards")
+
+	vals, err := store.BatchGet(keys)
+	if err != nil {
+		t.Fatalf("BatchGet returned an error: %v", err)
+	}
+	if len(vals) != 102 {
+		t.Errorf("Expected 102 values, got %d", len(vals))
+	}
+	for i := 0; i < 100; i++ {
+		if vals[fmt.Sprintf("key%d", i)] != IntValue(i) {
+			t.Errorf("Expected %d, got %v", i, vals[fmt.Sprintf("key%d", i)])
+		}
+	}
+	if vals["alias"] != IntValue(7) {
+		t.Errorf("Expected the alias to resolve to 7, got %v", vals["alias"])
+	}
+	if _, ok := vals["missing"]; ok {
+		t.Errorf("Expected missing keys to be omitted")
+	}
+
+	if stats := store.Stats(); stats.Misses != 1 {
+		t.Errorf("Expected 1 miss, got %d", stats.Misses)
+	}
+}
+
+func TestBatchSet(t *testing.T) {
+	store, err := NewKeyValueStore(8)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	keys := make([]string, 32)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("key%d", i)
+	}
+
+	// Overlapping batches from many goroutines must neither deadlock nor interleave.
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				pairs := make(map[string]Value, len(keys))
+				for _, key := range keys {
+					pairs[key] = IntValue(g)
+				}
+				if err := store.BatchSet(pairs); err != nil {
+					t.Errorf("BatchSet returned an error: %v", err)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	vals, err := store.BatchGet(keys)
+	if err != nil {
+		t.Fatalf("BatchGet returned an error: %v", err)
+	}
+	for _, key := range keys {
+		if vals[key] != vals[keys[0]] {
+			t.Fatalf("Expected every key to hold the value of the last batch, got %v", vals)
+		}
+	}
+
+	if err := store.SetImmutable("frozen", IntValue(1)); err != nil {
+		t.Fatalf("SetImmutable returned an error: %v", err)
+	}
+	err = store.BatchSet(map[string]Value{"fresh": IntValue(1), "frozen": IntValue(2)})
+	if err != ErrImmutable {
+		t.Errorf("Expected ErrImmutable, got %v", err)
+	}
+	if _, err := store.Get("fresh"); err != ErrNotFound {
+		t.Errorf("Expected no pair to be written, got %v", err)
+	}
+
+	if err := store.BatchSet(map[string]Value{"a": nil}); err != ErrNilValue {
+		t.Errorf("Expected ErrNilValue, got %v", err)
+	}
+}
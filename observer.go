@@ -0,0 +1,40 @@
+package kvs
+
+import "time"
+
+// Op identifies a store operation reported to an Observer.
+type Op string
+
+const (
+	// OpGet is reported for Get.
+	OpGet Op = "get"
+	// OpSet is reported for Set and SetImmutable.
+	OpSet Op = "set"
+	// OpDelete is reported for Delete and ForceDelete.
+	OpDelete Op = "delete"
+)
+
+// Observer receives a callback after every store operation with the time it took,
+// including the time spent waiting for the shard lock, and the error it returned.
+// A Get of a missing key is reported with ErrNotFound.
+// ObserveOp is called concurrently and must not call back into the store.
+type Observer interface {
+	ObserveOp(op Op, key string, d time.Duration, err error)
+}
+
+// WithObserver registers an Observer that is notified of every store operation.
+// It can be given more than once to register several observers.
+func WithObserver(obs Observer) Option {
+	return func(o *options) {
+		o.observers = append(o.observers, obs)
+	}
+}
+
+// observe reports an operation that started at start to the registered observers.
+// It is meant to be deferred by exported operations that return a named error.
+func (kvs *KeyValueStore) observe(op Op, key string, start time.Time, err *error) {
+	d := time.Since(start)
+	for _, obs := range kvs.opts.observers {
+		obs.ObserveOp(op, key, d, *err)
+	}
+}
@@ -0,0 +1,79 @@
+package kvs
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingObserver struct {
+	mu   sync.Mutex
+	ops  []Op
+	errs []error
+}
+
+func (r *recordingObserver) ObserveOp(op Op, _ string, _ time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.ops = append(r.ops, op)
+	r.errs = append(r.errs, err)
+}
+
+func TestWithObserver(t *testing.T) {
+	obs := &recordingObserver{}
+	store, err := NewKeyValueStore(2, WithObserver(obs))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	_ = store.Set("a", IntValue(1))
+	_, _ = store.Get("a")
+	_, _ = store.Get("b")
+	_ = store.Delete("a")
+
+	want := []Op{OpSet, OpGet, OpGet, OpDelete}
+	if len(obs.ops) != len(want) {
+		t.Fatalf("Expected %d observed operations, got %d", len(want), len(obs.ops))
+	}
+	for i, op := range want {
+		if obs.ops[i] != op {
+			t.Errorf("Expected operation %d to be %s, got %s", i, op, obs.ops[i])
+		}
+	}
+	if obs.errs[2] != ErrNotFound {
+		t.Errorf("Expected the miss to be reported with ErrNotFound, got %v", obs.errs[2])
+	}
+}
+
+func TestShardBytes(t *testing.T) {
+	store, _ := NewKeyValueStore(1)
+
+	if err := store.Set("k", Bytes("0123456789")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if got := store.ShardBytes()[0]; got != 11 {
+		t.Errorf("Expected 11 bytes, got %d", got)
+	}
+
+	if err := store.Set("k", Bytes("01234")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if got := store.ShardBytes()[0]; got != 6 {
+		t.Errorf("Expected 6 bytes after overwrite, got %d", got)
+	}
+
+	if err := store.Delete("k"); err != nil {
+		t.Fatalf("Delete returned an error: %v", err)
+	}
+	if got := store.ShardBytes()[0]; got != 0 {
+		t.Errorf("Expected 0 bytes after delete, got %d", got)
+	}
+
+	if err := store.Set("p", Person{Name: "Alice", Age: 30}); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if got := store.ShardBytes()[0]; got <= int64(len("Alice")) {
+		t.Errorf("Expected the struct estimate to include its string, got %d", got)
+	}
+}
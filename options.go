@@ -0,0 +1,70 @@
+package kvs
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Option configures optional behaviour of a KeyValueStore.
+type Option func(*options)
+
+// options holds the optional settings of a KeyValueStore.
+type options struct {
+	backend           BackendFactory
+	codec             Codec
+	compressor        Compressor
+	compressThreshold int
+	backgroundRate    int64
+	recycleCapacity   int
+	recycleRetention  time.Duration
+	observers         []Observer
+	logger            *slog.Logger
+	logConfig         LogConfig
+	hotKeyCapacity    int
+	batchWindow       time.Duration
+	lockStrategy      LockStrategy
+	slowLog           *slowLog
+	eventBuffer       int
+	historyDepth      int
+	replicaInterval   time.Duration
+	compaction        CompactionPolicy
+	scrubInterval     time.Duration
+	txnLimits         TxnLimits
+	statsInterval     time.Duration
+}
+
+// WithCodec sets the codec used to encode values whenever the store needs
+// their byte representation, e.g. for compression.
+// If no codec is set, GobCodec is used. Value types must be registered with RegisterType.
+func WithCodec(c Codec) Option {
+	return func(o *options) {
+		o.codec = c
+	}
+}
+
+// WithCompression enables transparent compression of values whose encoded
+// size is at least threshold bytes. Values are encoded with the store's codec,
+// compressed on Set and decompressed on Get.
+func WithCompression(c Compressor, threshold int) Option {
+	return func(o *options) {
+		o.compressor = c
+		o.compressThreshold = threshold
+	}
+}
+
+// WithBackend sets the factory used to create the storage backend of each shard.
+// If no factory is set, every shard uses an in-memory backend.
+func WithBackend(f BackendFactory) Option {
+	return func(o *options) {
+		o.backend = f
+	}
+}
+
+// WithBackgroundRateLimit limits background data movement, such as scheduled
+// snapshots and backups, to bytesPerSecond so it does not starve foreground traffic
+// of disk or network bandwidth. Zero means unlimited.
+func WithBackgroundRateLimit(bytesPerSecond int64) Option {
+	return func(o *options) {
+		o.backgroundRate = bytesPerSecond
+	}
+}
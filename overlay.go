@@ -0,0 +1,155 @@
+package kvs
+
+import "sync"
+
+// OverlayStore is a Store layering a delta store over a base store.
+// Reads fall through from delta to base and writes go to delta, so base is never
+// modified until Flatten is called.
+type OverlayStore struct {
+	mu    sync.RWMutex
+	base  Store
+	delta Store
+	// deleted holds the keys of base that were deleted through the overlay.
+	deleted map[string]struct{}
+}
+
+var _ Store = (*OverlayStore)(nil)
+
+// Overlay creates a store that reads from delta and then from base and writes to
+// delta. Deleting a key of base hides it in the overlay without touching base.
+//
+//	staging := kvs.Overlay(production, scratch)
+//	_ = staging.Set("feature/x", kvs.Bytes("on"))
+func Overlay(base, delta Store) *OverlayStore {
+	return &OverlayStore{
+		base:    base,
+		delta:   delta,
+		deleted: make(map[string]struct{}),
+	}
+}
+
+// Get retrieves the value of key from delta, or from base if delta does not hold it.
+// If the key is not found in either store, it returns an ErrNotFound error.
+func (o *OverlayStore) Get(key string) (Value, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	return o.get(key)
+}
+
+// get looks key up in the layers. o.mu must be held.
+func (o *OverlayStore) get(key string) (Value, error) {
+	if _, ok := o.deleted[key]; ok {
+		return nil, ErrNotFound
+	}
+
+	val, err := o.delta.Get(key)
+	if err != ErrNotFound {
+		return val, err
+	}
+
+	return o.base.Get(key)
+}
+
+// Set adds or updates the given key-value pair in delta.
+func (o *OverlayStore) Set(key string, val Value) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := o.delta.Set(key, val); err != nil {
+		return err
+	}
+	delete(o.deleted, key)
+
+	return nil
+}
+
+// Delete removes key from the overlay. A key held by delta is deleted from it,
+// and a key held by base is hidden. If the key is not found, it returns an
+// ErrNotFound error.
+func (o *OverlayStore) Delete(key string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, err := o.get(key); err != nil {
+		return err
+	}
+
+	if err := o.delta.Delete(key); err != nil && err != ErrNotFound {
+		return err
+	}
+	if _, err := o.base.Get(key); err == nil {
+		o.deleted[key] = struct{}{}
+	}
+
+	return nil
+}
+
+// Keys returns the keys of both layers, without the keys deleted through the overlay.
+func (o *OverlayStore) Keys() ([]string, error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	deltaKeys, err := o.delta.Keys()
+	if err != nil {
+		return nil, err
+	}
+	baseKeys, err := o.base.Keys()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]struct{}, len(deltaKeys))
+	keys := make([]string, 0, len(deltaKeys)+len(baseKeys))
+	for _, key := range deltaKeys {
+		seen[key] = struct{}{}
+		keys = append(keys, key)
+	}
+	for _, key := range baseKeys {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		if _, ok := o.deleted[key]; ok {
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+// Flatten merges the overlay into base: keys held by delta are written to base
+// and removed from delta, and keys deleted through the overlay are deleted from
+// base. If a write to base fails, Flatten stops and the remaining changes stay
+// in the overlay.
+func (o *OverlayStore) Flatten() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	keys, err := o.delta.Keys()
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		val, err := o.delta.Get(key)
+		if err != nil {
+			return err
+		}
+		if err := o.base.Set(key, val); err != nil {
+			return err
+		}
+		if err := o.delta.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	for key := range o.deleted {
+		if err := o.base.Delete(key); err != nil && err != ErrNotFound {
+			return err
+		}
+		delete(o.deleted, key)
+	}
+
+	return nil
+}
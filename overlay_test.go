@@ -0,0 +1,60 @@
+package kvs
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestOverlay(t *testing.T) {
+	base, _ := NewKeyValueStore(4)
+	delta, _ := NewKeyValueStore(4)
+	_ = base.Set("a", IntValue(1))
+	_ = base.Set("b", IntValue(2))
+
+	o := Overlay(base, delta)
+
+	if err := o.Set("a", IntValue(10)); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if err := o.Set("c", IntValue(3)); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if err := o.Delete("b"); err != nil {
+		t.Fatalf("Delete returned an error: %v", err)
+	}
+	if err := o.Delete("missing"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if val, err := o.Get("a"); err != nil || val.(IntValue) != 10 {
+		t.Errorf("Expected 10 from delta, got %v, %v", val, err)
+	}
+	if _, err := o.Get("b"); err != ErrNotFound {
+		t.Errorf("Expected the deleted key to be hidden, got %v", err)
+	}
+	if val, _ := base.Get("a"); val.(IntValue) != 1 {
+		t.Errorf("Expected base to be unchanged, got %v", val)
+	}
+
+	keys, err := o.Keys()
+	if err != nil {
+		t.Fatalf("Keys returned an error: %v", err)
+	}
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "a" || keys[1] != "c" {
+		t.Errorf("Expected [a c], got %v", keys)
+	}
+
+	if err := o.Flatten(); err != nil {
+		t.Fatalf("Flatten returned an error: %v", err)
+	}
+	if val, _ := base.Get("a"); val.(IntValue) != 10 {
+		t.Errorf("Expected 10 in base, got %v", val)
+	}
+	if _, err := base.Get("b"); err != ErrNotFound {
+		t.Errorf("Expected b to be deleted from base, got %v", err)
+	}
+	if keys, _ := delta.Keys(); len(keys) != 0 {
+		t.Errorf("Expected delta to be empty, got %v", keys)
+	}
+}
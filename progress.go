@@ -0,0 +1,152 @@
+package kvs
+
+import (
+	"io"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// ImportProgress describes how far an import has come.
+type ImportProgress struct {
+	// Keys is the number of entries loaded so far.
+	Keys int
+	// Bytes is the number of snapshot bytes read so far.
+	Bytes int64
+	// Total is the size of the snapshot in bytes, or zero if it is unknown.
+	Total int64
+	// Elapsed is the time since the import started.
+	Elapsed time.Duration
+	// ETA is the estimated time until the import completes, or zero if the size
+	// of the snapshot is unknown.
+	ETA time.Duration
+	// Done reports whether the import has completed.
+	Done bool
+}
+
+// WithProgress reports the progress of an import to fn at most once per interval
+// and once more when it completes. If the store has a logger, every report is also
+// logged at the recovery level of its LogConfig. fn may be nil to only log.
+//
+// The store keeps serving reads and writes while an import runs, so entries are
+// readable as soon as they are loaded; keys that were not loaded yet return
+// ErrNotFound.
+func WithProgress(interval time.Duration, fn func(ImportProgress)) ImportOption {
+	return func(o *importOptions) {
+		o.progress = fn
+		o.progressInterval = interval
+	}
+}
+
+// progressTracker reports the progress of an import.
+type progressTracker struct {
+	kvs   *KeyValueStore
+	opts  importOptions
+	start time.Time
+	last  time.Time
+	pos   func() int64
+	total int64
+	keys  int
+}
+
+// newProgressTracker creates a tracker for an import started at start.
+// It returns nil if progress is not reported.
+func newProgressTracker(kvs *KeyValueStore, o importOptions, start time.Time, pos func() int64, total int64) *progressTracker {
+	if o.progressInterval <= 0 {
+		return nil
+	}
+
+	return &progressTracker{
+		kvs:   kvs,
+		opts:  o,
+		start: start,
+		last:  start,
+		pos:   pos,
+		total: total,
+	}
+}
+
+// entry counts a loaded entry and reports the progress if the interval has passed.
+func (p *progressTracker) entry() {
+	if p == nil {
+		return
+	}
+
+	p.keys++
+	if now := time.Now(); now.Sub(p.last) >= p.opts.progressInterval {
+		p.last = now
+		p.report(now, false)
+	}
+}
+
+// finish reports the completed import.
+func (p *progressTracker) finish() {
+	if p != nil {
+		p.report(time.Now(), true)
+	}
+}
+
+// report hands the current progress to the callback and the logger.
+func (p *progressTracker) report(now time.Time, done bool) {
+	progress := ImportProgress{
+		Keys:    p.keys,
+		Bytes:   p.pos(),
+		Total:   p.total,
+		Elapsed: now.Sub(p.start),
+		Done:    done,
+	}
+	if !done && progress.Total > 0 && progress.Bytes > 0 && progress.Bytes < progress.Total {
+		remaining := float64(progress.Total-progress.Bytes) / float64(progress.Bytes)
+		progress.ETA = time.Duration(float64(progress.Elapsed) * remaining)
+	}
+
+	p.kvs.log(p.kvs.opts.logConfig.RecoveryLevel, "kvs: importing snapshot",
+		slog.Int("keys", progress.Keys),
+		slog.Int64("bytes", progress.Bytes),
+		slog.Int64("total", progress.Total),
+		slog.Duration("eta", progress.ETA),
+		slog.Bool("done", progress.Done),
+	)
+	if p.opts.progress != nil {
+		p.opts.progress(progress)
+	}
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read reads from the underlying reader.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+
+	return n, err
+}
+
+// count returns the number of bytes read so far.
+func (c *countingReader) count() int64 {
+	return c.n
+}
+
+// sizeOf returns the number of bytes left in r if it can tell, and zero otherwise.
+func sizeOf(r io.Reader) int64 {
+	switch r := r.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len())
+	case *os.File:
+		info, err := r.Stat()
+		if err != nil {
+			return 0
+		}
+		offset, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0
+		}
+		return info.Size() - offset
+	}
+
+	return 0
+}
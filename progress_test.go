@@ -0,0 +1,45 @@
+package kvs
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestImportSnapshot_Progress(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	for i := 0; i < 100; i++ {
+		_ = store.Set(fmt.Sprintf("key-%d", i), IntValue(i))
+	}
+
+	var buf bytes.Buffer
+	if err := store.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("WriteSnapshot returned an error: %v", err)
+	}
+	size := int64(buf.Len())
+
+	restored, _ := NewKeyValueStore(4)
+	var reports []ImportProgress
+	_, err = restored.ImportSnapshot(&buf, WithProgress(1, func(p ImportProgress) {
+		reports = append(reports, p)
+	}))
+	if err != nil {
+		t.Fatalf("ImportSnapshot returned an error: %v", err)
+	}
+
+	if len(reports) < 2 {
+		t.Fatalf("Expected several progress reports, got %d", len(reports))
+	}
+	last := reports[len(reports)-1]
+	if !last.Done || last.Keys != 100 || last.Total != size || last.Bytes != size {
+		t.Errorf("unexpected final report %+v", last)
+	}
+	for _, p := range reports[:len(reports)-1] {
+		if p.Done {
+			t.Errorf("Expected only the last report to be done, got %+v", p)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package kvs
+
+import (
+	"strings"
+	"sync"
+)
+
+// BucketQuota limits the size of a bucket. A zero field means no limit.
+type BucketQuota struct {
+	// MaxEntries is the maximum number of entries of the bucket.
+	MaxEntries int
+	// MaxBytes is the maximum estimated size of the keys and values of the
+	// bucket, measured like BucketStats.Bytes.
+	MaxBytes int64
+}
+
+// bucketQuota is the quota of a bucket and the usage it is checked against.
+type bucketQuota struct {
+	mu     sync.Mutex
+	limits BucketQuota
+	// usage is the measured usage of the bucket, or nil if it has to be measured
+	// again because the bucket was changed in bulk.
+	usage *BucketStats
+}
+
+// bucketQuotas holds the quotas of a store's buckets by bucket path.
+type bucketQuotas struct {
+	mu sync.Mutex
+	m  map[string]*bucketQuota
+}
+
+// get returns the quota of the bucket at path, or nil if it has none.
+func (q *bucketQuotas) get(path string) *bucketQuota {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.m[path]
+}
+
+// set sets the quota of the bucket at path; a zero quota removes it.
+func (q *bucketQuotas) set(path string, limits BucketQuota) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if limits == (BucketQuota{}) {
+		delete(q.m, path)
+		return
+	}
+	if q.m == nil {
+		q.m = make(map[string]*bucketQuota)
+	}
+	q.m[path] = &bucketQuota{limits: limits}
+}
+
+// invalidate makes the quotas of the bucket at path, and with nested set of the
+// buckets nested in it, measure their usage again. An empty path with nested set
+// covers every bucket.
+func (q *bucketQuotas) invalidate(path string, nested bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for p, bq := range q.m {
+		if p == path || (nested && (path == "" || strings.HasPrefix(p, path+"/"))) {
+			bq.mu.Lock()
+			bq.usage = nil
+			bq.mu.Unlock()
+		}
+	}
+}
+
+// invalidateUsage makes the quota of the bucket or tenant holding the stored key
+// measure its usage again.
+func (kvs *KeyValueStore) invalidateUsage(key string) {
+	switch {
+	case strings.HasPrefix(key, bucketPrefix):
+		if path, _, ok := strings.Cut(strings.TrimPrefix(key, bucketPrefix), "//"); ok {
+			kvs.quotas.invalidate(path, false)
+		}
+	case strings.HasPrefix(key, tenantPrefix):
+		if id, _, ok := strings.Cut(strings.TrimPrefix(key, tenantPrefix), "/"); ok {
+			kvs.tenants.invalidate(id)
+		}
+	}
+}
+
+// SetQuota limits the entries and bytes of the bucket. Writes through the bucket
+// that would exceed a limit fail with ErrQuotaExceeded; writes that shrink the
+// bucket are always allowed, so a bucket over a lowered quota can be cleaned up.
+// The quota covers the bucket's own entries, not those of nested buckets. A zero
+// quota removes the limits.
+//
+//	_ = store.Bucket("sessions").SetQuota(kvs.BucketQuota{MaxEntries: 10000, MaxBytes: 64 << 20})
+//
+// Quotas are kept in memory and are not part of snapshots or clones. Imports
+// and restores from the recycle bin bypass them. Writes to a bucket with a quota are serialized.
+func (b *Bucket) SetQuota(q BucketQuota) error {
+	if err := b.check(); err != nil {
+		return err
+	}
+	b.kvs.quotas.set(b.Path(), q)
+
+	return nil
+}
+
+// Quota returns the quota of the bucket, which is zero if it has none.
+func (b *Bucket) Quota() BucketQuota {
+	if bq := b.kvs.quotas.get(b.Path()); bq != nil {
+		return bq.limits
+	}
+
+	return BucketQuota{}
+}
+
+// measure returns the usage of the bucket, measuring it if needed. bq must be locked.
+func (b *Bucket) measure(bq *bucketQuota) (*BucketStats, error) {
+	if bq.usage == nil {
+		stats, err := b.Stats()
+		if err != nil {
+			return nil, err
+		}
+		bq.usage = &stats
+	}
+
+	return bq.usage, nil
+}
+
+// storedSize returns the estimated size of the entry stored under stored, as
+// counted for key, and whether it exists.
+func (kvs *KeyValueStore) storedSize(stored, key string) (int64, bool, error) {
+	sh, stored, err := kvs.rlockKey(stored)
+	if err != nil {
+		return 0, false, err
+	}
+	val, err := sh.backend.Get(stored)
+	sh.mu.RUnlock()
+
+	if err == ErrNotFound {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+
+	return estimateSize(key, val), true, nil
+}
+
+// setWithQuota stores val under key if the quota bq allows it.
+func (b *Bucket) setWithQuota(bq *bucketQuota, key string, val Value) error {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+
+	usage, err := b.measure(bq)
+	if err != nil {
+		return err
+	}
+
+	return b.kvs.setAccounted(usage, bq.limits, b.prefix, key, val)
+}
+
+// setAccounted stores val under prefix+key if the write keeps usage, the
+// measured usage of the keys with prefix, within limits, and updates usage. The
+// caller must serialize the writes accounted to usage.
+func (kvs *KeyValueStore) setAccounted(usage *BucketStats, limits BucketQuota, prefix, key string, val Value) error {
+	val, err := kvs.compress(val)
+	if err != nil {
+		return err
+	}
+	oldSize, exists, err := kvs.storedSize(prefix+key, key)
+	if err != nil {
+		return err
+	}
+
+	grown, err := limits.grow(*usage, oldSize, exists, estimateSize(key, val))
+	if err != nil {
+		return err
+	}
+
+	if err := kvs.putCompressed(prefix+key, val); err != nil {
+		return err
+	}
+	*usage = grown
+
+	return nil
+}
+
+// grow returns usage after an entry of oldSize, if it exists, is replaced by one
+// of newSize, or ErrQuotaExceeded if that exceeds q. Writes that do not grow the
+// usage are always allowed.
+func (q BucketQuota) grow(usage BucketStats, oldSize int64, exists bool, newSize int64) (BucketStats, error) {
+	if !exists {
+		usage.Entries++
+		if q.MaxEntries > 0 && usage.Entries > q.MaxEntries {
+			return usage, ErrQuotaExceeded
+		}
+	}
+	usage.Bytes += newSize - oldSize
+	if q.MaxBytes > 0 && usage.Bytes > q.MaxBytes && newSize > oldSize {
+		return usage, ErrQuotaExceeded
+	}
+
+	return usage, nil
+}
+
+// deleteWithQuota removes key and accounts for it in the quota bq.
+func (b *Bucket) deleteWithQuota(bq *bucketQuota, key string) error {
+	bq.mu.Lock()
+	defer bq.mu.Unlock()
+
+	usage, err := b.measure(bq)
+	if err != nil {
+		return err
+	}
+
+	return b.kvs.deleteAccounted(usage, b.prefix, key)
+}
+
+// deleteAccounted removes prefix+key and updates usage, the measured usage of
+// the keys with prefix. The caller must serialize the writes accounted to usage.
+func (kvs *KeyValueStore) deleteAccounted(usage *BucketStats, prefix, key string) error {
+	oldSize, _, err := kvs.storedSize(prefix+key, key)
+	if err != nil {
+		return err
+	}
+
+	if err := kvs.delete(prefix + key); err != nil {
+		return err
+	}
+	usage.Entries--
+	usage.Bytes -= oldSize
+
+	return nil
+}
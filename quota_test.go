@@ -0,0 +1,102 @@
+package kvs
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func TestBucket_Quota(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	sessions := store.Bucket("sessions")
+	_ = sessions.Set("a", Bytes("1"))
+
+	if err := sessions.SetQuota(BucketQuota{MaxEntries: 2, MaxBytes: 20}); err != nil {
+		t.Fatalf("SetQuota returned an error: %v", err)
+	}
+	if q := sessions.Quota(); q.MaxEntries != 2 {
+		t.Errorf("unexpected quota %+v", q)
+	}
+
+	if err := sessions.Set("b", Bytes("2")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if err := sessions.Set("c", Bytes("3")); err != ErrQuotaExceeded {
+		t.Errorf("Expected ErrQuotaExceeded for entries, got %v", err)
+	}
+	if err := sessions.Set("b", Bytes("overwrites are fine")); err != ErrQuotaExceeded {
+		t.Errorf("Expected ErrQuotaExceeded for bytes, got %v", err)
+	}
+	if err := sessions.Set("b", Bytes("22")); err != nil {
+		t.Errorf("Expected an overwrite within the quota to succeed, got %v", err)
+	}
+
+	// Other buckets and the root keyspace are not limited.
+	if err := store.Bucket("cache").Set("c", Bytes("3")); err != nil {
+		t.Errorf("Set returned an error: %v", err)
+	}
+
+	if err := sessions.Delete("a"); err != nil {
+		t.Fatalf("Delete returned an error: %v", err)
+	}
+	if err := sessions.Set("c", Bytes("3")); err != nil {
+		t.Errorf("Expected a delete to free quota, got %v", err)
+	}
+	if err := sessions.Clear(); err != nil {
+		t.Fatalf("Clear returned an error: %v", err)
+	}
+	_ = sessions.Set("x", Bytes("1"))
+	if err := sessions.Set("y", Bytes("2")); err != nil {
+		t.Errorf("Expected Clear to free quota, got %v", err)
+	}
+
+	if err := sessions.SetQuota(BucketQuota{}); err != nil {
+		t.Fatalf("SetQuota returned an error: %v", err)
+	}
+	if err := sessions.Set("z", Bytes("3")); err != nil {
+		t.Errorf("Expected a removed quota not to limit, got %v", err)
+	}
+}
+
+func TestBucket_QuotaConcurrent(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	b := store.Bucket("jobs")
+	_ = b.SetQuota(BucketQuota{MaxEntries: 10})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_ = b.Set(string(rune('a'+i)), Bytes("x"))
+		}(i)
+	}
+	wg.Wait()
+
+	if stats, _ := b.Stats(); stats.Entries != 10 {
+		t.Errorf("Expected 10 entries, got %d", stats.Entries)
+	}
+}
+
+func TestBucket_QuotaImport(t *testing.T) {
+	src, _ := NewKeyValueStore(4, WithCodec(BytesCodec{}))
+	_ = src.Bucket("jobs").Set("a", Bytes("1"))
+	_ = src.Bucket("jobs").Set("b", Bytes("2"))
+	var buf bytes.Buffer
+	_ = src.WriteSnapshot(&buf)
+
+	store, _ := NewKeyValueStore(4, WithCodec(BytesCodec{}))
+	b := store.Bucket("jobs")
+	_ = b.SetQuota(BucketQuota{MaxEntries: 2})
+	_ = b.Set("c", Bytes("3"))
+
+	if err := store.ReadSnapshot(&buf); err != nil {
+		t.Fatalf("ReadSnapshot returned an error: %v", err)
+	}
+	if err := b.Set("d", Bytes("4")); err != ErrQuotaExceeded {
+		t.Errorf("Expected imported entries to count, got %v", err)
+	}
+}
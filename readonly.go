@@ -0,0 +1,72 @@
+package kvs
+
+// ReadOnlyStore is a Store whose entries cannot be changed. Set and Delete
+// return ErrReadOnly.
+type ReadOnlyStore struct {
+	kvs *KeyValueStore
+}
+
+var _ Store = (*ReadOnlyStore)(nil)
+
+// OpenSnapshot creates a read-only store holding the entries of a snapshot written
+// by WriteSnapshot. It is meant for datasets shipped inside the binary:
+//
+//	//go:embed data.kvs
+//	var data []byte
+//
+//	var dataset, _ = kvs.OpenSnapshot(data, 16, kvs.WithCodec(kvs.BytesCodec{}))
+//
+// Records are read straight from data. With BytesCodec the stored values share
+// their memory with data instead of being copied, so data must not be modified
+// afterwards.
+func OpenSnapshot(data []byte, numShards int, opts ...Option) (*ReadOnlyStore, error) {
+	kvs, err := NewKeyValueStore(numShards, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = kvs.importSnapshot(func(fn recordFunc) error {
+		return readSnapshotBytes(data, fn)
+	}, nil, int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReadOnlyStore{kvs: kvs}, nil
+}
+
+// Get retrieves the value associated with the given key from the store.
+// If the key is not found in the store, it returns an ErrNotFound error.
+func (s *ReadOnlyStore) Get(key string) (Value, error) {
+	return s.kvs.Get(key)
+}
+
+// Set returns ErrReadOnly.
+func (s *ReadOnlyStore) Set(key string, val Value) error {
+	return ErrReadOnly
+}
+
+// Delete returns ErrReadOnly.
+func (s *ReadOnlyStore) Delete(key string) error {
+	return ErrReadOnly
+}
+
+// Keys returns a slice of all the keys in the store.
+func (s *ReadOnlyStore) Keys() ([]string, error) {
+	return s.kvs.Keys()
+}
+
+// Scan returns an iterator over the entries whose key starts with prefix.
+func (s *ReadOnlyStore) Scan(prefix string) Iterator {
+	return s.kvs.Scan(prefix)
+}
+
+// Meta returns a copy of the metadata of the entry associated with the given key.
+func (s *ReadOnlyStore) Meta(key string) (map[string]string, error) {
+	return s.kvs.Meta(key)
+}
+
+// Close releases the resources held by the store's backends.
+func (s *ReadOnlyStore) Close() error {
+	return s.kvs.Close()
+}
@@ -0,0 +1,52 @@
+package kvs
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestOpenSnapshot(t *testing.T) {
+	store, err := NewKeyValueStore(4, WithCodec(BytesCodec{}))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	for i := 0; i < 50; i++ {
+		_ = store.Set(fmt.Sprintf("key-%d", i), Bytes(fmt.Sprint(i)))
+	}
+	_ = store.Alias("first", "key-0")
+
+	var buf bytes.Buffer
+	if err := store.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("WriteSnapshot returned an error: %v", err)
+	}
+
+	dataset, err := OpenSnapshot(buf.Bytes(), 8, WithCodec(BytesCodec{}))
+	if err != nil {
+		t.Fatalf("OpenSnapshot returned an error: %v", err)
+	}
+
+	for i := 0; i < 50; i++ {
+		val, err := dataset.Get(fmt.Sprintf("key-%d", i))
+		if err != nil {
+			t.Fatalf("Get returned an error: %v", err)
+		}
+		if string(val.(Bytes)) != fmt.Sprint(i) {
+			t.Errorf("unexpected value %s", val)
+		}
+	}
+	if val, err := dataset.Get("first"); err != nil || string(val.(Bytes)) != "0" {
+		t.Errorf("Expected the alias to resolve, got %v, %v", val, err)
+	}
+
+	if err := dataset.Set("key-0", Bytes("x")); err != ErrReadOnly {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+	if err := dataset.Delete("key-0"); err != ErrReadOnly {
+		t.Errorf("Expected ErrReadOnly, got %v", err)
+	}
+
+	if _, err := OpenSnapshot(buf.Bytes()[:buf.Len()-1], 8, WithCodec(BytesCodec{})); err != ErrCorruptSnapshot {
+		t.Errorf("Expected ErrCorruptSnapshot, got %v", err)
+	}
+}
@@ -0,0 +1,187 @@
+package kvs
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// DeletedEntry describes an entry held in the recycle bin.
+type DeletedEntry struct {
+	// Key is the original key of the entry.
+	Key string
+	// DeletedAt is the time the entry was deleted.
+	DeletedAt time.Time
+	// ExpiresAt is the time the entry is dropped from the recycle bin.
+	ExpiresAt time.Time
+}
+
+// binEntry is an entry of the recycle bin.
+type binEntry struct {
+	DeletedEntry
+	val       Value
+	immutable bool
+	meta      map[string]string
+}
+
+// recycleBin keeps recently deleted entries so they can be restored.
+// It holds at most capacity entries, evicting the oldest deletion first,
+// and drops entries once their retention has passed.
+type recycleBin struct {
+	mu        sync.Mutex
+	capacity  int
+	retention time.Duration
+	order     *list.List
+	entries   map[string]*list.Element
+	now       func() time.Time
+	onEvict   func(key, reason string)
+}
+
+// WithRecycleBin routes deleted entries into a recycle bin holding up to capacity
+// entries for the given retention, from where they can be recovered with Restore.
+func WithRecycleBin(capacity int, retention time.Duration) Option {
+	return func(o *options) {
+		o.recycleCapacity = capacity
+		o.recycleRetention = retention
+	}
+}
+
+// newRecycleBin creates a recycle bin.
+func newRecycleBin(capacity int, retention time.Duration) *recycleBin {
+	return &recycleBin{
+		capacity:  capacity,
+		retention: retention,
+		order:     list.New(),
+		entries:   make(map[string]*list.Element),
+		now:       time.Now,
+	}
+}
+
+// add puts a deleted entry into the bin, replacing an older deletion of the same key.
+func (b *recycleBin) add(key string, val Value, immutable bool, meta map[string]string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.remove(key)
+
+	now := b.now()
+	b.entries[key] = b.order.PushBack(&binEntry{
+		DeletedEntry: DeletedEntry{Key: key, DeletedAt: now, ExpiresAt: now.Add(b.retention)},
+		val:          val,
+		immutable:    immutable,
+		meta:         meta,
+	})
+
+	for b.order.Len() > b.capacity {
+		b.evict(b.order.Front().Value.(*binEntry).Key, "capacity")
+	}
+	b.prune(now)
+}
+
+// take removes and returns the entry for key if it has not expired.
+func (b *recycleBin) take(key string) (*binEntry, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.prune(b.now())
+
+	el, ok := b.entries[key]
+	if !ok {
+		return nil, false
+	}
+	b.remove(key)
+
+	return el.Value.(*binEntry), true
+}
+
+// list returns the entries in the bin, oldest deletion first.
+func (b *recycleBin) list() []DeletedEntry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.prune(b.now())
+
+	entries := make([]DeletedEntry, 0, b.order.Len())
+	for el := b.order.Front(); el != nil; el = el.Next() {
+		entries = append(entries, el.Value.(*binEntry).DeletedEntry)
+	}
+
+	return entries
+}
+
+// remove drops key from the bin.
+func (b *recycleBin) remove(key string) {
+	if el, ok := b.entries[key]; ok {
+		b.order.Remove(el)
+		delete(b.entries, key)
+	}
+}
+
+// evict drops key from the bin and reports it to onEvict.
+func (b *recycleBin) evict(key, reason string) {
+	b.remove(key)
+	if b.onEvict != nil {
+		b.onEvict(key, reason)
+	}
+}
+
+// prune drops entries whose retention has passed.
+func (b *recycleBin) prune(now time.Time) {
+	for el := b.order.Front(); el != nil; el = b.order.Front() {
+		e := el.Value.(*binEntry)
+		if now.Before(e.ExpiresAt) {
+			return
+		}
+		b.evict(e.Key, "expired")
+	}
+}
+
+// Restore recovers a deleted entry from the recycle bin together with its metadata.
+// Write-once entries are restored as write-once.
+// It returns ErrNotFound if the key is not in the bin or its retention has passed,
+// and ErrDuplicate if the key has been set again or made an alias since it was deleted.
+// Restored entries of buckets and tenants are not checked against their quotas.
+func (kvs *KeyValueStore) Restore(key string) error {
+	if kvs.bin == nil {
+		return ErrNotFound
+	}
+
+	sh := kvs.shards[kvs.shardIndex(key)]
+
+	// Runs after the shard is unlocked, as quota writes lock the shard.
+	defer kvs.invalidateUsage(key)
+	kvs.lockShard(sh)
+	defer sh.mu.Unlock()
+
+	if _, err := sh.backend.Get(key); err == nil {
+		return ErrDuplicate
+	}
+	if _, ok := sh.aliases[key]; ok {
+		return ErrDuplicate
+	}
+
+	e, ok := kvs.bin.take(key)
+	if !ok {
+		return ErrNotFound
+	}
+
+	sh.preserve(key)
+	if err := sh.backend.Set(key, e.val); err != nil {
+		return err
+	}
+	sh.setImmutable(key, e.immutable)
+	sh.setMeta(key, e.meta)
+	rev := kvs.record(sh, key, e.val, false)
+	kvs.events.publish(EventSet, key, rev)
+
+	return nil
+}
+
+// RecycleBin lists the entries that can currently be restored, oldest deletion first.
+func (kvs *KeyValueStore) RecycleBin() []DeletedEntry {
+	if kvs.bin == nil {
+		return []DeletedEntry{}
+	}
+
+	return kvs.bin.list()
+}
@@ -0,0 +1,117 @@
+package kvs
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRecycleBin(t *testing.T) {
+	store, err := NewKeyValueStore(4, WithRecycleBin(2, time.Minute))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		if err := store.Set(key, IntValue(i)); err != nil {
+			t.Fatalf("Set returned an error: %v", err)
+		}
+		if err := store.Delete(key); err != nil {
+			t.Fatalf("Delete returned an error: %v", err)
+		}
+	}
+
+	entries := store.RecycleBin()
+	if len(entries) != 2 || entries[0].Key != "key-1" || entries[1].Key != "key-2" {
+		t.Fatalf("unexpected recycle bin contents %v", entries)
+	}
+
+	if err := store.Restore("key-0"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound for an evicted entry, got %v", err)
+	}
+
+	if err := store.Restore("key-1"); err != nil {
+		t.Fatalf("Restore returned an error: %v", err)
+	}
+	if val, err := store.Get("key-1"); err != nil || val != IntValue(1) {
+		t.Errorf("Expected restored IntValue(1), got %v, %v", val, err)
+	}
+	if err := store.Restore("key-1"); err != ErrDuplicate {
+		t.Errorf("Expected ErrDuplicate for a live key, got %v", err)
+	}
+
+	if err := store.Set("key-2", IntValue(20)); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if err := store.Restore("key-2"); err != ErrDuplicate {
+		t.Errorf("Expected ErrDuplicate for a key set again, got %v", err)
+	}
+}
+
+func TestRecycleBin_Retention(t *testing.T) {
+	store, err := NewKeyValueStore(1, WithRecycleBin(10, time.Minute))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	now := time.Now()
+	store.bin.now = func() time.Time { return now }
+
+	if err := store.Set("key", IntValue(1)); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if err := store.Delete("key"); err != nil {
+		t.Fatalf("Delete returned an error: %v", err)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if entries := store.RecycleBin(); len(entries) != 0 {
+		t.Errorf("Expected an empty recycle bin after the retention, got %v", entries)
+	}
+	if err := store.Restore("key"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRecycleBin_Disabled(t *testing.T) {
+	store, err := NewKeyValueStore(1)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	if err := store.Set("key", IntValue(1)); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if err := store.Delete("key"); err != nil {
+		t.Fatalf("Delete returned an error: %v", err)
+	}
+	if err := store.Restore("key"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRecycleBin_Quota(t *testing.T) {
+	store, _ := NewKeyValueStore(4, WithRecycleBin(4, time.Minute))
+	b, tenant := store.Bucket("b"), store.Tenant("billing")
+	_ = b.SetQuota(BucketQuota{MaxEntries: 1})
+	_ = tenant.SetQuota(TenantQuota{MaxEntries: 1})
+
+	for _, s := range []Store{b, tenant} {
+		_ = s.Set("a", IntValue(1))
+		_ = s.Delete("a")
+	}
+	if err := store.Restore(b.Prefix() + "a"); err != nil {
+		t.Fatalf("Restore returned an error: %v", err)
+	}
+	if err := store.Restore(tenant.Prefix() + "a"); err != nil {
+		t.Fatalf("Restore returned an error: %v", err)
+	}
+
+	if err := b.Set("b", IntValue(2)); err != ErrQuotaExceeded {
+		t.Errorf("Expected the restored bucket entry to count, got %v", err)
+	}
+	if stats, _ := tenant.Stats(); stats.Entries != 1 {
+		t.Errorf("Expected the restored tenant entry to count, got %+v", stats)
+	}
+}
@@ -0,0 +1,88 @@
+package kvs
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// registry maps value types to stable names and back. Codecs use it to record
+// the type of an encoded value and to decode only types that were registered.
+var registry = struct {
+	sync.RWMutex
+	byName map[string]reflect.Type
+	byType map[reflect.Type]string
+}{
+	byName: make(map[string]reflect.Type),
+	byType: make(map[reflect.Type]string),
+}
+
+// RegisterType registers the value type T under name, e.g. "person/v1".
+// Codecs refuse to encode or decode value types that are not registered.
+// Names are persisted in snapshots and sent over the network, so a name must
+// not be reused for a type with a different layout; bump the version instead.
+// RegisterType panics if the name or the type is already registered differently.
+func RegisterType[T Value](name string) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() == reflect.Interface {
+		panic(fmt.Sprintf("kvs: cannot register interface type %v", t))
+	}
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if other, ok := registry.byName[name]; ok && other != t {
+		panic(fmt.Sprintf("kvs: type name %q registered for both %v and %v", name, other, t))
+	}
+	if other, ok := registry.byType[t]; ok && other != name {
+		panic(fmt.Sprintf("kvs: type %v registered as both %q and %q", t, other, name))
+	}
+
+	registry.byName[name] = t
+	registry.byType[t] = name
+}
+
+// TypeName returns the name val's type was registered under.
+func TypeName(val Value) (string, error) {
+	registry.RLock()
+	defer registry.RUnlock()
+
+	name, ok := registry.byType[reflect.TypeOf(val)]
+	if !ok {
+		return "", fmt.Errorf("%w: %T", ErrUnknownType, val)
+	}
+
+	return name, nil
+}
+
+// lookupType returns the type registered under name if it is allowed.
+// An empty allowlist allows every registered type.
+func lookupType(name string, allowed []string) (reflect.Type, error) {
+	if len(allowed) > 0 {
+		ok := false
+		for _, a := range allowed {
+			if a == name {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", ErrTypeNotAllowed, name)
+		}
+	}
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	t, ok := registry.byName[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownType, name)
+	}
+
+	return t, nil
+}
+
+func init() {
+	RegisterType[Bytes]("kvs/bytes")
+	RegisterType[Counter]("kvs/counter")
+}
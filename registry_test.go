@@ -0,0 +1,78 @@
+package kvs
+
+import (
+	"errors"
+	"testing"
+)
+
+type unregisteredValue struct{}
+
+func (unregisteredValue) Clone() Value {
+	return unregisteredValue{}
+}
+
+func TestCodecs(t *testing.T) {
+	for name, codec := range map[string]Codec{"gob": GobCodec{}, "json": JSONCodec{}} {
+		t.Run(name, func(t *testing.T) {
+			want := Person{Name: "Alice", Age: 30}
+
+			data, err := codec.Marshal(want)
+			if err != nil {
+				t.Fatalf("Marshal returned an error: %v", err)
+			}
+			val, err := codec.Unmarshal(data)
+			if err != nil {
+				t.Fatalf("Unmarshal returned an error: %v", err)
+			}
+			if p, ok := val.(Person); !ok || p != want {
+				t.Errorf("Expected %v, got %v", want, val)
+			}
+
+			if _, err := codec.Marshal(unregisteredValue{}); !errors.Is(err, ErrUnknownType) {
+				t.Errorf("Expected ErrUnknownType, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCodecs_AllowedTypes(t *testing.T) {
+	for name, pair := range map[string][2]Codec{
+		"gob":  {GobCodec{}, GobCodec{AllowedTypes: []string{"int/v1"}}},
+		"json": {JSONCodec{}, JSONCodec{AllowedTypes: []string{"int/v1"}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data, err := pair[0].Marshal(Person{Name: "Mallory"})
+			if err != nil {
+				t.Fatalf("Marshal returned an error: %v", err)
+			}
+			if _, err := pair[1].Unmarshal(data); !errors.Is(err, ErrTypeNotAllowed) {
+				t.Errorf("Expected ErrTypeNotAllowed, got %v", err)
+			}
+
+			data, err = pair[0].Marshal(IntValue(7))
+			if err != nil {
+				t.Fatalf("Marshal returned an error: %v", err)
+			}
+			if val, err := pair[1].Unmarshal(data); err != nil || val != IntValue(7) {
+				t.Errorf("Expected IntValue(7), got %v, %v", val, err)
+			}
+		})
+	}
+}
+
+func TestRegisterType_Conflict(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected RegisterType to panic for a reused name")
+		}
+	}()
+
+	RegisterType[unregisteredValue]("person/v1")
+}
+
+func TestJSONCodec_UnknownName(t *testing.T) {
+	_, err := JSONCodec{}.Unmarshal([]byte(`{"type":"nope/v1","value":{}}`))
+	if !errors.Is(err, ErrUnknownType) {
+		t.Errorf("Expected ErrUnknownType, got %v", err)
+	}
+}
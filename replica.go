@@ -0,0 +1,141 @@
+package kvs
+
+import (
+	"log/slog"
+	"time"
+)
+
+// replica is a read-only copy of a shard's entries and aliases.
+type replica struct {
+	values  map[string]Value
+	aliases map[string]string
+}
+
+// WithReadReplicas serves Get and Keys from a read-only copy of every shard instead
+// of the shard itself, so reads never take a lock. Copies of changed shards are
+// refreshed every interval, which bounds how stale a read can be; a Get right
+// after a Set may not see it yet. Writes are unaffected.
+//
+// Every refresh copies the entries of a changed shard, so this suits read-mostly
+// stores on the in-memory backend.
+func WithReadReplicas(interval time.Duration) Option {
+	return func(o *options) {
+		o.replicaInterval = interval
+	}
+}
+
+// startReplicas builds the initial replicas and refreshes them until Close.
+func (kvs *KeyValueStore) startReplicas() error {
+	for _, sh := range kvs.shards {
+		if err := sh.refreshReplica(); err != nil {
+			return err
+		}
+	}
+
+	kvs.bg.Add(1)
+	go kvs.runReplicas()
+
+	return nil
+}
+
+// runReplicas refreshes the replicas of changed shards until the store is closed.
+func (kvs *KeyValueStore) runReplicas() {
+	defer kvs.bg.Done()
+
+	ticker := time.NewTicker(kvs.opts.replicaInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-kvs.stop:
+			return
+		case <-ticker.C:
+			for _, sh := range kvs.shards {
+				if !sh.dirty.Load() {
+					continue
+				}
+				if err := sh.refreshReplica(); err != nil {
+					kvs.log(slog.LevelError, "kvs: replica refresh failed",
+						slog.Int("shard", sh.id),
+						slog.Any("error", err),
+					)
+				}
+			}
+		}
+	}
+}
+
+// refreshReplica replaces the replica of the shard with a copy of its current state.
+func (s *shard) refreshReplica() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	s.dirty.Store(false)
+
+	keys, err := s.backend.Keys()
+	if err != nil {
+		s.dirty.Store(true)
+		return err
+	}
+
+	r := &replica{
+		values:  make(map[string]Value, len(keys)),
+		aliases: make(map[string]string, len(s.aliases)),
+	}
+	for _, key := range keys {
+		val, err := s.backend.Get(key)
+		if err != nil {
+			s.dirty.Store(true)
+			return err
+		}
+		r.values[key] = val
+	}
+	for alias, target := range s.aliases {
+		r.aliases[alias] = target
+	}
+	s.replica.Store(r)
+
+	return nil
+}
+
+// replicaGet looks key up in the replicas, following aliases.
+func (kvs *KeyValueStore) replicaGet(key string) (Value, error) {
+	for depth := 0; ; depth++ {
+		sh := kvs.shards[kvs.shardIndex(key)]
+		r := sh.replica.Load()
+
+		target, ok := r.aliases[key]
+		if !ok {
+			sh.recordAccess(key)
+			val, ok := r.values[key]
+			if !ok {
+				sh.counters.countGet(ErrNotFound)
+				return nil, ErrNotFound
+			}
+			sh.counters.countGet(nil)
+			return val, nil
+		}
+
+		if depth == maxAliasDepth {
+			return nil, ErrAliasLoop
+		}
+		if isSystemKey(target) {
+			return nil, ErrReservedKey
+		}
+		key = target
+	}
+}
+
+// replicaKeys returns the keys held by the replicas.
+func (kvs *KeyValueStore) replicaKeys() []string {
+	keys := make([]string, 0)
+	for _, sh := range kvs.shards {
+		for key := range sh.replica.Load().values {
+			if !isSystemKey(key) {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	return keys
+}
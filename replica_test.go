@@ -0,0 +1,57 @@
+package kvs
+
+import (
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestReadReplicas(t *testing.T) {
+	store, err := NewKeyValueStore(4, WithReadReplicas(5*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Set("a", IntValue(1)); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if err := store.Alias("b", "a"); err != nil {
+		t.Fatalf("Alias returned an error: %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		val, err := store.Get("b")
+		if err == nil {
+			if val.(IntValue) != 1 {
+				t.Errorf("Expected 1, got %v", val)
+			}
+			break
+		}
+		if err != ErrNotFound || time.Now().After(deadline) {
+			t.Fatalf("Get returned an error: %v", err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	keys, err := store.Keys()
+	if err != nil {
+		t.Fatalf("Keys returned an error: %v", err)
+	}
+	sort.Strings(keys)
+	if len(keys) != 1 || keys[0] != "a" {
+		t.Errorf("Expected [a], got %v", keys)
+	}
+
+	_ = store.Delete("a")
+	for {
+		if _, err := store.Get("a"); err == ErrNotFound {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the deletion to reach the replica")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
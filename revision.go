@@ -0,0 +1,153 @@
+package kvs
+
+import (
+	"slices"
+	"time"
+)
+
+// version is the state of a key as of a revision. at is the time of the change
+// in Unix nanoseconds.
+type version struct {
+	rev     int64
+	at      int64
+	val     Value
+	deleted bool
+}
+
+// history holds the retained versions of a key, oldest first.
+type history struct {
+	versions []version
+	// truncated reports whether older versions have been dropped.
+	truncated bool
+}
+
+// WithRevisionHistory retains the last depth versions of every key, including
+// deletions, so they can be read with GetAtRevision. Versions are kept in memory
+// and are not part of snapshots.
+func WithRevisionHistory(depth int) Option {
+	return func(o *options) {
+		o.historyDepth = depth
+	}
+}
+
+// Revision returns the revision of the latest change to the store. Every set and
+// delete of a key increments the revision by one, starting from zero.
+func (kvs *KeyValueStore) Revision() int64 {
+	return kvs.rev.Load()
+}
+
+// record assigns the next revision to a change of key, which becomes the key's
+// version, marks the shard's replica stale and retains the change when revision
+// history is enabled. It returns the revision.
+// The shard must be locked.
+func (kvs *KeyValueStore) record(sh *shard, key string, val Value, deleted bool) int64 {
+	rev := kvs.rev.Add(1)
+	sh.setRev(key, rev, deleted)
+	sh.dirty.Store(true)
+	if kvs.opts.historyDepth <= 0 {
+		return rev
+	}
+
+	if sh.history == nil {
+		sh.history = make(map[string]*history)
+	}
+	h, ok := sh.history[key]
+	if !ok {
+		h = &history{}
+		sh.history[key] = h
+	}
+
+	h.versions = append(h.versions, version{rev: rev, at: time.Now().UnixNano(), val: val, deleted: deleted})
+	if n := len(h.versions) - kvs.opts.historyDepth; n > 0 {
+		h.versions = slices.Delete(h.versions, 0, n)
+		h.truncated = true
+		if oldest := h.versions[0]; oldest.rev > sh.horizon.rev {
+			sh.horizon = version{rev: oldest.rev, at: oldest.at}
+		}
+	}
+
+	return rev
+}
+
+// versionAt returns the value of the newest version of key that is visible.
+// The shard must be locked.
+func (s *shard) versionAt(key string, visible func(v version) bool) (Value, error) {
+	h, ok := s.history[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	for i := len(h.versions) - 1; i >= 0; i-- {
+		v := h.versions[i]
+		if !visible(v) {
+			continue
+		}
+		if v.deleted {
+			return nil, ErrNotFound
+		}
+		return v.val, nil
+	}
+
+	if h.truncated {
+		return nil, ErrCompacted
+	}
+
+	return nil, ErrNotFound
+}
+
+// GetAtRevision returns the value key had as of revision rev. Reading several keys
+// at the same revision gives a consistent view across shards without locking them
+// together. Aliases are resolved as they are now.
+// It returns ErrNotFound if the key did not exist at rev, ErrCompacted if the
+// versions needed are no longer retained or have been compacted, and
+// ErrFutureRevision if rev is greater than Revision. Without WithRevisionHistory
+// no versions are retained.
+func (kvs *KeyValueStore) GetAtRevision(key string, rev int64) (Value, error) {
+	if rev > kvs.Revision() {
+		return nil, ErrFutureRevision
+	}
+	if kvs.opts.historyDepth <= 0 || rev < kvs.CompactedRevision() {
+		return nil, ErrCompacted
+	}
+	if isSystemKey(key) {
+		return nil, ErrReservedKey
+	}
+
+	return kvs.getVersion(key, func(v version) bool {
+		return v.rev <= rev
+	})
+}
+
+// GetAsOf returns the value key had at time t, as retained by WithRevisionHistory.
+// Aliases are resolved as they are now.
+// It returns ErrNotFound if the key did not exist at t and ErrCompacted if the
+// versions needed are no longer retained or have been compacted.
+func (kvs *KeyValueStore) GetAsOf(key string, t time.Time) (Value, error) {
+	at := t.UnixNano()
+	if kvs.opts.historyDepth <= 0 || at < kvs.compactedAt.Load() {
+		return nil, ErrCompacted
+	}
+	if isSystemKey(key) {
+		return nil, ErrReservedKey
+	}
+
+	return kvs.getVersion(key, func(v version) bool {
+		return v.at <= at
+	})
+}
+
+// getVersion returns the newest visible version of key.
+func (kvs *KeyValueStore) getVersion(key string, visible func(v version) bool) (Value, error) {
+	sh, key, err := kvs.rlockKey(key)
+	if err != nil {
+		return nil, err
+	}
+	val, err := sh.versionAt(key, visible)
+	sh.mu.RUnlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return kvs.decompress(val)
+}
@@ -0,0 +1,105 @@
+package kvs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetAtRevision(t *testing.T) {
+	store, err := NewKeyValueStore(4, WithRevisionHistory(3))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	_ = store.Set("a", IntValue(1))
+	first := store.Revision()
+	_ = store.Set("b", IntValue(10))
+	_ = store.Set("a", IntValue(2))
+	_ = store.Delete("b")
+	last := store.Revision()
+
+	if first != 1 || last != 4 {
+		t.Fatalf("Expected revisions 1 and 4, got %d and %d", first, last)
+	}
+
+	val, err := store.GetAtRevision("a", first)
+	if err != nil {
+		t.Fatalf("GetAtRevision returned an error: %v", err)
+	}
+	if val.(IntValue) != 1 {
+		t.Errorf("Expected 1 at revision %d, got %v", first, val)
+	}
+
+	val, err = store.GetAtRevision("a", last)
+	if err != nil {
+		t.Fatalf("GetAtRevision returned an error: %v", err)
+	}
+	if val.(IntValue) != 2 {
+		t.Errorf("Expected 2 at revision %d, got %v", last, val)
+	}
+
+	if _, err := store.GetAtRevision("b", first); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound before b was set, got %v", err)
+	}
+	if val, err := store.GetAtRevision("b", 3); err != nil || val.(IntValue) != 10 {
+		t.Errorf("Expected 10 at revision 3, got %v, %v", val, err)
+	}
+	if _, err := store.GetAtRevision("b", last); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound after b was deleted, got %v", err)
+	}
+	if _, err := store.GetAtRevision("a", last+1); err != ErrFutureRevision {
+		t.Errorf("Expected ErrFutureRevision, got %v", err)
+	}
+}
+
+func TestGetAtRevision_Compacted(t *testing.T) {
+	store, _ := NewKeyValueStore(4, WithRevisionHistory(2))
+
+	for i := 1; i <= 3; i++ {
+		_ = store.Set("a", IntValue(i))
+	}
+
+	if _, err := store.GetAtRevision("a", 1); err != ErrCompacted {
+		t.Errorf("Expected ErrCompacted, got %v", err)
+	}
+	if val, err := store.GetAtRevision("a", 2); err != nil || val.(IntValue) != 2 {
+		t.Errorf("Expected 2 at revision 2, got %v, %v", val, err)
+	}
+
+	plain, _ := NewKeyValueStore(4)
+	_ = plain.Set("a", IntValue(1))
+	if _, err := plain.GetAtRevision("a", 1); err != ErrCompacted {
+		t.Errorf("Expected ErrCompacted without history, got %v", err)
+	}
+}
+
+func TestGetAsOf(t *testing.T) {
+	store, err := NewKeyValueStore(4, WithRevisionHistory(10))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	before := time.Now()
+	_ = store.Set("config/x", IntValue(1))
+	time.Sleep(time.Millisecond)
+	first := time.Now()
+	time.Sleep(time.Millisecond)
+	_ = store.Set("config/x", IntValue(2))
+
+	if _, err := store.GetAsOf("config/x", before); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound before the first set, got %v", err)
+	}
+	if val, err := store.GetAsOf("config/x", first); err != nil || val.(IntValue) != 1 {
+		t.Errorf("Expected 1 after the first set, got %v, %v", val, err)
+	}
+	if val, err := store.GetAsOf("config/x", time.Now()); err != nil || val.(IntValue) != 2 {
+		t.Errorf("Expected 2 now, got %v, %v", val, err)
+	}
+
+	if err := store.Compact(store.Revision()); err != nil {
+		t.Fatalf("Compact returned an error: %v", err)
+	}
+	if _, err := store.GetAsOf("config/x", first); err != ErrCompacted {
+		t.Errorf("Expected ErrCompacted, got %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package kvs
+
+// Savepoint marks a point in a transaction that RollbackTo can return to.
+type Savepoint struct {
+	tx *Txn
+	id int
+}
+
+// savepointMark is the position of a savepoint in the undo log of a transaction.
+type savepointMark struct {
+	id  int
+	pos int
+}
+
+// txnUndo restores the buffered write of a key replaced by a later write.
+type txnUndo struct {
+	key     string
+	prev    txnWrite
+	existed bool
+}
+
+// Savepoint marks the current state of the transaction so that the writes
+// buffered after it can be discarded with RollbackTo, without aborting the whole
+// transaction. Savepoints nest: rolling back to one invalidates the savepoints
+// taken after it.
+//
+//	sp := tx.Savepoint()
+//	if err := reserveStock(tx); err != nil {
+//		_ = tx.RollbackTo(sp)
+//	}
+func (tx *Txn) Savepoint() Savepoint {
+	tx.nextSavepoint++
+	tx.marks = append(tx.marks, savepointMark{id: tx.nextSavepoint, pos: len(tx.undo)})
+
+	return Savepoint{tx: tx, id: tx.nextSavepoint}
+}
+
+// RollbackTo discards the writes buffered since sp was taken. sp stays valid, so
+// the transaction can roll back to it again; savepoints taken after it do not.
+// It returns ErrInvalidSavepoint if sp does not belong to the transaction or was
+// invalidated.
+func (tx *Txn) RollbackTo(sp Savepoint) error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	if sp.tx != tx {
+		return ErrInvalidSavepoint
+	}
+
+	i := len(tx.marks) - 1
+	for i >= 0 && tx.marks[i].id != sp.id {
+		i--
+	}
+	if i < 0 {
+		return ErrInvalidSavepoint
+	}
+
+	pos := tx.marks[i].pos
+	for j := len(tx.undo) - 1; j >= pos; j-- {
+		u := tx.undo[j]
+		tx.size -= tx.writes[u.key].size
+		if u.existed {
+			tx.writes[u.key] = u.prev
+			tx.size += u.prev.size
+		} else {
+			delete(tx.writes, u.key)
+		}
+	}
+	tx.undo = tx.undo[:pos]
+	tx.marks = tx.marks[:i+1]
+
+	return nil
+}
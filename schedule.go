@@ -0,0 +1,197 @@
+package kvs
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	snapshotPrefix = "snapshot-"
+	snapshotSuffix = ".kvs"
+)
+
+// SaveSnapshot atomically writes a snapshot of the store to path.
+// The snapshot is written to a temporary file in the same directory, synced and
+// renamed over path, so readers never observe a partially written snapshot.
+func (kvs *KeyValueStore) SaveSnapshot(path string) error {
+	return kvs.saveSnapshot(path, false)
+}
+
+// saveSnapshot implements SaveSnapshot. Background snapshots are rate limited.
+func (kvs *KeyValueStore) saveSnapshot(path string, background bool) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	var w io.Writer = tmp
+	if background {
+		w = kvs.throttle(w)
+	}
+	if err := kvs.WriteSnapshot(w); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+// LoadSnapshot loads the snapshot file at path into the store. Options such as
+// WithProgress configure the import.
+func (kvs *KeyValueStore) LoadSnapshot(path string, opts ...ImportOption) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = kvs.ImportSnapshot(f, opts...)
+
+	return err
+}
+
+// syncDir flushes a directory entry so a rename inside it survives a crash.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
+
+// SnapshotSchedule configures periodic snapshots.
+type SnapshotSchedule struct {
+	// Dir is the directory snapshots are written to.
+	Dir string
+	// Interval is the time between two snapshots.
+	Interval time.Duration
+	// Retain is the number of snapshots to keep. Zero keeps all of them.
+	Retain int
+	// OnError is called when taking or pruning a snapshot fails. It may be nil.
+	OnError func(error)
+}
+
+// SnapshotScheduler takes snapshots of a store at a fixed interval.
+type SnapshotScheduler struct {
+	kvs      *KeyValueStore
+	schedule SnapshotSchedule
+	stop     chan struct{}
+	done     chan struct{}
+	once     sync.Once
+}
+
+// ScheduleSnapshots starts taking a snapshot of the store into schedule.Dir every
+// schedule.Interval, keeping the last schedule.Retain snapshots.
+func (kvs *KeyValueStore) ScheduleSnapshots(schedule SnapshotSchedule) (*SnapshotScheduler, error) {
+	if schedule.Interval <= 0 {
+		return nil, fmt.Errorf("kvs: invalid snapshot interval %v", schedule.Interval)
+	}
+	if err := os.MkdirAll(schedule.Dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	s := &SnapshotScheduler{
+		kvs:      kvs,
+		schedule: schedule,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go s.run()
+
+	return s, nil
+}
+
+// run takes snapshots until the scheduler is stopped.
+func (s *SnapshotScheduler) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.schedule.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			if err := s.Snapshot(); err != nil {
+				s.kvs.log(slog.LevelError, "kvs: scheduled snapshot failed", slog.Any("error", err))
+				if s.schedule.OnError != nil {
+					s.schedule.OnError(err)
+				}
+			}
+		}
+	}
+}
+
+// Snapshot takes a snapshot immediately and prunes old snapshots.
+func (s *SnapshotScheduler) Snapshot() error {
+	name := fmt.Sprintf("%s%020d%s", snapshotPrefix, time.Now().UnixNano(), snapshotSuffix)
+	if err := s.kvs.saveSnapshot(filepath.Join(s.schedule.Dir, name), true); err != nil {
+		return err
+	}
+
+	if s.schedule.Retain <= 0 {
+		return nil
+	}
+
+	snapshots, err := ListSnapshots(s.schedule.Dir)
+	if err != nil {
+		return err
+	}
+	for len(snapshots) > s.schedule.Retain {
+		if err := os.Remove(snapshots[0]); err != nil {
+			return err
+		}
+		snapshots = snapshots[1:]
+	}
+
+	return nil
+}
+
+// Stop stops the scheduler and waits for an in-flight snapshot to finish.
+func (s *SnapshotScheduler) Stop() {
+	s.once.Do(func() {
+		close(s.stop)
+	})
+	<-s.done
+}
+
+// ListSnapshots returns the paths of the scheduled snapshots in dir, oldest first.
+func ListSnapshots(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, e := range entries {
+		name := e.Name()
+		if e.Type().IsRegular() && strings.HasPrefix(name, snapshotPrefix) && strings.HasSuffix(name, snapshotSuffix) {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+	sort.Strings(paths)
+
+	return paths, nil
+}
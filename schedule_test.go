@@ -0,0 +1,76 @@
+package kvs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSaveSnapshot(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	if err := store.Set("person", Person{Name: "Alice", Age: 30}); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "store.kvs")
+	if err := store.SaveSnapshot(path); err != nil {
+		t.Fatalf("SaveSnapshot returned an error: %v", err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("Expected only the snapshot file, found %d entries", len(entries))
+	}
+
+	restored, _ := NewKeyValueStore(4)
+	if err := restored.LoadSnapshot(path); err != nil {
+		t.Fatalf("LoadSnapshot returned an error: %v", err)
+	}
+	if _, err := restored.Get("person"); err != nil {
+		t.Errorf("Get returned an error: %v", err)
+	}
+}
+
+func TestScheduleSnapshots(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	dir := t.TempDir()
+	scheduler, err := store.ScheduleSnapshots(SnapshotSchedule{
+		Dir:      dir,
+		Interval: time.Hour,
+		Retain:   2,
+	})
+	if err != nil {
+		t.Fatalf("ScheduleSnapshots returned an error: %v", err)
+	}
+	defer scheduler.Stop()
+
+	for i := 0; i < 5; i++ {
+		if err := scheduler.Snapshot(); err != nil {
+			t.Fatalf("Snapshot returned an error: %v", err)
+		}
+	}
+
+	snapshots, err := ListSnapshots(dir)
+	if err != nil {
+		t.Fatalf("ListSnapshots returned an error: %v", err)
+	}
+	if len(snapshots) != 2 {
+		t.Errorf("Expected 2 retained snapshots, got %d", len(snapshots))
+	}
+}
+
+func TestScheduleSnapshots_InvalidInterval(t *testing.T) {
+	store, _ := NewKeyValueStore(1)
+	if _, err := store.ScheduleSnapshots(SnapshotSchedule{Dir: t.TempDir()}); err == nil {
+		t.Error("Expected an error for a zero interval")
+	}
+}
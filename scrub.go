@@ -0,0 +1,285 @@
+package kvs
+
+import (
+	"log/slog"
+	"maps"
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+// ScrubStats holds the findings of the integrity scrubber.
+type ScrubStats struct {
+	// Passes is the number of completed walks over all shards.
+	Passes uint64
+	// Keys is the number of keys checked.
+	Keys uint64
+	// Issues is the number of inconsistencies found.
+	Issues uint64
+	// Repaired is the number of inconsistencies that were repaired.
+	Repaired uint64
+}
+
+// scrubCounters accumulate the findings of the scrubber.
+type scrubCounters struct {
+	passes   atomic.Uint64
+	keys     atomic.Uint64
+	issues   atomic.Uint64
+	repaired atomic.Uint64
+}
+
+// add counts the findings of s.
+func (c *scrubCounters) add(s ScrubStats) {
+	c.passes.Add(s.Passes)
+	c.keys.Add(s.Keys)
+	c.issues.Add(s.Issues)
+	c.repaired.Add(s.Repaired)
+}
+
+// WithScrubber starts a background scrubber that checks one shard every interval,
+// walking the shards in turn. It verifies that every value can be read and
+// decoded, that every key lives in the shard it hashes to, and that metadata,
+// write-once marks, versions and read replicas match the entries of the shard.
+// Unreadable values are restored from the shard's read replica when it holds a
+// readable copy, and stale index entries are dropped. See Scrub for reporting.
+func WithScrubber(interval time.Duration) Option {
+	return func(o *options) {
+		o.scrubInterval = interval
+	}
+}
+
+// ScrubStats returns the findings of the scrubber and of Scrub since the store was created.
+func (kvs *KeyValueStore) ScrubStats() ScrubStats {
+	return ScrubStats{
+		Passes:   kvs.scrub.passes.Load(),
+		Keys:     kvs.scrub.keys.Load(),
+		Issues:   kvs.scrub.issues.Load(),
+		Repaired: kvs.scrub.repaired.Load(),
+	}
+}
+
+// Scrub checks every shard once, as the scrubber of WithScrubber does, and returns
+// its findings. Every inconsistency is logged at Warn level and, if it concerns a
+// key, published as an EventCorrupt for that key.
+func (kvs *KeyValueStore) Scrub() ScrubStats {
+	stats := ScrubStats{Passes: 1}
+	for _, sh := range kvs.shards {
+		s := kvs.scrubShard(sh)
+		stats.Keys += s.Keys
+		stats.Issues += s.Issues
+		stats.Repaired += s.Repaired
+	}
+	kvs.scrub.passes.Add(1)
+
+	return stats
+}
+
+// startScrubber runs the scrubber until Close.
+func (kvs *KeyValueStore) startScrubber() {
+	kvs.bg.Add(1)
+	go kvs.runScrubber()
+}
+
+// runScrubber checks the next shard every interval until the store is closed.
+func (kvs *KeyValueStore) runScrubber() {
+	defer kvs.bg.Done()
+
+	ticker := time.NewTicker(kvs.opts.scrubInterval)
+	defer ticker.Stop()
+
+	next := 0
+	for {
+		select {
+		case <-kvs.stop:
+			return
+		case <-ticker.C:
+			kvs.scrubShard(kvs.shards[next])
+			next = (next + 1) % len(kvs.shards)
+			if next == 0 {
+				kvs.scrub.passes.Add(1)
+			}
+		}
+	}
+}
+
+// scrubFinding is an inconsistency found in a shard.
+type scrubFinding struct {
+	// key is the affected key, or empty if the finding concerns the whole shard.
+	key     string
+	problem string
+	// repair fixes the inconsistency and reports whether it did. It is nil if
+	// the inconsistency cannot be repaired. The shard must be locked.
+	repair func() bool
+}
+
+// scrubShard checks sh, repairs what it can and reports the findings.
+// The shard is only read-locked while it is checked, and write-locked only if
+// there is something to repair.
+func (kvs *KeyValueStore) scrubShard(sh *shard) ScrubStats {
+	sh.mu.RLock()
+	findings, checked := kvs.checkShard(sh)
+	sh.mu.RUnlock()
+
+	stats := ScrubStats{Keys: uint64(checked), Issues: uint64(len(findings))}
+	repaired := make([]bool, len(findings))
+	if len(findings) > 0 {
+		kvs.lockShard(sh)
+		for i, f := range findings {
+			if f.repair != nil && f.repair() {
+				repaired[i] = true
+				stats.Repaired++
+			}
+		}
+		sh.mu.Unlock()
+	}
+
+	for i, f := range findings {
+		kvs.log(slog.LevelWarn, "kvs: scrubber found inconsistency",
+			slog.Int("shard", sh.id),
+			slog.String("key", f.key),
+			slog.String("problem", f.problem),
+			slog.Bool("repaired", repaired[i]),
+		)
+		if f.key != "" {
+			kvs.events.publish(EventCorrupt, f.key, 0)
+		}
+	}
+	kvs.scrub.add(stats)
+
+	return stats
+}
+
+// checkShard returns the inconsistencies of sh and the number of keys checked.
+// The shard must be locked.
+func (kvs *KeyValueStore) checkShard(sh *shard) ([]scrubFinding, int) {
+	keys, err := sh.backend.Keys()
+	if err != nil {
+		return []scrubFinding{{problem: "unreadable keys: " + err.Error()}}, 0
+	}
+
+	var findings []scrubFinding
+	r := sh.replica.Load()
+	present := make(map[string]struct{}, len(keys))
+	unreadable := make(map[string]struct{})
+
+	for _, key := range keys {
+		present[key] = struct{}{}
+		if kvs.shardIndex(key) != sh.id {
+			findings = append(findings, scrubFinding{key: key, problem: "misplaced key"})
+			continue
+		}
+		if !kvs.readable(sh, key) {
+			unreadable[key] = struct{}{}
+			findings = append(findings, scrubFinding{
+				key:     key,
+				problem: "unreadable value",
+				repair:  kvs.restoreFromReplica(sh, r, key),
+			})
+		}
+	}
+
+	orphaned := func(key string) bool {
+		_, err := sh.backend.Get(key)
+		return err == ErrNotFound
+	}
+	for key := range sh.meta {
+		if _, ok := present[key]; !ok {
+			findings = append(findings, scrubFinding{key: key, problem: "orphaned metadata", repair: func() bool {
+				if !orphaned(key) {
+					return false
+				}
+				sh.setMeta(key, nil)
+				return true
+			}})
+		}
+	}
+	for key := range sh.immutable {
+		if _, ok := present[key]; !ok {
+			findings = append(findings, scrubFinding{key: key, problem: "orphaned write-once mark", repair: func() bool {
+				if !orphaned(key) {
+					return false
+				}
+				sh.setImmutable(key, false)
+				return true
+			}})
+		}
+	}
+	for key := range sh.revs {
+		if _, ok := present[key]; !ok {
+			findings = append(findings, scrubFinding{key: key, problem: "orphaned version", repair: func() bool {
+				if !orphaned(key) {
+					return false
+				}
+				delete(sh.revs, key)
+				return true
+			}})
+		}
+	}
+
+	if r != nil && !sh.dirty.Load() && !replicaMatches(sh, r, keys, unreadable) {
+		findings = append(findings, scrubFinding{problem: "stale replica", repair: func() bool {
+			sh.dirty.Store(true)
+			return true
+		}})
+	}
+
+	return findings, len(keys)
+}
+
+// readable reports whether the value of key can be read and decoded.
+// The shard must be locked.
+func (kvs *KeyValueStore) readable(sh *shard, key string) bool {
+	val, err := sh.backend.Get(key)
+	if err != nil {
+		return false
+	}
+	_, err = kvs.decompress(val)
+
+	return err == nil
+}
+
+// restoreFromReplica returns a repair that writes the replica's copy of key back
+// to the shard, or nil if r holds no readable copy.
+func (kvs *KeyValueStore) restoreFromReplica(sh *shard, r *replica, key string) func() bool {
+	if r == nil {
+		return nil
+	}
+	val, ok := r.values[key]
+	if !ok {
+		return nil
+	}
+	if _, err := kvs.decompress(val); err != nil {
+		return nil
+	}
+
+	return func() bool {
+		if kvs.readable(sh, key) {
+			return false
+		}
+		return sh.backend.Set(key, val) == nil
+	}
+}
+
+// replicaMatches reports whether r holds the entries and aliases of sh, ignoring
+// the values of the skipped keys. The shard must be locked.
+func replicaMatches(sh *shard, r *replica, keys []string, skip map[string]struct{}) bool {
+	if len(r.values) != len(keys) || !maps.Equal(r.aliases, sh.aliases) {
+		return false
+	}
+
+	for _, key := range keys {
+		rv, ok := r.values[key]
+		if !ok {
+			return false
+		}
+		if _, ok := skip[key]; ok {
+			continue
+		}
+		val, err := sh.backend.Get(key)
+		if err != nil || !reflect.DeepEqual(val, rv) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,118 @@
+package kvs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScrub(t *testing.T) {
+	store, err := NewKeyValueStore(4,
+		WithCodec(BytesCodec{}),
+		WithCompression(FlateCompressor{}, 0),
+		WithReadReplicas(time.Hour),
+	)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Set("a", Bytes("alpha")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if err := store.SetMeta("a", map[string]string{"owner": "ops"}); err != nil {
+		t.Fatalf("SetMeta returned an error: %v", err)
+	}
+	sh := store.shards[store.shardIndex("a")]
+	if err := sh.refreshReplica(); err != nil {
+		t.Fatalf("refreshReplica returned an error: %v", err)
+	}
+
+	if stats := store.Scrub(); stats.Issues != 0 || stats.Keys != 1 {
+		t.Fatalf("Expected a clean pass over 1 key, got %+v", stats)
+	}
+
+	events, cancel := store.Subscribe("*")
+	defer cancel()
+
+	// Corrupt the value, orphan the metadata of a deleted key and store a key
+	// in a shard it does not hash to, which also leaves that shard's replica stale.
+	_ = sh.backend.Set("a", &compressedValue{data: []byte("garbage")})
+	sh.setMeta("gone", map[string]string{"owner": "ops"})
+	other := store.shards[(sh.id+1)%len(store.shards)]
+	_ = other.backend.Set("a", Bytes("misplaced"))
+
+	stats := store.Scrub()
+	if stats.Issues != 4 || stats.Repaired != 3 {
+		t.Errorf("Expected 4 issues and 3 repairs, got %+v", stats)
+	}
+
+	val, err := store.Get("a")
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+	if string(val.(Bytes)) != "alpha" {
+		t.Errorf("Expected alpha, got %q", val)
+	}
+	if _, ok := sh.meta["gone"]; ok {
+		t.Errorf("Expected orphaned metadata to be dropped")
+	}
+
+	got := map[string]int{}
+	for i := 0; i < 3; i++ {
+		select {
+		case ev := <-events:
+			if ev.Type != EventCorrupt {
+				t.Errorf("Expected a corrupt event, got %v", ev.Type)
+			}
+			got[ev.Key]++
+		case <-time.After(time.Second):
+			t.Fatalf("Expected 3 corrupt events, got %v", got)
+		}
+	}
+	if got["a"] != 2 || got["gone"] != 1 {
+		t.Errorf("Unexpected corrupt events: %v", got)
+	}
+
+	if total := store.ScrubStats(); total.Passes != 2 || total.Issues != 4 || total.Keys != 3 {
+		t.Errorf("Unexpected totals: %+v", total)
+	}
+}
+
+func TestScrub_StaleReplica(t *testing.T) {
+	store, err := NewKeyValueStore(1, WithReadReplicas(time.Hour))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	defer store.Close()
+
+	// A write that bypasses the store leaves the replica stale without marking it.
+	_ = store.shards[0].backend.Set("a", IntValue(1))
+
+	if stats := store.Scrub(); stats.Issues != 1 || stats.Repaired != 1 {
+		t.Errorf("Expected 1 repaired issue, got %+v", stats)
+	}
+	if !store.shards[0].dirty.Load() {
+		t.Errorf("Expected the replica to be marked for refresh")
+	}
+}
+
+func TestWithScrubber(t *testing.T) {
+	store, err := NewKeyValueStore(2, WithScrubber(time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	defer store.Close()
+
+	_ = store.Set("a", IntValue(1))
+	store.shards[store.shardIndex("a")].mu.Lock()
+	store.shards[store.shardIndex("a")].setImmutable("gone", true)
+	store.shards[store.shardIndex("a")].mu.Unlock()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for store.ScrubStats().Repaired == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the scrubber to repair the orphaned mark, got %+v", store.ScrubStats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
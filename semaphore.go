@@ -0,0 +1,305 @@
+package kvs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// defaultLeaseTTL is the lease duration used without WithLeaseTTL.
+const defaultLeaseTTL = 10 * time.Second
+
+// errSemaphoreFull aborts the update of a semaphore without a free slot.
+var errSemaphoreFull = errors.New("kvs: semaphore is full")
+
+// leaseOptions holds the settings of a lease.
+type leaseOptions struct {
+	ttl time.Duration
+}
+
+// LeaseOption configures a lease.
+type LeaseOption func(*leaseOptions)
+
+// WithLeaseTTL sets how long a lease lasts without being renewed. Leases are
+// renewed every third of ttl until they are released, so a holder whose process
+// died frees its slot after at most ttl. The default is 10 seconds.
+func WithLeaseTTL(ttl time.Duration) LeaseOption {
+	return func(o *leaseOptions) {
+		o.ttl = ttl
+	}
+}
+
+// leaseHolders maps the ids of the holders of a key to the expiry of their
+// leases in Unix nanoseconds.
+type leaseHolders map[string]int64
+
+// leaseState is the state of a semaphore or barrier. It is stored as JSON, so any
+// client can read it.
+type leaseState struct {
+	Holders leaseHolders `json:"holders"`
+	// Released is set once a barrier was reached.
+	Released bool `json:"released,omitempty"`
+}
+
+// decodeLeaseState parses the state stored in val. A missing key has no holders.
+func decodeLeaseState(val Value, exists bool) (*leaseState, error) {
+	st := &leaseState{}
+	if exists {
+		b, ok := val.(Bytes)
+		if !ok {
+			return nil, ErrNotSemaphore
+		}
+		if err := json.Unmarshal(b, st); err != nil {
+			return nil, ErrNotSemaphore
+		}
+	}
+	if st.Holders == nil {
+		st.Holders = make(leaseHolders)
+	}
+
+	return st, nil
+}
+
+// encode returns the stored form of st.
+func (st *leaseState) encode() (Value, error) {
+	b, err := json.Marshal(st)
+	if err != nil {
+		return nil, err
+	}
+
+	return Bytes(b), nil
+}
+
+// prune removes the holders whose lease expired before now.
+func (h leaseHolders) prune(now time.Time) {
+	for id, expiry := range h {
+		if expiry <= now.UnixNano() {
+			delete(h, id)
+		}
+	}
+}
+
+// earliest returns the time the first lease expires, or the zero time if there
+// are no holders.
+func (h leaseHolders) earliest() time.Time {
+	var first int64
+	for _, expiry := range h {
+		if first == 0 || expiry < first {
+			first = expiry
+		}
+	}
+	if first == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, first)
+}
+
+// Lease is a slot of a semaphore held by Acquire. It is renewed in the
+// background until Release is called or the store is closed.
+type Lease struct {
+	kvs  *KeyValueStore
+	name string
+	id   string
+	ttl  time.Duration
+
+	stop chan struct{}
+	once sync.Once
+	done chan struct{}
+}
+
+// Acquire takes one of the n slots of the counting semaphore stored under key
+// name, waiting until a slot is free or ctx is done. Holders are kept in the key
+// as JSON with the expiry of their leases; expired holders no longer count, so a
+// slot held by a dead process is freed once its lease runs out. Waiters watch the
+// key, so they wake up as soon as a slot is released.
+//
+//	lease, err := store.Acquire(ctx, "limits/exports", 3)
+//	if err != nil {
+//		// Handle the error
+//	}
+//	defer lease.Release()
+//
+// Slots are taken with an atomic update of the key, so semaphores coordinate the
+// users of one KeyValueStore, such as the goroutines of a process; kvsclient and
+// the network servers do not offer them.
+//
+// It returns ErrInvalidArgument if n is not positive or the lease duration is
+// too short to be renewed, and ErrNotSemaphore if name holds a value that is not
+// a semaphore.
+func (kvs *KeyValueStore) Acquire(ctx context.Context, name string, n int, opts ...LeaseOption) (*Lease, error) {
+	o := leaseOptions{ttl: defaultLeaseTTL}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := checkLease(n, o.ttl); err != nil {
+		return nil, err
+	}
+	id := rand.Text()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// Watch before trying, so a release between a failed try and the wait is not missed.
+	changes := kvs.Watch(ctx, name)
+
+	for {
+		next, err := kvs.tryAcquire(name, id, n, o.ttl)
+		if err == nil {
+			return kvs.startLease(name, id, o.ttl), nil
+		}
+		if err != errSemaphoreFull {
+			return nil, err
+		}
+
+		if err := kvs.waitForChange(ctx, name, &changes, next); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// waitForChange waits until an event arrives on changes, the time next is
+// reached or ctx is done. A zero next is never reached. If changes was closed
+// because the watch overflowed, the key is watched again and waitForChange
+// returns, so the caller checks the key once more.
+func (kvs *KeyValueStore) waitForChange(ctx context.Context, key string, changes *<-chan Event, next time.Time) error {
+	var expired <-chan time.Time
+	if !next.IsZero() {
+		timer := time.NewTimer(time.Until(next))
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case _, ok := <-*changes:
+		if !ok {
+			*changes = kvs.Watch(ctx, key)
+		}
+	case <-expired:
+	}
+
+	return nil
+}
+
+// tryAcquire adds id to the holders of name if fewer than n leases are live.
+// Otherwise it returns errSemaphoreFull and the time the first lease expires.
+func (kvs *KeyValueStore) tryAcquire(name, id string, n int, ttl time.Duration) (time.Time, error) {
+	var next time.Time
+	err := kvs.Update(name, func(old Value, exists bool) (Value, error) {
+		st, err := decodeLeaseState(old, exists)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		st.Holders.prune(now)
+		if len(st.Holders) >= n {
+			next = st.Holders.earliest()
+			return nil, errSemaphoreFull
+		}
+		st.Holders[id] = now.Add(ttl).UnixNano()
+
+		return st.encode()
+	})
+
+	return next, err
+}
+
+// checkLease returns ErrInvalidArgument unless n is positive and ttl is long
+// enough for the positive renewal interval startLease needs.
+func checkLease(n int, ttl time.Duration) error {
+	if n <= 0 || ttl/3 <= 0 {
+		return ErrInvalidArgument
+	}
+
+	return nil
+}
+
+// startLease starts renewing the lease of id on name.
+func (kvs *KeyValueStore) startLease(name, id string, ttl time.Duration) *Lease {
+	l := &Lease{
+		kvs:  kvs,
+		name: name,
+		id:   id,
+		ttl:  ttl,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	kvs.bg.Add(1)
+	go l.renew()
+
+	return l
+}
+
+// renew extends the lease every third of its duration until it is released, it
+// expired or the store is closed.
+func (l *Lease) renew() {
+	defer l.kvs.bg.Done()
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-l.kvs.stop:
+			return
+		case <-ticker.C:
+			err := l.update(func(h leaseHolders) {
+				h[l.id] = time.Now().Add(l.ttl).UnixNano()
+			})
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// update applies fn to the holders of the lease's key. It returns
+// ErrLeaseExpired if the lease is no longer held.
+func (l *Lease) update(fn func(h leaseHolders)) error {
+	return l.kvs.Update(l.name, func(old Value, exists bool) (Value, error) {
+		st, err := decodeLeaseState(old, exists)
+		if err != nil {
+			return nil, err
+		}
+		if expiry, ok := st.Holders[l.id]; !ok || expiry <= time.Now().UnixNano() {
+			return nil, ErrLeaseExpired
+		}
+		fn(st.Holders)
+
+		return st.encode()
+	})
+}
+
+// Done returns a channel that is closed when the lease is no longer renewed:
+// after Release, once it expired because it could not be renewed, or when the
+// store is closed.
+func (l *Lease) Done() <-chan struct{} {
+	return l.done
+}
+
+// Release frees the slot of the lease and stops renewing it. It returns
+// ErrLeaseExpired if the lease had already expired or was released before.
+func (l *Lease) Release() error {
+	l.stopRenewing()
+
+	return l.update(func(h leaseHolders) {
+		delete(h, l.id)
+	})
+}
+
+// stopRenewing stops renewing the lease and waits until the renewal has ended.
+func (l *Lease) stopRenewing() {
+	l.once.Do(func() {
+		close(l.stop)
+	})
+	<-l.done
+}
@@ -0,0 +1,131 @@
+package kvs
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestAcquire(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	first, err := store.Acquire(ctx, "sem", 2)
+	if err != nil {
+		t.Fatalf("Acquire returned an error: %v", err)
+	}
+	if _, err := store.Acquire(ctx, "sem", 2); err != nil {
+		t.Fatalf("Acquire returned an error: %v", err)
+	}
+
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, err := store.Acquire(short, "sem", 2); err != context.DeadlineExceeded {
+		t.Fatalf("Expected the full semaphore to block until the deadline, got %v", err)
+	}
+
+	acquired := make(chan *Lease)
+	go func() {
+		lease, err := store.Acquire(ctx, "sem", 2)
+		if err != nil {
+			t.Errorf("Acquire returned an error: %v", err)
+		}
+		acquired <- lease
+	}()
+
+	if err := first.Release(); err != nil {
+		t.Fatalf("Release returned an error: %v", err)
+	}
+	select {
+	case lease := <-acquired:
+		if lease == nil {
+			t.Fatal("Expected a lease")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the released slot")
+	}
+
+	if err := first.Release(); err != ErrLeaseExpired {
+		t.Errorf("Expected ErrLeaseExpired, got %v", err)
+	}
+	select {
+	case <-first.Done():
+	default:
+		t.Error("Expected Done to be closed after Release")
+	}
+}
+
+func TestAcquire_DeadHolder(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	defer store.Close()
+
+	// A holder that stopped renewing, as if its process died.
+	expiry := time.Now().Add(50 * time.Millisecond).UnixNano()
+	_ = store.Set("sem", Bytes(fmt.Sprintf(`{"holders":{"dead":%d}}`, expiry)))
+
+	start := time.Now()
+	lease, err := store.Acquire(context.Background(), "sem", 1, WithLeaseTTL(time.Second))
+	if err != nil {
+		t.Fatalf("Acquire returned an error: %v", err)
+	}
+	if time.Since(start) < 40*time.Millisecond {
+		t.Errorf("Expected Acquire to wait for the dead holder's lease to expire")
+	}
+	if err := lease.Release(); err != nil {
+		t.Errorf("Release returned an error: %v", err)
+	}
+}
+
+func TestAcquire_Renew(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	defer store.Close()
+
+	ttl := 30 * time.Millisecond
+	lease, err := store.Acquire(context.Background(), "sem", 1, WithLeaseTTL(ttl))
+	if err != nil {
+		t.Fatalf("Acquire returned an error: %v", err)
+	}
+
+	// The lease outlives its TTL because it is renewed.
+	time.Sleep(3 * ttl)
+	short, cancel := context.WithTimeout(context.Background(), ttl)
+	defer cancel()
+	if _, err := store.Acquire(short, "sem", 1); err != context.DeadlineExceeded {
+		t.Errorf("Expected the renewed lease to hold the slot, got %v", err)
+	}
+	if err := lease.Release(); err != nil {
+		t.Errorf("Release returned an error: %v", err)
+	}
+}
+
+func TestAcquire_NotSemaphore(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	defer store.Close()
+
+	_ = store.Set("sem", IntValue(1))
+	if _, err := store.Acquire(context.Background(), "sem", 1); err != ErrNotSemaphore {
+		t.Errorf("Expected ErrNotSemaphore, got %v", err)
+	}
+}
+
+func TestAcquire_InvalidArgument(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	defer store.Close()
+
+	if _, err := store.Acquire(context.Background(), "sem", 0); err != ErrInvalidArgument {
+		t.Errorf("Expected ErrInvalidArgument, got %v", err)
+	}
+	for _, ttl := range []time.Duration{0, 2 * time.Nanosecond} {
+		if _, err := store.Acquire(context.Background(), "sem", 1, WithLeaseTTL(ttl)); err != ErrInvalidArgument {
+			t.Errorf("Expected ErrInvalidArgument for a lease of %v, got %v", ttl, err)
+		}
+	}
+	if _, err := store.Get("sem"); err != ErrNotFound {
+		t.Errorf("Expected the semaphore not to be written, got %v", err)
+	}
+}
@@ -1,24 +1,47 @@
 package kvs
 
 import (
-	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // shard represents a partition of the key-value store.
 type shard struct {
-	id    int
-	mu    sync.RWMutex
-	store map[string]Value
+	id        int
+	mu        rwLocker
+	backend   Backend
+	immutable map[string]struct{}
+	aliases   map[string]string
+	meta      map[string]map[string]string
+	counters  shardCounters
+	hot       *topK
+	batcher   batcher
+	history   map[string]*history
+	revs      map[string]int64
+	// horizon is the newest version that became the oldest retained version
+	// of its key because older ones exceeded the history depth.
+	horizon version
+	// views are the open copy-on-write views of the store.
+	views []*view
+
+	// replica is the read-only copy of the shard served by WithReadReplicas,
+	// and dirty reports whether the shard changed since it was taken.
+	replica atomic.Pointer[replica]
+	dirty   atomic.Bool
+
+	// contention counts the key operations that had to wait for the shard lock
+	// and the total time they waited.
+	contention struct {
+		count atomic.Uint64
+		wait  atomic.Int64
+	}
+	// lastAccess is the time of the last key operation in Unix nanoseconds.
+	lastAccess atomic.Int64
 }
 
 // Keys returns a slice of all the keys in the shard.
 func (s *shard) Keys() ([]string, error) {
-	keys := make([]string, 0, len(s.store))
-	for k := range s.store {
-		keys = append(keys, k)
-	}
-
-	return keys, nil
+	return s.backend.Keys()
 }
 
 // Size returns the size of the shard in human-readable format.
@@ -26,5 +49,72 @@ func (s *shard) Size() string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	return formatSize(uint64(len(s.store)))
+	return formatSize(uint64(s.backend.Len()))
+}
+
+// isImmutable reports whether key is a write-once entry.
+func (s *shard) isImmutable(key string) bool {
+	_, ok := s.immutable[key]
+	return ok
+}
+
+// setImmutable marks or unmarks key as a write-once entry.
+func (s *shard) setImmutable(key string, immutable bool) {
+	if !immutable {
+		delete(s.immutable, key)
+		return
+	}
+
+	if s.immutable == nil {
+		s.immutable = make(map[string]struct{})
+	}
+	s.immutable[key] = struct{}{}
+}
+
+// setAlias points alias at target.
+func (s *shard) setAlias(alias, target string) {
+	if s.aliases == nil {
+		s.aliases = make(map[string]string)
+	}
+	s.aliases[alias] = target
+	s.dirty.Store(true)
+}
+
+// removeAlias deletes alias.
+func (s *shard) removeAlias(alias string) {
+	delete(s.aliases, alias)
+	s.dirty.Store(true)
+}
+
+// setMeta replaces the metadata of key. Empty metadata is dropped.
+func (s *shard) setMeta(key string, meta map[string]string) {
+	if len(meta) == 0 {
+		delete(s.meta, key)
+		return
+	}
+
+	if s.meta == nil {
+		s.meta = make(map[string]map[string]string)
+	}
+	s.meta[key] = meta
+}
+
+// touch records a key operation on the shard.
+func (s *shard) touch() {
+	s.lastAccess.Store(time.Now().UnixNano())
+}
+
+// set stores val under key unless key is write-once. The shard must be locked.
+func (s *shard) set(key string, val Value) error {
+	if s.isImmutable(key) {
+		return ErrImmutable
+	}
+
+	s.preserve(key)
+	if err := s.backend.Set(key, val); err != nil {
+		return err
+	}
+	s.counters.sets.Add(1)
+
+	return nil
 }
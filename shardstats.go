@@ -0,0 +1,51 @@
+package kvs
+
+import "time"
+
+// ShardStats describes the state of a single shard.
+type ShardStats struct {
+	// ID is the id of the shard.
+	ID int
+	// Entries is the number of entries in the shard.
+	Entries int
+	// Bytes is the estimated number of bytes held by the shard.
+	Bytes int64
+	// OpCounts holds the operation counters of the shard.
+	OpCounts
+	// LockContentions is the number of key operations that had to wait for the shard lock.
+	LockContentions uint64
+	// LockWait is the total time key operations waited for the shard lock.
+	LockWait time.Duration
+	// LastAccess is the time of the last key operation on the shard,
+	// or the zero time if there was none.
+	LastAccess time.Time
+}
+
+// ShardStats returns the state of every shard, indexed by shard id. Comparing the
+// entries, bytes and lock contention of the shards shows how evenly keys are spread.
+func (kvs *KeyValueStore) ShardStats() []ShardStats {
+	stats := make([]ShardStats, len(kvs.shards))
+
+	for i, sh := range kvs.shards {
+		s := ShardStats{
+			ID:              sh.id,
+			OpCounts:        sh.counters.snapshot(),
+			LockContentions: sh.contention.count.Load(),
+			LockWait:        time.Duration(sh.contention.wait.Load()),
+		}
+		if ns := sh.lastAccess.Load(); ns != 0 {
+			s.LastAccess = time.Unix(0, ns)
+		}
+
+		sh.mu.RLock()
+		s.Entries = sh.backend.Len()
+		if bs, ok := sh.backend.(byteSizer); ok {
+			s.Bytes = bs.bytes()
+		}
+		sh.mu.RUnlock()
+
+		stats[i] = s
+	}
+
+	return stats
+}
@@ -0,0 +1,54 @@
+package kvs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShardStats(t *testing.T) {
+	store, err := NewKeyValueStore(2)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	stats := store.ShardStats()
+	if len(stats) != 2 || !stats[0].LastAccess.IsZero() {
+		t.Fatalf("unexpected stats for an unused store: %+v", stats)
+	}
+
+	before := time.Now()
+	if err := store.Set("a", Bytes("hello")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+
+	s := store.ShardStats()[store.ShardOf("a")]
+	if s.ID != store.ShardOf("a") || s.Entries != 1 || s.Bytes != int64(len("a")+len("hello")) || s.Sets != 1 {
+		t.Errorf("unexpected shard stats %+v", s)
+	}
+	if s.LastAccess.Before(before) {
+		t.Errorf("Expected the last access to be updated, got %v", s.LastAccess)
+	}
+}
+
+func TestShardStats_LockContention(t *testing.T) {
+	store, _ := NewKeyValueStore(1)
+	sh := store.shards[0]
+
+	sh.mu.Lock()
+	done := make(chan struct{})
+	go func() {
+		_ = store.Set("a", IntValue(1))
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	sh.mu.Unlock()
+	<-done
+
+	s := store.ShardStats()[0]
+	if s.LockContentions != 1 {
+		t.Errorf("Expected 1 lock contention, got %d", s.LockContentions)
+	}
+	if s.LockWait < 10*time.Millisecond {
+		t.Errorf("Expected the lock wait to be recorded, got %v", s.LockWait)
+	}
+}
@@ -0,0 +1,82 @@
+package kvs
+
+import "reflect"
+
+// Sizer is implemented by values that can report their approximate size in bytes.
+// The store uses it to estimate how much memory a shard holds; values that do not
+// implement it are estimated from their type.
+type Sizer interface {
+	Size() int
+}
+
+// byteSizer is implemented by backends that can estimate the number of bytes they hold.
+type byteSizer interface {
+	bytes() int64
+}
+
+// estimateSize returns the approximate number of bytes used by a key-value pair.
+func estimateSize(key string, val Value) int64 {
+	return int64(len(key)) + valueSize(val)
+}
+
+// valueSize returns the approximate number of bytes used by val.
+func valueSize(val Value) int64 {
+	switch v := val.(type) {
+	case Sizer:
+		return int64(v.Size())
+	case *compressedValue:
+		return int64(len(v.data))
+	case Bytes:
+		return int64(len(v))
+	}
+
+	return reflectSize(reflect.ValueOf(val), 2)
+}
+
+// reflectSize estimates the size of v by following pointers, strings and slices
+// up to depth levels deep.
+func reflectSize(v reflect.Value, depth int) int64 {
+	if !v.IsValid() {
+		return 0
+	}
+
+	size := int64(v.Type().Size())
+	if depth == 0 {
+		return size
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			size += reflectSize(v.Elem(), depth-1)
+		}
+	case reflect.String:
+		size += int64(v.Len())
+	case reflect.Slice:
+		size += int64(v.Len()) * int64(v.Type().Elem().Size())
+	case reflect.Map:
+		size += int64(v.Len()) * int64(v.Type().Key().Size()+v.Type().Elem().Size())
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			size += reflectSize(v.Field(i), depth-1) - int64(v.Field(i).Type().Size())
+		}
+	}
+
+	return size
+}
+
+// ShardBytes returns the estimated number of bytes held by each shard, indexed by shard id.
+// Shards whose backend cannot estimate its size report 0.
+func (kvs *KeyValueStore) ShardBytes() []int64 {
+	sizes := make([]int64, len(kvs.shards))
+
+	for i, sh := range kvs.shards {
+		sh.mu.RLock()
+		if bs, ok := sh.backend.(byteSizer); ok {
+			sizes[i] = bs.bytes()
+		}
+		sh.mu.RUnlock()
+	}
+
+	return sizes
+}
@@ -0,0 +1,114 @@
+package kvs
+
+import (
+	"sync"
+	"time"
+)
+
+// SlowLogEntry is an operation recorded in the slow log.
+type SlowLogEntry struct {
+	// ID is a unique, increasing id of the entry.
+	ID uint64
+	// Op is the operation.
+	Op Op
+	// Key is the key the operation was called with.
+	Key string
+	// Start is the time the operation started.
+	Start time.Time
+	// Duration is the time the operation took, including the time spent waiting
+	// for the shard lock.
+	Duration time.Duration
+	// Err is the error the operation returned, if any.
+	Err error
+}
+
+// WithSlowLog records operations that take at least threshold in a slow log holding
+// the latest capacity entries, which can be read with SlowLog.
+func WithSlowLog(threshold time.Duration, capacity int) Option {
+	return func(o *options) {
+		o.slowLog = &slowLog{
+			threshold: threshold,
+			entries:   make([]SlowLogEntry, capacity),
+		}
+		o.observers = append(o.observers, o.slowLog)
+	}
+}
+
+// slowLog is a ring buffer of slow operations. It is an Observer.
+type slowLog struct {
+	mu        sync.Mutex
+	threshold time.Duration
+	entries   []SlowLogEntry
+	next      uint64
+	start     uint64
+}
+
+// ObserveOp records the operation if it was slow.
+func (l *slowLog) ObserveOp(op Op, key string, d time.Duration, err error) {
+	if d < l.threshold || len(l.entries) == 0 {
+		return
+	}
+
+	now := time.Now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries[l.next%uint64(len(l.entries))] = SlowLogEntry{
+		ID:       l.next,
+		Op:       op,
+		Key:      key,
+		Start:    now.Add(-d),
+		Duration: d,
+		Err:      err,
+	}
+	l.next++
+}
+
+// empty returns a slow log with the threshold and capacity of l and no entries.
+func (l *slowLog) empty() *slowLog {
+	return &slowLog{threshold: l.threshold, entries: make([]SlowLogEntry, len(l.entries))}
+}
+
+// list returns the recorded entries, newest first.
+func (l *slowLog) list() []SlowLogEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	first := l.start
+	if n := uint64(len(l.entries)); l.next-first > n {
+		first = l.next - n
+	}
+
+	entries := make([]SlowLogEntry, 0, l.next-first)
+	for id := l.next; id > first; id-- {
+		entries = append(entries, l.entries[(id-1)%uint64(len(l.entries))])
+	}
+
+	return entries
+}
+
+// reset drops all recorded entries.
+func (l *slowLog) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.start = l.next
+}
+
+// SlowLog returns the operations recorded in the slow log, newest first.
+// It returns an empty slice unless the store was created with WithSlowLog.
+func (kvs *KeyValueStore) SlowLog() []SlowLogEntry {
+	if kvs.opts.slowLog == nil {
+		return []SlowLogEntry{}
+	}
+
+	return kvs.opts.slowLog.list()
+}
+
+// ResetSlowLog drops all entries from the slow log.
+func (kvs *KeyValueStore) ResetSlowLog() {
+	if kvs.opts.slowLog != nil {
+		kvs.opts.slowLog.reset()
+	}
+}
@@ -0,0 +1,70 @@
+package kvs
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSlowLog(t *testing.T) {
+	store, err := NewKeyValueStore(2, WithSlowLog(0, 3))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		_ = store.Set(fmt.Sprintf("key-%d", i), IntValue(i))
+	}
+	_, _ = store.Get("missing")
+
+	entries := store.SlowLog()
+	if len(entries) != 3 {
+		t.Fatalf("Expected the slow log to keep 3 entries, got %d", len(entries))
+	}
+	if entries[0].Op != OpGet || entries[0].Key != "missing" || entries[0].Err != ErrNotFound {
+		t.Errorf("Expected the newest entry first, got %+v", entries[0])
+	}
+	if entries[1].Key != "key-4" || entries[2].Key != "key-3" {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+	if entries[0].ID != 5 || entries[2].ID != 3 {
+		t.Errorf("Expected increasing ids, got %d and %d", entries[0].ID, entries[2].ID)
+	}
+
+	store.ResetSlowLog()
+	if n := len(store.SlowLog()); n != 0 {
+		t.Errorf("Expected an empty slow log after reset, got %d entries", n)
+	}
+	_ = store.Set("after", IntValue(1))
+	if entries := store.SlowLog(); len(entries) != 1 || entries[0].Key != "after" {
+		t.Errorf("Expected one entry after reset, got %+v", entries)
+	}
+}
+
+func TestSlowLog_Threshold(t *testing.T) {
+	store, _ := NewKeyValueStore(1, WithSlowLog(time.Hour, 10))
+	_ = store.Set("a", IntValue(1))
+
+	if n := len(store.SlowLog()); n != 0 {
+		t.Errorf("Expected fast operations not to be logged, got %d entries", n)
+	}
+}
+
+func TestSlowLog_LockWait(t *testing.T) {
+	store, _ := NewKeyValueStore(1, WithSlowLog(5*time.Millisecond, 10))
+	sh := store.shards[0]
+
+	sh.mu.Lock()
+	done := make(chan struct{})
+	go func() {
+		_ = store.Set("a", IntValue(1))
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	sh.mu.Unlock()
+	<-done
+
+	if entries := store.SlowLog(); len(entries) != 1 || entries[0].Duration < 5*time.Millisecond {
+		t.Errorf("Expected the lock wait to make the set slow, got %+v", entries)
+	}
+}
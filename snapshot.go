@@ -0,0 +1,441 @@
+package kvs
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+// snapshotMagic identifies the snapshot format and its version.
+var snapshotMagic = []byte("KVS\x01")
+
+// Kinds of snapshot records.
+const (
+	snapshotEntry byte = iota + 1
+	snapshotEnd
+	snapshotImmutableEntry
+	snapshotAlias
+	snapshotMeta
+)
+
+// maxRecordSize bounds the length of a snapshot key or value so corrupt
+// input cannot trigger huge allocations.
+const maxRecordSize = 1 << 32
+
+// recordChunk is the number of bytes of a record read from a stream at a time.
+const recordChunk = 1 << 20
+
+// recordWriter is the set of methods needed to write snapshot records.
+type recordWriter interface {
+	io.Writer
+	io.ByteWriter
+	io.StringWriter
+}
+
+// WriteSnapshot writes all entries of the store to w, encoding values with the store's codec.
+// Each shard is read-locked while it is encoded, so the snapshot is consistent per shard
+// but writes to other shards may interleave.
+func (kvs *KeyValueStore) WriteSnapshot(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.Write(snapshotMagic); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, sh := range kvs.shards {
+		buf.Reset()
+		if err := kvs.encodeShard(&buf, sh); err != nil {
+			return err
+		}
+
+		// The shard lock is released before writing so a slow writer does not block the shard.
+		if _, err := bw.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	if err := bw.WriteByte(snapshotEnd); err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// encodeShard writes the records of a single shard to w.
+func (kvs *KeyValueStore) encodeShard(w recordWriter, sh *shard) error {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	keys, err := sh.Keys()
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		val, err := sh.backend.Get(key)
+		if err != nil {
+			return err
+		}
+
+		data, err := kvs.encode(val)
+		if err != nil {
+			return err
+		}
+
+		kind := snapshotEntry
+		if sh.isImmutable(key) {
+			kind = snapshotImmutableEntry
+		}
+
+		if err := writeRecord(w, kind, key, data); err != nil {
+			return err
+		}
+
+		if meta, ok := sh.meta[key]; ok {
+			if err := writeRecord(w, snapshotMeta, key, encodeMeta(meta)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for alias, target := range sh.aliases {
+		if err := writeRecord(w, snapshotAlias, alias, []byte(target)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encode returns the codec representation of a stored value.
+func (kvs *KeyValueStore) encode(val Value) ([]byte, error) {
+	if cv, ok := val.(*compressedValue); ok {
+		data, err := kvs.opts.compressor.Decompress(cv.data)
+		if err != nil {
+			return nil, fmt.Errorf("kvs: decompress value: %w", err)
+		}
+		return data, nil
+	}
+
+	data, err := kvs.opts.codec.Marshal(val)
+	if err != nil {
+		return nil, fmt.Errorf("kvs: encode value: %w", err)
+	}
+
+	return data, nil
+}
+
+// writeRecord writes a single entry record.
+func writeRecord(w recordWriter, kind byte, key string, data []byte) error {
+	var buf [binary.MaxVarintLen64]byte
+
+	if err := w.WriteByte(kind); err != nil {
+		return err
+	}
+	n := binary.PutUvarint(buf[:], uint64(len(key)))
+	if _, err := w.Write(buf[:n]); err != nil {
+		return err
+	}
+	if _, err := w.WriteString(key); err != nil {
+		return err
+	}
+	n = binary.PutUvarint(buf[:], uint64(len(data)))
+	if _, err := w.Write(buf[:n]); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+
+	return err
+}
+
+// ReadSnapshot loads the entries of a snapshot written by WriteSnapshot into the store,
+// overwriting existing keys. Keys that are not part of the snapshot are left untouched.
+func (kvs *KeyValueStore) ReadSnapshot(r io.Reader) error {
+	_, err := kvs.ImportSnapshot(r)
+	return err
+}
+
+// maxSampleKeys is the number of keys listed in an ImportReport.
+const maxSampleKeys = 10
+
+// ImportReport summarizes the changes made by an import, or in dry-run mode the
+// changes it would make.
+type ImportReport struct {
+	// Created is the number of keys that did not exist before.
+	Created int
+	// Overwritten is the number of existing keys that were replaced.
+	Overwritten int
+	// OverwrittenSample lists up to ten of the overwritten keys.
+	OverwrittenSample []string
+	// DryRun reports whether the store was left unchanged.
+	DryRun bool
+}
+
+// importOptions holds the settings of an import.
+type importOptions struct {
+	dryRun           bool
+	progress         func(ImportProgress)
+	progressInterval time.Duration
+}
+
+// ImportOption configures an import.
+type ImportOption func(*importOptions)
+
+// DryRun makes an import decode and validate its input and report what it would
+// change without modifying the store.
+func DryRun() ImportOption {
+	return func(o *importOptions) {
+		o.dryRun = true
+	}
+}
+
+// ImportSnapshot loads the entries of a snapshot into the store like ReadSnapshot
+// and reports how many keys were created or overwritten. Entries of system keys
+// that are computed rather than stored, such as "__kvs/stats/entries", fail with
+// ErrReservedKey.
+func (kvs *KeyValueStore) ImportSnapshot(r io.Reader, opts ...ImportOption) (ImportReport, error) {
+	cr := &countingReader{r: r}
+
+	return kvs.importSnapshot(func(fn recordFunc) error {
+		return readSnapshot(cr, fn)
+	}, cr.count, sizeOf(r), opts...)
+}
+
+// importSnapshot imports the records produced by read. pos returns the number of
+// bytes read so far out of total, which is zero if unknown; it is only needed
+// when progress is reported.
+func (kvs *KeyValueStore) importSnapshot(read func(fn recordFunc) error, pos func() int64, total int64, opts ...ImportOption) (report ImportReport, err error) {
+	var o importOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	report.DryRun = o.dryRun
+
+	start := time.Now()
+	progress := newProgressTracker(kvs, o, start, pos, total)
+	defer func() {
+		kvs.log(kvs.opts.logConfig.RecoveryLevel, "kvs: snapshot imported",
+			slog.Int("created", report.Created),
+			slog.Int("overwritten", report.Overwritten),
+			slog.Bool("dry_run", report.DryRun),
+			slog.Duration("duration", time.Since(start)),
+			slog.Any("error", err),
+		)
+	}()
+
+	err = read(func(kind byte, key string, data []byte) error {
+		switch kind {
+		case snapshotAlias:
+			if report.DryRun {
+				return nil
+			}
+			return kvs.Alias(key, string(data))
+		case snapshotMeta:
+			meta, err := decodeMeta(data)
+			if err != nil || report.DryRun {
+				return err
+			}
+			return kvs.restoreMeta(key, meta)
+		}
+
+		val, err := kvs.decode(data)
+		if err != nil {
+			return err
+		}
+
+		if isSystemKey(key) && !isStoredSystemKey(key) {
+			return fmt.Errorf("%w: %s", ErrReservedKey, key)
+		}
+		// Content entries hold the value their key is the hash of, so an
+		// existing one is the same value; only its reference count, in the
+		// metadata record that follows, is taken from the snapshot.
+		content := strings.HasPrefix(key, contentPrefix) && kind == snapshotImmutableEntry
+		if kvs.IsImmutable(key) && !content {
+			return fmt.Errorf("%w: %s", ErrImmutable, key)
+		}
+		progress.entry()
+
+		exists, err := kvs.contains(key)
+		if err != nil {
+			return err
+		}
+		if exists {
+			report.Overwritten++
+			if len(report.OverwrittenSample) < maxSampleKeys {
+				report.OverwrittenSample = append(report.OverwrittenSample, key)
+			}
+		} else {
+			report.Created++
+		}
+
+		if report.DryRun || (exists && content) {
+			return nil
+		}
+
+		if kind == snapshotImmutableEntry {
+			// Entries of buckets, tenants and the content store are stored
+			// under the system keyspace.
+			return kvs.putImmutable(key, val)
+		}
+		if isSystemKey(key) {
+			return kvs.put(key, val)
+		}
+
+		return kvs.Set(key, val)
+	})
+	if !report.DryRun {
+		// Imported entries of buckets and tenants bypass their quotas.
+		kvs.quotas.invalidate("", true)
+		kvs.tenants.invalidate("")
+	}
+	if err == nil {
+		progress.finish()
+	}
+
+	return report, err
+}
+
+// decode returns the value of a snapshot record. Record data is never reused,
+// so Bytes values are taken as they are instead of being copied.
+func (kvs *KeyValueStore) decode(data []byte) (Value, error) {
+	if _, ok := kvs.opts.codec.(BytesCodec); ok {
+		return Bytes(data), nil
+	}
+
+	val, err := kvs.opts.codec.Unmarshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("kvs: decode value: %w", err)
+	}
+
+	return val, nil
+}
+
+// recordFunc is called for every record of a snapshot.
+type recordFunc func(kind byte, key string, data []byte) error
+
+// recordReader is the set of methods needed to read snapshot records.
+type recordReader interface {
+	io.ByteReader
+	// next returns the next n bytes.
+	next(n uint64) ([]byte, error)
+}
+
+// streamReader reads records from a stream into freshly allocated buffers.
+type streamReader struct {
+	*bufio.Reader
+}
+
+// next reads the next n bytes into a new buffer. The buffer grows as the bytes
+// arrive, so a corrupt length in a short stream does not allocate it in full.
+func (r streamReader) next(n uint64) ([]byte, error) {
+	data := make([]byte, 0, min(n, recordChunk))
+	for uint64(len(data)) < n {
+		chunk := min(n-uint64(len(data)), recordChunk)
+		data = slices.Grow(data, int(chunk))
+		if _, err := io.ReadFull(r, data[len(data):len(data)+int(chunk)]); err != nil {
+			return nil, err
+		}
+		data = data[:len(data)+int(chunk)]
+	}
+
+	return data, nil
+}
+
+// sliceReader reads records from a byte slice without copying them.
+type sliceReader struct {
+	data []byte
+}
+
+// ReadByte returns the next byte.
+func (r *sliceReader) ReadByte() (byte, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+
+	return b, nil
+}
+
+// next returns the next n bytes of the slice.
+func (r *sliceReader) next(n uint64) ([]byte, error) {
+	if n > uint64(len(r.data)) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	data := r.data[:n:n]
+	r.data = r.data[n:]
+
+	return data, nil
+}
+
+// readSnapshot parses a snapshot and calls fn for every record.
+func readSnapshot(r io.Reader, fn recordFunc) error {
+	br := bufio.NewReader(r)
+
+	magic := make([]byte, len(snapshotMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != string(snapshotMagic) {
+		return ErrCorruptSnapshot
+	}
+
+	return readRecords(streamReader{br}, fn)
+}
+
+// readSnapshotBytes parses a snapshot held in memory and calls fn for every
+// record. The data passed to fn points into the snapshot.
+func readSnapshotBytes(data []byte, fn recordFunc) error {
+	if !bytes.HasPrefix(data, snapshotMagic) {
+		return ErrCorruptSnapshot
+	}
+
+	return readRecords(&sliceReader{data: data[len(snapshotMagic):]}, fn)
+}
+
+// readRecords reads records up to the end marker and calls fn for every record.
+func readRecords(r recordReader, fn recordFunc) error {
+	for {
+		kind, err := r.ReadByte()
+		if err != nil {
+			return ErrCorruptSnapshot
+		}
+
+		switch kind {
+		case snapshotEnd:
+			return nil
+		case snapshotEntry, snapshotImmutableEntry, snapshotAlias, snapshotMeta:
+			key, err := readBytes(r)
+			if err != nil {
+				return err
+			}
+			data, err := readBytes(r)
+			if err != nil {
+				return err
+			}
+			if err := fn(kind, string(key), data); err != nil {
+				return err
+			}
+		default:
+			return ErrCorruptSnapshot
+		}
+	}
+}
+
+// readBytes reads a length-prefixed byte slice.
+func readBytes(r recordReader) ([]byte, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil || n > maxRecordSize {
+		return nil, ErrCorruptSnapshot
+	}
+
+	data, err := r.next(n)
+	if err != nil {
+		return nil, ErrCorruptSnapshot
+	}
+
+	return data, nil
+}
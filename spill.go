@@ -0,0 +1,239 @@
+package kvs
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+const (
+	// fileHeaderSize is the size of a record header: kind, key length and value length.
+	fileHeaderSize = 9
+
+	// fileCompactMin is the amount of dead space in bytes a backend file may hold
+	// before it is considered for compaction.
+	fileCompactMin = 1 << 20
+)
+
+// Kinds of records in a backend file.
+const (
+	recordEncoded byte = iota
+	recordCompressed
+)
+
+// fileEntry locates a value inside a backend file.
+type fileEntry struct {
+	kind   byte
+	offset int64
+	length int
+}
+
+// fileBackend is a Backend that keeps its values in a file on disk and only
+// an index of their locations in memory.
+type fileBackend struct {
+	path  string
+	file  *os.File
+	codec Codec
+	index map[string]fileEntry
+	size  int64
+	dead  int64
+}
+
+// NewFileBackend creates a Backend that encodes values with codec and stores them
+// in the file at path. The file is scratch space: it is truncated on open and
+// removed on Close.
+func NewFileBackend(path string, codec Codec) (Backend, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileBackend{
+		path:  path,
+		file:  f,
+		codec: codec,
+		index: make(map[string]fileEntry),
+	}, nil
+}
+
+// NewSpillBackend creates a Backend that holds up to maxEntries entries in memory.
+// Entries beyond that high-water mark are encoded with codec and spilled to the file
+// at path, and are loaded back into memory transparently when they are accessed.
+func NewSpillBackend(path string, maxEntries int, codec Codec) (Backend, error) {
+	if maxEntries <= 0 {
+		return nil, fmt.Errorf("kvs: invalid spill high-water mark %d", maxEntries)
+	}
+
+	cold, err := NewFileBackend(path, codec)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewTieredBackend(maxEntries, cold)
+}
+
+// Get reads the value associated with the given key from disk.
+func (f *fileBackend) Get(key string) (Value, error) {
+	e, ok := f.index[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	data := make([]byte, e.length)
+	if _, err := f.file.ReadAt(data, e.offset); err != nil {
+		return nil, fmt.Errorf("kvs: read backend file: %w", err)
+	}
+
+	if e.kind == recordCompressed {
+		return &compressedValue{data: data}, nil
+	}
+
+	val, err := f.codec.Unmarshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("kvs: decode value: %w", err)
+	}
+
+	return val, nil
+}
+
+// Set appends the given key-value pair to the file.
+func (f *fileBackend) Set(key string, val Value) error {
+	kind := recordEncoded
+	var data []byte
+	if cv, ok := val.(*compressedValue); ok {
+		kind = recordCompressed
+		data = cv.data
+	} else {
+		var err error
+		data, err = f.codec.Marshal(val)
+		if err != nil {
+			return fmt.Errorf("kvs: encode value: %w", err)
+		}
+	}
+
+	offset, err := f.write(key, kind, data)
+	if err != nil {
+		return err
+	}
+
+	f.discard(key)
+	f.index[key] = fileEntry{kind: kind, offset: offset, length: len(data)}
+
+	// The write has taken effect, so a failed compaction must not fail it. The
+	// dead space stays accounted for and the next write retries the compaction.
+	_ = f.maybeCompact()
+
+	return nil
+}
+
+// Delete removes the key-value pair associated with the given key.
+func (f *fileBackend) Delete(key string) error {
+	if _, ok := f.index[key]; !ok {
+		return ErrNotFound
+	}
+
+	f.discard(key)
+
+	return nil
+}
+
+// Keys returns a slice of all the keys in the backend.
+func (f *fileBackend) Keys() ([]string, error) {
+	keys := make([]string, 0, len(f.index))
+	for k := range f.index {
+		keys = append(keys, k)
+	}
+
+	return keys, nil
+}
+
+// Len returns the number of entries in the backend.
+func (f *fileBackend) Len() int {
+	return len(f.index)
+}
+
+// bytes returns the number of live bytes in the backend file.
+func (f *fileBackend) bytes() int64 {
+	return f.size - f.dead
+}
+
+// Close closes and removes the backend file.
+func (f *fileBackend) Close() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(f.path)
+}
+
+// discard drops the record of key from the index and accounts for its dead space.
+func (f *fileBackend) discard(key string) {
+	if e, ok := f.index[key]; ok {
+		delete(f.index, key)
+		f.dead += fileHeaderSize + int64(len(key)+e.length)
+	}
+}
+
+// write appends a record to the file and returns the offset of its value.
+func (f *fileBackend) write(key string, kind byte, data []byte) (int64, error) {
+	buf := make([]byte, fileHeaderSize+len(key)+len(data))
+	buf[0] = kind
+	binary.LittleEndian.PutUint32(buf[1:5], uint32(len(key)))
+	binary.LittleEndian.PutUint32(buf[5:9], uint32(len(data)))
+	copy(buf[fileHeaderSize:], key)
+	copy(buf[fileHeaderSize+len(key):], data)
+
+	if _, err := f.file.WriteAt(buf, f.size); err != nil {
+		return 0, fmt.Errorf("kvs: write backend file: %w", err)
+	}
+
+	offset := f.size + fileHeaderSize + int64(len(key))
+	f.size += int64(len(buf))
+
+	return offset, nil
+}
+
+// maybeCompact rewrites the file once more than half of it is dead space.
+func (f *fileBackend) maybeCompact() error {
+	if f.dead < fileCompactMin || f.dead < f.size/2 {
+		return nil
+	}
+
+	tmpPath := f.path + ".compact"
+	tmp, err := os.OpenFile(tmpPath, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+
+	compacted := &fileBackend{file: tmp, index: make(map[string]fileEntry, len(f.index))}
+	for key, e := range f.index {
+		data := make([]byte, e.length)
+		if _, err := f.file.ReadAt(data, e.offset); err != nil {
+			tmp.Close()
+			os.Remove(tmpPath)
+			return fmt.Errorf("kvs: read backend file: %w", err)
+		}
+
+		offset, err := compacted.write(key, e.kind, data)
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmpPath)
+			return err
+		}
+		compacted.index[key] = fileEntry{kind: e.kind, offset: offset, length: e.length}
+	}
+
+	if err := os.Rename(tmpPath, f.path); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	f.file.Close()
+
+	f.file = tmp
+	f.index = compacted.index
+	f.size = compacted.size
+	f.dead = 0
+
+	return nil
+}
@@ -0,0 +1,173 @@
+package kvs
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSpillBackend(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewKeyValueStore(2, WithBackend(func(shardID int) (Backend, error) {
+		path := filepath.Join(dir, fmt.Sprintf("shard-%d.spill", shardID))
+		return NewSpillBackend(path, 10, GobCodec{})
+	}))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		if err := store.Set(key, Person{Name: key, Age: i}); err != nil {
+			t.Fatalf("Set returned an error: %v", err)
+		}
+	}
+
+	for _, ts := range store.TierStats() {
+		if ts.HotEntries > 10 {
+			t.Errorf("shard %d holds %d entries in memory, expected at most 10", ts.Shard, ts.HotEntries)
+		}
+		if ts.ColdEntries == 0 {
+			t.Errorf("shard %d did not spill any entries", ts.Shard)
+		}
+	}
+
+	keys, err := store.Keys()
+	if err != nil {
+		t.Fatalf("Keys returned an error: %v", err)
+	}
+	if len(keys) != 100 {
+		t.Errorf("Expected 100 keys, got %d", len(keys))
+	}
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("key-%d", i)
+		val, err := store.Get(key)
+		if err != nil {
+			t.Fatalf("Get(%q) returned an error: %v", key, err)
+		}
+		if p, ok := val.(Person); !ok || p.Age != i {
+			t.Errorf("Get(%q) returned unexpected value %v", key, val)
+		}
+	}
+
+	if err := store.Delete("key-0"); err != nil {
+		t.Errorf("Delete returned an error: %v", err)
+	}
+	if _, err := store.Get("key-0"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close returned an error: %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("Expected spill files to be removed, found %d", len(entries))
+	}
+}
+
+func TestFileBackend_Compressed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shard.data")
+	backend, err := NewFileBackend(path, GobCodec{})
+	if err != nil {
+		t.Fatalf("NewFileBackend returned an error: %v", err)
+	}
+	defer backend.(io.Closer).Close()
+
+	cv := &compressedValue{data: []byte("compressed")}
+	if err := backend.Set("a", cv); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if err := backend.Set("b", IntValue(1)); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+
+	val, err := backend.Get("a")
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+	if got, ok := val.(*compressedValue); !ok || string(got.data) != "compressed" {
+		t.Errorf("Get returned unexpected value %v", val)
+	}
+}
+
+func TestFileBackend_Compact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shard.data")
+	backend, err := NewFileBackend(path, GobCodec{})
+	if err != nil {
+		t.Fatalf("NewFileBackend returned an error: %v", err)
+	}
+	defer backend.(io.Closer).Close()
+
+	name := strings.Repeat("x", 64<<10)
+	for i := 0; i < 64; i++ {
+		if err := backend.Set(fmt.Sprintf("key-%d", i%4), Person{Name: name, Age: i}); err != nil {
+			t.Fatalf("Set returned an error: %v", err)
+		}
+	}
+
+	fb := backend.(*fileBackend)
+	if fb.size > 2*fileCompactMin {
+		t.Errorf("backend file grew to %d bytes without compaction", fb.size)
+	}
+
+	for i := 60; i < 64; i++ {
+		val, err := backend.Get(fmt.Sprintf("key-%d", i%4))
+		if err != nil {
+			t.Fatalf("Get returned an error: %v", err)
+		}
+		if p := val.(Person); p.Age != i {
+			t.Errorf("Expected age %d, got %d", i, p.Age)
+		}
+	}
+}
+
+func TestFileBackend_CompactFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shard.data")
+	backend, err := NewFileBackend(path, GobCodec{})
+	if err != nil {
+		t.Fatalf("NewFileBackend returned an error: %v", err)
+	}
+	defer backend.(io.Closer).Close()
+
+	// A directory in the way of the compaction file makes every compaction fail.
+	if err := os.Mkdir(path+".compact", 0o700); err != nil {
+		t.Fatalf("Mkdir returned an error: %v", err)
+	}
+
+	name := strings.Repeat("x", 64<<10)
+	for i := 0; i < 64; i++ {
+		if err := backend.Set(fmt.Sprintf("key-%d", i%4), Person{Name: name, Age: i}); err != nil {
+			t.Fatalf("Set returned an error: %v", err)
+		}
+	}
+
+	fb := backend.(*fileBackend)
+	if fb.dead < fileCompactMin {
+		t.Errorf("Expected uncompacted dead space, got %d bytes", fb.dead)
+	}
+
+	for i := 60; i < 64; i++ {
+		val, err := backend.Get(fmt.Sprintf("key-%d", i%4))
+		if err != nil {
+			t.Fatalf("Get returned an error: %v", err)
+		}
+		if p := val.(Person); p.Age != i {
+			t.Errorf("Expected age %d, got %d", i, p.Age)
+		}
+	}
+
+	if err := os.Remove(path + ".compact"); err != nil {
+		t.Fatalf("Remove returned an error: %v", err)
+	}
+	if err := backend.Set("key-0", Person{Name: name}); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if fb.dead != 0 {
+		t.Errorf("Expected the next write to compact the file, %d dead bytes left", fb.dead)
+	}
+}
@@ -0,0 +1,83 @@
+package kvs
+
+import "sync/atomic"
+
+// OpCounts holds operation counters of a store or shard.
+type OpCounts struct {
+	// Hits is the number of gets that found their key.
+	Hits uint64
+	// Misses is the number of gets for keys that were not found.
+	Misses uint64
+	// Sets is the number of successful writes.
+	Sets uint64
+	// Deletes is the number of successful deletes.
+	Deletes uint64
+}
+
+// HitRate returns the fraction of gets that found their key, or 0 if there were none.
+func (c OpCounts) HitRate() float64 {
+	if c.Hits+c.Misses == 0 {
+		return 0
+	}
+
+	return float64(c.Hits) / float64(c.Hits+c.Misses)
+}
+
+// add adds the counters of o to c.
+func (c *OpCounts) add(o OpCounts) {
+	c.Hits += o.Hits
+	c.Misses += o.Misses
+	c.Sets += o.Sets
+	c.Deletes += o.Deletes
+}
+
+// StoreStats contains the operation counters of a store.
+type StoreStats struct {
+	// OpCounts holds the totals over all shards.
+	OpCounts
+	// Shards holds the counters of every shard, indexed by shard id.
+	Shards []OpCounts
+}
+
+// shardCounters are the operation counters of a shard. They are updated atomically
+// so that reads holding only the shard's read lock can count.
+type shardCounters struct {
+	hits    atomic.Uint64
+	misses  atomic.Uint64
+	sets    atomic.Uint64
+	deletes atomic.Uint64
+}
+
+// countGet counts a get that returned err.
+func (c *shardCounters) countGet(err error) {
+	switch err {
+	case nil:
+		c.hits.Add(1)
+	case ErrNotFound:
+		c.misses.Add(1)
+	}
+}
+
+// snapshot returns the current counter values.
+func (c *shardCounters) snapshot() OpCounts {
+	return OpCounts{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Sets:    c.sets.Load(),
+		Deletes: c.deletes.Load(),
+	}
+}
+
+// Stats returns the hit, miss, set and delete counters of the store and of every shard.
+// Operations on aliases are counted on the shard of the key they resolve to; reads of
+// system keys are not counted.
+func (kvs *KeyValueStore) Stats() StoreStats {
+	stats := StoreStats{Shards: make([]OpCounts, len(kvs.shards))}
+
+	for i, sh := range kvs.shards {
+		stats.Shards[i] = sh.counters.snapshot()
+		stats.OpCounts.add(stats.Shards[i])
+	}
+
+	return stats
+}
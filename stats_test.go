@@ -0,0 +1,42 @@
+package kvs
+
+import "testing"
+
+func TestStats(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	_ = store.Set("a", IntValue(1))
+	_ = store.Set("b", IntValue(2))
+	_, _ = store.Get("a")
+	_, _ = store.Get("a")
+	_, _ = store.Get("b")
+	_, _ = store.Get("missing")
+	_ = store.Delete("b")
+	_ = store.Delete("missing")
+	_, _ = store.Get("__kvs/stats/entries")
+
+	stats := store.Stats()
+	want := OpCounts{Hits: 3, Misses: 1, Sets: 2, Deletes: 1}
+	if stats.OpCounts != want {
+		t.Errorf("Expected %+v, got %+v", want, stats.OpCounts)
+	}
+	if rate := stats.HitRate(); rate != 0.75 {
+		t.Errorf("Expected a hit rate of 0.75, got %v", rate)
+	}
+
+	if len(stats.Shards) != 4 {
+		t.Fatalf("Expected 4 shards, got %d", len(stats.Shards))
+	}
+	if got := stats.Shards[store.ShardOf("a")]; got.Hits < 2 || got.Sets < 1 {
+		t.Errorf("Expected the shard of a to count its operations, got %+v", got)
+	}
+}
+
+func TestOpCounts_HitRate(t *testing.T) {
+	if rate := (OpCounts{}).HitRate(); rate != 0 {
+		t.Errorf("Expected 0 without gets, got %v", rate)
+	}
+}
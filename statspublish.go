@@ -0,0 +1,102 @@
+package kvs
+
+import (
+	"log/slog"
+	"strconv"
+	"time"
+)
+
+// metricsPrefix is the prefix of the system keys the stats publisher writes.
+const metricsPrefix = SystemPrefix + "metrics/"
+
+// WithStatsPublishing starts a background publisher that writes the statistics
+// of the store into the system keyspace every interval, so tools that already
+// scan or watch keys can collect them without a separate metrics pipeline:
+//
+//	__kvs/metrics/entries             total number of entries
+//	__kvs/metrics/bytes               estimated bytes held by the shards
+//	__kvs/metrics/hits                gets that found their key
+//	__kvs/metrics/misses              gets for keys that were not found
+//	__kvs/metrics/sets                successful writes
+//	__kvs/metrics/deletes             successful deletes
+//	__kvs/metrics/hit_rate            fraction of gets that found their key
+//	__kvs/metrics/gets_per_sec        gets per second over the last interval
+//	__kvs/metrics/sets_per_sec        writes per second over the last interval
+//	__kvs/metrics/deletes_per_sec     deletes per second over the last interval
+//	__kvs/metrics/published_at        time of the publication in RFC 3339 format
+//
+// Values are decimal Bytes. Unlike the computed keys of SystemKeys, the metrics
+// are stored, so Scan and Watch with the prefix "__kvs/metrics/" see them, and
+// they are included in snapshots. The writes of the publisher are counted like
+// any other write.
+func WithStatsPublishing(interval time.Duration) Option {
+	return func(o *options) {
+		o.statsInterval = interval
+	}
+}
+
+// startStatsPublisher runs the stats publisher until Close.
+func (kvs *KeyValueStore) startStatsPublisher() {
+	kvs.bg.Add(1)
+	go kvs.runStatsPublisher()
+}
+
+// runStatsPublisher publishes the statistics every interval until the store is
+// closed. The rates are computed from the counters of the previous publication.
+func (kvs *KeyValueStore) runStatsPublisher() {
+	defer kvs.bg.Done()
+
+	ticker := time.NewTicker(kvs.opts.statsInterval)
+	defer ticker.Stop()
+
+	prev, prevAt := kvs.Stats().OpCounts, time.Now()
+	for {
+		select {
+		case <-kvs.stop:
+			return
+		case now := <-ticker.C:
+			// Read the counters before writing, so the rates cover the interval.
+			cur := kvs.Stats().OpCounts
+			if err := kvs.publishStats(cur, prev, now.Sub(prevAt)); err != nil {
+				kvs.log(slog.LevelError, "kvs: publishing stats failed", slog.Any("error", err))
+			}
+			prev, prevAt = cur, now
+		}
+	}
+}
+
+// publishStats writes the counters cur and their rates since prev, elapsed ago.
+func (kvs *KeyValueStore) publishStats(cur, prev OpCounts, elapsed time.Duration) error {
+	var entries int
+	var bytes int64
+	for _, s := range kvs.ShardStats() {
+		entries += s.Entries
+		bytes += s.Bytes
+	}
+
+	rate := func(n, old uint64) string {
+		return strconv.FormatFloat(float64(n-old)/elapsed.Seconds(), 'f', 2, 64)
+	}
+	metrics := []struct {
+		name, val string
+	}{
+		{"entries", strconv.Itoa(entries)},
+		{"bytes", strconv.FormatInt(bytes, 10)},
+		{"hits", strconv.FormatUint(cur.Hits, 10)},
+		{"misses", strconv.FormatUint(cur.Misses, 10)},
+		{"sets", strconv.FormatUint(cur.Sets, 10)},
+		{"deletes", strconv.FormatUint(cur.Deletes, 10)},
+		{"hit_rate", strconv.FormatFloat(cur.HitRate(), 'f', 4, 64)},
+		{"gets_per_sec", rate(cur.Hits+cur.Misses, prev.Hits+prev.Misses)},
+		{"sets_per_sec", rate(cur.Sets, prev.Sets)},
+		{"deletes_per_sec", rate(cur.Deletes, prev.Deletes)},
+		{"published_at", time.Now().UTC().Format(time.RFC3339Nano)},
+	}
+	for _, m := range metrics {
+		if err := kvs.put(metricsPrefix+m.name, Bytes(m.val)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package kvs
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestWithStatsPublishing(t *testing.T) {
+	store, err := NewKeyValueStore(4, WithStatsPublishing(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events := store.Watch(ctx, SystemPrefix+"metrics/published_at")
+
+	_ = store.Set("a", IntValue(1))
+	_, _ = store.Get("a")
+	_, _ = store.Get("missing")
+
+	// Wait for a publication that covers the operations above.
+	for {
+		select {
+		case <-events:
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for the stats to be published")
+		}
+		val, err := store.Get(SystemPrefix + "metrics/hit_rate")
+		if err != nil {
+			t.Fatalf("Get returned an error: %v", err)
+		}
+		if rate, err := strconv.ParseFloat(string(val.(Bytes)), 64); err != nil {
+			t.Fatalf("Expected a number, got %s", val)
+		} else if rate == 0.5 {
+			break
+		}
+	}
+	if val, _ := store.Get(SystemPrefix + "metrics/entries"); string(val.(Bytes)) == "0" {
+		t.Errorf("Expected entries to be counted, got %s", val)
+	}
+
+	n := 0
+	it := store.Scan(SystemPrefix + "metrics/")
+	for it.Next() {
+		n++
+	}
+	it.Close()
+	if n != 11 {
+		t.Errorf("Expected 11 metrics, got %d", n)
+	}
+	if keys, _ := store.Keys(); len(keys) != 1 {
+		t.Errorf("Expected the metrics to be left out of Keys, got %v", keys)
+	}
+	if err := store.Set(SystemPrefix+"metrics/sets", Bytes("0")); err != ErrReservedKey {
+		t.Errorf("Expected ErrReservedKey, got %v", err)
+	}
+}
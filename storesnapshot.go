@@ -0,0 +1,112 @@
+package kvs
+
+import (
+	"sort"
+	"strings"
+)
+
+// StoreSnapshot is a point-in-time, read-only copy of a KeyValueStore, taken
+// atomically across all shards. It is copy-on-write: taking it only registers it
+// with the shards, and writers copy the previous value of a key the first time
+// they change it. A snapshot must be released with Release, or writers keep
+// copying for it.
+type StoreSnapshot struct {
+	v *view
+}
+
+// Snapshot captures the store at one point in time. Unlike Keys or Scan, which
+// read the shards one after another, the snapshot never mixes the state of a
+// shard before a write with the state of another shard after it. The live store
+// keeps serving writes while the snapshot is used.
+//
+//	snap := store.Snapshot()
+//	defer snap.Release()
+//	it := snap.Iterate("order:")
+//	defer it.Close()
+//	for it.Next() {
+//		fmt.Println(it.Key(), it.Value())
+//	}
+func (kvs *KeyValueStore) Snapshot() *StoreSnapshot {
+	return &StoreSnapshot{v: kvs.openView()}
+}
+
+// Get retrieves the value of key as of the snapshot, following aliases.
+// If the key is not found, it returns an ErrNotFound error, and after Release it
+// returns ErrTxnClosed.
+func (s *StoreSnapshot) Get(key string) (Value, error) {
+	return s.v.Get(key)
+}
+
+// Keys returns the keys of the store as of the snapshot.
+func (s *StoreSnapshot) Keys() ([]string, error) {
+	return s.v.Keys()
+}
+
+// Iterate returns an iterator over the entries of the snapshot whose key starts
+// with prefix, in key order; an empty prefix iterates over the whole snapshot.
+// System keys are not part of a snapshot.
+func (s *StoreSnapshot) Iterate(prefix string) Iterator {
+	keys, err := s.v.Keys()
+
+	matched := keys[:0]
+	for _, key := range keys {
+		if strings.HasPrefix(key, prefix) {
+			matched = append(matched, key)
+		}
+	}
+	sort.Strings(matched)
+
+	return &snapshotIterator{snap: s, keys: matched, err: err}
+}
+
+// Release ends the snapshot, so writers stop copying values for it. It is safe to
+// call Release more than once.
+func (s *StoreSnapshot) Release() {
+	s.v.close()
+}
+
+// snapshotIterator iterates over the keys of a snapshot, reading values as it goes.
+type snapshotIterator struct {
+	snap   *StoreSnapshot
+	keys   []string
+	key    string
+	val    Value
+	err    error
+	closed bool
+}
+
+// Next advances to the next entry.
+func (it *snapshotIterator) Next() bool {
+	if it.closed || it.err != nil || len(it.keys) == 0 {
+		return false
+	}
+
+	it.key, it.keys = it.keys[0], it.keys[1:]
+	it.val, it.err = it.snap.Get(it.key)
+
+	return it.err == nil
+}
+
+// Key returns the key of the current entry.
+func (it *snapshotIterator) Key() string {
+	return it.key
+}
+
+// Value returns the value of the current entry.
+func (it *snapshotIterator) Value() Value {
+	return it.val
+}
+
+// Err returns the error that stopped the iteration.
+func (it *snapshotIterator) Err() error {
+	return it.err
+}
+
+// Close releases the keys buffered by the iterator. It does not release the snapshot.
+func (it *snapshotIterator) Close() error {
+	it.closed = true
+	it.keys = nil
+	it.key, it.val = "", nil
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package kvs
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestStoreSnapshot(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	_ = store.Set("order:2", IntValue(2))
+	_ = store.Set("order:1", IntValue(1))
+	_ = store.Set("user:1", IntValue(10))
+
+	snap := store.Snapshot()
+
+	_ = store.Set("order:1", IntValue(100))
+	_ = store.Delete("order:2")
+	_ = store.Set("order:3", IntValue(3))
+
+	if val, err := snap.Get("order:1"); err != nil || val.(IntValue) != 1 {
+		t.Errorf("Expected the value at the snapshot, got %v, %v", val, err)
+	}
+	if _, err := snap.Get("order:3"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if keys, err := snap.Keys(); err != nil || len(keys) != 3 {
+		t.Errorf("Expected 3 keys, got %v, %v", keys, err)
+	}
+
+	it := snap.Iterate("order:")
+	var got []string
+	for it.Next() {
+		got = append(got, fmt.Sprintf("%s=%v", it.Key(), it.Value()))
+	}
+	if err := it.Err(); err != nil {
+		t.Fatalf("Iterate returned an error: %v", err)
+	}
+	it.Close()
+	if fmt.Sprint(got) != "[order:1=1 order:2=2]" {
+		t.Errorf("Expected the orders at the snapshot in key order, got %v", got)
+	}
+
+	if val, _ := store.Get("order:1"); val.(IntValue) != 100 {
+		t.Errorf("Expected the live store to see the writes, got %v", val)
+	}
+
+	snap.Release()
+	snap.Release()
+	if _, err := snap.Get("order:1"); err != ErrTxnClosed {
+		t.Errorf("Expected ErrTxnClosed, got %v", err)
+	}
+	it = snap.Iterate("")
+	if it.Next() || it.Err() != ErrTxnClosed {
+		t.Errorf("Expected iterating a released snapshot to fail with ErrTxnClosed, got %v", it.Err())
+	}
+}
@@ -0,0 +1,141 @@
+package kvs
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+)
+
+// SyncEvent is an entry of the initial state or a change delivered by Sync.
+type SyncEvent struct {
+	Event
+	// Value is the value of the key for EventSet. For entries of the initial
+	// state it is the listed value; for later sets it is read when the event is
+	// delivered, so it may be newer than the change the event reports.
+	Value Value
+}
+
+// Sync returns a channel that first delivers an EventSet for every key starting
+// with prefix, then an EventSynced, and then the changes of those keys, like a
+// Kubernetes list and watch. The watch starts before the listing and changes
+// already contained in it are skipped, so a consumer that applies the events in
+// order builds a mirror without missing or reapplying changes.
+//
+// The options and overflow behaviour are those of Watch: if the consumer falls
+// behind, it receives EventOverflow and must call Sync again. OverflowBlock falls
+// back to OverflowCancel, because Sync reads the values of changed keys and would
+// wait for the writers it blocks. The channel is closed when ctx is done.
+func (kvs *KeyValueStore) Sync(ctx context.Context, prefix string, opts ...WatchOption) <-chan SyncEvent {
+	opts = append(opts[:len(opts):len(opts)], func(o *watchOptions) {
+		if o.policy == OverflowBlock {
+			o.policy = OverflowCancel
+		}
+	})
+
+	ctx, cancel := context.WithCancel(ctx)
+	events := kvs.Watch(ctx, prefix, opts...)
+	ch := make(chan SyncEvent)
+
+	go func() {
+		defer close(ch)
+		defer cancel()
+
+		send := func(ev SyncEvent) bool {
+			select {
+			case ch <- ev:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		// cutoff holds the revision every shard was listed at; its later
+		// changes have greater revisions.
+		cutoff := make([]int64, len(kvs.shards))
+		for i, sh := range kvs.shards {
+			entries, rev, err := kvs.listShard(sh, prefix)
+			if err != nil {
+				kvs.log(slog.LevelError, "kvs: sync listing failed",
+					slog.Int("shard", sh.id),
+					slog.Any("error", err),
+				)
+				return
+			}
+			cutoff[i] = rev
+
+			for _, e := range entries {
+				if !send(e) {
+					return
+				}
+			}
+		}
+		if !send(SyncEvent{Event: Event{Type: EventSynced}}) {
+			return
+		}
+
+		for ev := range events {
+			if ev.Rev != 0 && ev.Rev <= cutoff[kvs.shardIndex(ev.Key)] {
+				continue
+			}
+
+			se := SyncEvent{Event: ev}
+			if ev.Type == EventSet {
+				val, err := kvs.getLive(ev.Key)
+				if err == ErrNotFound {
+					// A delete of the key follows.
+					continue
+				}
+				if err != nil {
+					kvs.log(slog.LevelError, "kvs: sync read failed",
+						slog.String("key", ev.Key),
+						slog.Any("error", err),
+					)
+					return
+				}
+				se.Value = val
+			}
+			if !send(se) {
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// listShard returns an EventSet for every key of sh starting with prefix, sorted
+// by key, and the revision of the store while the shard was read.
+func (kvs *KeyValueStore) listShard(sh *shard, prefix string) ([]SyncEvent, int64, error) {
+	sh.mu.RLock()
+	keys, err := sh.backend.Keys()
+	if err != nil {
+		sh.mu.RUnlock()
+		return nil, 0, err
+	}
+
+	var entries []SyncEvent
+	for _, key := range keys {
+		if !matchesPrefix(key, prefix) {
+			continue
+		}
+		val, err := sh.backend.Get(key)
+		if err != nil {
+			sh.mu.RUnlock()
+			return nil, 0, err
+		}
+		entries = append(entries, SyncEvent{Event: Event{Type: EventSet, Key: key, Rev: sh.revs[key]}, Value: val})
+	}
+	rev := kvs.Revision()
+	sh.mu.RUnlock()
+
+	for i := range entries {
+		if entries[i].Value, err = kvs.decompress(entries[i].Value); err != nil {
+			return nil, 0, err
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Key < entries[j].Key
+	})
+
+	return entries, rev, nil
+}
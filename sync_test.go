@@ -0,0 +1,140 @@
+package kvs
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSync(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		_ = store.Set(fmt.Sprintf("p/%d", i), IntValue(i))
+	}
+	_ = store.Set("other", IntValue(0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Keep writing while the state is listed, so changes race with the listing.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		rng := rand.New(rand.NewSource(1))
+		for i := 0; i < 2000; i++ {
+			key := fmt.Sprintf("p/%d", rng.Intn(20))
+			if rng.Intn(3) == 0 {
+				_ = store.Delete(key)
+			} else {
+				_ = store.Set(key, IntValue(i))
+			}
+		}
+	}()
+
+	// Once the writes are done and the listing is complete, p/end is set. Its
+	// event is delivered after those of all writes, so the mirror is complete
+	// when it arrives.
+	listed := make(chan struct{})
+	go func() {
+		<-done
+		<-listed
+		_ = store.Set("p/end", IntValue(0))
+	}()
+
+	mirror := make(map[string]Value)
+	synced := false
+	timeout := time.After(10 * time.Second)
+	for ev := range store.Sync(ctx, "p/", WithWatchBuffer(1<<14)) {
+		switch ev.Type {
+		case EventSynced:
+			synced = true
+			close(listed)
+		case EventSet:
+			mirror[ev.Key] = ev.Value
+		case EventDelete:
+			delete(mirror, ev.Key)
+		default:
+			t.Fatalf("Unexpected event %+v", ev)
+		}
+		if _, ok := mirror["p/end"]; ok && synced {
+			break
+		}
+		select {
+		case <-timeout:
+			t.Fatal("timed out waiting for the mirror to catch up")
+		default:
+		}
+	}
+
+	want := make(map[string]Value)
+	it := store.Scan("p/")
+	for it.Next() {
+		want[it.Key()] = it.Value()
+	}
+	_ = it.Close()
+
+	if !reflect.DeepEqual(mirror, want) {
+		t.Errorf("Expected the mirror to match the store:\n got %v\nwant %v", mirror, want)
+	}
+}
+
+func TestSync_InitialState(t *testing.T) {
+	store, _ := NewKeyValueStore(2)
+	_ = store.Set("p/a", IntValue(1))
+	_ = store.Set("p/b", IntValue(2))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := store.Sync(ctx, "p/")
+
+	got := map[string]Value{}
+	for ev := range events {
+		if ev.Type == EventSynced {
+			break
+		}
+		if ev.Type != EventSet || ev.Rev == 0 {
+			t.Errorf("Expected a set with a revision, got %+v", ev)
+		}
+		got[ev.Key] = ev.Value
+	}
+	if !reflect.DeepEqual(got, map[string]Value{"p/a": IntValue(1), "p/b": IntValue(2)}) {
+		t.Errorf("Unexpected initial state: %v", got)
+	}
+
+	_ = store.Set("p/a", IntValue(3))
+	if ev := <-events; ev.Type != EventSet || ev.Key != "p/a" || ev.Value != IntValue(3) {
+		t.Errorf("Expected the set of p/a, got %+v", ev)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestSync_ReadReplicas(t *testing.T) {
+	store, _ := NewKeyValueStore(2, WithReadReplicas(time.Hour))
+	defer store.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := store.Sync(ctx, "p/")
+	if ev := <-events; ev.Type != EventSynced {
+		t.Fatalf("Expected the synced event, got %+v", ev)
+	}
+
+	// The replicas are not refreshed before the change is delivered.
+	_ = store.Set("p/new", IntValue(1))
+	select {
+	case ev := <-events:
+		if ev.Type != EventSet || ev.Key != "p/new" || ev.Value != IntValue(1) {
+			t.Errorf("Expected the set of p/new, got %+v", ev)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the set")
+	}
+}
@@ -0,0 +1,140 @@
+package kvs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SystemPrefix is the prefix of the reserved, read-only system keyspace.
+// Getting a key under it returns a Bytes value with the current state of the store:
+//
+//	__kvs/stats/entries               total number of entries
+//	__kvs/stats/shards                number of shards
+//	__kvs/stats/shard/<id>/entries    number of entries in a shard
+//	__kvs/stats/shard/<id>/bytes      estimated bytes held by a shard
+//	__kvs/config/codec                type of the codec
+//	__kvs/config/compression          compression threshold in bytes, or "off"
+//	__kvs/config/recycle_bin          recycle bin capacity, or "off"
+//	__kvs/buckets/<path>//<key>       entry of a bucket, see KeyValueStore.Bucket
+//	__kvs/tenants/<id>/<key>          entry of a tenant, see KeyValueStore.Tenant
+//	__kvs/cas/<hash>                  content-addressed entry, see KeyValueStore.Content
+//	__kvs/metrics/<name>              published statistics, see WithStatsPublishing
+//
+// Writing or deleting a key under the prefix fails with ErrReservedKey.
+// System keys are not returned by Keys; use SystemKeys to list them.
+const SystemPrefix = "__kvs/"
+
+// isSystemKey reports whether key belongs to the reserved system keyspace.
+func isSystemKey(key string) bool {
+	return strings.HasPrefix(key, SystemPrefix)
+}
+
+// isStoredSystemKey reports whether key belongs to the part of the system
+// keyspace that is stored in the shards, such as the entries of buckets, rather
+// than computed.
+func isStoredSystemKey(key string) bool {
+	return strings.HasPrefix(key, bucketPrefix) || strings.HasPrefix(key, tenantPrefix) ||
+		strings.HasPrefix(key, contentPrefix) || strings.HasPrefix(key, metricsPrefix)
+}
+
+// withoutSystemKeys removes the system keys stored in a shard, such as the
+// entries of buckets, from keys.
+func withoutSystemKeys(keys []string) []string {
+	filtered := keys[:0]
+	for _, key := range keys {
+		if !isSystemKey(key) {
+			filtered = append(filtered, key)
+		}
+	}
+
+	return filtered
+}
+
+// matchesPrefix reports whether key is listed or watched for prefix. Stored
+// system keys, such as the entries of buckets, are only included when a prefix
+// is given, like the keys returned by SystemKeys.
+func matchesPrefix(key, prefix string) bool {
+	if prefix == "" {
+		return !isSystemKey(key)
+	}
+
+	return strings.HasPrefix(key, prefix)
+}
+
+// SystemKeys returns the keys of the reserved system keyspace.
+func (kvs *KeyValueStore) SystemKeys() []string {
+	keys := []string{
+		SystemPrefix + "stats/entries",
+		SystemPrefix + "stats/shards",
+	}
+	for i := range kvs.shards {
+		keys = append(keys,
+			fmt.Sprintf("%sstats/shard/%d/entries", SystemPrefix, i),
+			fmt.Sprintf("%sstats/shard/%d/bytes", SystemPrefix, i),
+		)
+	}
+
+	return append(keys,
+		SystemPrefix+"config/codec",
+		SystemPrefix+"config/compression",
+		SystemPrefix+"config/recycle_bin",
+	)
+}
+
+// systemValue returns the value of a system key.
+func (kvs *KeyValueStore) systemValue(key string) (Value, error) {
+	if isStoredSystemKey(key) {
+		return kvs.get(key)
+	}
+	name := strings.TrimPrefix(key, SystemPrefix)
+
+	switch name {
+	case "stats/entries":
+		total := 0
+		for _, n := range kvs.ShardSizes() {
+			total += n
+		}
+		return Bytes(strconv.Itoa(total)), nil
+	case "stats/shards":
+		return Bytes(strconv.Itoa(kvs.count)), nil
+	case "config/codec":
+		return Bytes(fmt.Sprintf("%T", kvs.opts.codec)), nil
+	case "config/compression":
+		if kvs.opts.compressor == nil {
+			return Bytes("off"), nil
+		}
+		return Bytes(strconv.Itoa(kvs.opts.compressThreshold)), nil
+	case "config/recycle_bin":
+		if kvs.bin == nil {
+			return Bytes("off"), nil
+		}
+		return Bytes(strconv.Itoa(kvs.opts.recycleCapacity)), nil
+	}
+
+	var id int
+	var stat string
+	if _, err := fmt.Sscanf(name, "stats/shard/%d/%s", &id, &stat); err != nil || id < 0 || id >= kvs.count {
+		return nil, ErrNotFound
+	}
+	if name != fmt.Sprintf("stats/shard/%d/%s", id, stat) {
+		return nil, ErrNotFound
+	}
+
+	sh := kvs.shards[id]
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	switch stat {
+	case "entries":
+		return Bytes(strconv.Itoa(sh.backend.Len())), nil
+	case "bytes":
+		var n int64
+		if bs, ok := sh.backend.(byteSizer); ok {
+			n = bs.bytes()
+		}
+		return Bytes(strconv.FormatInt(n, 10)), nil
+	default:
+		return nil, ErrNotFound
+	}
+}
@@ -0,0 +1,77 @@
+package kvs
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSystemKeys(t *testing.T) {
+	store, err := NewKeyValueStore(2)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	_ = store.Set("a", IntValue(1))
+	_ = store.Set("b", IntValue(2))
+
+	tests := map[string]string{
+		"__kvs/stats/entries":      "2",
+		"__kvs/stats/shards":       "2",
+		"__kvs/config/codec":       "kvs.GobCodec",
+		"__kvs/config/compression": "off",
+		"__kvs/config/recycle_bin": "off",
+	}
+	for key, want := range tests {
+		val, err := store.Get(key)
+		if err != nil {
+			t.Errorf("Get(%q) returned an error: %v", key, err)
+			continue
+		}
+		if got := string(val.(Bytes)); got != want {
+			t.Errorf("Get(%q) = %q, want %q", key, got, want)
+		}
+	}
+
+	shard := store.ShardOf("a")
+	val, err := store.Get("__kvs/stats/shard/" + strconv.Itoa(shard) + "/entries")
+	if err != nil || string(val.(Bytes)) == "0" {
+		t.Errorf("Expected the shard of a to hold entries, got %v, %v", val, err)
+	}
+
+	for _, key := range []string{"__kvs/unknown", "__kvs/stats/shard/9/entries", "__kvs/stats/shard/01/entries"} {
+		if _, err := store.Get(key); err != ErrNotFound {
+			t.Errorf("Expected ErrNotFound for %q, got %v", key, err)
+		}
+	}
+
+	for _, key := range store.SystemKeys() {
+		if !strings.HasPrefix(key, SystemPrefix) {
+			t.Errorf("Expected %q to be under the system prefix", key)
+		}
+		if _, err := store.Get(key); err != nil {
+			t.Errorf("Get(%q) returned an error: %v", key, err)
+		}
+	}
+
+	keys, _ := store.Keys()
+	if len(keys) != 2 {
+		t.Errorf("Expected Keys to exclude system keys, got %v", keys)
+	}
+}
+
+func TestSystemKeys_ReadOnly(t *testing.T) {
+	store, _ := NewKeyValueStore(2)
+
+	if err := store.Set("__kvs/stats/entries", IntValue(1)); err != ErrReservedKey {
+		t.Errorf("Expected ErrReservedKey from Set, got %v", err)
+	}
+	if err := store.SetImmutable("__kvs/x", IntValue(1)); err != ErrReservedKey {
+		t.Errorf("Expected ErrReservedKey from SetImmutable, got %v", err)
+	}
+	if err := store.Delete("__kvs/stats/entries"); err != ErrReservedKey {
+		t.Errorf("Expected ErrReservedKey from Delete, got %v", err)
+	}
+	if err := store.Alias("__kvs/x", "a"); err != ErrReservedKey {
+		t.Errorf("Expected ErrReservedKey from Alias, got %v", err)
+	}
+}
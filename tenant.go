@@ -0,0 +1,468 @@
+package kvs
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// tenantPrefix is the prefix of the system keys holding the entries of tenants.
+const tenantPrefix = SystemPrefix + "tenants/"
+
+// Tenant is the share of a KeyValueStore that belongs to one tenant, such as a
+// team using a store run as a shared service. Like a bucket, a tenant has a
+// keyspace of its own; in addition, every operation through the tenant is
+// accounted to it and checked against its quota.
+//
+// The entries of a tenant are stored under the system key prefix
+// "__kvs/tenants/<id>/".
+type Tenant struct {
+	kvs    *KeyValueStore
+	id     string
+	prefix string
+}
+
+var _ Store = (*Tenant)(nil)
+
+// TenantQuota limits the usage of a tenant. A zero field means no limit.
+type TenantQuota struct {
+	// MaxEntries is the maximum number of entries of the tenant.
+	MaxEntries int
+	// MaxBytes is the maximum estimated size of the keys and values of the
+	// tenant, measured like TenantStats.Bytes.
+	MaxBytes int64
+	// MaxOpsPerSecond is the maximum number of gets, sets and deletes of the
+	// tenant per second.
+	MaxOpsPerSecond int
+}
+
+// TenantStats describes the usage of a tenant.
+type TenantStats struct {
+	// Entries is the number of entries of the tenant.
+	Entries int
+	// Bytes is the estimated size of the keys and values of the tenant.
+	Bytes int64
+	// OpCounts holds the operations of the tenant since the store was created.
+	OpCounts
+	// GetsPerSecond, SetsPerSecond and DeletesPerSecond are the numbers of
+	// operations the tenant performed in the last full second.
+	GetsPerSecond, SetsPerSecond, DeletesPerSecond float64
+}
+
+// opRate counts the operations admitted in a one-second window.
+type opRate struct {
+	gets, sets, deletes int
+}
+
+// total returns the number of operations in the window.
+func (r opRate) total() int {
+	return r.gets + r.sets + r.deletes
+}
+
+// tenantAccount is the usage and quota of a tenant.
+type tenantAccount struct {
+	// mu serializes the writes of the tenant, so usage stays exact.
+	mu sync.Mutex
+	// usage is the measured usage of the tenant, or nil if it has to be
+	// measured again because the tenant was changed in bulk.
+	usage *BucketStats
+
+	// rateMu guards the fields below, which gets update, too.
+	rateMu sync.Mutex
+	limits TenantQuota
+	ops    OpCounts
+	// window is the start of the window counted in cur; last holds the window
+	// before it.
+	window    time.Time
+	cur, last opRate
+}
+
+// admit counts op in the current window, or returns ErrRateLimited if the
+// tenant used up its operations for the window.
+func (a *tenantAccount) admit(op Op, now time.Time) error {
+	a.rateMu.Lock()
+	defer a.rateMu.Unlock()
+
+	a.roll(now)
+	if a.limits.MaxOpsPerSecond > 0 && a.cur.total() >= a.limits.MaxOpsPerSecond {
+		return ErrRateLimited
+	}
+	switch op {
+	case OpGet:
+		a.cur.gets++
+	case OpSet:
+		a.cur.sets++
+	case OpDelete:
+		a.cur.deletes++
+	}
+
+	return nil
+}
+
+// roll starts a new window if the current one is over. a.rateMu must be locked.
+func (a *tenantAccount) roll(now time.Time) {
+	elapsed := now.Sub(a.window)
+	if elapsed < time.Second {
+		return
+	}
+
+	a.last = opRate{}
+	if elapsed < 2*time.Second {
+		a.last = a.cur
+	}
+	a.cur = opRate{}
+	a.window = now
+}
+
+// count counts an admitted op that returned err.
+func (a *tenantAccount) count(op Op, err error) {
+	a.rateMu.Lock()
+	defer a.rateMu.Unlock()
+
+	switch {
+	case op == OpGet && err == nil:
+		a.ops.Hits++
+	case op == OpGet && err == ErrNotFound:
+		a.ops.Misses++
+	case op == OpSet && err == nil:
+		a.ops.Sets++
+	case op == OpDelete && err == nil:
+		a.ops.Deletes++
+	}
+}
+
+// quota returns the limits of the tenant.
+func (a *tenantAccount) quota() TenantQuota {
+	a.rateMu.Lock()
+	defer a.rateMu.Unlock()
+
+	return a.limits
+}
+
+// tenantAccounts holds the accounts of a store's tenants by tenant id.
+type tenantAccounts struct {
+	mu sync.Mutex
+	m  map[string]*tenantAccount
+}
+
+// get returns the account of the tenant id, creating it if needed.
+func (t *tenantAccounts) get(id string) *tenantAccount {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	a, ok := t.m[id]
+	if !ok {
+		if t.m == nil {
+			t.m = make(map[string]*tenantAccount)
+		}
+		a = &tenantAccount{}
+		t.m[id] = a
+	}
+
+	return a
+}
+
+// lookup returns the account of the tenant id, or nil if it has none.
+func (t *tenantAccounts) lookup(id string) *tenantAccount {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.m[id]
+}
+
+// invalidate makes the tenant id, or every tenant for an empty id, measure its
+// usage again.
+func (t *tenantAccounts) invalidate(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, a := range t.m {
+		if id == "" || i == id {
+			a.mu.Lock()
+			a.usage = nil
+			a.mu.Unlock()
+		}
+	}
+}
+
+// Tenant returns the tenant with the given id. Tenants need not be created.
+// Ids must be non-empty and must not contain a slash, otherwise every operation
+// of the tenant returns ErrInvalidTenant.
+//
+//	billing := store.Tenant("billing")
+//	_ = billing.SetQuota(kvs.TenantQuota{MaxBytes: 1 << 30, MaxOpsPerSecond: 5000})
+//	err := billing.Set("invoice/42", kvs.Bytes("paid"))
+func (kvs *KeyValueStore) Tenant(id string) *Tenant {
+	return &Tenant{kvs: kvs, id: id, prefix: tenantPrefix + id + "/"}
+}
+
+// Tenants returns the sorted ids of the tenants that hold entries.
+func (kvs *KeyValueStore) Tenants() ([]string, error) {
+	return kvs.childBuckets(tenantPrefix)
+}
+
+// ID returns the id of the tenant.
+func (t *Tenant) ID() string {
+	return t.id
+}
+
+// Prefix returns the prefix of the system keys holding the entries of the
+// tenant, for use with Watch and Scan on the store.
+func (t *Tenant) Prefix() string {
+	return t.prefix
+}
+
+// check returns ErrInvalidTenant if the id of the tenant is invalid.
+func (t *Tenant) check() error {
+	if t.id == "" || strings.Contains(t.id, "/") {
+		return ErrInvalidTenant
+	}
+
+	return nil
+}
+
+// account returns the account of the tenant, creating it if needed. Accounts
+// are only created for tenants that hold entries or a quota, so operations
+// naming arbitrary tenants do not grow the accounts without bound.
+func (t *Tenant) account() *tenantAccount {
+	return t.kvs.tenants.get(t.id)
+}
+
+// measure returns the usage of the tenant, measuring it if needed. a.mu must be
+// locked.
+func (t *Tenant) measure(a *tenantAccount) (*BucketStats, error) {
+	if a.usage == nil {
+		stats, err := t.kvs.usage(t.prefix)
+		if err != nil {
+			return nil, err
+		}
+		a.usage = &stats
+	}
+
+	return a.usage, nil
+}
+
+// Get retrieves the value of key of the tenant.
+// If the key is not found, it returns an ErrNotFound error.
+func (t *Tenant) Get(key string) (_ Value, err error) {
+	if err := t.check(); err != nil {
+		return nil, err
+	}
+	if len(t.kvs.opts.observers) > 0 {
+		defer t.kvs.observe(OpGet, t.prefix+key, time.Now(), &err)
+	}
+
+	a := t.kvs.tenants.lookup(t.id)
+	if a == nil {
+		val, err := t.kvs.get(t.prefix + key)
+		if err != nil {
+			return nil, err
+		}
+		a = t.account()
+		if err := a.admit(OpGet, time.Now()); err != nil {
+			return nil, err
+		}
+		a.count(OpGet, nil)
+		return val, nil
+	}
+
+	if err := a.admit(OpGet, time.Now()); err != nil {
+		return nil, err
+	}
+	val, err := t.kvs.get(t.prefix + key)
+	a.count(OpGet, err)
+
+	return val, err
+}
+
+// Set adds or updates the given key-value pair of the tenant.
+// It returns ErrNilValue if val is nil, ErrQuotaExceeded if the write would
+// exceed the entries or bytes of the tenant's quota and ErrRateLimited if the
+// tenant used up its operations for the current second.
+func (t *Tenant) Set(key string, val Value) (err error) {
+	if err := t.check(); err != nil {
+		return err
+	}
+	if len(t.kvs.opts.observers) > 0 {
+		defer t.kvs.observe(OpSet, t.prefix+key, time.Now(), &err)
+	}
+
+	if val == nil {
+		return ErrNilValue
+	}
+	a := t.account()
+	if err := a.admit(OpSet, time.Now()); err != nil {
+		return err
+	}
+	defer func() { a.count(OpSet, err) }()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	usage, err := t.measure(a)
+	if err != nil {
+		return err
+	}
+	q := a.quota()
+
+	return t.kvs.setAccounted(usage, BucketQuota{MaxEntries: q.MaxEntries, MaxBytes: q.MaxBytes}, t.prefix, key, val)
+}
+
+// Delete removes key of the tenant.
+// If the key is not found, it returns an ErrNotFound error.
+func (t *Tenant) Delete(key string) (err error) {
+	if err := t.check(); err != nil {
+		return err
+	}
+	if len(t.kvs.opts.observers) > 0 {
+		defer t.kvs.observe(OpDelete, t.prefix+key, time.Now(), &err)
+	}
+
+	a := t.kvs.tenants.lookup(t.id)
+	if a == nil {
+		ok, err := t.kvs.contains(t.prefix + key)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrNotFound
+		}
+		a = t.account()
+	}
+	if err := a.admit(OpDelete, time.Now()); err != nil {
+		return err
+	}
+	defer func() { a.count(OpDelete, err) }()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	usage, err := t.measure(a)
+	if err != nil {
+		return err
+	}
+
+	return t.kvs.deleteAccounted(usage, t.prefix, key)
+}
+
+// Keys returns the keys of the tenant.
+func (t *Tenant) Keys() ([]string, error) {
+	if err := t.check(); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0)
+	err := t.kvs.eachShard(func(sh *shard, shardKeys []string) error {
+		for _, key := range shardKeys {
+			keys = append(keys, strings.TrimPrefix(key, t.prefix))
+		}
+		return nil
+	}, t.prefix, false)
+
+	return keys, err
+}
+
+// Drop deletes every entry of the tenant. Its quota and operation counters are
+// kept. Write-once entries, which come from imported snapshots, are kept as well,
+// and Drop then returns ErrImmutable after deleting the other entries.
+func (t *Tenant) Drop() error {
+	return t.drop(false)
+}
+
+// ForceDrop deletes every entry of the tenant like Drop, including write-once
+// entries.
+func (t *Tenant) ForceDrop() error {
+	return t.drop(true)
+}
+
+// drop deletes the entries of the tenant, including write-once ones if force is
+// set.
+func (t *Tenant) drop(force bool) error {
+	if err := t.check(); err != nil {
+		return err
+	}
+
+	a := t.kvs.tenants.lookup(t.id)
+	if a == nil {
+		// An account created while the entries are removed measures them again.
+		defer t.kvs.tenants.invalidate(t.id)
+		return t.kvs.removeAll(t.prefix, force)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.usage = nil
+
+	return t.kvs.removeAll(t.prefix, force)
+}
+
+// SetQuota limits the entries, bytes and operation rate of the tenant. Writes
+// that would exceed the entries or bytes fail with ErrQuotaExceeded, while writes
+// that shrink the tenant are always allowed. Operations beyond the rate fail
+// with ErrRateLimited until the next second. A zero quota removes the limits.
+//
+// Quotas are kept in memory and are not part of snapshots or clones. Imports
+// and restores from the recycle bin bypass them.
+func (t *Tenant) SetQuota(q TenantQuota) error {
+	if err := t.check(); err != nil {
+		return err
+	}
+
+	a := t.account()
+	a.rateMu.Lock()
+	a.limits = q
+	a.rateMu.Unlock()
+
+	return nil
+}
+
+// Quota returns the quota of the tenant, which is zero if it has none.
+func (t *Tenant) Quota() TenantQuota {
+	if t.check() != nil {
+		return TenantQuota{}
+	}
+	if a := t.kvs.tenants.lookup(t.id); a != nil {
+		return a.quota()
+	}
+
+	return TenantQuota{}
+}
+
+// Stats returns the usage of the tenant: its entries and their estimated size,
+// its operations since the store was created and its operation rates. The
+// operations of a tenant are only counted once it holds entries or a quota.
+func (t *Tenant) Stats() (TenantStats, error) {
+	var stats TenantStats
+	if err := t.check(); err != nil {
+		return stats, err
+	}
+
+	a := t.kvs.tenants.lookup(t.id)
+	if a == nil {
+		usage, err := t.kvs.usage(t.prefix)
+		stats.Entries, stats.Bytes = usage.Entries, usage.Bytes
+		return stats, err
+	}
+
+	a.mu.Lock()
+	usage, err := t.measure(a)
+	if err == nil {
+		stats.Entries, stats.Bytes = usage.Entries, usage.Bytes
+	}
+	a.mu.Unlock()
+	if err != nil {
+		return stats, err
+	}
+
+	a.rateMu.Lock()
+	defer a.rateMu.Unlock()
+
+	a.roll(time.Now())
+	stats.OpCounts = a.ops
+	stats.GetsPerSecond = float64(a.last.gets)
+	stats.SetsPerSecond = float64(a.last.sets)
+	stats.DeletesPerSecond = float64(a.last.deletes)
+
+	return stats, nil
+}
@@ -0,0 +1,196 @@
+package kvs
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+func TestTenant(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	billing, search := store.Tenant("billing"), store.Tenant("search")
+	if err := billing.Set("k", Bytes("billing")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if err := search.Set("k", Bytes("search")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+
+	val, err := billing.Get("k")
+	if err != nil || string(val.(Bytes)) != "billing" {
+		t.Errorf("Expected billing, got %v, %v", val, err)
+	}
+	if _, err := store.Get("k"); err != ErrNotFound {
+		t.Errorf("Expected tenant keys to be invisible in the root keyspace, got %v", err)
+	}
+	if keys, _ := store.Keys(); len(keys) != 0 {
+		t.Errorf("Expected no root keys, got %v", keys)
+	}
+	if ids, _ := store.Tenants(); fmt.Sprint(ids) != "[billing search]" {
+		t.Errorf("Expected [billing search], got %v", ids)
+	}
+
+	if err := store.Tenant("a/b").Set("k", Bytes("v")); err != ErrInvalidTenant {
+		t.Errorf("Expected ErrInvalidTenant, got %v", err)
+	}
+	if err := store.Set(billing.Prefix()+"k", Bytes("v")); err != ErrReservedKey {
+		t.Errorf("Expected ErrReservedKey, got %v", err)
+	}
+}
+
+func TestTenant_Stats(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	tenant := store.Tenant("billing")
+
+	for i := 0; i < 3; i++ {
+		_ = tenant.Set(fmt.Sprint(i), Bytes("value"))
+	}
+	_ = tenant.Set("0", Bytes("longer value"))
+	_ = tenant.Delete("1")
+	_, _ = tenant.Get("0")
+	_, _ = tenant.Get("1")
+
+	stats, err := tenant.Stats()
+	if err != nil {
+		t.Fatalf("Stats returned an error: %v", err)
+	}
+	want := estimateSize("0", Bytes("longer value")) + estimateSize("2", Bytes("value"))
+	if stats.Entries != 2 || stats.Bytes != want {
+		t.Errorf("Expected 2 entries of %d bytes, got %+v", want, stats)
+	}
+	if stats.Sets != 4 || stats.Deletes != 1 || stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Unexpected operation counts %+v", stats.OpCounts)
+	}
+
+	if err := tenant.Drop(); err != nil {
+		t.Fatalf("Drop returned an error: %v", err)
+	}
+	if stats, _ := tenant.Stats(); stats.Entries != 0 || stats.Bytes != 0 || stats.Sets != 4 {
+		t.Errorf("Expected an empty tenant with its counters kept, got %+v", stats)
+	}
+}
+
+func TestTenant_Unknown(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+
+	for i := 0; i < 100; i++ {
+		tenant := store.Tenant(fmt.Sprintf("tenant-%d", i))
+		if _, err := tenant.Get("k"); err != ErrNotFound {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		if err := tenant.Delete("k"); err != ErrNotFound {
+			t.Errorf("Expected ErrNotFound, got %v", err)
+		}
+		if _, err := tenant.Stats(); err != nil {
+			t.Errorf("Stats returned an error: %v", err)
+		}
+		if err := tenant.Drop(); err != nil {
+			t.Errorf("Drop returned an error: %v", err)
+		}
+		_ = tenant.Quota()
+	}
+	if n := len(store.tenants.m); n != 0 {
+		t.Errorf("Expected no tenant accounts, got %d", n)
+	}
+
+	tenant := store.Tenant("billing")
+	if err := tenant.Set("k", Bytes("v")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if _, err := tenant.Get("k"); err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+	if stats, _ := tenant.Stats(); stats.Entries != 1 || stats.Sets != 1 || stats.Hits != 1 {
+		t.Errorf("Unexpected stats %+v", stats)
+	}
+	if n := len(store.tenants.m); n != 1 {
+		t.Errorf("Expected 1 tenant account, got %d", n)
+	}
+}
+
+func TestTenant_Quota(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	tenant := store.Tenant("billing")
+
+	if err := tenant.SetQuota(TenantQuota{MaxEntries: 2}); err != nil {
+		t.Fatalf("SetQuota returned an error: %v", err)
+	}
+	_ = tenant.Set("a", Bytes("1"))
+	_ = tenant.Set("b", Bytes("2"))
+	if err := tenant.Set("c", Bytes("3")); err != ErrQuotaExceeded {
+		t.Errorf("Expected ErrQuotaExceeded, got %v", err)
+	}
+	if err := tenant.Set("a", Bytes("4")); err != nil {
+		t.Errorf("Expected overwrites within the quota to succeed, got %v", err)
+	}
+	if err := store.Tenant("search").Set("c", Bytes("3")); err != nil {
+		t.Errorf("Expected other tenants to be unaffected, got %v", err)
+	}
+
+	_ = tenant.SetQuota(TenantQuota{MaxOpsPerSecond: 3})
+	if tenant.Quota().MaxOpsPerSecond != 3 {
+		t.Errorf("Expected the quota to be replaced, got %+v", tenant.Quota())
+	}
+	var limited int
+	for i := 0; i < 10; i++ {
+		if _, err := tenant.Get("a"); err == ErrRateLimited {
+			limited++
+		}
+	}
+	// The quota allows 3 operations per second, fewer if the writes above
+	// fell into the same second.
+	if limited < 7 {
+		t.Errorf("Expected at least 7 rate limited gets, got %d", limited)
+	}
+}
+
+func TestTenant_Import(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	tenant := store.Tenant("billing")
+	_ = tenant.Set("a", Bytes("1"))
+	_, _ = tenant.Stats()
+
+	var buf bytes.Buffer
+	if err := store.WriteSnapshot(&buf); err != nil {
+		t.Fatalf("WriteSnapshot returned an error: %v", err)
+	}
+	_ = tenant.Drop()
+	if _, err := store.ImportSnapshot(&buf); err != nil {
+		t.Fatalf("ImportSnapshot returned an error: %v", err)
+	}
+
+	if stats, _ := tenant.Stats(); stats.Entries != 1 {
+		t.Errorf("Expected the imported entry to be accounted, got %+v", stats)
+	}
+}
+
+func TestTenant_DropImmutable(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	tenant := store.Tenant("billing")
+	_ = tenant.Set("a", Bytes("1"))
+	// Write-once tenant entries come from imported snapshots.
+	if err := store.putImmutable(tenant.Prefix()+"frozen", Bytes("2")); err != nil {
+		t.Fatalf("putImmutable returned an error: %v", err)
+	}
+
+	if err := tenant.Drop(); err != ErrImmutable {
+		t.Errorf("Expected ErrImmutable, got %v", err)
+	}
+	if keys, _ := tenant.Keys(); fmt.Sprint(keys) != "[frozen]" {
+		t.Errorf("Expected only the write-once entry to be kept, got %v", keys)
+	}
+	if stats, _ := tenant.Stats(); stats.Entries != 1 {
+		t.Errorf("Expected the kept entry to be accounted, got %+v", stats)
+	}
+
+	if err := tenant.ForceDrop(); err != nil {
+		t.Fatalf("ForceDrop returned an error: %v", err)
+	}
+	if keys, _ := tenant.Keys(); len(keys) != 0 {
+		t.Errorf("Expected an empty tenant, got %v", keys)
+	}
+}
@@ -0,0 +1,59 @@
+package kvs
+
+import (
+	"io"
+	"time"
+)
+
+// throttledWriter limits the rate at which data is written to an underlying writer.
+type throttledWriter struct {
+	w       io.Writer
+	rate    int64
+	start   time.Time
+	written int64
+}
+
+// throttle wraps w so writes respect the store's background rate limit.
+func (kvs *KeyValueStore) throttle(w io.Writer) io.Writer {
+	if kvs.opts.backgroundRate <= 0 {
+		return w
+	}
+
+	return &throttledWriter{
+		w:     w,
+		rate:  kvs.opts.backgroundRate,
+		start: time.Now(),
+	}
+}
+
+// Write writes p in chunks of at most a tenth of the rate, sleeping whenever
+// the writer gets ahead of the allowed rate.
+func (t *throttledWriter) Write(p []byte) (int, error) {
+	chunk := int(t.rate / 10)
+	if chunk < 1 {
+		chunk = 1
+	}
+
+	var n int
+	for len(p) > 0 {
+		c := p
+		if len(c) > chunk {
+			c = c[:chunk]
+		}
+
+		m, err := t.w.Write(c)
+		n += m
+		t.written += int64(m)
+		if err != nil {
+			return n, err
+		}
+		p = p[m:]
+
+		due := time.Duration(float64(t.written) / float64(t.rate) * float64(time.Second))
+		if d := due - time.Since(t.start); d > 0 {
+			time.Sleep(d)
+		}
+	}
+
+	return n, nil
+}
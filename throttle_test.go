@@ -0,0 +1,40 @@
+package kvs
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestThrottledWriter(t *testing.T) {
+	store, err := NewKeyValueStore(1, WithBackgroundRateLimit(10000))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	w := store.throttle(&buf)
+
+	start := time.Now()
+	if _, err := w.Write(make([]byte, 3000)); err != nil {
+		t.Fatalf("Write returned an error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 250*time.Millisecond {
+		t.Errorf("3000 bytes at 10000 B/s took only %v", elapsed)
+	}
+	if buf.Len() != 3000 {
+		t.Errorf("Expected 3000 bytes written, got %d", buf.Len())
+	}
+}
+
+func TestThrottle_Unlimited(t *testing.T) {
+	store, err := NewKeyValueStore(1)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if w := store.throttle(&buf); w != &buf {
+		t.Error("Expected the writer to be returned unchanged without a rate limit")
+	}
+}
@@ -0,0 +1,336 @@
+package kvs
+
+import (
+	"container/list"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// TierStats contains statistics about the tiers of a shard backed by a tiered backend.
+type TierStats struct {
+	// Shard is the id of the shard.
+	Shard int
+	// HotEntries is the number of entries in the hot tier.
+	HotEntries int
+	// ColdEntries is the number of entries in the cold tier.
+	ColdEntries int
+	// HotHits is the number of reads served from the hot tier.
+	HotHits uint64
+	// ColdHits is the number of reads served from the cold tier.
+	ColdHits uint64
+	// Promotions is the number of entries moved from the cold to the hot tier.
+	Promotions uint64
+	// Demotions is the number of entries moved from the hot to the cold tier.
+	Demotions uint64
+}
+
+// tierStatser is implemented by backends that can report tier statistics.
+type tierStatser interface {
+	tierStats() TierStats
+}
+
+// memEntry is an entry of the hot tier.
+type memEntry struct {
+	key string
+	val Value
+}
+
+// tieredBackend is a Backend with a small in-memory hot tier in front of a cold backend.
+// Entries read from the cold tier are promoted to the hot tier, and the least recently
+// used entries are demoted to the cold tier once the hot tier is full.
+type tieredBackend struct {
+	mu         sync.Mutex
+	maxEntries int
+	lru        *list.List
+	hot        map[string]*list.Element
+	cold       Backend
+	stats      TierStats
+	hotSize    int64
+}
+
+// NewTieredBackend creates a Backend that keeps up to hotEntries recently used entries
+// in memory and demotes the rest to cold.
+func NewTieredBackend(hotEntries int, cold Backend) (Backend, error) {
+	if hotEntries <= 0 {
+		return nil, fmt.Errorf("kvs: invalid hot tier size %d", hotEntries)
+	}
+
+	return &tieredBackend{
+		maxEntries: hotEntries,
+		lru:        list.New(),
+		hot:        make(map[string]*list.Element),
+		cold:       cold,
+	}, nil
+}
+
+// Get retrieves the value associated with the given key, promoting it if it is cold.
+func (t *tieredBackend) Get(key string) (Value, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if el, ok := t.hot[key]; ok {
+		t.lru.MoveToFront(el)
+		t.stats.HotHits++
+		return el.Value.(*memEntry).val, nil
+	}
+
+	val, err := t.cold.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	t.stats.ColdHits++
+
+	if err := t.cold.Delete(key); err != nil {
+		return nil, err
+	}
+	t.stats.Promotions++
+
+	if err := t.set(key, val); err != nil {
+		return nil, err
+	}
+
+	return val, nil
+}
+
+// Set adds or updates the given key-value pair in the hot tier.
+func (t *tieredBackend) Set(key string, val Value) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.hot[key]; !ok {
+		if err := t.cold.Delete(key); err != nil && err != ErrNotFound {
+			return err
+		}
+	}
+
+	return t.set(key, val)
+}
+
+// set stores the pair in the hot tier and demotes entries that no longer fit.
+func (t *tieredBackend) set(key string, val Value) error {
+	if el, ok := t.hot[key]; ok {
+		me := el.Value.(*memEntry)
+		t.hotSize += estimateSize(key, val) - estimateSize(key, me.val)
+		me.val = val
+		t.lru.MoveToFront(el)
+		return nil
+	}
+
+	t.hot[key] = t.lru.PushFront(&memEntry{key: key, val: val})
+	t.hotSize += estimateSize(key, val)
+
+	for t.lru.Len() > t.maxEntries {
+		el := t.lru.Back()
+		me := el.Value.(*memEntry)
+		if err := t.cold.Set(me.key, me.val); err != nil {
+			return err
+		}
+
+		t.lru.Remove(el)
+		delete(t.hot, me.key)
+		t.hotSize -= estimateSize(me.key, me.val)
+		t.stats.Demotions++
+	}
+
+	return nil
+}
+
+// Delete removes the key-value pair associated with the given key from either tier.
+func (t *tieredBackend) Delete(key string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if el, ok := t.hot[key]; ok {
+		t.lru.Remove(el)
+		delete(t.hot, key)
+		t.hotSize -= estimateSize(key, el.Value.(*memEntry).val)
+		return nil
+	}
+
+	return t.cold.Delete(key)
+}
+
+// Keys returns a slice of all the keys in both tiers.
+func (t *tieredBackend) Keys() ([]string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	keys, err := t.cold.Keys()
+	if err != nil {
+		return nil, err
+	}
+	for k := range t.hot {
+		keys = append(keys, k)
+	}
+
+	return keys, nil
+}
+
+// Len returns the number of entries in both tiers.
+func (t *tieredBackend) Len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return len(t.hot) + t.cold.Len()
+}
+
+// Close closes the cold tier if it holds external resources.
+func (t *tieredBackend) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if c, ok := t.cold.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+// bytes returns the estimated number of bytes held by both tiers.
+func (t *tieredBackend) bytes() int64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	size := t.hotSize
+	if bs, ok := t.cold.(byteSizer); ok {
+		size += bs.bytes()
+	}
+
+	return size
+}
+
+// tierStats returns the tier statistics of the backend.
+func (t *tieredBackend) tierStats() TierStats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := t.stats
+	stats.HotEntries = len(t.hot)
+	stats.ColdEntries = t.cold.Len()
+
+	return stats
+}
+
+// compressedBackend is an in-memory Backend that keeps its values compressed.
+type compressedBackend struct {
+	store      map[string]*compressedValue
+	codec      Codec
+	compressor Compressor
+	size       int64
+}
+
+// NewCompressedBackend creates an in-memory Backend that stores every value encoded
+// with codec and compressed with c. It trades CPU for memory and is meant to be used
+// as the cold tier of a tiered backend.
+func NewCompressedBackend(codec Codec, c Compressor) Backend {
+	return &compressedBackend{
+		store:      make(map[string]*compressedValue),
+		codec:      codec,
+		compressor: c,
+	}
+}
+
+// Get retrieves and decompresses the value associated with the given key.
+func (c *compressedBackend) Get(key string) (Value, error) {
+	cv, ok := c.store[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	data, err := c.compressor.Decompress(cv.data)
+	if err != nil {
+		return nil, fmt.Errorf("kvs: decompress value: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("kvs: decode value: %w", io.ErrUnexpectedEOF)
+	}
+	if data[0] == 1 {
+		return &compressedValue{data: data[1:]}, nil
+	}
+
+	val, err := c.codec.Unmarshal(data[1:])
+	if err != nil {
+		return nil, fmt.Errorf("kvs: decode value: %w", err)
+	}
+
+	return val, nil
+}
+
+// Set compresses and stores the given key-value pair.
+func (c *compressedBackend) Set(key string, val Value) error {
+	// The first byte records whether the value was already compressed by the store.
+	var data []byte
+	if cv, ok := val.(*compressedValue); ok {
+		data = append([]byte{1}, cv.data...)
+	} else {
+		encoded, err := c.codec.Marshal(val)
+		if err != nil {
+			return fmt.Errorf("kvs: encode value: %w", err)
+		}
+		data = append([]byte{0}, encoded...)
+	}
+
+	data, err := c.compressor.Compress(data)
+	if err != nil {
+		return fmt.Errorf("kvs: compress value: %w", err)
+	}
+
+	if old, ok := c.store[key]; ok {
+		c.size -= int64(len(key) + len(old.data))
+	}
+	c.store[key] = &compressedValue{data: data}
+	c.size += int64(len(key) + len(data))
+
+	return nil
+}
+
+// Delete removes the key-value pair associated with the given key.
+func (c *compressedBackend) Delete(key string) error {
+	old, ok := c.store[key]
+	if !ok {
+		return ErrNotFound
+	}
+
+	delete(c.store, key)
+	c.size -= int64(len(key) + len(old.data))
+
+	return nil
+}
+
+// Keys returns a slice of all the keys in the backend.
+func (c *compressedBackend) Keys() ([]string, error) {
+	keys := make([]string, 0, len(c.store))
+	for k := range c.store {
+		keys = append(keys, k)
+	}
+
+	return keys, nil
+}
+
+// Len returns the number of entries in the backend.
+func (c *compressedBackend) Len() int {
+	return len(c.store)
+}
+
+// bytes returns the number of compressed bytes held by the backend.
+func (c *compressedBackend) bytes() int64 {
+	return c.size
+}
+
+// TierStats returns the tier statistics of every shard that uses a tiered backend.
+func (kvs *KeyValueStore) TierStats() []TierStats {
+	stats := make([]TierStats, 0)
+
+	for _, sh := range kvs.shards {
+		sh.mu.RLock()
+		if ts, ok := sh.backend.(tierStatser); ok {
+			s := ts.tierStats()
+			s.Shard = sh.id
+			stats = append(stats, s)
+		}
+		sh.mu.RUnlock()
+	}
+
+	return stats
+}
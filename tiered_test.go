@@ -0,0 +1,92 @@
+package kvs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestTieredBackend(t *testing.T) {
+	store, err := NewKeyValueStore(1, WithBackend(func(shardID int) (Backend, error) {
+		return NewTieredBackend(2, NewCompressedBackend(GobCodec{}, FlateCompressor{}))
+	}))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	for i := 0; i < 4; i++ {
+		if err := store.Set(fmt.Sprintf("key-%d", i), IntValue(i)); err != nil {
+			t.Fatalf("Set returned an error: %v", err)
+		}
+	}
+
+	stats := store.TierStats()
+	if len(stats) != 1 {
+		t.Fatalf("Expected stats for 1 shard, got %d", len(stats))
+	}
+	if stats[0].HotEntries != 2 || stats[0].ColdEntries != 2 || stats[0].Demotions != 2 {
+		t.Errorf("unexpected stats after writes: %+v", stats[0])
+	}
+
+	// key-0 is cold and gets promoted, demoting the least recently used hot entry.
+	val, err := store.Get("key-0")
+	if err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+	if val != IntValue(0) {
+		t.Errorf("Expected IntValue(0), got %v", val)
+	}
+	if _, err := store.Get("key-0"); err != nil {
+		t.Fatalf("Get returned an error: %v", err)
+	}
+
+	stats = store.TierStats()
+	if stats[0].Promotions != 1 || stats[0].Demotions != 3 {
+		t.Errorf("unexpected promotion stats: %+v", stats[0])
+	}
+	if stats[0].ColdHits != 1 || stats[0].HotHits != 1 {
+		t.Errorf("unexpected hit stats: %+v", stats[0])
+	}
+
+	keys, err := store.Keys()
+	if err != nil {
+		t.Fatalf("Keys returned an error: %v", err)
+	}
+	if len(keys) != 4 {
+		t.Errorf("Expected 4 keys, got %d", len(keys))
+	}
+
+	if err := store.Delete("key-1"); err != nil {
+		t.Errorf("Delete returned an error: %v", err)
+	}
+	if _, err := store.Get("key-1"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestTierStats_Untiered(t *testing.T) {
+	store, err := NewKeyValueStore(2)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	if stats := store.TierStats(); len(stats) != 0 {
+		t.Errorf("Expected no tier stats, got %v", stats)
+	}
+}
+
+func TestCompressedBackend_EmptyValue(t *testing.T) {
+	compressor := FlateCompressor{}
+	backend := NewCompressedBackend(GobCodec{}, compressor).(*compressedBackend)
+
+	data, err := compressor.Compress(nil)
+	if err != nil {
+		t.Fatalf("Compress returned an error: %v", err)
+	}
+	backend.store["key"] = &compressedValue{data: data}
+
+	if _, err := backend.Get("key"); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
@@ -0,0 +1,351 @@
+package kvs
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// txnWrite is a buffered write of a transaction.
+type txnWrite struct {
+	val     Value
+	deleted bool
+	// size is the estimated size of the write, counted against TxnLimits.
+	size int64
+}
+
+// Txn is a transaction on a KeyValueStore. Writes are buffered in the transaction
+// and applied atomically by Commit, which locks only the shards they touch.
+// Reads see the transaction's own writes and otherwise the latest committed state.
+// A Txn must not be used concurrently.
+//
+//	tx := store.Txn()
+//	from, _ := tx.Get("account:alice")
+//	_ = tx.Set("account:alice", from.(Balance)-10)
+//	_ = tx.Set("account:bob", to.(Balance)+10)
+//	err := tx.Commit()
+type Txn struct {
+	kvs     *KeyValueStore
+	writes  map[string]txnWrite
+	size    int64
+	started time.Time
+	// undo records the writes replaced since the oldest live savepoint, and
+	// marks the savepoints, oldest first.
+	undo          []txnUndo
+	marks         []savepointMark
+	nextSavepoint int
+	isolation     Isolation
+	// reads holds the keys read by a serializable transaction.
+	reads map[string]txnRead
+	done  bool
+	// open is cleared when the transaction is committed, rolled back or
+	// aborted by the long-running transaction detection.
+	open atomic.Bool
+}
+
+// Txn starts a new transaction. Without options it is ReadCommitted.
+func (kvs *KeyValueStore) Txn(opts ...TxnOption) *Txn {
+	var o txnOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	tx := &Txn{
+		kvs:       kvs,
+		writes:    make(map[string]txnWrite),
+		started:   time.Now(),
+		isolation: o.isolation,
+	}
+	if o.isolation == Serializable {
+		tx.reads = make(map[string]txnRead)
+	}
+	tx.open.Store(true)
+	if kvs.opts.txnLimits.MaxAge > 0 {
+		kvs.txns.add(tx)
+	}
+
+	return tx
+}
+
+// check returns ErrTxnClosed if the transaction was committed or rolled back,
+// and rolls it back and returns ErrTxnAborted if it was aborted.
+func (tx *Txn) check() error {
+	if tx.done {
+		return ErrTxnClosed
+	}
+	if !tx.open.Load() {
+		tx.Rollback()
+		return ErrTxnAborted
+	}
+
+	return nil
+}
+
+// Get returns the value of key as seen by the transaction.
+func (tx *Txn) Get(key string) (Value, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+
+	if w, ok := tx.writes[key]; ok {
+		if w.deleted {
+			return nil, ErrNotFound
+		}
+		return tx.kvs.decompress(w.val)
+	}
+	if tx.isolation == Serializable && !isSystemKey(key) {
+		return tx.readTracked(key)
+	}
+
+	return tx.kvs.Get(key)
+}
+
+// Set buffers a write of val to key. If key is an alias when the transaction
+// commits, the key it points at is set.
+// It returns ErrTxnTooLarge if the write exceeds the store's TxnLimits.
+func (tx *Txn) Set(key string, val Value) error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	if isSystemKey(key) {
+		return ErrReservedKey
+	}
+	if val == nil {
+		return ErrNilValue
+	}
+
+	val, err := tx.kvs.compress(val)
+	if err != nil {
+		return err
+	}
+	return tx.buffer(key, txnWrite{val: val, size: estimateSize(key, val)})
+}
+
+// Delete buffers the removal of key. It returns ErrNotFound if the key does not
+// exist as seen by the transaction. If key is an alias, only the alias is removed.
+// It returns ErrTxnTooLarge if the delete exceeds the store's TxnLimits.
+func (tx *Txn) Delete(key string) error {
+	if isSystemKey(key) {
+		return ErrReservedKey
+	}
+	if _, err := tx.Get(key); err != nil {
+		return err
+	}
+	return tx.buffer(key, txnWrite{deleted: true, size: int64(len(key))})
+}
+
+// Rollback discards the buffered writes and closes the transaction.
+func (tx *Txn) Rollback() {
+	if tx.done {
+		return
+	}
+
+	tx.done = true
+	tx.writes, tx.reads = nil, nil
+	tx.undo, tx.marks = nil, nil
+	tx.open.Store(false)
+	if tx.kvs.opts.txnLimits.MaxAge > 0 {
+		tx.kvs.txns.remove(tx)
+	}
+}
+
+// Commit applies the buffered writes atomically and closes the transaction.
+// The shards holding the written keys are locked in shard order, so concurrent
+// commits cannot deadlock. Nothing is written if a key is write-once, which
+// returns ErrImmutable, and if the backend fails to apply a write, the writes
+// applied before it are reverted.
+// A key deleted by the transaction that no longer exists at commit is skipped.
+// It returns ErrTxnAborted if the transaction was aborted for running too long,
+// and ErrTxnConflict if a Serializable transaction read a key that has changed.
+func (tx *Txn) Commit() error {
+	if tx.done {
+		return ErrTxnClosed
+	}
+	defer tx.Rollback()
+	if !tx.open.CompareAndSwap(true, false) {
+		return ErrTxnAborted
+	}
+
+	keys := make([]string, 0, len(tx.writes))
+	for key := range tx.writes {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for depth := 0; ; depth++ {
+		targets, err := tx.resolve(keys)
+		if err != nil {
+			return err
+		}
+
+		shards := tx.kvs.lockShards(append(targets, tx.readTargets()...))
+		// An alias may have been created since the keys were resolved.
+		if tx.aliased(keys, targets) {
+			tx.kvs.unlockShards(shards)
+			if depth == maxAliasDepth {
+				return ErrAliasLoop
+			}
+			continue
+		}
+
+		err = tx.validate()
+		if err == nil {
+			err = tx.apply(keys, targets)
+		}
+		tx.kvs.unlockShards(shards)
+
+		return err
+	}
+}
+
+// resolve returns the key each buffered write applies to: the key an alias points
+// at for sets, and the key itself for deletes.
+func (tx *Txn) resolve(keys []string) ([]string, error) {
+	targets := make([]string, len(keys))
+	for i, key := range keys {
+		if tx.writes[key].deleted {
+			targets[i] = key
+			continue
+		}
+
+		target, err := tx.kvs.ResolveAlias(key)
+		if err != nil {
+			return nil, err
+		}
+		targets[i] = target
+	}
+
+	return targets, nil
+}
+
+// appliedWrite is the state of a key before Commit wrote it, so a commit that
+// fails partway can put it back.
+type appliedWrite struct {
+	sh     *shard
+	key    string
+	old    Value
+	exists bool
+	meta   map[string]string
+	// alias is the target of an alias removed by the commit.
+	alias string
+}
+
+// apply validates and then applies the buffered writes. If a write fails, the
+// writes applied before it are reverted. The shards of all targets must be
+// locked.
+func (tx *Txn) apply(keys, targets []string) error {
+	for i, key := range keys {
+		sh := tx.kvs.shards[tx.kvs.shardIndex(targets[i])]
+		if tx.writes[key].deleted {
+			if _, ok := sh.aliases[key]; ok {
+				continue
+			}
+		}
+		if sh.isImmutable(targets[i]) {
+			return ErrImmutable
+		}
+	}
+
+	applied := make([]appliedWrite, 0, len(keys))
+	for i, key := range keys {
+		target := targets[i]
+		sh := tx.kvs.shards[tx.kvs.shardIndex(target)]
+		sh.recordAccess(target)
+
+		w := tx.writes[key]
+		if alias, ok := sh.aliases[key]; ok && w.deleted {
+			sh.removeAlias(key)
+			tx.kvs.events.publish(EventDelete, key, 0)
+			applied = append(applied, appliedWrite{sh: sh, key: key, alias: alias})
+			continue
+		}
+
+		old, err := sh.backend.Get(target)
+		if w.deleted && err != nil {
+			continue
+		}
+		prev := appliedWrite{sh: sh, key: target, old: old, exists: err == nil, meta: sh.meta[target]}
+		if w.deleted {
+			err = tx.kvs.remove(sh, target)
+		} else {
+			err = tx.kvs.apply(sh, target, w.val)
+		}
+		if err != nil {
+			tx.revert(applied)
+			return err
+		}
+		applied = append(applied, prev)
+	}
+
+	return nil
+}
+
+// revert puts back the keys written by a failed commit, newest write first.
+// Reverting is best effort: the changes are published as writes of their own,
+// and a backend that keeps failing may leave some of them in place. The shards
+// of the keys must be locked.
+func (tx *Txn) revert(applied []appliedWrite) {
+	for i := len(applied) - 1; i >= 0; i-- {
+		a := applied[i]
+		switch {
+		case a.alias != "":
+			a.sh.setAlias(a.key, a.alias)
+		case a.exists:
+			if tx.kvs.bin != nil {
+				tx.kvs.bin.take(a.key)
+			}
+			if err := tx.kvs.apply(a.sh, a.key, a.old); err == nil {
+				a.sh.setMeta(a.key, a.meta)
+			}
+		default:
+			if err := a.sh.backend.Delete(a.key); err == nil {
+				rev := tx.kvs.record(a.sh, a.key, nil, true)
+				tx.kvs.events.publish(EventDelete, a.key, rev)
+			}
+		}
+	}
+}
+
+// aliased reports whether the target of a buffered set has become an alias.
+// The shards of all targets must be locked.
+func (tx *Txn) aliased(keys, targets []string) bool {
+	for i, target := range targets {
+		if tx.writes[keys[i]].deleted {
+			continue
+		}
+		if _, ok := tx.kvs.shards[tx.kvs.shardIndex(target)].aliases[target]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// lockShards write-locks the shards holding keys in shard order and returns them.
+func (kvs *KeyValueStore) lockShards(keys []string) []*shard {
+	seen := make(map[int]bool)
+	var indices []int
+	for _, key := range keys {
+		i := kvs.shardIndex(key)
+		if !seen[i] {
+			seen[i] = true
+			indices = append(indices, i)
+		}
+	}
+	sort.Ints(indices)
+
+	shards := make([]*shard, len(indices))
+	for j, i := range indices {
+		shards[j] = kvs.shards[i]
+		kvs.lockShard(shards[j])
+	}
+
+	return shards
+}
+
+// unlockShards unlocks shards locked by lockShards.
+func (kvs *KeyValueStore) unlockShards(shards []*shard) {
+	for _, sh := range shards {
+		sh.mu.Unlock()
+	}
+}
@@ -0,0 +1,418 @@
+package kvs
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTxn(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	_ = store.Set("alice", IntValue(100))
+	_ = store.Set("old", IntValue(1))
+
+	tx := store.Txn()
+	if err := tx.Set("alice", IntValue(90)); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if err := tx.Set("bob", IntValue(10)); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if err := tx.Delete("old"); err != nil {
+		t.Fatalf("Delete returned an error: %v", err)
+	}
+
+	if val, err := tx.Get("alice"); err != nil || val.(IntValue) != 90 {
+		t.Errorf("Expected the transaction to see its own write, got %v, %v", val, err)
+	}
+	if val, _ := store.Get("alice"); val.(IntValue) != 100 {
+		t.Errorf("Expected the store to be unchanged before commit, got %v", val)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit returned an error: %v", err)
+	}
+
+	if val, _ := store.Get("alice"); val.(IntValue) != 90 {
+		t.Errorf("Expected 90, got %v", val)
+	}
+	if val, _ := store.Get("bob"); val.(IntValue) != 10 {
+		t.Errorf("Expected 10, got %v", val)
+	}
+	if _, err := store.Get("old"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+
+	if err := tx.Commit(); err != ErrTxnClosed {
+		t.Errorf("Expected ErrTxnClosed, got %v", err)
+	}
+}
+
+func TestTxn_Immutable(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	_ = store.SetImmutable("frozen", IntValue(1))
+
+	tx := store.Txn()
+	_ = tx.Set("other", IntValue(1))
+	_ = tx.Set("frozen", IntValue(2))
+	if err := tx.Commit(); err != ErrImmutable {
+		t.Fatalf("Expected ErrImmutable, got %v", err)
+	}
+
+	if _, err := store.Get("other"); err != ErrNotFound {
+		t.Errorf("Expected no write to be applied, got %v", err)
+	}
+}
+
+func TestTxn_Rollback(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+
+	tx := store.Txn()
+	_ = tx.Set("key", IntValue(1))
+	tx.Rollback()
+
+	if _, err := store.Get("key"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if err := tx.Set("key", IntValue(1)); err != ErrTxnClosed {
+		t.Errorf("Expected ErrTxnClosed, got %v", err)
+	}
+}
+
+func TestTxn_Concurrent(t *testing.T) {
+	store, _ := NewKeyValueStore(8)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				tx := store.Txn()
+				for k := 0; k < 10; k++ {
+					_ = tx.Set(fmt.Sprintf("key-%d", (i+j+k)%32), IntValue(i))
+				}
+				if err := tx.Commit(); err != nil {
+					t.Errorf("Commit returned an error: %v", err)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestTxn_Limits(t *testing.T) {
+	store, err := NewKeyValueStore(4, WithTxnLimits(TxnLimits{MaxKeys: 2, MaxBytes: 64}))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	defer store.Close()
+
+	tx := store.Txn()
+	if err := tx.Set("a", Bytes("1")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if err := tx.Set("b", Bytes("2")); err != nil {
+		t.Fatalf("Set returned an error: %v", err)
+	}
+	if err := tx.Set("c", Bytes("3")); err != ErrTxnTooLarge {
+		t.Errorf("Expected ErrTxnTooLarge for a third key, got %v", err)
+	}
+	if err := tx.Set("a", Bytes("11")); err != nil {
+		t.Errorf("Expected overwriting a buffered key to succeed, got %v", err)
+	}
+	if err := tx.Set("b", make(Bytes, 100)); err != ErrTxnTooLarge {
+		t.Errorf("Expected ErrTxnTooLarge for a large value, got %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit returned an error: %v", err)
+	}
+	if val, _ := store.Get("b"); string(val.(Bytes)) != "2" {
+		t.Errorf("Expected the rejected write to be dropped, got %q", val)
+	}
+}
+
+func TestTxn_AbortLong(t *testing.T) {
+	store, err := NewKeyValueStore(4, WithTxnLimits(TxnLimits{MaxAge: 5 * time.Millisecond, AbortLong: true}))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	defer store.Close()
+
+	short := store.Txn()
+	_ = short.Set("a", IntValue(1))
+	if err := short.Commit(); err != nil {
+		t.Fatalf("Commit returned an error: %v", err)
+	}
+
+	tx := store.Txn()
+	_ = tx.Set("b", IntValue(2))
+
+	deadline := time.Now().Add(5 * time.Second)
+	for store.TxnStats().Aborted == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the transaction to be aborted, got %+v", store.TxnStats())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := tx.Commit(); err != ErrTxnAborted {
+		t.Errorf("Expected ErrTxnAborted, got %v", err)
+	}
+	if err := tx.Commit(); err != ErrTxnClosed {
+		t.Errorf("Expected ErrTxnClosed, got %v", err)
+	}
+	if _, err := store.Get("b"); err != ErrNotFound {
+		t.Errorf("Expected the aborted write to be discarded, got %v", err)
+	}
+	if stats := store.TxnStats(); stats.Long != 1 {
+		t.Errorf("Expected 1 long transaction, got %+v", stats)
+	}
+}
+
+func TestTxn_Savepoints(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	_ = store.Set("stock", IntValue(5))
+
+	tx := store.Txn()
+	_ = tx.Set("order", IntValue(1))
+	outer := tx.Savepoint()
+	_ = tx.Set("order", IntValue(2))
+	_ = tx.Delete("stock")
+	inner := tx.Savepoint()
+	_ = tx.Set("audit", IntValue(1))
+
+	if err := tx.RollbackTo(inner); err != nil {
+		t.Fatalf("RollbackTo returned an error: %v", err)
+	}
+	if _, err := tx.Get("audit"); err != ErrNotFound {
+		t.Errorf("Expected the write after the inner savepoint to be discarded, got %v", err)
+	}
+	if _, err := tx.Get("stock"); err != ErrNotFound {
+		t.Errorf("Expected the delete before the inner savepoint to be kept, got %v", err)
+	}
+
+	if err := tx.RollbackTo(outer); err != nil {
+		t.Fatalf("RollbackTo returned an error: %v", err)
+	}
+	if err := tx.RollbackTo(inner); err != ErrInvalidSavepoint {
+		t.Errorf("Expected ErrInvalidSavepoint for a rolled back savepoint, got %v", err)
+	}
+	if err := tx.RollbackTo(store.Txn().Savepoint()); err != ErrInvalidSavepoint {
+		t.Errorf("Expected ErrInvalidSavepoint for a foreign savepoint, got %v", err)
+	}
+	if err := tx.RollbackTo(outer); err != nil {
+		t.Errorf("Expected a savepoint to stay valid after rolling back to it, got %v", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		t.Fatalf("Commit returned an error: %v", err)
+	}
+	if val, _ := store.Get("order"); val != IntValue(1) {
+		t.Errorf("Expected order 1, got %v", val)
+	}
+	if val, _ := store.Get("stock"); val != IntValue(5) {
+		t.Errorf("Expected stock to be kept, got %v", val)
+	}
+}
+
+func TestTxn_SavepointLimits(t *testing.T) {
+	store, err := NewKeyValueStore(4, WithTxnLimits(TxnLimits{MaxKeys: 1}))
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	tx := store.Txn()
+	sp := tx.Savepoint()
+	_ = tx.Set("a", IntValue(1))
+	if err := tx.RollbackTo(sp); err != nil {
+		t.Fatalf("RollbackTo returned an error: %v", err)
+	}
+	if err := tx.Set("b", IntValue(1)); err != nil {
+		t.Errorf("Expected rolled back writes to free their share of the limits, got %v", err)
+	}
+}
+
+func TestTxn_Serializable(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	_ = store.Set("counter", IntValue(0))
+
+	increment := func(tx *Txn) {
+		val, err := tx.Get("counter")
+		if err != nil {
+			t.Fatalf("Get returned an error: %v", err)
+		}
+		if err := tx.Set("counter", val.(IntValue)+1); err != nil {
+			t.Fatalf("Set returned an error: %v", err)
+		}
+	}
+
+	// Two read-committed transactions lose one increment.
+	a, b := store.Txn(), store.Txn()
+	increment(a)
+	increment(b)
+	if err := a.Commit(); err != nil {
+		t.Fatalf("Commit returned an error: %v", err)
+	}
+	if err := b.Commit(); err != nil {
+		t.Fatalf("Commit returned an error: %v", err)
+	}
+	if val, _ := store.Get("counter"); val != IntValue(1) {
+		t.Errorf("Expected the lost update to leave 1, got %v", val)
+	}
+
+	// Serializable transactions detect it.
+	a, b = store.Txn(WithIsolation(Serializable)), store.Txn(WithIsolation(Serializable))
+	increment(a)
+	increment(b)
+	if err := a.Commit(); err != nil {
+		t.Fatalf("Commit returned an error: %v", err)
+	}
+	if err := b.Commit(); err != ErrTxnConflict {
+		t.Errorf("Expected ErrTxnConflict, got %v", err)
+	}
+	if val, _ := store.Get("counter"); val != IntValue(2) {
+		t.Errorf("Expected 2, got %v", val)
+	}
+
+	// Reading a missing key conflicts with its creation.
+	tx := store.Txn(WithIsolation(Serializable))
+	if _, err := tx.Get("lock"); err != ErrNotFound {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	_ = tx.Set("owner", IntValue(1))
+	_ = store.Set("lock", IntValue(2))
+	if err := tx.Commit(); err != ErrTxnConflict {
+		t.Errorf("Expected ErrTxnConflict, got %v", err)
+	}
+	if _, err := store.Get("owner"); err != ErrNotFound {
+		t.Errorf("Expected nothing to be written, got %v", err)
+	}
+}
+
+func TestTxn_SerializableConcurrent(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	_ = store.Set("counter", IntValue(0))
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; {
+				tx := store.Txn(WithIsolation(Serializable))
+				val, _ := tx.Get("counter")
+				_ = tx.Set("counter", val.(IntValue)+1)
+				switch err := tx.Commit(); err {
+				case nil:
+					i++
+				case ErrTxnConflict:
+				default:
+					t.Errorf("Commit returned an error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if val, _ := store.Get("counter"); val != IntValue(400) {
+		t.Errorf("Expected 400, got %v", val)
+	}
+}
+
+// failingBackend is a memory backend whose writes of failKey fail.
+type failingBackend struct {
+	Backend
+	failKey string
+}
+
+func (b failingBackend) Set(key string, val Value) error {
+	if key == b.failKey {
+		return errors.New("disk full")
+	}
+	return b.Backend.Set(key, val)
+}
+
+func TestTxn_RevertFailedCommit(t *testing.T) {
+	store, _ := NewKeyValueStore(4, WithRecycleBin(4, time.Minute), WithBackend(func(int) (Backend, error) {
+		return failingBackend{Backend: NewMemoryBackend(), failKey: "c"}, nil
+	}))
+	_ = store.Set("a", IntValue(1))
+	_ = store.SetMeta("a", map[string]string{"owner": "ops"})
+	_ = store.Set("a2", IntValue(4))
+	_ = store.Alias("al", "a")
+
+	tx := store.Txn()
+	_ = tx.Set("a", IntValue(10))
+	_ = tx.Set("b", IntValue(2))
+	_ = tx.Set("c", IntValue(3))
+	_ = tx.Delete("a2")
+	_ = tx.Delete("al")
+	if err := tx.Commit(); err == nil {
+		t.Fatal("Expected Commit to fail")
+	}
+
+	if val, _ := store.Get("a"); val != IntValue(1) {
+		t.Errorf("Expected a to be reverted to 1, got %v", val)
+	}
+	if meta, _ := store.Meta("a"); meta["owner"] != "ops" {
+		t.Errorf("Expected the metadata of a to be kept, got %v", meta)
+	}
+	if _, err := store.Get("b"); err != ErrNotFound {
+		t.Errorf("Expected b to be removed again, got %v", err)
+	}
+	if target, _ := store.ResolveAlias("al"); target != "a" {
+		t.Errorf("Expected the alias to be kept, got %s", target)
+	}
+	if val, _ := store.Get("a2"); val != IntValue(4) {
+		t.Errorf("Expected a2 to be kept, got %v", val)
+	}
+}
+
+func TestTxn_SerializableAlias(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+	_ = store.Set("plan/v1", IntValue(1))
+	_ = store.Set("plan/v2", IntValue(2))
+	_ = store.Alias("plan", "plan/v1")
+
+	tx := store.Txn(WithIsolation(Serializable))
+	if val, err := tx.Get("plan"); err != nil || val != IntValue(1) {
+		t.Fatalf("Expected 1, got %v, %v", val, err)
+	}
+	_ = tx.Set("owner", IntValue(1))
+	// Repointing the alias changes what the transaction read, though neither
+	// version changed.
+	_ = store.Alias("plan", "plan/v2")
+	if err := tx.Commit(); err != ErrTxnConflict {
+		t.Errorf("Expected ErrTxnConflict, got %v", err)
+	}
+
+	tx = store.Txn(WithIsolation(Serializable))
+	if _, err := tx.Get("missing"); err != ErrNotFound {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	_ = tx.Set("owner", IntValue(1))
+	if err := store.Alias("missing", "plan/v2"); err != nil {
+		t.Fatalf("Alias returned an error: %v", err)
+	}
+	if err := tx.Commit(); err != ErrTxnConflict {
+		t.Errorf("Expected ErrTxnConflict for a key that became an alias, got %v", err)
+	}
+}
@@ -0,0 +1,145 @@
+package kvs
+
+import (
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TxnLimits bounds the transactions of a store.
+type TxnLimits struct {
+	// MaxKeys is the number of keys a transaction may write. Zero means unlimited.
+	MaxKeys int
+	// MaxBytes is the estimated size of the writes a transaction may buffer.
+	// Zero means unlimited.
+	MaxBytes int64
+	// MaxAge is the time after which an open transaction is reported as
+	// long-running. Zero disables the detection.
+	MaxAge time.Duration
+	// AbortLong aborts transactions that are open for longer than MaxAge.
+	AbortLong bool
+}
+
+// TxnStats holds the counters of long-running transactions.
+type TxnStats struct {
+	// Long is the number of transactions that were open for longer than MaxAge.
+	Long uint64
+	// Aborted is the number of long-running transactions that were aborted.
+	Aborted uint64
+}
+
+// WithTxnLimits enforces limits on every transaction of the store. A write that
+// would exceed MaxKeys or MaxBytes fails with ErrTxnTooLarge and leaves the
+// transaction as it was. Transactions open for longer than MaxAge are logged at
+// Warn level and counted in TxnStats; with AbortLong they are also aborted, so
+// their next operation rolls them back and returns ErrTxnAborted.
+func WithTxnLimits(limits TxnLimits) Option {
+	return func(o *options) {
+		o.txnLimits = limits
+	}
+}
+
+// txnTracker keeps the open transactions of a store for long-running detection.
+type txnTracker struct {
+	mu      sync.Mutex
+	open    map[*Txn]struct{}
+	long    atomic.Uint64
+	aborted atomic.Uint64
+}
+
+// add starts tracking tx.
+func (t *txnTracker) add(tx *Txn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.open == nil {
+		t.open = make(map[*Txn]struct{})
+	}
+	t.open[tx] = struct{}{}
+}
+
+// remove stops tracking tx.
+func (t *txnTracker) remove(tx *Txn) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.open, tx)
+}
+
+// TxnStats returns the number of long-running transactions detected and aborted
+// since the store was created.
+func (kvs *KeyValueStore) TxnStats() TxnStats {
+	return TxnStats{
+		Long:    kvs.txns.long.Load(),
+		Aborted: kvs.txns.aborted.Load(),
+	}
+}
+
+// startTxnWatchdog reports long-running transactions until Close.
+func (kvs *KeyValueStore) startTxnWatchdog() {
+	kvs.bg.Add(1)
+	go kvs.runTxnWatchdog()
+}
+
+// runTxnWatchdog checks the open transactions twice per MaxAge until the store
+// is closed. A transaction is reported once and then no longer tracked.
+func (kvs *KeyValueStore) runTxnWatchdog() {
+	defer kvs.bg.Done()
+
+	limits := kvs.opts.txnLimits
+	ticker := time.NewTicker(max(limits.MaxAge/2, time.Millisecond))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-kvs.stop:
+			return
+		case now := <-ticker.C:
+			var long []*Txn
+			kvs.txns.mu.Lock()
+			for tx := range kvs.txns.open {
+				if now.Sub(tx.started) > limits.MaxAge {
+					long = append(long, tx)
+					delete(kvs.txns.open, tx)
+				}
+			}
+			kvs.txns.mu.Unlock()
+
+			for _, tx := range long {
+				aborted := limits.AbortLong && tx.open.CompareAndSwap(true, false)
+				kvs.txns.long.Add(1)
+				if aborted {
+					kvs.txns.aborted.Add(1)
+				}
+				kvs.log(slog.LevelWarn, "kvs: long-running transaction",
+					slog.Duration("age", now.Sub(tx.started)),
+					slog.Bool("aborted", aborted),
+				)
+			}
+		}
+	}
+}
+
+// buffer adds w to the writes of the transaction unless that exceeds the limits.
+func (tx *Txn) buffer(key string, w txnWrite) error {
+	limits := tx.kvs.opts.txnLimits
+	prev, exists := tx.writes[key]
+
+	keys := len(tx.writes)
+	if !exists {
+		keys++
+	}
+	size := tx.size - prev.size + w.size
+	if (limits.MaxKeys > 0 && keys > limits.MaxKeys) || (limits.MaxBytes > 0 && size > limits.MaxBytes) {
+		return ErrTxnTooLarge
+	}
+
+	if len(tx.marks) > 0 {
+		tx.undo = append(tx.undo, txnUndo{key: key, prev: prev, existed: exists})
+	}
+	tx.writes[key] = w
+	tx.size = size
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package kvs
+
+import "fmt"
+
+// Bytes is a Value holding raw bytes. It is the value type used by the
+// network front ends for opaque payloads.
+type Bytes []byte
+
+// Clone creates a copy of the value.
+func (b Bytes) Clone() Value {
+	c := make(Bytes, len(b))
+	copy(c, b)
+
+	return c
+}
+
+// Counter is a Value holding a signed integer, as maintained by Incr.
+type Counter int64
+
+// Clone creates a copy of the value.
+func (c Counter) Clone() Value {
+	return c
+}
+
+// BytesCodec is a Codec for Bytes values that passes payloads through unchanged.
+type BytesCodec struct{}
+
+// Marshal returns the payload of a Bytes value.
+func (BytesCodec) Marshal(val Value) ([]byte, error) {
+	b, ok := val.(Bytes)
+	if !ok {
+		return nil, fmt.Errorf("kvs: BytesCodec cannot encode %T", val)
+	}
+
+	return b, nil
+}
+
+// Unmarshal returns data as a Bytes value.
+func (BytesCodec) Unmarshal(data []byte) (Value, error) {
+	return Bytes(data).Clone(), nil
+}
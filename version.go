@@ -0,0 +1,94 @@
+package kvs
+
+import "time"
+
+// setRev records rev as the revision of the last change of key. Deleted keys
+// have no revision. The shard must be locked.
+func (s *shard) setRev(key string, rev int64, deleted bool) {
+	if deleted {
+		delete(s.revs, key)
+		return
+	}
+
+	if s.revs == nil {
+		s.revs = make(map[string]int64)
+	}
+	s.revs[key] = rev
+}
+
+// GetVersioned returns the value of key together with its version. The version
+// changes on every write of the key; it is the store revision of the last write,
+// so versions of a key only grow, even across a delete and a new set.
+// It returns ErrNotFound if the key does not exist.
+func (kvs *KeyValueStore) GetVersioned(key string) (_ Value, _ uint64, err error) {
+	if len(kvs.opts.observers) > 0 {
+		defer kvs.observe(OpGet, key, time.Now(), &err)
+	}
+
+	if isSystemKey(key) {
+		return nil, 0, ErrReservedKey
+	}
+
+	val, _, version, err := kvs.getVersioned(key)
+
+	return val, version, err
+}
+
+// getVersioned returns the value and version of key together with the key it
+// resolves to. The version of a key that does not exist is zero, and it is
+// returned along with ErrNotFound.
+func (kvs *KeyValueStore) getVersioned(key string) (Value, string, uint64, error) {
+	sh, key, err := kvs.rlockKey(key)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	val, err := sh.backend.Get(key)
+	version := uint64(sh.revs[key])
+	sh.mu.RUnlock()
+	sh.counters.countGet(err)
+
+	if err != nil {
+		return nil, key, version, err
+	}
+
+	val, err = kvs.decompress(val)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	return val, key, version, nil
+}
+
+// SetIfVersion sets key to val only if the key's version is still version, as
+// returned by GetVersioned. A version of zero sets the key only if it does not
+// exist. Otherwise it returns ErrVersionMismatch and leaves the key unchanged,
+// so concurrent writers detect lost updates without holding a lock.
+func (kvs *KeyValueStore) SetIfVersion(key string, val Value, version uint64) (err error) {
+	if len(kvs.opts.observers) > 0 {
+		defer kvs.observe(OpSet, key, time.Now(), &err)
+	}
+
+	if isSystemKey(key) {
+		return ErrReservedKey
+	}
+	if val == nil {
+		return ErrNilValue
+	}
+
+	val, err = kvs.compress(val)
+	if err != nil {
+		return err
+	}
+
+	sh, key, err := kvs.lockKey(key)
+	if err != nil {
+		return err
+	}
+	defer sh.mu.Unlock()
+
+	if uint64(sh.revs[key]) != version {
+		return ErrVersionMismatch
+	}
+
+	return kvs.apply(sh, key, val)
+}
@@ -0,0 +1,48 @@
+package kvs
+
+import "testing"
+
+func TestSetIfVersion(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	if err := store.SetIfVersion("counter", IntValue(1), 0); err != nil {
+		t.Fatalf("SetIfVersion returned an error: %v", err)
+	}
+	if err := store.SetIfVersion("counter", IntValue(1), 0); err != ErrVersionMismatch {
+		t.Errorf("Expected ErrVersionMismatch for an existing key, got %v", err)
+	}
+
+	val, version, err := store.GetVersioned("counter")
+	if err != nil {
+		t.Fatalf("GetVersioned returned an error: %v", err)
+	}
+	if val.(IntValue) != 1 || version == 0 {
+		t.Errorf("unexpected value %v at version %d", val, version)
+	}
+
+	// A concurrent writer changes the key.
+	_ = store.Set("counter", IntValue(5))
+
+	if err := store.SetIfVersion("counter", IntValue(2), version); err != ErrVersionMismatch {
+		t.Errorf("Expected ErrVersionMismatch, got %v", err)
+	}
+
+	val, version2, _ := store.GetVersioned("counter")
+	if version2 <= version {
+		t.Errorf("Expected the version to grow, got %d after %d", version2, version)
+	}
+	if err := store.SetIfVersion("counter", val.(IntValue)+1, version2); err != nil {
+		t.Fatalf("SetIfVersion returned an error: %v", err)
+	}
+	if val, _ := store.Get("counter"); val.(IntValue) != 6 {
+		t.Errorf("Expected 6, got %v", val)
+	}
+
+	_ = store.Delete("counter")
+	if _, _, err := store.GetVersioned("counter"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+package kvs
+
+import (
+	"maps"
+	"sync/atomic"
+)
+
+// ReadTx is a read-only, consistent view of a KeyValueStore. All reads see the
+// store as it was when the view was opened, across all shards.
+type ReadTx interface {
+	// Get retrieves the value of key as of the view, following aliases.
+	// If the key is not found, it returns an ErrNotFound error.
+	Get(key string) (Value, error)
+
+	// Keys returns the keys of the store as of the view.
+	Keys() ([]string, error)
+}
+
+// frozen is the value a key had when a view was opened.
+type frozen struct {
+	val    Value
+	exists bool
+}
+
+// view is a copy-on-write view of the store. Opening it only registers it with
+// every shard; writers then save the previous value of a key into the view the
+// first time they change it, so reads combine the saved values with the live
+// entries that have not changed since.
+type view struct {
+	kvs *KeyValueStore
+	// saved holds the previous values of the keys changed since the view was
+	// opened, per shard. It is guarded by the shard lock.
+	saved   []map[string]frozen
+	aliases []map[string]string
+	closed  atomic.Bool
+}
+
+var _ ReadTx = (*view)(nil)
+
+// View calls fn with a read-only view of the store that is consistent across all
+// shards. Opening the view locks every shard only briefly; while fn runs, writers
+// proceed and merely copy the previous value of a key the first time they change
+// it. The view must not be used after fn returns; it then returns ErrTxnClosed.
+// System keys are not part of the view and return ErrReservedKey.
+//
+//	err := store.View(func(tx kvs.ReadTx) error {
+//		keys, err := tx.Keys()
+//		if err != nil {
+//			return err
+//		}
+//		for _, key := range keys {
+//			val, _ := tx.Get(key)
+//			report(key, val)
+//		}
+//		return nil
+//	})
+func (kvs *KeyValueStore) View(fn func(tx ReadTx) error) error {
+	v := kvs.openView()
+	defer v.close()
+
+	return fn(v)
+}
+
+// openView registers a new view with every shard. All shards are locked at once,
+// so no write can land between the views of two shards.
+func (kvs *KeyValueStore) openView() *view {
+	v := &view{
+		kvs:     kvs,
+		saved:   make([]map[string]frozen, len(kvs.shards)),
+		aliases: make([]map[string]string, len(kvs.shards)),
+	}
+	for _, sh := range kvs.shards {
+		kvs.lockShard(sh)
+	}
+	for i, sh := range kvs.shards {
+		v.saved[i] = make(map[string]frozen)
+		v.aliases[i] = maps.Clone(sh.aliases)
+		sh.views = append(sh.views, v)
+	}
+	kvs.unlockShards(kvs.shards)
+
+	return v
+}
+
+// close unregisters v from every shard.
+func (v *view) close() {
+	if !v.closed.CompareAndSwap(false, true) {
+		return
+	}
+
+	for _, sh := range v.kvs.shards {
+		v.kvs.lockShard(sh)
+		for i, w := range sh.views {
+			if w == v {
+				sh.views = append(sh.views[:i], sh.views[i+1:]...)
+				break
+			}
+		}
+		sh.mu.Unlock()
+	}
+}
+
+// Get retrieves the value of key as of the view.
+func (v *view) Get(key string) (Value, error) {
+	if v.closed.Load() {
+		return nil, ErrTxnClosed
+	}
+	if isSystemKey(key) {
+		return nil, ErrReservedKey
+	}
+
+	for depth := 0; ; depth++ {
+		target, ok := v.aliases[v.kvs.shardIndex(key)][key]
+		if !ok {
+			break
+		}
+		if depth == maxAliasDepth {
+			return nil, ErrAliasLoop
+		}
+		if isSystemKey(target) {
+			return nil, ErrReservedKey
+		}
+		key = target
+	}
+
+	sh := v.kvs.shards[v.kvs.shardIndex(key)]
+	v.kvs.rlockShard(sh)
+	val, err := v.lookup(sh, key)
+	sh.mu.RUnlock()
+	sh.counters.countGet(err)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return v.kvs.decompress(val)
+}
+
+// lookup returns the stored value of key as of the view. The shard must be locked.
+func (v *view) lookup(sh *shard, key string) (Value, error) {
+	f, ok := v.saved[sh.id][key]
+	if !ok {
+		return sh.backend.Get(key)
+	}
+	if !f.exists {
+		return nil, ErrNotFound
+	}
+
+	return f.val, nil
+}
+
+// Keys returns the keys of the store as of the view.
+func (v *view) Keys() ([]string, error) {
+	if v.closed.Load() {
+		return nil, ErrTxnClosed
+	}
+
+	keys := make([]string, 0)
+	for _, sh := range v.kvs.shards {
+		sh.mu.RLock()
+		shardKeys, err := v.shardKeys(sh)
+		sh.mu.RUnlock()
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, shardKeys...)
+	}
+
+	return keys, nil
+}
+
+// shardKeys returns the keys of sh as of the view. The shard must be locked.
+func (v *view) shardKeys(sh *shard) ([]string, error) {
+	live, err := sh.backend.Keys()
+	if err != nil {
+		return nil, err
+	}
+	live = withoutSystemKeys(live)
+
+	saved := v.saved[sh.id]
+	keys := make([]string, 0, len(live))
+	for _, key := range live {
+		if _, ok := saved[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	for key, f := range saved {
+		if f.exists && !isSystemKey(key) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+// preserve saves the current value of key into the open views of the shard that
+// have not saved it yet. It must be called before key changes, with the shard
+// write-locked.
+func (s *shard) preserve(key string) {
+	for _, v := range s.views {
+		saved := v.saved[s.id]
+		if _, ok := saved[key]; ok {
+			continue
+		}
+		val, err := s.backend.Get(key)
+		saved[key] = frozen{val: val, exists: err == nil}
+	}
+}
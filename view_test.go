@@ -0,0 +1,123 @@
+package kvs
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"testing"
+)
+
+func TestView(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+	_ = store.Set("a", IntValue(1))
+	_ = store.Set("b", IntValue(2))
+	_ = store.Alias("link", "a")
+
+	var leaked ReadTx
+	err = store.View(func(tx ReadTx) error {
+		leaked = tx
+
+		_ = store.Set("a", IntValue(10))
+		_ = store.Set("a", IntValue(11))
+		_ = store.Delete("b")
+		_ = store.Set("c", IntValue(3))
+		_ = store.Alias("link", "c")
+
+		if val, err := tx.Get("a"); err != nil || val.(IntValue) != 1 {
+			t.Errorf("Expected the value before the view's writes, got %v, %v", val, err)
+		}
+		if val, err := tx.Get("b"); err != nil || val.(IntValue) != 2 {
+			t.Errorf("Expected the deleted key to stay visible, got %v, %v", val, err)
+		}
+		if _, err := tx.Get("c"); err != ErrNotFound {
+			t.Errorf("Expected ErrNotFound for a key created later, got %v", err)
+		}
+		if val, err := tx.Get("link"); err != nil || val.(IntValue) != 1 {
+			t.Errorf("Expected the alias to resolve as of the view, got %v, %v", val, err)
+		}
+
+		keys, err := tx.Keys()
+		if err != nil {
+			t.Fatalf("Keys returned an error: %v", err)
+		}
+		sort.Strings(keys)
+		if fmt.Sprint(keys) != "[a b]" {
+			t.Errorf("Expected [a b], got %v", keys)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("View returned an error: %v", err)
+	}
+
+	if val, _ := store.Get("a"); val.(IntValue) != 11 {
+		t.Errorf("Expected the live store to see the writes, got %v", val)
+	}
+	if _, err := leaked.Get("a"); err != ErrTxnClosed {
+		t.Errorf("Expected ErrTxnClosed, got %v", err)
+	}
+	for _, sh := range store.shards {
+		if len(sh.views) != 0 {
+			t.Errorf("Expected the view to be unregistered from shard %d", sh.id)
+		}
+	}
+}
+
+func TestView_Consistent(t *testing.T) {
+	store, _ := NewKeyValueStore(8)
+
+	// Writers move units between keys, so every consistent view sums to the same total.
+	const keys, total = 16, 1600
+	for i := 0; i < keys; i++ {
+		_ = store.Set(fmt.Sprintf("account:%d", i), IntValue(total/keys))
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				from, to := fmt.Sprintf("account:%d", (i+w)%keys), fmt.Sprintf("account:%d", (i+w+1)%keys)
+				tx := store.Txn()
+				a, _ := tx.Get(from)
+				b, _ := tx.Get(to)
+				_ = tx.Set(from, a.(IntValue)-1)
+				_ = tx.Set(to, b.(IntValue)+1)
+				_ = tx.Commit()
+			}
+		}(w)
+	}
+
+	for i := 0; i < 50; i++ {
+		err := store.View(func(tx ReadTx) error {
+			sum := IntValue(0)
+			for k := 0; k < keys; k++ {
+				val, err := tx.Get(fmt.Sprintf("account:%d", k))
+				if err != nil {
+					return err
+				}
+				sum += val.(IntValue)
+			}
+			if sum != total {
+				t.Errorf("Expected the view to sum to %d, got %d", total, sum)
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("View returned an error: %v", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
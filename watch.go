@@ -0,0 +1,204 @@
+package kvs
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// defaultWatchBuffer is the number of events buffered for a watch.
+const defaultWatchBuffer = 1024
+
+// watchOptions holds the settings of a watch.
+type watchOptions struct {
+	buffer       int
+	policy       OverflowPolicy
+	blockTimeout time.Duration
+}
+
+// WatchOption configures a watch.
+type WatchOption func(*watchOptions)
+
+// WithWatchBuffer sets the number of events buffered for a watch before its consumer
+// counts as fallen behind. The default is 1024.
+func WithWatchBuffer(size int) WatchOption {
+	return func(o *watchOptions) {
+		o.buffer = size
+	}
+}
+
+// Watch returns a channel of events for every set and delete of a key starting
+// with prefix; an empty prefix watches the whole keyspace. The channel is closed
+// when ctx is done.
+//
+// By default writers never wait for a watch. If the consumer falls behind by more
+// than the watch buffer, it receives an EventOverflow event and the channel is
+// closed, so it knows that events were lost and it must re-read the keys it depends
+// on before watching again. WithOverflowPolicy chooses another behaviour.
+func (kvs *KeyValueStore) Watch(ctx context.Context, prefix string, opts ...WatchOption) <-chan Event {
+	o := watchOptions{buffer: defaultWatchBuffer, blockTimeout: defaultBlockTimeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.buffer <= 0 {
+		o.buffer = 1
+	}
+
+	s := newSubscription(func(key string) bool {
+		return matchesPrefix(key, prefix)
+	}, o.buffer, true)
+	s.policy, s.timeout = o.policy, o.blockTimeout
+	if s.queued() {
+		s.startPump()
+	}
+	cancel := kvs.events.subscribe(s)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-s.done:
+		}
+		cancel()
+	}()
+
+	return s.ch
+}
+
+// OverflowPolicy decides what happens to a watch whose consumer falls behind by
+// more than the watch buffer.
+type OverflowPolicy int
+
+const (
+	// OverflowCancel sends EventOverflow and closes the channel. It is the default.
+	OverflowCancel OverflowPolicy = iota
+	// OverflowBlock makes writers wait until the consumer catches up, for at
+	// most the block timeout; then the watch is canceled as with OverflowCancel.
+	// Writers wait while holding the lock of the shard they write to.
+	OverflowBlock
+	// OverflowDropOldest drops the oldest buffered events to make room for new
+	// ones and puts an EventGap in their place, so the consumer knows to re-read
+	// the keys it depends on. The watch continues.
+	OverflowDropOldest
+)
+
+// defaultBlockTimeout is the longest time OverflowBlock makes a writer wait.
+const defaultBlockTimeout = time.Second
+
+// WithOverflowPolicy sets what happens when the consumer of the watch falls behind.
+func WithOverflowPolicy(p OverflowPolicy) WatchOption {
+	return func(o *watchOptions) {
+		o.policy = p
+	}
+}
+
+// WithBlockTimeout sets how long OverflowBlock makes a writer wait for the
+// consumer before the watch is canceled. The default is one second.
+func WithBlockTimeout(d time.Duration) WatchOption {
+	return func(o *watchOptions) {
+		o.blockTimeout = d
+	}
+}
+
+// queued reports whether events are queued and forwarded by pump.
+func (s *subscription) queued() bool {
+	return s.policy != OverflowCancel
+}
+
+// startPump sets up the queue of the subscription and forwards it to ch.
+func (s *subscription) startPump() {
+	s.ch = make(chan Event)
+	s.wake = make(chan struct{}, 1)
+	s.space = make(chan struct{}, 1)
+	s.quit = make(chan struct{})
+	go s.pump()
+}
+
+// pump forwards queued events to ch until the subscription is canceled or closed
+// and drained, and then closes ch and done.
+func (s *subscription) pump() {
+	defer close(s.done)
+	defer close(s.ch)
+
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			closed := s.closed
+			s.mu.Unlock()
+			if closed {
+				return
+			}
+			select {
+			case <-s.wake:
+			case <-s.quit:
+				return
+			}
+			continue
+		}
+		ev := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+		notify(s.space)
+
+		select {
+		case s.ch <- ev:
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// enqueue queues ev according to the overflow policy. s.mu must be held.
+func (s *subscription) enqueue(ev Event) {
+	switch s.policy {
+	case OverflowDropOldest:
+		gap := len(s.queue) > 0 && s.queue[0].Type == EventGap
+		pending := len(s.queue)
+		if gap {
+			pending--
+		}
+		if pending >= s.limit {
+			if gap {
+				s.queue = slices.Delete(s.queue, 1, 2)
+			} else {
+				s.queue[0] = Event{Type: EventGap}
+			}
+		}
+	case OverflowBlock:
+		var timeout <-chan time.Time
+		for len(s.queue) >= s.limit {
+			if timeout == nil {
+				timer := time.NewTimer(s.timeout)
+				defer timer.Stop()
+				timeout = timer.C
+			}
+
+			s.mu.Unlock()
+			select {
+			case <-s.space:
+			case <-s.quit:
+			case <-timeout:
+				s.mu.Lock()
+				if !s.closed {
+					s.queue = append(s.queue, Event{Type: EventOverflow})
+					s.closeLocked()
+				}
+				return
+			}
+			s.mu.Lock()
+			if s.closed {
+				return
+			}
+		}
+	}
+
+	s.queue = append(s.queue, ev)
+	notify(s.wake)
+}
+
+// notify signals ch without blocking.
+func notify(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,137 @@
+package kvs
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	store, err := NewKeyValueStore(4)
+	if err != nil {
+		t.Fatalf("NewKeyValueStore returned an error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := store.Watch(ctx, "config/")
+
+	_ = store.Set("other", IntValue(1))
+	_ = store.Set("config/a", IntValue(1))
+	_ = store.Delete("config/a")
+
+	if ev := receive(t, events); ev.Type != EventSet || ev.Key != "config/a" {
+		t.Errorf("Expected a set of config/a, got %+v", ev)
+	}
+	if ev := receive(t, events); ev.Type != EventDelete || ev.Key != "config/a" {
+		t.Errorf("Expected a delete of config/a, got %+v", ev)
+	}
+
+	cancel()
+	for range events {
+		t.Error("Expected no events after the context was canceled")
+	}
+}
+
+func TestWatch_Overflow(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+
+	events := store.Watch(context.Background(), "", WithWatchBuffer(3))
+	for i := 0; i < 10; i++ {
+		_ = store.Set(fmt.Sprintf("key-%d", i), IntValue(i))
+	}
+
+	var got []Event
+	for ev := range events {
+		got = append(got, ev)
+	}
+
+	if len(got) != 4 {
+		t.Fatalf("Expected 3 events and the overflow signal, got %v", got)
+	}
+	for i, ev := range got[:3] {
+		if ev.Key != fmt.Sprintf("key-%d", i) {
+			t.Errorf("Expected event %d to be key-%d, got %+v", i, i, ev)
+		}
+	}
+	if got[3].Type != EventOverflow {
+		t.Errorf("Expected the last event to signal the overflow, got %+v", got[3])
+	}
+}
+
+func TestWatch_Block(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := store.Watch(ctx, "", WithWatchBuffer(2), WithOverflowPolicy(OverflowBlock), WithBlockTimeout(5*time.Second))
+
+	go func() {
+		for i := 0; i < 20; i++ {
+			_ = store.Set(fmt.Sprintf("key-%d", i), IntValue(i))
+		}
+	}()
+
+	for i := 0; i < 20; i++ {
+		time.Sleep(time.Millisecond)
+		if ev := receive(t, events); ev.Key != fmt.Sprintf("key-%d", i) {
+			t.Fatalf("Expected event %d to be key-%d, got %+v", i, i, ev)
+		}
+	}
+}
+
+func TestWatch_BlockTimeout(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+
+	events := store.Watch(context.Background(), "", WithWatchBuffer(1), WithOverflowPolicy(OverflowBlock), WithBlockTimeout(10*time.Millisecond))
+	for i := 0; i < 5; i++ {
+		_ = store.Set(fmt.Sprintf("key-%d", i), IntValue(i))
+	}
+
+	var got []Event
+	for ev := range events {
+		got = append(got, ev)
+	}
+	if len(got) == 0 || got[len(got)-1].Type != EventOverflow {
+		t.Fatalf("Expected the watch to end with an overflow, got %v", got)
+	}
+	for i, ev := range got[:len(got)-1] {
+		if ev.Key != fmt.Sprintf("key-%d", i) {
+			t.Errorf("Expected event %d to be key-%d, got %+v", i, i, ev)
+		}
+	}
+}
+
+func TestWatch_DropOldest(t *testing.T) {
+	store, _ := NewKeyValueStore(4)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := store.Watch(ctx, "", WithWatchBuffer(3), WithOverflowPolicy(OverflowDropOldest))
+	for i := 0; i < 10; i++ {
+		_ = store.Set(fmt.Sprintf("key-%d", i), IntValue(i))
+	}
+
+	var got []Event
+	for len(got) == 0 || got[len(got)-1].Key != "key-9" {
+		got = append(got, receive(t, events))
+	}
+
+	n := len(got)
+	if n < 4 || got[n-4].Type != EventGap {
+		t.Fatalf("Expected a gap before the newest events, got %v", got)
+	}
+	for i, ev := range got[n-3:] {
+		if ev.Key != fmt.Sprintf("key-%d", 7+i) {
+			t.Errorf("Expected key-%d, got %+v", 7+i, ev)
+		}
+	}
+
+	_ = store.Set("after", IntValue(1))
+	if ev := receive(t, events); ev.Key != "after" {
+		t.Errorf("Expected the watch to continue after a gap, got %+v", ev)
+	}
+
+	cancel()
+	for range events {
+	}
+}